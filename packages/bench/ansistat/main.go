@@ -0,0 +1,175 @@
+// Command ansistat reports what captured terminal output is made of:
+// sequence counts by kind, SGR churn and, for a session recorded with
+// --record, a per-frame breakdown. It reads the capture after the fact, so
+// no benchmark has to be rerun to look at its output another way.
+//
+//	ansistat stats [--json] session.bin
+//
+// A file that is not a recorded session is read as one raw stream.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/ansistat"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+const usage = "usage: ansistat stats [--json] FILE"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "stats" {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	asJSON := false
+	path := ""
+	for _, arg := range args[1:] {
+		switch {
+		case arg == "--json":
+			asJSON = true
+		case strings.HasPrefix(arg, "-") || path != "":
+			fmt.Fprintln(stderr, usage)
+			return 2
+		default:
+			path = arg
+		}
+	}
+	if path == "" {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		var r report
+		r, err = analyze(data)
+		if err == nil && asJSON {
+			enc := json.NewEncoder(stdout)
+			enc.SetIndent("", "  ")
+			err = enc.Encode(r)
+		} else if err == nil {
+			err = r.write(stdout, path)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "ansistat: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// report is the stats of a capture. For a session, Preamble is the output
+// before the first frame and Frames the output of each tick; what follows a
+// program's shutdown is counted only in Total.
+type report struct {
+	Session  bool            `json:"session"`
+	Total    ansistat.Stats  `json:"total"`
+	Preamble *ansistat.Stats `json:"preamble,omitempty"`
+	Frames   []frameStats    `json:"frames,omitempty"`
+}
+
+type frameStats struct {
+	Tick   int `json:"tick"`
+	Writes int `json:"writes"`
+	ansistat.Stats
+}
+
+// analyze runs one Analyzer over the capture in order, so state such as the
+// last SGR carries from frame to frame as it would on a terminal.
+func analyze(data []byte) (report, error) {
+	a := ansistat.NewAnalyzer()
+	if !harness.IsSession(data) {
+		_, _ = a.Write(data)
+		return report{Total: a.Stats()}, nil
+	}
+	preamble, frames, err := harness.ParseSession(data)
+	if err != nil {
+		return report{}, err
+	}
+	if len(frames) == 0 {
+		return report{}, errors.New("session has no frames")
+	}
+	r := report{Session: true, Frames: make([]frameStats, 0, len(frames))}
+	_, _ = a.Write(preamble)
+	start := a.Stats()
+	r.Preamble = &start
+	for _, frame := range frames {
+		before := a.Stats()
+		for _, p := range frame.Writes {
+			_, _ = a.Write(p)
+		}
+		r.Frames = append(r.Frames, frameStats{Tick: frame.Tick, Writes: len(frame.Writes), Stats: a.Stats().Sub(before)})
+		_, _ = a.Write(frame.Trailer)
+	}
+	r.Total = a.Stats()
+	return r, nil
+}
+
+func (r report) write(w io.Writer, path string) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "%s\tbytes\tprinted\tcontrols\tsequences\tseq bytes\tsgr\tsgr bytes\tresets\trepeats\t\n", path)
+	row := func(name string, s ansistat.Stats) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t%d\t\n", name, s.Bytes, s.Printed, s.Controls,
+			s.Sequences, s.SequenceBytes, s.SGR.Sequences, s.SGR.Bytes, s.SGR.Resets, s.SGR.Repeats)
+	}
+	if r.Preamble != nil {
+		row("preamble", *r.Preamble)
+	}
+	row("total", r.Total)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(w, "\nsequences by kind:")
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, kind := range byCount(r.Total.Kinds) {
+		fmt.Fprintf(tw, "  %s\t%d\n", kind, r.Total.Kinds[kind])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(r.Frames) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w, "\nframes (%d):\n", len(r.Frames))
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "tick\twrites\tbytes\tprinted\tsequences\tsgr\trepeats\ttop kinds\t")
+	for _, f := range r.Frames {
+		kinds := byCount(f.Kinds)
+		top := make([]string, 0, 3)
+		for _, kind := range kinds[:min(3, len(kinds))] {
+			top = append(top, fmt.Sprintf("%s×%d", kind, f.Kinds[kind]))
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n", f.Tick, f.Writes, f.Bytes, f.Printed,
+			f.Sequences, f.SGR.Sequences, f.SGR.Repeats, strings.Join(top, " "))
+	}
+	return tw.Flush()
+}
+
+// byCount is the kinds most frequent first, ties by name.
+func byCount(kinds map[string]int64) []string {
+	out := make([]string, 0, len(kinds))
+	for kind := range kinds {
+		out = append(out, kind)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if kinds[out[i]] != kinds[out[j]] {
+			return kinds[out[i]] > kinds[out[j]]
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// component is a scenario built from stock bubbles components, as an app
+// author would write it: the components' own styles, sizing and default
+// key bindings. Each reads its data back out of the scenario's lines, so
+// every framework shows the same values, but the frames are the
+// components' own and verify reports them as mismatched.
+type component interface {
+	// setLines loads a frame's lines.
+	setLines(lines []string) tea.Cmd
+	setSize(width int, height int)
+	// update hands other messages, keys among them, to the components.
+	update(msg tea.Msg) tea.Cmd
+	view() string
+}
+
+// components maps scenarios to the component that renders them; the rest
+// show their lines in a viewport.
+var components = map[string]func() component{
+	"startup":               func() component { return newListComponent(2, "") },
+	"tree-construction":     func() component { return newListComponent(2, "") },
+	"content-update":        func() component { return newListComponent(1, ">") },
+	"scroll-stress":         func() component { return newListComponent(2, "▶") },
+	"virtual-list":          func() component { return newListComponent(2, "") },
+	"terminal-virtual-list": func() component { return newListComponent(2, "<") },
+	"tables":                func() component { return newTableComponent(2) },
+	"terminal-table":        func() component { return newTableComponent(0) },
+	"terminal-fps-stream":   newStreamComponent,
+}
+
+func newComponent(scenario string) component {
+	if newComp, ok := components[scenario]; ok {
+		return newComp()
+	}
+	return newViewportComponent()
+}
+
+// lineItem is a list row; the default delegate shows its title.
+type lineItem string
+
+func (i lineItem) Title() string       { return string(i) }
+func (i lineItem) Description() string { return "" }
+func (i lineItem) FilterValue() string { return string(i) }
+
+// listComponent shows a list scenario in a list.Model titled with its
+// header lines. The scenario's selection marker, where it has one, moves
+// the list's cursor.
+type listComponent struct {
+	split  int
+	marker string
+	list   list.Model
+	items  []list.Item
+}
+
+func newListComponent(split int, marker string) component {
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = false
+	return &listComponent{split: split, marker: marker, list: list.New(nil, delegate, 0, 0)}
+}
+
+func (c *listComponent) setLines(lines []string) tea.Cmd {
+	split := min(c.split, len(lines))
+	heading := make([]string, 0, split)
+	for _, line := range lines[:split] {
+		heading = append(heading, strings.TrimSpace(line))
+	}
+	c.list.Title = strings.Join(heading, " · ")
+
+	c.items = c.items[:0]
+	selected := -1
+	for i, line := range lines[split:] {
+		text := strings.TrimSpace(line)
+		if c.marker != "" && strings.Contains(text, c.marker) {
+			selected = i
+		}
+		c.items = append(c.items, lineItem(text))
+	}
+	cmd := c.list.SetItems(c.items)
+	if selected >= 0 {
+		c.list.Select(selected)
+	}
+	return cmd
+}
+
+func (c *listComponent) setSize(width int, height int) { c.list.SetSize(width, height) }
+
+func (c *listComponent) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	c.list, cmd = c.list.Update(msg)
+	return cmd
+}
+
+func (c *listComponent) view() string { return c.list.View() }
+
+// columnGap separates the cells of a table scenario's lines, which may
+// themselves hold single spaces.
+var columnGap = regexp.MustCompile(`\s{2,}`)
+
+// tableComponent shows a table scenario as its header lines over a
+// table.Model. The first line after them names the columns; a rule under
+// it is dropped, as the table draws its own.
+type tableComponent struct {
+	split   int
+	heading string
+	table   table.Model
+	height  int
+}
+
+func newTableComponent(split int) component {
+	return &tableComponent{split: split, table: table.New(table.WithFocused(true))}
+}
+
+func (c *tableComponent) setLines(lines []string) tea.Cmd {
+	split := min(c.split, len(lines))
+	heading := make([]string, 0, split)
+	for _, line := range lines[:split] {
+		heading = append(heading, strings.TrimRight(line, " "))
+	}
+	c.heading = strings.Join(heading, "\n")
+	if split == len(lines) {
+		c.table.SetRows(nil)
+		return nil
+	}
+
+	titles := columnGap.Split(strings.TrimSpace(lines[split]), -1)
+	body := lines[split+1:]
+	if len(body) > 0 && strings.Trim(body[0], "- ") == "" {
+		body = body[1:]
+	}
+	widths := make([]int, len(titles))
+	for i, title := range titles {
+		widths[i] = lipgloss.Width(title)
+	}
+	rows := make([]table.Row, 0, len(body))
+	for _, line := range body {
+		cells := columnGap.Split(strings.TrimSpace(line), -1)
+		for len(cells) < len(titles) {
+			cells = append(cells, "")
+		}
+		cells = cells[:len(titles)]
+		for i, cell := range cells {
+			widths[i] = max(widths[i], lipgloss.Width(cell))
+		}
+		rows = append(rows, cells)
+	}
+	columns := make([]table.Column, len(titles))
+	for i, title := range titles {
+		columns[i] = table.Column{Title: title, Width: widths[i]}
+	}
+	// Rows must fit the columns when they are set, so clear them first.
+	c.table.SetRows(nil)
+	c.table.SetColumns(columns)
+	c.table.SetRows(rows)
+	c.table.SetHeight(max(1, c.height-split))
+	return nil
+}
+
+func (c *tableComponent) setSize(width int, height int) {
+	c.height = height
+	c.table.SetWidth(width)
+	c.table.SetHeight(max(1, height-c.split))
+}
+
+func (c *tableComponent) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	c.table, cmd = c.table.Update(msg)
+	return cmd
+}
+
+func (c *tableComponent) view() string {
+	if c.heading == "" {
+		return c.table.View()
+	}
+	return c.heading + "\n" + c.table.View()
+}
+
+// streamComponent shows terminal-fps-stream as its header line, a
+// progress bar per channel, and the remaining rows in a viewport.
+type streamComponent struct {
+	header string
+	names  []string
+	values []float64
+	bars   []progress.Model
+	rest   viewport.Model
+	width  int
+	height int
+}
+
+func newStreamComponent() component {
+	return &streamComponent{rest: viewport.New(0, 0)}
+}
+
+func (c *streamComponent) setLines(lines []string) tea.Cmd {
+	if len(lines) == 0 {
+		return nil
+	}
+	c.header = strings.TrimRight(lines[0], " ")
+	c.names, c.values = c.names[:0], c.values[:0]
+	var rest []string
+	for _, line := range lines[1:] {
+		if name, percent, ok := parseChannel(line); ok {
+			c.names = append(c.names, name)
+			c.values = append(c.values, percent)
+			continue
+		}
+		rest = append(rest, strings.TrimRight(line, " "))
+	}
+	for len(c.bars) < len(c.names) {
+		c.bars = append(c.bars, progress.New(progress.WithDefaultGradient()))
+	}
+	c.rest.SetContent(strings.Join(rest, "\n"))
+	c.layout()
+	return nil
+}
+
+// layout sizes the bars to the width left after their labels and gives the
+// viewport the rows below them.
+func (c *streamComponent) layout() {
+	for i := range c.bars {
+		c.bars[i].Width = max(1, c.width-len("ch-00 100.0% "))
+	}
+	c.rest.Width = c.width
+	c.rest.Height = max(0, c.height-1-len(c.names))
+}
+
+func (c *streamComponent) setSize(width int, height int) {
+	c.width, c.height = width, height
+	c.layout()
+}
+
+func (c *streamComponent) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	c.rest, cmd = c.rest.Update(msg)
+	return cmd
+}
+
+func (c *streamComponent) view() string {
+	var b strings.Builder
+	b.WriteString(c.header)
+	for i, name := range c.names {
+		fmt.Fprintf(&b, "\n%s %5.1f%% %s", name, c.values[i], c.bars[i].ViewAs(c.values[i]/100))
+	}
+	b.WriteByte('\n')
+	b.WriteString(c.rest.View())
+	return b.String()
+}
+
+// parseChannel reads a "ch-03 ####---- 42.0%" telemetry line.
+func parseChannel(line string) (string, float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[0], "ch-") || !strings.HasSuffix(fields[len(fields)-1], "%") {
+		return "", 0, false
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "%"), 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return fields[0], percent, true
+}
+
+// viewportComponent shows any other scenario's lines in a viewport.Model.
+type viewportComponent struct {
+	viewport viewport.Model
+}
+
+func newViewportComponent() component {
+	return &viewportComponent{viewport: viewport.New(0, 0)}
+}
+
+func (c *viewportComponent) setLines(lines []string) tea.Cmd {
+	c.viewport.SetContent(strings.Join(lines, "\n"))
+	return nil
+}
+
+func (c *viewportComponent) setSize(width int, height int) {
+	c.viewport.Width = width
+	c.viewport.Height = height
+}
+
+func (c *viewportComponent) update(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	c.viewport, cmd = c.viewport.Update(msg)
+	return cmd
+}
+
+func (c *viewportComponent) view() string { return c.viewport.View() }
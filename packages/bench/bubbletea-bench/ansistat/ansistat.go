@@ -0,0 +1,242 @@
+// Package ansistat tallies what a terminal output stream is made of: the
+// characters it prints, its control bytes, its escape sequences by kind and
+// how much of it is SGR churn. It reads bytes as a terminal would, so it
+// works on output captured from any program, after the fact.
+package ansistat
+
+import "strings"
+
+// Stats is the makeup of a stretch of output.
+type Stats struct {
+	Bytes int64 `json:"bytes"`
+
+	// Printed counts characters printed, not the columns they cover.
+	Printed int64 `json:"printed"`
+
+	// Controls counts C0 control bytes other than ESC, such as CR and LF.
+	Controls int64 `json:"controls"`
+
+	Sequences     int64 `json:"sequences"`
+	SequenceBytes int64 `json:"sequenceBytes"`
+
+	// Kinds counts sequences by kind: "CSI " then any private marker and
+	// intermediates and the final byte ("CSI m", "CSI H", "CSI ?h"), "ESC "
+	// and the final byte ("ESC 7"), "OSC " and its number, or "DCS", "APC",
+	// "PM" and "SOS".
+	Kinds map[string]int64 `json:"kinds"`
+
+	SGR SGRStats `json:"sgr"`
+}
+
+// SGRStats is the SGR (CSI m) share of a stream.
+type SGRStats struct {
+	Sequences int64 `json:"sequences"`
+	Bytes     int64 `json:"bytes"`
+
+	// Resets counts SGRs that reset every attribute: CSI m and CSI 0 m.
+	Resets int64 `json:"resets"`
+
+	// Repeats counts SGRs identical to the one before them, which leave the
+	// attributes as they were: a renderer restating the style of every cell
+	// rather than only where it changes.
+	Repeats int64 `json:"repeats"`
+}
+
+// Sub returns the stats of the output between prev and s, where prev was
+// taken from the same Analyzer earlier.
+func (s Stats) Sub(prev Stats) Stats {
+	out := Stats{
+		Bytes:         s.Bytes - prev.Bytes,
+		Printed:       s.Printed - prev.Printed,
+		Controls:      s.Controls - prev.Controls,
+		Sequences:     s.Sequences - prev.Sequences,
+		SequenceBytes: s.SequenceBytes - prev.SequenceBytes,
+		Kinds:         map[string]int64{},
+		SGR: SGRStats{
+			Sequences: s.SGR.Sequences - prev.SGR.Sequences,
+			Bytes:     s.SGR.Bytes - prev.SGR.Bytes,
+			Resets:    s.SGR.Resets - prev.SGR.Resets,
+			Repeats:   s.SGR.Repeats - prev.SGR.Repeats,
+		},
+	}
+	for kind, n := range s.Kinds {
+		if n -= prev.Kinds[kind]; n != 0 {
+			out.Kinds[kind] = n
+		}
+	}
+	return out
+}
+
+const (
+	ground = iota
+	escape
+	escapeIntermediate
+	csi
+	str
+	strEscape
+)
+
+// maxSeq is how much of a sequence is kept to name it; the rest of a long
+// OSC or DCS payload is only counted.
+const maxSeq = 256
+
+// Analyzer tallies output written to it. State carries across writes, so a
+// sequence split between them is counted once. It is not safe for
+// concurrent use.
+type Analyzer struct {
+	stats   Stats
+	state   int
+	seq     []byte
+	seqLen  int64
+	lastSGR string
+}
+
+// NewAnalyzer returns an Analyzer at the start of a stream.
+func NewAnalyzer() *Analyzer {
+	return &Analyzer{stats: Stats{Kinds: map[string]int64{}}}
+}
+
+// Analyze returns the stats of p as a whole stream.
+func Analyze(p []byte) Stats {
+	a := NewAnalyzer()
+	_, _ = a.Write(p)
+	return a.Stats()
+}
+
+// Printed is Stats().Printed without copying the rest.
+func (a *Analyzer) Printed() int64 {
+	return a.stats.Printed
+}
+
+// Stats returns the tallies so far.
+func (a *Analyzer) Stats() Stats {
+	out := a.stats
+	out.Kinds = make(map[string]int64, len(a.stats.Kinds))
+	for kind, n := range a.stats.Kinds {
+		out.Kinds[kind] = n
+	}
+	return out
+}
+
+// Write tallies p. It never fails.
+func (a *Analyzer) Write(p []byte) (int, error) {
+	a.stats.Bytes += int64(len(p))
+	for _, b := range p {
+		switch a.state {
+		case ground:
+			switch {
+			case b == 0x1b:
+				a.begin(b)
+				a.state = escape
+			case b < 0x20 || b == 0x7f:
+				a.stats.Controls++
+			case b >= 0x80 && b < 0xc0:
+				// A UTF-8 continuation byte of a character already counted.
+			default:
+				a.stats.Printed++
+			}
+		case escape:
+			a.add(b)
+			switch {
+			case b == '[':
+				a.state = csi
+			case b == ']' || b == 'P' || b == '_' || b == '^' || b == 'X':
+				a.state = str
+			case b >= 0x20 && b <= 0x2f:
+				a.state = escapeIntermediate
+			default:
+				a.end()
+			}
+		case escapeIntermediate:
+			a.add(b)
+			if b < 0x20 || b > 0x2f {
+				a.end()
+			}
+		case csi:
+			a.add(b)
+			if b >= 0x40 && b <= 0x7e {
+				a.end()
+			}
+		case str:
+			a.add(b)
+			if b == 0x07 {
+				a.end()
+			} else if b == 0x1b {
+				a.state = strEscape
+			}
+		case strEscape:
+			a.add(b)
+			if b == '\\' {
+				a.end()
+			} else {
+				a.state = str
+			}
+		}
+	}
+	return len(p), nil
+}
+
+func (a *Analyzer) begin(b byte) {
+	a.seq = append(a.seq[:0], b)
+	a.seqLen = 1
+}
+
+func (a *Analyzer) add(b byte) {
+	if len(a.seq) < maxSeq {
+		a.seq = append(a.seq, b)
+	}
+	a.seqLen++
+}
+
+// end counts the sequence just completed.
+func (a *Analyzer) end() {
+	a.state = ground
+	a.stats.Sequences++
+	a.stats.SequenceBytes += a.seqLen
+	kind := kindOf(a.seq)
+	a.stats.Kinds[kind]++
+	if kind != "CSI m" || int64(len(a.seq)) != a.seqLen {
+		return
+	}
+	a.stats.SGR.Sequences++
+	a.stats.SGR.Bytes += a.seqLen
+	params := string(a.seq[2 : len(a.seq)-1])
+	if params == "" || params == "0" {
+		a.stats.SGR.Resets++
+	}
+	if params == a.lastSGR {
+		a.stats.SGR.Repeats++
+	}
+	a.lastSGR = params
+}
+
+// kindOf names a sequence for Stats.Kinds.
+func kindOf(seq []byte) string {
+	if len(seq) < 2 {
+		return "ESC"
+	}
+	body := seq[2:]
+	switch seq[1] {
+	case '[':
+		var kind strings.Builder
+		kind.WriteString("CSI ")
+		for _, b := range body {
+			if (b >= '<' && b <= '?') || (b >= 0x20 && b <= 0x2f) || (b >= 0x40 && b <= 0x7e) {
+				kind.WriteByte(b)
+			}
+		}
+		return kind.String()
+	case ']':
+		number, _, _ := strings.Cut(strings.TrimRight(string(body), "\x07\x1b\\"), ";")
+		return "OSC " + number
+	case 'P':
+		return "DCS"
+	case '_':
+		return "APC"
+	case '^':
+		return "PM"
+	case 'X':
+		return "SOS"
+	}
+	return "ESC " + string(seq[1:])
+}
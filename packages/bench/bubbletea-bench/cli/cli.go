@@ -0,0 +1,1878 @@
+// Package cli is the command line the Go bench binaries share: flags, modes
+// and result documents around harness runs. A binary supplies its Framework
+// and calls Main, so every framework answers the same CLI contract with the
+// same result schema.
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+	"gopkg.in/yaml.v3"
+)
+
+// Timeouts bound how long a session may take to start, to render one tick
+// and to shut down before the run fails.
+type Timeouts struct {
+	Startup  time.Duration
+	Tick     time.Duration
+	Shutdown time.Duration
+}
+
+var defaultTimeouts = Timeouts{Startup: 3 * time.Second, Tick: 3 * time.Second, Shutdown: 3 * time.Second}
+
+// Framework is the part of a bench binary specific to the framework it
+// benches.
+type Framework struct {
+	// Modules are the framework modules whose versions a result records.
+	Modules []string
+	// Start returns the harness.StartFunc that launches the program under
+	// test, bounded by timeouts.
+	Start func(timeouts Timeouts) harness.StartFunc
+}
+
+// cliArgs is a harness.Config plus the flags that only the CLI acts on.
+type cliArgs struct {
+	harness.Config
+
+	mode        string
+	resultPath  string
+	archivePath string
+
+	// appendResults, from --append, adds each document to --result-path as
+	// an NDJSON line instead of replacing the file. inputs are the files
+	// merge combines or validate checks.
+	appendResults bool
+	inputs        []string
+
+	scenarioPlugin string
+	scenarioExec   string
+	scenarioFiles  []string
+	labels         map[string]string
+
+	// dumpTick and styles are dump's --tick and --styles; fixturesDir is
+	// fixtures' --out.
+	dumpTick    int
+	styles      bool
+	fixturesDir string
+
+	// markdown and diffJSON are diff's --markdown and --json; calibration
+	// is aggregate's --calibration.
+	markdown    bool
+	diffJSON    bool
+	calibration string
+
+	// manifestPath is parity's --manifest.
+	manifestPath string
+
+	// format is the --emit format. With "ndjson", records is where iteration
+	// records stream during the run, followed by the summary document.
+	format  string
+	records *os.File
+
+	metricsAddr   string
+	metricsLinger time.Duration
+
+	// heartbeat is the --heartbeat fd or path, written every heartbeatEvery.
+	heartbeat      string
+	heartbeatEvery time.Duration
+
+	baselinePath string
+	baseline     *harness.ResultFile
+	gateRules    []harness.GateRule
+	assertions   []harness.Assertion
+
+	storePath  string
+	queryLimit int
+
+	// replayPath, from --replay, is a result whose scenario, params, seed
+	// and ticks verify renders again.
+	replayPath string
+
+	// strictArgs, from --strict-args, rejects flags that are neither
+	// harness flags nor a param of any scenario instead of passing them on.
+	strictArgs bool
+
+	// sweep, from --sweep, runs the scenario at every combination of these
+	// params instead of once.
+	sweep []harness.SweepAxis
+
+	// fuzzTrials, from --fuzz-params, runs the scenario that many times at
+	// random values of the params not given.
+	fuzzTrials int
+
+	run    *harness.RunInfo
+	stderr *harness.StderrCapture
+
+	// gomaxprocs, gcPercent and memoryLimit override the runtime's settings
+	// when set; gcPercent -1 turns the collector off.
+	gomaxprocs  int
+	gcPercent   *int
+	memoryLimit int64
+
+	// cgroup, from --cgroup, runs the process in a transient cgroup under
+	// cgroupParent with cgroupLimits, reported in every document.
+	cgroup       bool
+	cgroupParent string
+	cgroupLimits harness.CgroupLimits
+	sandbox      *harness.Cgroup
+
+	timeouts Timeouts
+}
+
+// envPrefix marks environment variables that give flag defaults:
+// REZI_BENCH_TICK_TIMEOUT=10s is --tick-timeout 10s.
+const envPrefix = "REZI_BENCH_"
+
+// envFlagNames are the harness flags an environment variable may default.
+// Other REZI_BENCH_* variables belong to the TypeScript suite, which sets
+// them for every framework it runs, and are ignored here.
+var envFlagNames = map[string]bool{
+	"warmup": true, "iterations": true, "duration": true, "fps": true, "io": true,
+	"seed": true, "repeats": true, "cooldown": true, "retries": true,
+	"outlier-policy": true, "tail-kb": true, "gomaxprocs": true, "gogc": true,
+	"memlimit": true, "store": true, "strict-args": true,
+	"startup-timeout": true, "tick-timeout": true, "shutdown-timeout": true,
+}
+
+// envFlags turns the REZI_BENCH_* variables for flags in envFlagNames that
+// given does not hold into --flag=value arguments, sorted so the order does
+// not depend on the environment's.
+func envFlags(environ []string, given map[string]bool) []string {
+	flags := []string{}
+	for _, entry := range environ {
+		name, value, _ := strings.Cut(entry, "=")
+		key, ok := strings.CutPrefix(name, envPrefix)
+		if !ok {
+			continue
+		}
+		flag := strings.ToLower(strings.ReplaceAll(key, "_", "-"))
+		if !envFlagNames[flag] || given[flag] {
+			continue
+		}
+		flags = append(flags, "--"+flag+"="+value)
+	}
+	sort.Strings(flags)
+	return flags
+}
+
+// givenFlags is the set of flags args names, from the command line or a
+// --config file.
+func givenFlags(args []string) map[string]bool {
+	given := map[string]bool{}
+	for i := 0; i < len(args); i++ {
+		arg, ok := strings.CutPrefix(args[i], "--")
+		if !ok {
+			continue
+		}
+		key, _, hasValue := strings.Cut(arg, "=")
+		given[key] = true
+		if !hasValue && !boolFlags[key] {
+			i++
+		}
+	}
+	return given
+}
+
+// expandConfig splices the flags described by a --config file in front of the
+// command-line flags, so anything given on the command line overrides it, and
+// the REZI_BENCH_* defaults for flags neither gives in front of both. An
+// environment value is never parsed when a flag overrides it. The file, which
+// REZI_BENCH_CONFIG names when --config does not, maps flag names to values,
+// plus "params" for scenario parameters and "labels" for free-form run labels:
+//
+//	scenario: terminal-full-ui
+//	iterations: 5000
+//	io: pty
+//	params: {rows: 40, services: 24}
+//	labels: {host: ci-arm64}
+func expandConfig(argv []string) ([]string, error) {
+	path := os.Getenv(envPrefix + "CONFIG")
+	rest := []string{}
+	for i := 1; i < len(argv); i++ {
+		if value, ok := strings.CutPrefix(argv[i], "--config="); ok {
+			path = value
+			continue
+		}
+		if argv[i] == "--config" && i+1 < len(argv) {
+			path = argv[i+1]
+			i++
+			continue
+		}
+		rest = append(rest, argv[i])
+	}
+	flags, err := configFlags(path)
+	if err != nil {
+		return nil, err
+	}
+
+	out := []string{argv[0]}
+	if len(rest) > 0 && !strings.HasPrefix(rest[0], "--") {
+		out = append(out, rest[0])
+		rest = rest[1:]
+	}
+	args := append(flags, rest...)
+	out = append(out, envFlags(os.Environ(), givenFlags(args))...)
+	return append(out, args...), nil
+}
+
+// configFlags reads the --config file at path into flags; none without one.
+func configFlags(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read --config: %w", err)
+	}
+	var doc map[string]any
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal(data, &doc)
+	} else {
+		err = yaml.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse --config: %w", err)
+	}
+
+	flags := []string{}
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case "config":
+			return nil, errors.New("--config files cannot include another config")
+		case "params", "labels":
+			entries, ok := doc[key].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("--config %s must be a mapping", key)
+			}
+			names := make([]string, 0, len(entries))
+			for name := range entries {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				value, err := configScalar(key+"."+name, entries[name])
+				if err != nil {
+					return nil, err
+				}
+				if key == "labels" {
+					flags = append(flags, "--label", name+"="+value)
+				} else {
+					flags = append(flags, "--"+name, value)
+				}
+			}
+		default:
+			value, err := configScalar(key, doc[key])
+			if err != nil {
+				return nil, err
+			}
+			flags = append(flags, "--"+key, value)
+		}
+	}
+	return flags, nil
+}
+
+func configScalar(key string, value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return v, nil
+	case int:
+		return strconv.Itoa(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case nil:
+		return "", fmt.Errorf("--config %s has no value (quote YAML null as \"null\")", key)
+	default:
+		return "", fmt.Errorf("--config %s must be a scalar", key)
+	}
+}
+
+// boolFlags take no value, though --flag=false is accepted for scripts.
+var boolFlags = map[string]bool{"print-schema": true, "selftest": true, "styles": true, "markdown": true, "json": true, "strict-args": true, "append": true, "cgroup": true}
+
+// checkParamFlags rejects params no registered scenario declares, which
+// without --strict-args are passed through and ignored.
+func checkParamFlags(params map[string]string) error {
+	known := map[string]bool{}
+	for _, info := range harness.Scenarios() {
+		for _, spec := range info.Params {
+			known[spec.Name] = true
+		}
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if !known[key] {
+			return fmt.Errorf("unknown flag --%s", key)
+		}
+	}
+	return nil
+}
+
+func parseArgs(argv []string, fw Framework) (cliArgs, error) {
+	argv, err := expandConfig(argv)
+	if err != nil {
+		return cliArgs{}, err
+	}
+	out := cliArgs{
+		Config: harness.Config{
+			Scenario:   "",
+			Warmup:     100,
+			Iterations: 1000,
+			FPS:        1000,
+			IO:         "pty",
+			Params:     map[string]string{},
+			WarmupMax:  2000,
+
+			SoakInterval: time.Minute,
+
+			OutlierFactor: 3,
+			TailKb:        4,
+			VerifyTicks:   10,
+		},
+		resultPath: "",
+		mode:       "run",
+		labels:     map[string]string{},
+		format:     "json",
+		queryLimit: 50,
+		timeouts:   defaultTimeouts,
+
+		heartbeatEvery: time.Second,
+	}
+
+	for i := 1; i < len(argv); i++ {
+		arg := argv[i]
+		if !strings.HasPrefix(arg, "--") {
+			if i == 1 && (arg == "verify" || arg == "export-frames" || arg == "fixtures" || arg == "parity" || arg == "dump" || arg == "list-scenarios" || arg == "list" || arg == "query" || arg == "merge" || arg == "validate" || arg == "diff" || arg == "aggregate") {
+				out.mode = arg
+			} else if out.mode == "merge" || out.mode == "validate" || out.mode == "diff" || out.mode == "aggregate" {
+				out.inputs = append(out.inputs, arg)
+			}
+			continue
+		}
+		key, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if boolFlags[key] {
+			on := true
+			if hasValue {
+				b, err := strconv.ParseBool(value)
+				if err != nil {
+					return out, fmt.Errorf("--%s takes no value or true|false, got %q", key, value)
+				}
+				on = b
+			}
+			switch {
+			case key == "strict-args":
+				out.strictArgs = on
+			case key == "append":
+				out.appendResults = on
+			case key == "cgroup":
+				out.cgroup = on
+			case key == "styles":
+				out.styles = on
+			case key == "markdown":
+				out.markdown = on
+			case !on:
+			case key == "json" && out.mode == "diff":
+				out.diffJSON = true
+			case key == "json":
+				if out.mode != "list" && out.mode != "list-scenarios" {
+					return out, errors.New("--json is only valid after list or diff")
+				}
+				out.mode = "list-scenarios"
+			default:
+				out.mode = key
+			}
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(argv) {
+				return out, fmt.Errorf("missing value for %s", arg)
+			}
+			value = argv[i+1]
+			i++
+		}
+
+		switch key {
+		case "scenario":
+			out.Scenario = value
+		case "warmup":
+			if value == "auto" {
+				out.WarmupAuto = true
+				break
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --warmup: %w", err)
+			}
+			out.Warmup = n
+			out.WarmupAuto = false
+		case "warmup-max":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return out, errors.New("--warmup-max must be a positive integer")
+			}
+			out.WarmupMax = n
+		case "warmup-cv":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f <= 0 {
+				return out, errors.New("--warmup-cv must be a positive number")
+			}
+			out.WarmupCV = f
+		case "duration":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, errors.New("--duration must be a positive duration like 30s")
+			}
+			out.Duration = d
+		case "cooldown":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, errors.New("--cooldown must be a positive duration like 5ms")
+			}
+			out.Cooldown = d
+		case "between-iterations":
+			if !slices.Contains(harness.BetweenPolicies, value) {
+				return out, fmt.Errorf("--between-iterations must be %s", strings.Join(harness.BetweenPolicies, "|"))
+			}
+			out.BetweenIterations = value
+		case "scratch-mb":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return out, errors.New("--scratch-mb must be a positive integer")
+			}
+			out.ScratchMB = n
+		case "resize-schedule":
+			steps, err := harness.ParseResizeSchedule(value)
+			if err != nil {
+				return out, err
+			}
+			out.ResizeSchedule = steps
+		case "sessions":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return out, errors.New("--sessions must be a positive integer")
+			}
+			out.Sessions = n
+		case "sweep":
+			axes, err := harness.ParseSweep(value)
+			if err != nil {
+				return out, err
+			}
+			for _, axis := range axes {
+				for _, prev := range out.sweep {
+					if prev.Param == axis.Param {
+						return out, fmt.Errorf("--sweep axis %q given twice", axis.Param)
+					}
+				}
+			}
+			out.sweep = append(out.sweep, axes...)
+		case "fuzz-params":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return out, errors.New("--fuzz-params must be a positive integer")
+			}
+			out.fuzzTrials = n
+		case "throughput":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, errors.New("--throughput must be a positive duration like 10s")
+			}
+			out.Throughput = d
+		case "soak", "checkpoint-every":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, fmt.Errorf("--%s must be a positive duration like 2h", key)
+			}
+			if key == "soak" {
+				out.Soak = d
+			} else {
+				out.SoakInterval = d
+			}
+		case "seed":
+			n, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return out, errors.New("--seed must be a non-negative integer")
+			}
+			out.Seed = n
+		case "repeats":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return out, errors.New("--repeats must be a positive integer")
+			}
+			out.Repeats = n
+		case "gomaxprocs":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return out, errors.New("--gomaxprocs must be a positive integer")
+			}
+			out.gomaxprocs = n
+		case "gogc":
+			percent := -1
+			if value != "off" {
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return out, errors.New("--gogc must be a non-negative integer or off")
+				}
+				percent = n
+			}
+			out.gcPercent = &percent
+		case "load-cpu":
+			f, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+			if err != nil || f <= 0 || f > 100 {
+				return out, errors.New("--load-cpu must be a percentage in (0, 100] like 50%")
+			}
+			out.LoadCPUPercent = f
+		case "load-alloc":
+			f, err := strconv.ParseFloat(strings.TrimSuffix(value, "MB/s"), 64)
+			if err != nil || f <= 0 {
+				return out, errors.New("--load-alloc must be a positive rate like 64MB/s")
+			}
+			out.LoadAllocMBps = f
+		case "memlimit":
+			n, err := parseMemoryLimit("memlimit", value)
+			if err != nil {
+				return out, err
+			}
+			out.memoryLimit = n
+		case "cgroup-memory":
+			n, err := parseMemoryLimit(key, value)
+			if err != nil {
+				return out, err
+			}
+			out.cgroupLimits.MemoryBytes = n
+		case "cgroup-cpus":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil || f < 0.01 {
+				return out, errors.New("--cgroup-cpus must be a number of cores like 1.5, at least 0.01")
+			}
+			out.cgroupLimits.CPUs = f
+		case "cgroup-parent":
+			out.cgroupParent = value
+		case "pace":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return out, errors.New("--pace must be a positive frame rate")
+			}
+			out.PaceFPS = n
+		case "retries":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return out, errors.New("--retries must be a non-negative integer")
+			}
+			out.Retries = n
+		case "iterations":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --iterations: %w", err)
+			}
+			out.Iterations = n
+		case "fps":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --fps: %w", err)
+			}
+			out.FPS = n
+		case "io":
+			switch value {
+			case "null", "stub":
+				// "stub" predates the sink selector and still means null.
+				out.IO = "null"
+			case "pipe", "file", "pty", "inherit", "tmux":
+				out.IO = value
+			default:
+				return out, fmt.Errorf("invalid --io %q (expected null|pipe|file|pty|inherit|tmux)", value)
+			}
+		case "consumer-cps":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n <= 0 {
+				return out, errors.New("--consumer-cps must be a positive integer")
+			}
+			out.ConsumerCPS = n
+		case "sink-path":
+			out.SinkPath = value
+		case "result-path":
+			out.resultPath = value
+		case "outlier-factor":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return out, fmt.Errorf("invalid --outlier-factor: %w", err)
+			}
+			out.OutlierFactor = f
+		case "outlier-policy":
+			if value != "keep" && value != "trim" && value != "winsorize" {
+				return out, errors.New("--outlier-policy must be keep, trim or winsorize")
+			}
+			out.OutlierPolicy = value
+		case "cold-frames":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --cold-frames: %w", err)
+			}
+			out.ColdFrames = n
+		case "throttle-bps":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return out, fmt.Errorf("invalid --throttle-bps: %w", err)
+			}
+			out.ThrottleBps = n
+		case "tail-kb":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return out, errors.New("--tail-kb must be a non-negative integer")
+			}
+			out.TailKb = n
+		case "record":
+			out.RecordPath = value
+		case "label":
+			name, text, ok := strings.Cut(value, "=")
+			if !ok || name == "" {
+				return out, fmt.Errorf("invalid --label %q (expected key=value)", value)
+			}
+			out.labels[name] = text
+		case "scenario-plugin":
+			out.scenarioPlugin = value
+		case "scenario-exec":
+			out.scenarioExec = value
+		case "scenario-file":
+			out.scenarioFiles = append(out.scenarioFiles, value)
+		case "archive":
+			out.archivePath = value
+		case "emit-stream":
+			out.EmitStream = value
+		case "emit":
+			if value != "json" && value != "ndjson" {
+				return out, errors.New("--emit must be json or ndjson")
+			}
+			out.format = value
+		case "startup-timeout", "tick-timeout", "shutdown-timeout":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, fmt.Errorf("--%s must be a positive duration like 10s", key)
+			}
+			switch key {
+			case "startup-timeout":
+				out.timeouts.Startup = d
+			case "tick-timeout":
+				out.timeouts.Tick = d
+			default:
+				out.timeouts.Shutdown = d
+			}
+		case "store":
+			out.storePath = value
+		case "limit":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return out, errors.New("--limit must be a positive integer")
+			}
+			out.queryLimit = n
+		case "baseline":
+			out.baselinePath = value
+		case "fail-on":
+			rules, err := harness.ParseGateRules(value)
+			if err != nil {
+				return out, err
+			}
+			out.gateRules = append(out.gateRules, rules...)
+		case "assert":
+			assertions, err := harness.ParseAssertions(value)
+			if err != nil {
+				return out, err
+			}
+			out.assertions = append(out.assertions, assertions...)
+		case "metrics-addr":
+			if _, _, err := net.SplitHostPort(value); err != nil {
+				return out, fmt.Errorf("invalid --metrics-addr (expected host:port): %w", err)
+			}
+			out.metricsAddr = value
+		case "heartbeat":
+			out.heartbeat = value
+		case "heartbeat-every":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, errors.New("--heartbeat-every must be a positive duration like 1s")
+			}
+			out.heartbeatEvery = d
+		case "progress":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return out, errors.New("--progress must be a positive duration like 10s")
+			}
+			out.ProgressEvery = d
+		case "metrics-linger":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return out, errors.New("--metrics-linger must be a non-negative duration")
+			}
+			out.metricsLinger = d
+		case "samples-csv":
+			out.SamplesCSV = value
+		case "session":
+			out.SessionPath = value
+		case "replay":
+			out.replayPath = value
+		case "golden":
+			out.GoldenDir = value
+		case "out":
+			out.fixturesDir = value
+		case "calibration":
+			out.calibration = value
+		case "manifest":
+			out.manifestPath = value
+		case "ticks":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --ticks: %w", err)
+			}
+			out.VerifyTicks = n
+		case "tick":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return out, fmt.Errorf("invalid --tick %q (expected a tick >= 0)", value)
+			}
+			out.dumpTick = n
+		case "tick-list":
+			ticks, err := parseTickList(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --tick-list: %w", err)
+			}
+			out.ReplayTicks = ticks
+		case "io-latency":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --io-latency: %w", err)
+			}
+			out.IOLatency = d
+		case "backpressure-bytes":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --backpressure-bytes: %w", err)
+			}
+			out.BackpressureBytes = n
+		case "reader-stall":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --reader-stall: %w", err)
+			}
+			out.ReaderStall = d
+		case "reader-stall-every":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --reader-stall-every: %w", err)
+			}
+			out.ReaderStallEvery = d
+		case "inject-write-errors":
+			points, err := harness.ParseWriteErrorPoints(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --inject-write-errors: %w", err)
+			}
+			out.WriteErrors = points
+		case "short-write-prob":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return out, fmt.Errorf("invalid --short-write-prob: %w", err)
+			}
+			out.ShortWriteProb = f
+		case "io-jitter":
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return out, fmt.Errorf("invalid --io-jitter: %w", err)
+			}
+			out.IOJitter = d
+		default:
+			out.Params[key] = value
+		}
+	}
+
+	if out.mode == "list-scenarios" || out.mode == "list" || out.mode == "print-schema" || out.mode == "selftest" {
+		return out, nil
+	}
+	if out.appendResults && out.resultPath == "" {
+		return out, errors.New("--append requires --result-path")
+	}
+	if !out.cgroup && (out.cgroupParent != "" || out.cgroupLimits != harness.CgroupLimits{}) {
+		return out, errors.New("--cgroup-memory, --cgroup-cpus and --cgroup-parent require --cgroup")
+	}
+	if out.calibration != "" && out.mode != "aggregate" {
+		return out, errors.New("--calibration is only valid with aggregate")
+	}
+	if out.mode == "diff" {
+		if len(out.inputs) != 2 {
+			return out, errors.New("diff requires two result files, old then new")
+		}
+		if out.markdown && out.diffJSON {
+			return out, errors.New("--markdown and --json are mutually exclusive")
+		}
+		return out, nil
+	}
+	if out.mode == "merge" || out.mode == "validate" || out.mode == "aggregate" {
+		if len(out.inputs) == 0 {
+			return out, fmt.Errorf("%s requires at least one result file", out.mode)
+		}
+		return out, nil
+	}
+	if out.mode == "dump" {
+		if out.Scenario == "" {
+			return out, errors.New("missing --scenario")
+		}
+		out.Start = fw.Start(out.timeouts)
+		return out, nil
+	}
+	if out.mode == "export-frames" {
+		if out.GoldenDir == "" {
+			return out, errors.New("export-frames requires --golden")
+		}
+		if out.ReplayTicks == nil && out.VerifyTicks <= 0 {
+			return out, errors.New("--ticks must be > 0")
+		}
+		return out, nil
+	}
+	if out.mode == "parity" {
+		if out.manifestPath == "" {
+			return out, errors.New("parity requires --manifest")
+		}
+		if out.ReplayTicks == nil && out.VerifyTicks <= 0 {
+			return out, errors.New("--ticks must be > 0")
+		}
+		return out, nil
+	}
+	if out.mode == "fixtures" {
+		if out.fixturesDir == "" {
+			return out, errors.New("fixtures requires --out")
+		}
+		if out.ReplayTicks == nil && out.VerifyTicks <= 0 {
+			return out, errors.New("--ticks must be > 0")
+		}
+		return out, nil
+	}
+	if out.mode == "query" {
+		if out.storePath == "" || out.Scenario == "" {
+			return out, errors.New("query requires --store and --scenario")
+		}
+		return out, nil
+	}
+	if out.ReplayTicks != nil && out.mode != "verify" {
+		return out, errors.New("--tick-list is only valid with verify, export-frames, fixtures and parity")
+	}
+	if out.replayPath != "" {
+		if out.mode != "verify" || out.SessionPath != "" || out.ReplayTicks != nil {
+			return out, errors.New("--replay is only valid with verify and without --session or --tick-list")
+		}
+		info, err := harness.LoadReplay(out.replayPath, out.Scenario)
+		if err != nil {
+			return out, err
+		}
+		out.Scenario = info.Scenario
+		out.Params = info.Params
+		out.Seed = info.Seed
+		out.ReplayTicks = info.TickList()
+	}
+	if out.Scenario == "" {
+		return out, errors.New("missing --scenario")
+	}
+	if out.Iterations <= 0 {
+		return out, errors.New("--iterations must be > 0")
+	}
+	if out.Warmup < 0 {
+		return out, errors.New("--warmup must be >= 0")
+	}
+	if out.FPS <= 0 {
+		return out, errors.New("--fps must be > 0")
+	}
+	if out.OutlierFactor <= 1 {
+		return out, errors.New("--outlier-factor must be > 1")
+	}
+	if out.ColdFrames < 0 || (out.Duration == 0 && out.ColdFrames >= out.Iterations) {
+		return out, errors.New("--cold-frames must be >= 0 and < --iterations")
+	}
+	if out.Soak > 0 {
+		switch {
+		case out.Scenario == "startup":
+			return out, errors.New("--soak is not supported for the startup scenario")
+		case out.Duration > 0:
+			return out, errors.New("--soak and --duration are mutually exclusive")
+		case out.PaceFPS > 0 || out.SamplesCSV != "" || out.RecordPath != "":
+			return out, errors.New("--soak does not support --pace, --samples-csv or --record")
+		}
+	}
+	if len(out.sweep) > 0 && (out.mode == "verify" || out.RecordPath != "" || out.archivePath != "" || out.SamplesCSV != "" || out.format == "ndjson" || out.baselinePath != "" || out.storePath != "" || len(out.assertions) > 0) {
+		return out, errors.New("--sweep does not support verify, --record, --archive, --samples-csv, --emit ndjson, --baseline, --store or --assert")
+	}
+	if out.fuzzTrials > 0 && (len(out.sweep) > 0 || out.mode == "verify" || out.RecordPath != "" || out.archivePath != "" || out.SamplesCSV != "" || out.format == "ndjson" || out.baselinePath != "" || out.storePath != "" || len(out.assertions) > 0) {
+		return out, errors.New("--fuzz-params does not support --sweep, verify, --record, --archive, --samples-csv, --emit ndjson, --baseline, --store or --assert")
+	}
+	if out.Cooldown > 0 && (out.PaceFPS > 0 || out.Soak > 0 || out.Throughput > 0 || out.Sessions > 1) {
+		return out, errors.New("--cooldown does not support --pace, --soak, --throughput or --sessions")
+	}
+	if (out.BetweenIterations != "" || out.ScratchMB > 0) && (out.PaceFPS > 0 || out.Soak > 0 || out.Throughput > 0 || out.Sessions > 1) {
+		return out, errors.New("--between-iterations and --scratch-mb do not support --pace, --soak, --throughput or --sessions")
+	}
+	if len(out.ResizeSchedule) > 0 {
+		last := out.ResizeSchedule[len(out.ResizeSchedule)-1].At
+		switch {
+		case out.Scenario == "startup":
+			return out, errors.New("--resize-schedule is not supported for the startup scenario")
+		case out.Soak > 0 || out.Throughput > 0 || out.Sessions > 1:
+			return out, errors.New("--resize-schedule does not support --soak, --throughput or --sessions")
+		case out.Duration == 0 && last >= out.Iterations:
+			return out, fmt.Errorf("--resize-schedule step at iteration %d is past --iterations %d", last, out.Iterations)
+		}
+	}
+	if out.Sessions > 1 {
+		switch {
+		case out.Scenario == "startup":
+			return out, errors.New("--sessions is not supported for the startup scenario")
+		case out.Soak > 0 || out.Throughput > 0 || out.PaceFPS > 0 || out.Retries > 0:
+			return out, errors.New("--sessions does not support --soak, --throughput, --pace or --retries")
+		case out.SamplesCSV != "" || out.RecordPath != "" || out.archivePath != "":
+			return out, errors.New("--sessions does not support --samples-csv, --record or --archive")
+		}
+	}
+	if out.Throughput > 0 {
+		switch {
+		case out.Scenario == "startup":
+			return out, errors.New("--throughput is not supported for the startup scenario")
+		case out.Duration > 0 || out.Soak > 0:
+			return out, errors.New("--throughput, --soak and --duration are mutually exclusive")
+		case out.PaceFPS > 0 || out.SamplesCSV != "" || out.Retries > 0:
+			return out, errors.New("--throughput does not support --pace, --samples-csv or --retries")
+		}
+	}
+	if out.VerifyTicks <= 0 {
+		return out, errors.New("--ticks must be > 0")
+	}
+	if out.ThrottleBps < 0 {
+		return out, errors.New("--throttle-bps must be >= 0")
+	}
+	if out.IOLatency < 0 || out.IOJitter < 0 {
+		return out, errors.New("--io-latency and --io-jitter must be >= 0")
+	}
+	if out.ShortWriteProb < 0 || out.ShortWriteProb > 1 {
+		return out, errors.New("--short-write-prob must be within [0, 1]")
+	}
+	if out.BackpressureBytes < 0 {
+		return out, errors.New("--backpressure-bytes must be >= 0")
+	}
+	if (out.ReaderStall > 0 || out.ReaderStallEvery > 0) && out.BackpressureBytes == 0 {
+		return out, errors.New("--reader-stall requires --backpressure-bytes")
+	}
+	if out.IO == "inherit" && out.resultPath == "" {
+		return out, errors.New("--io inherit writes frames to stdout and requires --result-path")
+	}
+	if out.SinkPath != "" && out.IO != "file" {
+		return out, errors.New("--sink-path requires --io file")
+	}
+	if out.ConsumerCPS > 0 && out.IO != "pty" && out.IO != "tmux" {
+		return out, errors.New("--consumer-cps requires --io pty or --io tmux")
+	}
+	if out.Repeats > 1 && (out.mode == "verify" || out.RecordPath != "" || out.archivePath != "" || out.SamplesCSV != "") {
+		return out, errors.New("--repeats does not support verify, --record, --archive or --samples-csv")
+	}
+	if (out.baselinePath == "") != (len(out.gateRules) == 0) {
+		return out, errors.New("--baseline and --fail-on must be given together")
+	}
+	if out.storePath != "" && out.mode == "verify" {
+		return out, errors.New("--store is not supported in verify mode")
+	}
+	if out.baselinePath != "" && out.mode == "verify" {
+		return out, errors.New("--baseline is not supported in verify mode")
+	}
+	if len(out.assertions) > 0 && out.mode == "verify" {
+		return out, errors.New("--assert is not supported in verify mode")
+	}
+	if out.format == "ndjson" && (out.mode == "verify" || out.Repeats > 1) {
+		return out, errors.New("--emit ndjson does not support verify or --repeats")
+	}
+	if out.SamplesCSV != "" && out.mode == "verify" {
+		return out, errors.New("--samples-csv is not supported in verify mode")
+	}
+	if out.archivePath != "" && out.mode == "verify" {
+		return out, errors.New("--archive is not supported in verify mode")
+	}
+	if out.EmitStream != "" {
+		if out.mode == "verify" {
+			return out, errors.New("--emit-stream is not supported in verify mode")
+		}
+		if _, _, err := net.SplitHostPort(out.EmitStream); err != nil {
+			return out, fmt.Errorf("invalid --emit-stream (expected host:port): %w", err)
+		}
+	}
+	if out.ReaderStall > 0 && out.ReaderStallEvery <= 0 {
+		return out, errors.New("--reader-stall requires --reader-stall-every > 0")
+	}
+
+	out.Start = fw.Start(out.timeouts)
+	return out, nil
+}
+
+// writeFailed is set once a result could not be written; the process then
+// exits 1 whatever the run's outcome, so a caller never reads a stale or
+// missing file as a result.
+var writeFailed bool
+
+// checkWrite reports a failed result write on stderr and sets writeFailed.
+func checkWrite(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "write result: %v\n", err)
+		writeFailed = true
+	}
+}
+
+func emit(resultPath string, appendTo bool, payload harness.ResultFile) {
+	payload.SchemaVersion = harness.SchemaVersion
+	serialized, _ := json.Marshal(payload)
+	if resultPath != "" && appendTo {
+		checkWrite(appendResult(resultPath, serialized))
+		return
+	}
+	if resultPath != "" {
+		checkWrite(os.WriteFile(resultPath, serialized, 0o644))
+		return
+	}
+	_, _ = os.Stdout.Write(append(serialized, '\n'))
+}
+
+// appendResult adds one document to path as an NDJSON line, creating it if
+// need be, so runs given the same --result-path --append accumulate.
+func appendResult(path string, serialized []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(serialized, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// tag stamps payload with the run's identity and labels, and a single
+// scenario's document with its name so it can be told apart once merged.
+func (a cliArgs) tag(payload harness.ResultFile) harness.ResultFile {
+	if a.run != nil {
+		payload.Run = a.run.Ended()
+	}
+	if payload.Scenario == "" && len(payload.Suite) == 0 && a.Scenario != "" {
+		if _, isSuite := harness.ExpandScenarios(a.Scenario); !isSuite {
+			payload.Scenario = a.Scenario
+		}
+	}
+	if len(a.labels) > 0 {
+		payload.Labels = a.labels
+	}
+	payload.Cgroup = a.sandbox.Report()
+	payload.AttachStderr(a.stderr.Tail())
+	return payload
+}
+
+// emit writes payload to the result path, tagged with the run's identity and
+// labels. With --emit ndjson it is the summary line after the iteration
+// records.
+func (a cliArgs) emit(payload harness.ResultFile) {
+	payload = a.tag(payload)
+	if a.records != nil {
+		payload.SchemaVersion = harness.SchemaVersion
+		payload.Type = "summary"
+		serialized, _ := json.Marshal(payload)
+		_, err := a.records.Write(append(serialized, '\n'))
+		if a.records != os.Stdout {
+			if closeErr := a.records.Close(); err == nil {
+				err = closeErr
+			}
+			checkWrite(err)
+		}
+		return
+	}
+	emit(a.resultPath, a.appendResults, payload)
+}
+
+// finish gates a run's payload against --baseline and --assert, emits it and returns the
+// exit code.
+func (a cliArgs) finish(payload harness.ResultFile) int {
+	if a.baseline != nil {
+		payload.Gate = harness.CheckGate(a.baselinePath, *a.baseline, payload, a.gateRules)
+		if !payload.Gate.Passed && payload.OK {
+			payload.OK = false
+			payload.Error = fmt.Sprintf("%d --fail-on thresholds exceeded against %s", payload.Gate.Violations, a.baselinePath)
+		}
+	}
+	if len(a.assertions) > 0 {
+		payload.Assert = harness.CheckAssertions(payload, a.assertions)
+		if !payload.Assert.Passed && payload.OK {
+			payload.OK = false
+			payload.Error = "--assert budgets exceeded: " + strings.Join(payload.Assert.Violations, "; ")
+		}
+	}
+	if a.storePath != "" {
+		if err := harness.StoreRun(a.storePath, a.Scenario, a.tag(payload), a.labels); err != nil && payload.OK {
+			payload.OK = false
+			payload.Error = fmt.Sprintf("write --store: %v", err)
+		}
+	}
+	a.emit(payload)
+	if payload.Interrupted {
+		return 130
+	}
+	if !payload.OK {
+		return 1
+	}
+	return 0
+}
+
+// parseMemoryLimit reads a --memlimit or --cgroup-memory value in GOMEMLIMIT
+// syntax: bytes with an optional B, KiB, MiB, GiB or TiB suffix, or "off".
+func parseMemoryLimit(flag string, value string) (int64, error) {
+	if value == "off" {
+		return math.MaxInt64, nil
+	}
+	number, scale := value, int64(1)
+	for i, suffix := range []string{"TiB", "GiB", "MiB", "KiB", "B"} {
+		if strings.HasSuffix(value, suffix) {
+			number = strings.TrimSuffix(value, suffix)
+			scale = int64(1) << (10 * (4 - i))
+			break
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 || n > math.MaxInt64/scale {
+		return 0, fmt.Errorf("--%s must be a size like 512MiB or off", flag)
+	}
+	return n * scale, nil
+}
+
+// runSelfTest checks the frames of --scenario, or of every scenario, against
+// their viewport. Any violation fails the run.
+func runSelfTest(ctx context.Context, args cliArgs) int {
+	var scenarios []string
+	if args.Scenario == "" {
+		for _, info := range harness.Scenarios() {
+			scenarios = append(scenarios, info.Name)
+		}
+	} else {
+		scenarios, _ = harness.ExpandScenarios(args.Scenario)
+	}
+	if err := harness.ValidateSuite(scenarios, args.Params); err != nil {
+		args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+		return 1
+	}
+	report, err := harness.RunSelfTest(ctx, args.Config, scenarios)
+	payload := harness.ResultFile{OK: err == nil && report.Violations == 0, SelfTest: &report}
+	switch {
+	case err != nil:
+		payload.Error = err.Error()
+	case report.Violations > 0:
+		payload.Error = fmt.Sprintf("%d scenario invariant violations in %d frames", report.Violations, report.Frames)
+	}
+	return args.finish(payload)
+}
+
+// runExportFrames writes the frames of --scenario, or of every scenario,
+// under --golden for other engines to check theirs against, or for fixtures
+// as drawlists under --out for the Rezi engine to render.
+func runExportFrames(ctx context.Context, args cliArgs) int {
+	var scenarios []string
+	if args.Scenario == "" {
+		for _, info := range harness.Scenarios() {
+			scenarios = append(scenarios, info.Name)
+		}
+	} else {
+		scenarios, _ = harness.ExpandScenarios(args.Scenario)
+	}
+	if err := harness.ValidateSuite(scenarios, args.Params); err != nil {
+		args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+		return 1
+	}
+	var payload harness.ResultFile
+	var report harness.FrameExportReport
+	var err error
+	if args.mode == "fixtures" {
+		report, err = harness.ExportFixtures(ctx, args.Config, scenarios, args.fixturesDir)
+		payload.Fixtures = &report
+	} else {
+		report, err = harness.ExportFrames(ctx, args.Config, scenarios, args.GoldenDir)
+		payload.ExportFrames = &report
+	}
+	payload.OK = err == nil
+	if err != nil {
+		payload.Error = err.Error()
+	}
+	return args.finish(payload)
+}
+
+// runParity checks the frames of the scenarios in --manifest, or of
+// --scenario only, against what the Go generators render.
+func runParity(ctx context.Context, args cliArgs) int {
+	var scenarios []string
+	if args.Scenario != "" {
+		scenarios, _ = harness.ExpandScenarios(args.Scenario)
+	}
+	report, err := harness.CheckParity(ctx, args.Config, args.manifestPath, scenarios)
+	payload := harness.ResultFile{OK: err == nil && report.OK(), Parity: &report}
+	switch {
+	case err != nil:
+		payload.Error = err.Error()
+	case report.Frames == 0 && report.Errors == 0:
+		payload.Error = fmt.Sprintf("--manifest %s has no frames to check", args.manifestPath)
+	case !payload.OK:
+		payload.Error = fmt.Sprintf("%d of %d frames differ from --manifest, %d ticks missing from it, %d scenarios not checked",
+			report.Mismatches, report.Frames, report.Missing, report.Errors)
+	}
+	return args.finish(payload)
+}
+
+// parseTickList reads a --tick-list such as "0,1,99".
+func parseTickList(value string) ([]int, error) {
+	ticks := []int{}
+	for _, field := range strings.Split(value, ",") {
+		tick, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil {
+			return nil, err
+		}
+		if tick < 0 {
+			return nil, fmt.Errorf("tick %d is negative", tick)
+		}
+		ticks = append(ticks, tick)
+	}
+	return ticks, nil
+}
+
+// writeScenarioList is the text form of list: each scenario with its viewport
+// and the params it accepts, then the suites.
+func writeScenarioList(w io.Writer, scenarios []harness.ScenarioInfo, suites map[string][]string) {
+	for _, s := range scenarios {
+		viewport := fmt.Sprintf("%dx%d", s.Rows, s.Cols)
+		if s.ViewportFromParams {
+			viewport += " at default params"
+		}
+		fmt.Fprintf(w, "%s: %s\n  viewport %s\n", s.Name, s.Description, viewport)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, p := range s.Params {
+			fmt.Fprintf(tw, "  --%s\t%s\tdefault %d\t%s\n", p.Name, p.Range(), p.Default, p.Doc)
+		}
+		tw.Flush()
+	}
+	if len(suites) == 0 {
+		return
+	}
+	names := make([]string, 0, len(suites))
+	for name := range suites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintln(w, "\nsuites:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(tw, "  %s\t%s\n", name, strings.Join(suites[name], ", "))
+	}
+	tw.Flush()
+}
+
+// expandResultPath fills the placeholders of a --result-path template:
+// {scenario}, {runid}, {seed}, {timestamp} (the UTC start, like
+// 20260102T150405Z) and {label.NAME} for a --label. Path separators in a
+// value become "_", and a value that would make a "." or ".." path segment
+// is an error, so a value cannot move the file elsewhere.
+func expandResultPath(template string, args cliArgs) (string, error) {
+	var literals, values []string
+	rest := template
+	for {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("--result-path %q has an unclosed {", template)
+		}
+		literals = append(literals, rest[:open])
+		name := rest[open+1 : open+end]
+		var value string
+		switch {
+		case name == "scenario":
+			value = args.Scenario
+		case name == "runid":
+			value = args.run.ID
+		case name == "seed":
+			value = strconv.FormatUint(args.Seed, 10)
+		case name == "timestamp":
+			value = args.run.StartedAt.UTC().Format("20060102T150405Z")
+		case strings.HasPrefix(name, "label."):
+			label, ok := args.labels[strings.TrimPrefix(name, "label.")]
+			if !ok {
+				return "", fmt.Errorf("--result-path uses {%s} but no --label %s was given", name, strings.TrimPrefix(name, "label."))
+			}
+			value = label
+		default:
+			return "", fmt.Errorf("unknown --result-path placeholder {%s} (expected scenario, runid, seed, timestamp or label.NAME)", name)
+		}
+		values = append(values, strings.NewReplacer("/", "_", string(os.PathSeparator), "_").Replace(value))
+		rest = rest[open+end+1:]
+	}
+	literals = append(literals, rest)
+
+	join := func(value func(i int) string) []string {
+		var out strings.Builder
+		for i, literal := range literals {
+			out.WriteString(literal)
+			if i < len(values) {
+				out.WriteString(value(i))
+			}
+		}
+		return strings.Split(filepath.ToSlash(out.String()), "/")
+	}
+	// Values hold no separators, so both expansions have the same segments;
+	// one that differs from the template's own is made of values.
+	segments := join(func(i int) string { return values[i] })
+	plain := join(func(int) string { return "_" })
+	for i, segment := range segments {
+		if (segment == "." || segment == "..") && segment != plain[i] {
+			return "", fmt.Errorf("--result-path %q expands to a %q path segment", template, segment)
+		}
+	}
+	return filepath.FromSlash(strings.Join(segments, "/")), nil
+}
+
+// Main runs the command line for fw and exits with its status.
+func Main(fw Framework) {
+	runInfo := harness.NewRunInfo(fw.Modules)
+	args, err := parseArgs(os.Args, fw)
+	if err != nil {
+		emit("", false, harness.ResultFile{OK: false, Run: runInfo.Ended(), Error: err.Error()})
+		os.Exit(1)
+	}
+	args.run = runInfo
+	args.RunID = runInfo.ID
+	if args.resultPath, err = expandResultPath(args.resultPath, args); err != nil {
+		emit("", false, harness.ResultFile{OK: false, Run: runInfo.Ended(), Error: err.Error()})
+		os.Exit(1)
+	}
+
+	code := run(args)
+	if writeFailed {
+		code = 1
+	}
+	os.Exit(code)
+}
+
+func run(args cliArgs) int {
+	// SIGINT and SIGTERM end the measured loop early; the run still reports
+	// what it collected. A second signal kills the process as usual.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	// Runtime overrides apply to the whole process, harness included, and
+	// are recorded in every result as gomaxprocs and gogc.
+	if args.gomaxprocs > 0 {
+		runtime.GOMAXPROCS(args.gomaxprocs)
+	}
+	if args.gcPercent != nil {
+		debug.SetGCPercent(*args.gcPercent)
+	}
+	if args.memoryLimit > 0 {
+		debug.SetMemoryLimit(args.memoryLimit)
+	}
+
+	// The process moves into its --cgroup before anything is measured, so
+	// the cgroupMemory of every run is the transient cgroup's, and leaves it
+	// once the result is written.
+	if args.cgroup && (args.mode == "run" || args.mode == "verify") {
+		sandbox, err := harness.EnterCgroup(args.cgroupParent, "rezi-bench-"+args.RunID, args.cgroupLimits)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		args.sandbox = sandbox
+		defer func() {
+			if err := sandbox.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+			}
+		}()
+	}
+
+	// Stderr is captured from here on so failed results carry what the
+	// program, a generator subprocess or the harness printed. Progress lines
+	// go to the real stderr, around the capture, to stay out of that tail.
+	args.Progress = os.Stderr
+	if capture, err := harness.CaptureStderr(); err == nil {
+		defer capture.Close()
+		args.stderr = capture
+	}
+
+	if args.scenarioPlugin != "" {
+		if err := harness.LoadPlugin(args.scenarioPlugin); err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+	}
+	for _, path := range args.scenarioFiles {
+		if err := harness.LoadScenarioFile(path); err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+	}
+	if args.scenarioExec != "" {
+		generator, err := harness.StartExecGenerator(strings.Fields(args.scenarioExec))
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		defer generator.Close()
+	}
+	// Plugins and scenario files can declare params, so typos are caught only once they load.
+	if args.strictArgs {
+		if err := checkParamFlags(args.Params); err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+	}
+
+	if args.baselinePath != "" {
+		baseline, err := harness.LoadBaseline(args.baselinePath)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		args.baseline = &baseline
+	}
+
+	if args.heartbeat != "" && (args.mode == "run" || args.mode == "verify") {
+		target, err := harness.OpenHeartbeat(args.heartbeat)
+		if err == nil {
+			args.Heartbeat, err = harness.StartHeartbeat(target, args.heartbeatEvery, args.RunID)
+			if err != nil {
+				_ = target.Close()
+			}
+		}
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		// Deferred before the metrics linger, so it runs after it: the
+		// process is alive until then.
+		defer args.Heartbeat.Close()
+	}
+
+	if args.metricsAddr != "" && args.mode != "list-scenarios" && args.mode != "list" && args.mode != "print-schema" {
+		exporter := harness.NewMetricsExporter()
+		if err := exporter.Serve(args.metricsAddr); err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		// Keep serving after the run so the last scrape sees final values.
+		defer func() {
+			time.Sleep(args.metricsLinger)
+			_ = exporter.Close()
+		}()
+		args.Metrics = exporter
+	}
+
+	if args.mode == "print-schema" {
+		serialized, _ := json.MarshalIndent(harness.ResultSchema(), "", "  ")
+		if args.resultPath != "" {
+			checkWrite(os.WriteFile(args.resultPath, serialized, 0o644))
+			return 0
+		}
+		_, _ = os.Stdout.Write(append(serialized, '\n'))
+		return 0
+	}
+
+	if args.mode == "query" {
+		history, err := harness.QueryHistory(args.storePath, args.Scenario, args.labels, args.queryLimit)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		args.emit(harness.ResultFile{OK: true, History: &history})
+		return 0
+	}
+
+	if args.mode == "merge" {
+		return runMerge(args)
+	}
+
+	if args.mode == "diff" {
+		return runDiff(args)
+	}
+
+	if args.mode == "aggregate" {
+		report, err := harness.AggregateFleet(args.inputs, args.calibration)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: fmt.Sprintf("aggregate: %v", err)})
+			return 1
+		}
+		args.emit(harness.ResultFile{OK: true, Fleet: &report})
+		return 0
+	}
+
+	if args.mode == "validate" {
+		report := harness.ValidateResults(args.inputs)
+		payload := harness.ResultFile{OK: report.Issues == 0, Validate: &report}
+		if report.Issues > 0 {
+			payload.Error = fmt.Sprintf("%d issues in %d result files", report.Issues, len(report.Files))
+			args.emit(payload)
+			return 1
+		}
+		args.emit(payload)
+		return 0
+	}
+
+	if args.mode == "list-scenarios" {
+		args.emit(harness.ResultFile{OK: true, Scenarios: harness.Scenarios(), Suites: harness.Suites()})
+		return 0
+	}
+
+	if args.mode == "selftest" {
+		return runSelfTest(ctx, args)
+	}
+
+	if args.mode == "export-frames" || args.mode == "fixtures" {
+		return runExportFrames(ctx, args)
+	}
+
+	if args.mode == "parity" {
+		return runParity(ctx, args)
+	}
+
+	if args.mode == "dump" {
+		dump, err := harness.DumpScreen(ctx, args.Config, args.dumpTick, args.styles)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		if args.resultPath != "" {
+			checkWrite(os.WriteFile(args.resultPath, []byte(dump), 0o644))
+			return 0
+		}
+		_, _ = os.Stdout.WriteString(dump)
+		return 0
+	}
+
+	if args.mode == "list" {
+		var listing strings.Builder
+		writeScenarioList(&listing, harness.Scenarios(), harness.Suites())
+		if args.resultPath != "" {
+			checkWrite(os.WriteFile(args.resultPath, []byte(listing.String()), 0o644))
+			return 0
+		}
+		_, _ = os.Stdout.WriteString(listing.String())
+		return 0
+	}
+
+	if scenarios, isSuite := harness.ExpandScenarios(args.Scenario); isSuite {
+		return runSuite(ctx, args, scenarios)
+	}
+
+	if len(args.sweep) > 0 {
+		return runSweep(ctx, args)
+	}
+
+	if args.fuzzTrials > 0 {
+		return runFuzz(ctx, args)
+	}
+
+	if args.Repeats > 1 {
+		payload := harness.RunRepeated(ctx, args.Config)
+		return args.finish(payload)
+	}
+
+	if args.mode == "verify" {
+		report, err := harness.RunVerify(ctx, args.Config)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+			return 1
+		}
+		if report.MismatchedTicks > 0 {
+			args.emit(harness.ResultFile{
+				OK:     false,
+				Verify: &report,
+				Error:  fmt.Sprintf("%d of %d ticks rendered a mismatched screen", report.MismatchedTicks, report.Ticks),
+			})
+			return 1
+		}
+		args.emit(harness.ResultFile{OK: true, Verify: &report})
+		return 0
+	}
+
+	// --archive is built from a recorded session; without --record the
+	// session goes to a temporary file that is removed once archived.
+	tempSession := ""
+	if args.archivePath != "" && args.RecordPath == "" {
+		file, err := os.CreateTemp("", "rezi-session-*")
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: fmt.Sprintf("create --archive session: %v", err)})
+			return 1
+		}
+		_ = file.Close()
+		tempSession = file.Name()
+		args.RecordPath = tempSession
+	}
+
+	if args.format == "ndjson" {
+		args.records = os.Stdout
+		args.RecordsName = "stdout"
+		if args.resultPath != "" {
+			flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+			if args.appendResults {
+				flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+			}
+			file, err := os.OpenFile(args.resultPath, flags, 0o644)
+			if err != nil {
+				args.emit(harness.ResultFile{OK: false, Error: fmt.Sprintf("create --result: %v", err)})
+				return 1
+			}
+			args.records = file
+			args.RecordsName = args.resultPath
+		}
+		args.Records = args.records
+	}
+
+	data, err := harness.RunScenario(ctx, args.Config)
+	if err == nil {
+		if tempSession != "" {
+			data.RecordPath = ""
+		}
+		data.ArchivePath = args.archivePath
+	}
+	payload := harness.NewResultFile(data, err)
+	// Tag the document before archiving so result.json in the bundle carries
+	// the same run ID and labels as the emitted one.
+	payload = args.tag(payload)
+
+	if args.archivePath != "" {
+		archiveErr := harness.WriteArchive(args.archivePath, args.RecordPath, payload)
+		if tempSession != "" {
+			_ = os.Remove(tempSession)
+		}
+		if archiveErr != nil && payload.OK {
+			payload = harness.ResultFile{OK: false, WriteErrors: payload.WriteErrors, Error: fmt.Sprintf("write --archive: %v", archiveErr)}
+		}
+	}
+
+	return args.finish(payload)
+}
+
+// runMerge combines the result files given to merge into one suite document.
+func runMerge(args cliArgs) int {
+	docs := []harness.ResultFile{}
+	for _, path := range args.inputs {
+		read, err := harness.ReadResults(path)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: fmt.Sprintf("merge: %v", err)})
+			return 1
+		}
+		docs = append(docs, read...)
+	}
+	merged := harness.MergeResults(docs)
+	args.emit(merged)
+	if !merged.OK {
+		return 1
+	}
+	return 0
+}
+
+// runDiff prints the delta table between two result files, as text, or
+// markdown with --markdown. With --json the report is emitted as a result
+// document instead.
+func runDiff(args cliArgs) int {
+	docs := make([]harness.ResultFile, 2)
+	for i, path := range args.inputs {
+		read, err := harness.ReadResults(path)
+		if err != nil {
+			args.emit(harness.ResultFile{OK: false, Error: fmt.Sprintf("diff: %v", err)})
+			return 1
+		}
+		docs[i] = read[0]
+		if len(read) > 1 {
+			docs[i] = harness.MergeResults(read)
+		}
+	}
+	report := harness.DiffResults(args.inputs[0], docs[0], args.inputs[1], docs[1])
+	if args.diffJSON {
+		args.emit(harness.ResultFile{OK: true, Diff: &report})
+		return 0
+	}
+	var table strings.Builder
+	if args.markdown {
+		writeDiffMarkdown(&table, report)
+	} else {
+		writeDiffText(&table, report)
+	}
+	if args.resultPath != "" {
+		checkWrite(os.WriteFile(args.resultPath, []byte(table.String()), 0o644))
+		return 0
+	}
+	_, _ = os.Stdout.WriteString(table.String())
+	return 0
+}
+
+// diffCells formats a row's old, new, delta and change; a significant
+// change is marked "*".
+func diffCells(row harness.DiffRow) (string, string, string, string) {
+	change := "n/a"
+	if row.Change != nil {
+		change = fmt.Sprintf("%+.1f%%", *row.Change*100)
+	}
+	if row.Significant != nil && *row.Significant {
+		change += " *"
+	}
+	return formatDiffValue(row.Old), formatDiffValue(row.New), formatDiffValue(row.Delta), change
+}
+
+func formatDiffValue(v float64) string {
+	switch a := math.Abs(v); {
+	case a >= 1000 || a == math.Trunc(a):
+		return strconv.FormatFloat(v, 'f', 0, 64)
+	case a >= 1:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// diffFootnote explains the marks and lists the scenarios not compared.
+func diffFootnote(report harness.DiffReport) []string {
+	lines := []string{"* significant: the change exceeds the noise both results measured; worse ones are marked"}
+	if len(report.OnlyOld) > 0 {
+		lines = append(lines, "only in "+report.Old+": "+strings.Join(report.OnlyOld, ", "))
+	}
+	if len(report.OnlyNew) > 0 {
+		lines = append(lines, "only in "+report.New+": "+strings.Join(report.OnlyNew, ", "))
+	}
+	return lines
+}
+
+func writeDiffText(w io.Writer, report harness.DiffReport) {
+	fmt.Fprintf(w, "%s -> %s\n\n", report.Old, report.New)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "scenario\tmetric\told\tnew\tdelta\tchange\t")
+	for _, row := range report.Rows {
+		old, next, delta, change := diffCells(row)
+		if row.Worse && row.Significant != nil && *row.Significant {
+			change = "! " + change
+		}
+		fmt.Fprintf(tw, "%s\t%s (%s)\t%s\t%s\t%s\t%s\t\n", row.Scenario, row.Metric, row.Unit, old, next, delta, change)
+	}
+	tw.Flush()
+	fmt.Fprintln(w, "\n! significantly worse")
+	for _, line := range diffFootnote(report) {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func writeDiffMarkdown(w io.Writer, report harness.DiffReport) {
+	fmt.Fprintf(w, "`%s` → `%s`\n\n", report.Old, report.New)
+	fmt.Fprintln(w, "| Scenario | Metric | Old | New | Δ | Δ% |")
+	fmt.Fprintln(w, "| --- | --- | ---: | ---: | ---: | ---: |")
+	for _, row := range report.Rows {
+		old, next, delta, change := diffCells(row)
+		if row.Worse && row.Significant != nil && *row.Significant {
+			change = "**" + change + "**"
+		}
+		fmt.Fprintf(w, "| %s | %s (%s) | %s | %s | %s | %s |\n", row.Scenario, row.Metric, row.Unit, old, next, delta, change)
+	}
+	fmt.Fprintln(w)
+	for _, line := range diffFootnote(report) {
+		fmt.Fprintf(w, "%s  \n", strings.ReplaceAll(line, "*", "\\*"))
+	}
+}
+
+// runSuite runs each scenario of a comma list or suite name in turn and emits
+// their results as one document.
+func runSuite(ctx context.Context, args cliArgs, scenarios []string) int {
+	if args.mode == "verify" || args.RecordPath != "" || args.archivePath != "" || args.SamplesCSV != "" || args.format == "ndjson" || len(args.sweep) > 0 || args.fuzzTrials > 0 {
+		args.emit(harness.ResultFile{OK: false, Error: "suite runs do not support verify, --record, --archive, --samples-csv, --emit ndjson, --sweep or --fuzz-params"})
+		return 1
+	}
+	if err := harness.ValidateSuite(scenarios, args.Params); err != nil {
+		args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+		return 1
+	}
+
+	results := harness.RunSuite(ctx, args.Config, scenarios)
+	payload := harness.ResultFile{OK: true, Suite: results, Interrupted: ctx.Err() != nil}
+	failed := 0
+	for _, result := range results {
+		if !result.OK {
+			failed++
+		}
+	}
+	if failed > 0 {
+		payload.OK = false
+		payload.Error = fmt.Sprintf("%d of %d scenarios failed", failed, len(results))
+	}
+	return args.finish(payload)
+}
+
+// runSweep runs --scenario at every cell of --sweep and emits the grid as
+// one document.
+func runSweep(ctx context.Context, args cliArgs) int {
+	if err := harness.ValidateSweep(args.Scenario, args.Params, args.sweep); err != nil {
+		args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+		return 1
+	}
+	report := harness.RunSweep(ctx, args.Config, args.sweep)
+	payload := harness.ResultFile{OK: true, Scenario: args.Scenario, Sweep: &report, Interrupted: ctx.Err() != nil}
+	failed := 0
+	for _, cell := range report.Cells {
+		if !cell.OK {
+			failed++
+		}
+	}
+	if failed > 0 {
+		payload.OK = false
+		payload.Error = fmt.Sprintf("%d of %d sweep cells failed", failed, len(report.Cells))
+	}
+	return args.finish(payload)
+}
+
+// runFuzz runs --scenario at --fuzz-params random draws of its params and
+// emits the trials as one document.
+func runFuzz(ctx context.Context, args cliArgs) int {
+	if err := harness.ValidateFuzz(args.Scenario, args.Params); err != nil {
+		args.emit(harness.ResultFile{OK: false, Error: err.Error()})
+		return 1
+	}
+	report := harness.RunFuzz(ctx, args.Config, args.fuzzTrials)
+	payload := harness.ResultFile{OK: true, Scenario: args.Scenario, Fuzz: &report, Interrupted: ctx.Err() != nil}
+	failed := 0
+	for _, trial := range report.Trials {
+		if !trial.OK {
+			failed++
+		}
+	}
+	if failed > 0 {
+		payload.OK = false
+		payload.Error = fmt.Sprintf("%d of %d fuzz trials failed", failed, len(report.Trials))
+	}
+	return args.finish(payload)
+}
@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+var testFramework = Framework{Start: func(Timeouts) harness.StartFunc { return nil }}
+
+// writeConfig writes a --config file named name and returns its path.
+func writeConfig(t *testing.T, name string, text string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExpandConfig(t *testing.T) {
+	yamlPath := writeConfig(t, "bench.yaml", "scenario: tables\niterations: 50\nio: stub\nparams: {rows: 12}\nlabels: {host: ci}\n")
+	jsonPath := writeConfig(t, "bench.json", `{"scenario": "tables", "fps": 60.5, "params": {"cols": 3}}`)
+
+	cases := []struct {
+		name string
+		env  string
+		argv []string
+		want []string
+		err  string
+	}{
+		{name: "no config", argv: []string{"bench", "--scenario", "rerender"}, want: []string{"bench", "--scenario", "rerender"}},
+		{
+			name: "yaml before the command line",
+			argv: []string{"bench", "--config", yamlPath, "--iterations", "9"},
+			want: []string{"bench", "--io", "stub", "--iterations", "50", "--label", "host=ci", "--rows", "12", "--scenario", "tables", "--iterations", "9"},
+		},
+		{
+			name: "json after the mode",
+			argv: []string{"bench", "verify", "--config=" + jsonPath},
+			want: []string{"bench", "verify", "--fps", "60.5", "--cols", "3", "--scenario", "tables"},
+		},
+		{
+			name: "named by the environment",
+			env:  jsonPath,
+			argv: []string{"bench"},
+			want: []string{"bench", "--fps", "60.5", "--cols", "3", "--scenario", "tables"},
+		},
+		{name: "missing file", argv: []string{"bench", "--config", "/nonexistent.yaml"}, err: "read --config"},
+		{name: "nested config", argv: []string{"bench", "--config", writeConfig(t, "c.yaml", "config: other.yaml\n")}, err: "cannot include another config"},
+		{name: "null value", argv: []string{"bench", "--config", writeConfig(t, "c.yaml", "scenario:\n")}, err: "scenario has no value"},
+		{name: "params not a mapping", argv: []string{"bench", "--config", writeConfig(t, "c.yaml", "params: [1]\n")}, err: "params must be a mapping"},
+		{name: "list value", argv: []string{"bench", "--config", writeConfig(t, "c.yaml", "iterations: [1]\n")}, err: "iterations must be a scalar"},
+		{name: "not yaml", argv: []string{"bench", "--config", writeConfig(t, "c.yaml", "a: [\n")}, err: "parse --config"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(envPrefix+"CONFIG", tc.env)
+			got, err := expandConfig(tc.argv)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("argv %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseArgsFlagForms(t *testing.T) {
+	cases := []struct {
+		name  string
+		argv  []string
+		check func(t *testing.T, args cliArgs)
+		err   string
+	}{
+		{
+			name: "flag=value and params",
+			argv: []string{"bench", "--scenario=tables", "--iterations=7", "--rows", "12"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.Scenario != "tables" || args.Iterations != 7 || args.Params["rows"] != "12" {
+					t.Errorf("scenario %q iterations %d params %v", args.Scenario, args.Iterations, args.Params)
+				}
+			},
+		},
+		{
+			name: "the last of a repeated flag wins",
+			argv: []string{"bench", "--scenario", "tables", "--config", writeConfig(t, "c.yaml", "iterations: 50\n"), "--iterations", "9"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.Iterations != 9 {
+					t.Errorf("iterations %d, want the command line's 9 over the config's 50", args.Iterations)
+				}
+			},
+		},
+		{
+			name: "boolean flags",
+			argv: []string{"bench", "--selftest", "--strict-args=true"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.mode != "selftest" || !args.strictArgs {
+					t.Errorf("mode %q strictArgs %t", args.mode, args.strictArgs)
+				}
+			},
+		},
+		{
+			name: "a false mode flag is no mode",
+			argv: []string{"bench", "--scenario", "tables", "--selftest=false"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.mode != "run" {
+					t.Errorf("mode %q, want run", args.mode)
+				}
+			},
+		},
+		{
+			name: "repeated list flags add up",
+			argv: []string{"bench", "--scenario", "tables", "--baseline", "base.json", "--fail-on", "p95>+5%", "--fail-on=fps<-3%", "--label", "a=1", "--label", "b=2"},
+			check: func(t *testing.T, args cliArgs) {
+				if len(args.gateRules) != 2 || !reflect.DeepEqual(args.labels, map[string]string{"a": "1", "b": "2"}) {
+					t.Errorf("gate rules %v labels %v", args.gateRules, args.labels)
+				}
+			},
+		},
+		{name: "bad boolean", argv: []string{"bench", "--strict-args=maybe"}, err: "takes no value or true|false"},
+		{name: "json outside list", argv: []string{"bench", "--json"}, err: "--json is only valid"},
+		{name: "missing value", argv: []string{"bench", "--iterations"}, err: "missing value for --iterations"},
+		{name: "repeated sweep axis", argv: []string{"bench", "--sweep", "rows=1,2", "--sweep", "rows=3"}, err: `axis "rows" given twice`},
+		{name: "bad label", argv: []string{"bench", "--label", "nokey"}, err: "invalid --label"},
+		{name: "assert in verify mode", argv: []string{"bench", "verify", "--scenario", "tables", "--assert", "p95<10"}, err: "--assert is not supported in verify mode"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			args, err := parseArgs(tc.argv, testFramework)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, args)
+		})
+	}
+}
+
+func TestParseArgsEnvDefaults(t *testing.T) {
+	cases := []struct {
+		name  string
+		env   map[string]string
+		argv  []string
+		check func(t *testing.T, args cliArgs)
+		err   string
+	}{
+		{
+			name: "default from the environment",
+			env:  map[string]string{"ITERATIONS": "7", "TICK_TIMEOUT": "3s"},
+			argv: []string{"bench", "--scenario", "tables"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.Iterations != 7 || args.timeouts.Tick != 3*time.Second {
+					t.Errorf("iterations %d tick timeout %s", args.Iterations, args.timeouts.Tick)
+				}
+			},
+		},
+		{
+			name: "the command line wins",
+			env:  map[string]string{"ITERATIONS": "7"},
+			argv: []string{"bench", "--scenario", "tables", "--iterations=9"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.Iterations != 9 {
+					t.Errorf("iterations %d, want the command line's 9", args.Iterations)
+				}
+			},
+		},
+		{
+			name: "a config file wins",
+			env:  map[string]string{"ITERATIONS": "7"},
+			argv: []string{"bench", "--scenario", "tables", "--config", writeConfig(t, "c.yaml", "iterations: 50\n")},
+			check: func(t *testing.T, args cliArgs) {
+				if args.Iterations != 50 {
+					t.Errorf("iterations %d, want the config's 50", args.Iterations)
+				}
+			},
+		},
+		{
+			name: "an overridden value is not parsed",
+			env:  map[string]string{"IO": "terminal", "STRICT_ARGS": "maybe"},
+			argv: []string{"bench", "--scenario", "tables", "--io", "stub", "--strict-args"},
+			check: func(t *testing.T, args cliArgs) {
+				if args.IO != "null" || !args.strictArgs {
+					t.Errorf("io %q strictArgs %t", args.IO, args.strictArgs)
+				}
+			},
+		},
+		{
+			name: "other variables are not flags",
+			env:  map[string]string{"CPU_AFFINITY": "0-3", "OPENTUI_DRIVER": "core", "OPENTUI_PROVIDER": "bubbletea", "ROWS": "12"},
+			argv: []string{"bench", "--scenario", "tables"},
+			check: func(t *testing.T, args cliArgs) {
+				if len(args.Params) != 0 {
+					t.Errorf("params %v, want none", args.Params)
+				}
+			},
+		},
+		{
+			name: "a bad default",
+			env:  map[string]string{"IO": "terminal"},
+			argv: []string{"bench", "--scenario", "tables"},
+			err:  `invalid --io "terminal"`,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, value := range tc.env {
+				t.Setenv(envPrefix+name, value)
+			}
+			args, err := parseArgs(tc.argv, testFramework)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, args)
+		})
+	}
+}
+
+func TestFinishGatesSingleRun(t *testing.T) {
+	dir := t.TempDir()
+	run := func(p95 float64) harness.ResultFile {
+		data := harness.Result{Frames: 10}
+		data.Summary.P95 = p95
+		return harness.NewResultFile(data, nil)
+	}
+	baseArgs, err := parseArgs([]string{"bench", "--scenario", "terminal-rerender"}, testFramework)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basePath := filepath.Join(dir, "base.json")
+	baseArgs.resultPath = basePath
+	if code := baseArgs.finish(baseArgs.tag(run(1))); code != 0 {
+		t.Fatalf("baseline run exited %d", code)
+	}
+
+	cases := []struct {
+		name string
+		p95  float64
+		code int
+	}{
+		{name: "regressed", p95: 2, code: 1},
+		{name: "within the limit", p95: 1.01, code: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			resultPath := filepath.Join(t.TempDir(), "result.json")
+			args, err := parseArgs([]string{"bench", "--scenario", "terminal-rerender", "--baseline", basePath, "--fail-on", "p95>+5%", "--result-path", resultPath}, testFramework)
+			if err != nil {
+				t.Fatal(err)
+			}
+			baseline, err := harness.LoadBaseline(basePath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			args.baseline = &baseline
+			if code := args.finish(args.tag(run(tc.p95))); code != tc.code {
+				t.Errorf("exit code %d, want %d", code, tc.code)
+			}
+			data, err := os.ReadFile(resultPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var doc harness.ResultFile
+			if err := json.Unmarshal(data, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Gate == nil || len(doc.Gate.Checks) != 1 || len(doc.Gate.Missing) != 0 {
+				t.Errorf("gate %+v, want one check and nothing missing", doc.Gate)
+			}
+		})
+	}
+}
+
+func TestExpandResultPath(t *testing.T) {
+	args := cliArgs{labels: map[string]string{"up": "..", "dot": ".", "ver": "v1.2", "dir": "a/b"}}
+	args.Scenario = "tables"
+	args.Seed = 7
+	cases := []struct {
+		template string
+		want     string
+		err      string
+	}{
+		{template: "out/{scenario}-{seed}.json", want: "out/tables-7.json"},
+		{template: "out/{label.ver}/r.json", want: "out/v1.2/r.json"},
+		{template: "out/{label.dir}.json", want: "out/a_b.json"},
+		{template: "out/{label.up}.json", want: "out/...json"},
+		{template: "../out/{scenario}.json", want: "../out/tables.json"},
+		{template: "out/{label.up}/r.json", err: `".." path segment`},
+		{template: "out/{label.dot}{label.dot}/r.json", err: `".." path segment`},
+		{template: "out/.{label.dot}/r.json", err: `".." path segment`},
+		{template: "{label.dot}/r.json", err: `"." path segment`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.template, func(t *testing.T) {
+			got, err := expandResultPath(tc.template, args)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("path %q err %v, want %q", got, err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != filepath.FromSlash(tc.want) {
+				t.Errorf("path %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package harness
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archives are a zstd-compressed tar of three members:
+//
+//	output.raw   every byte the program wrote, in order
+//	frames.json  one segment per frame marker, indexing output.raw
+//	result.json  the result payload emitted for the run
+//
+// They are built from a recorded session after the run, so a failed run is
+// archived up to the point it stopped.
+type archiveSegment struct {
+	// Kind is "frame" from a tick being sent until the next marker,
+	// "teardown" after a program shuts down, and "preamble" for output
+	// before the first marker. Like ParseSession, a later program's startup
+	// output lands in the previous program's teardown.
+	Kind   string  `json:"kind"`
+	Tick   int     `json:"tick"`
+	AtMs   float64 `json:"atMs"`
+	Offset int64   `json:"offset"`
+	Bytes  int64   `json:"bytes"`
+	Writes int     `json:"writes"`
+}
+
+type sessionRecord struct {
+	kind  byte
+	atNs  int64
+	value int
+}
+
+func readSessionRecord(r *bufio.Reader) (sessionRecord, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		if err == io.EOF && line != "" {
+			return sessionRecord{}, errors.New("truncated session record header")
+		}
+		return sessionRecord{}, err
+	}
+	fields := strings.Fields(line)
+	if len(fields) != 3 || len(fields[0]) != 1 {
+		return sessionRecord{}, fmt.Errorf("malformed session record header %q", strings.TrimSpace(line))
+	}
+	at, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return sessionRecord{}, fmt.Errorf("malformed session record header: %w", err)
+	}
+	n, err := strconv.Atoi(fields[2])
+	if err != nil || n < 0 {
+		return sessionRecord{}, fmt.Errorf("malformed session record header %q", strings.TrimSpace(line))
+	}
+	return sessionRecord{kind: fields[0][0], atNs: at, value: n}, nil
+}
+
+// scanSession walks a session file record by record, handing each write
+// payload to onWrite. Payloads are streamed, so soak-length sessions never
+// have to fit in memory.
+func scanSession(path string, onRecord func(sessionRecord), onWrite func(io.Reader, int) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	r := bufio.NewReaderSize(file, 256*1024)
+	magic := make([]byte, len(sessionMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != sessionMagic {
+		return errors.New("not a recorded session (bad magic)")
+	}
+	for {
+		rec, err := readSessionRecord(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch rec.kind {
+		case 'F', 'E':
+			onRecord(rec)
+		case 'W':
+			onRecord(rec)
+			if err := onWrite(io.LimitReader(r, int64(rec.value)), rec.value); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown session record type %q", rec.kind)
+		}
+	}
+}
+
+// archiveSegments indexes a session's output by frame marker and returns the
+// total payload size alongside.
+func archiveSegments(sessionPath string) ([]archiveSegment, int64, error) {
+	segments := []archiveSegment{}
+	var offset int64
+	onRecord := func(rec sessionRecord) {
+		at := nsToMs(rec.atNs)
+		switch {
+		case rec.kind == 'F':
+			segments = append(segments, archiveSegment{Kind: "frame", Tick: rec.value, AtMs: at, Offset: offset})
+		case rec.kind == 'E':
+			segments = append(segments, archiveSegment{Kind: "teardown", AtMs: at, Offset: offset})
+		case len(segments) == 0:
+			segments = append(segments, archiveSegment{Kind: "preamble", AtMs: at, Offset: offset})
+		}
+		if rec.kind == 'W' {
+			last := &segments[len(segments)-1]
+			last.Bytes += int64(rec.value)
+			last.Writes++
+			offset += int64(rec.value)
+		}
+	}
+	onWrite := func(payload io.Reader, n int) error {
+		skipped, err := io.Copy(io.Discard, payload)
+		if err == nil && skipped != int64(n) {
+			err = errors.New("truncated session write payload")
+		}
+		return err
+	}
+	if err := scanSession(sessionPath, onRecord, onWrite); err != nil {
+		return nil, 0, err
+	}
+	return segments, offset, nil
+}
+
+// WriteArchive bundles the session recorded at sessionPath and payload into
+// an archive at path.
+func WriteArchive(path string, sessionPath string, payload ResultFile) error {
+	segments, rawBytes, err := archiveSegments(sessionPath)
+	if err != nil {
+		return fmt.Errorf("read session: %w", err)
+	}
+	framesJSON, err := json.Marshal(segments)
+	if err != nil {
+		return err
+	}
+	resultJSON, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	zw, err := zstd.NewWriter(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+	tw := tar.NewWriter(zw)
+	modTime := time.Now()
+	header := func(name string, size int64) error {
+		return tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: size, ModTime: modTime, Typeflag: tar.TypeReg})
+	}
+
+	err = header("output.raw", rawBytes)
+	if err == nil {
+		err = scanSession(sessionPath, func(sessionRecord) {}, func(payload io.Reader, n int) error {
+			copied, err := io.Copy(tw, payload)
+			if err == nil && copied != int64(n) {
+				err = errors.New("truncated session write payload")
+			}
+			return err
+		})
+	}
+	for _, member := range []struct {
+		name string
+		data []byte
+	}{{"frames.json", framesJSON}, {"result.json", resultJSON}} {
+		if err == nil {
+			err = header(member.name, int64(len(member.data)))
+		}
+		if err == nil {
+			_, err = tw.Write(member.data)
+		}
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,160 @@
+package harness
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// assertUnits are the units of the gateMetrics an --assert limit may carry a
+// suffix for; the others are plain numbers.
+var assertUnits = map[string]string{
+	"mean":        "ms",
+	"median":      "ms",
+	"p95":         "ms",
+	"p99":         "ms",
+	"totalWall":   "ms",
+	"cpuPerFrame": "ms",
+	"rssPeak":     "KiB",
+}
+
+// Assertion is one --assert budget: metric compared by Op ("<=", "<", ">="
+// or ">") with an absolute Limit in the metric's own unit.
+type Assertion struct {
+	Metric string  `json:"metric"`
+	Op     string  `json:"op"`
+	Limit  float64 `json:"limit"`
+	Text   string  `json:"rule"`
+}
+
+// ParseAssertions parses an --assert list like "p95<=16.6ms,rssPeak<=200MiB".
+// Metrics are those of --fail-on, optionally spelled with their unit as in
+// the result ("p95Ms", "rssPeakKb"). Timing limits take a duration suffix
+// and memory limits KiB, MiB or GiB; a bare number is in the metric's unit.
+func ParseAssertions(spec string) ([]Assertion, error) {
+	out := []Assertion{}
+	for _, text := range strings.Split(spec, ",") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		i := strings.IndexAny(text, "<>")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid --assert %q (expected metric<=N or metric>=N)", text)
+		}
+		assertion := Assertion{Metric: assertMetric(text[:i]), Op: text[i : i+1], Text: text}
+		limit := text[i+1:]
+		if strings.HasPrefix(limit, "=") {
+			assertion.Op += "="
+			limit = limit[1:]
+		}
+		if _, ok := gateMetrics[assertion.Metric]; !ok {
+			return nil, fmt.Errorf("unknown --assert metric %q (expected %s)", text[:i], strings.Join(gateMetricNames(), "|"))
+		}
+		n, err := parseAssertLimit(assertUnits[assertion.Metric], strings.TrimSpace(limit))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --assert limit in %q: %w", text, err)
+		}
+		assertion.Limit = n
+		out = append(out, assertion)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("--assert names no budgets")
+	}
+	return out, nil
+}
+
+// assertMetric maps a result field name like "p95Ms" or "rssPeakKb" to its
+// gate metric.
+func assertMetric(name string) string {
+	if _, ok := gateMetrics[name]; ok {
+		return name
+	}
+	for _, suffix := range []string{"Ms", "Kb"} {
+		if trimmed, ok := strings.CutSuffix(name, suffix); ok {
+			return trimmed
+		}
+	}
+	return name
+}
+
+func parseAssertLimit(unit string, limit string) (float64, error) {
+	if n, err := strconv.ParseFloat(limit, 64); err == nil {
+		return n, nil
+	}
+	switch unit {
+	case "ms":
+		d, err := time.ParseDuration(limit)
+		if err != nil {
+			return 0, fmt.Errorf("expected milliseconds or a duration like 16.6ms")
+		}
+		return nsToMs(d.Nanoseconds()), nil
+	case "KiB":
+		for suffix, scale := range map[string]float64{"KiB": 1, "MiB": 1024, "GiB": 1024 * 1024} {
+			if number, ok := strings.CutSuffix(limit, suffix); ok {
+				if n, err := strconv.ParseFloat(number, 64); err == nil {
+					return n * scale, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("expected KiB or a size like 200MiB")
+	}
+	return 0, fmt.Errorf("expected a number")
+}
+
+// AssertReport is the "assert" field of a ResultFile: every budget checked
+// against every successful scenario of the run.
+type AssertReport struct {
+	Passed     bool          `json:"passed"`
+	Checks     []assertCheck `json:"checks"`
+	Violations []string      `json:"violations"`
+}
+
+type assertCheck struct {
+	Scenario string  `json:"scenario,omitempty"`
+	Rule     string  `json:"rule"`
+	Metric   string  `json:"metric"`
+	Value    float64 `json:"value"`
+	Limit    float64 `json:"limit"`
+	Violated bool    `json:"violated"`
+}
+
+// CheckAssertions evaluates assertions against each successful per-scenario
+// document of payload, aggregates when it was repeated. Violations lists
+// the failed budgets with the values that broke them.
+func CheckAssertions(payload ResultFile, assertions []Assertion) *AssertReport {
+	report := &AssertReport{Passed: true, Checks: []assertCheck{}, Violations: []string{}}
+	docs := gateDocuments(payload)
+	for _, scenario := range sortedKeys(docs) {
+		for _, assertion := range assertions {
+			check := assertCheck{
+				Scenario: scenario,
+				Rule:     assertion.Text,
+				Metric:   assertion.Metric,
+				Value:    gateValue(assertion.Metric, docs[scenario]),
+				Limit:    assertion.Limit,
+			}
+			switch assertion.Op {
+			case "<":
+				check.Violated = !(check.Value < check.Limit)
+			case "<=":
+				check.Violated = !(check.Value <= check.Limit)
+			case ">":
+				check.Violated = !(check.Value > check.Limit)
+			default:
+				check.Violated = !(check.Value >= check.Limit)
+			}
+			if check.Violated {
+				report.Passed = false
+				text := fmt.Sprintf("%s (%s=%g)", assertion.Text, assertion.Metric, check.Value)
+				if scenario != "" {
+					text = scenario + ": " + text
+				}
+				report.Violations = append(report.Violations, text)
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+	return report
+}
@@ -0,0 +1,79 @@
+package harness
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+// Capabilities is the "capabilities" field of a Result: the measurement
+// sources the host offered, probed once per process. Unmeasured names the
+// Result fields that read 0 only because their source is missing, such as
+// the RSS fields without /proc on macOS, so a zero there is not mistaken for
+// no growth.
+type Capabilities struct {
+	// Proc is /proc/self/status, the source of RSS and thread counts.
+	Proc bool `json:"proc"`
+	// Rusage is getrusage, the source of CPU time, faults and context
+	// switches.
+	Rusage bool `json:"rusage"`
+	// Perf is whether perf_event_paranoid lets this process open hardware
+	// counters, and RAPL whether the package energy counter is readable.
+	// Neither is read by the harness yet.
+	Perf bool `json:"perf"`
+	RAPL bool `json:"rapl"`
+	// PTY is whether a pseudo-terminal could be opened, which --io pty
+	// needs.
+	PTY bool `json:"pty"`
+	// CgroupV2 is memory accounting in the unified hierarchy, the source
+	// of cgroupMemory.
+	CgroupV2 bool `json:"cgroupV2"`
+
+	Unmeasured []string `json:"unmeasured"`
+}
+
+// procFields and rusageFields are the Result fields each source feeds.
+var (
+	procFields   = []string{"rssBeforeKb", "rssAfterKb", "rssPeakKb", "threadsBefore", "threadsAfter", "threadsPeak"}
+	rusageFields = []string{
+		"cpuUserMs", "cpuSysMs", "minorFaults", "majorFaults",
+		"voluntaryCtxSwitches", "involuntaryCtxSwitches",
+		"voluntaryCtxSwitchesPerFrame", "involuntaryCtxSwitchesPerFrame",
+	}
+)
+
+var probeCapabilities = sync.OnceValue(func() Capabilities {
+	c := Capabilities{Unmeasured: []string{}}
+	if data, err := os.ReadFile("/proc/self/status"); err == nil && strings.Contains(string(data), "VmRSS:") {
+		c.Proc = true
+	} else {
+		c.Unmeasured = append(c.Unmeasured, procFields...)
+	}
+	var ru syscall.Rusage
+	if syscall.Getrusage(syscall.RUSAGE_SELF, &ru) == nil {
+		c.Rusage = true
+	} else {
+		c.Unmeasured = append(c.Unmeasured, rusageFields...)
+	}
+	// Paranoia 2 and below still allow profiling one's own process.
+	if data, err := os.ReadFile("/proc/sys/kernel/perf_event_paranoid"); err == nil {
+		level, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		c.Perf = err == nil && (level <= 2 || os.Geteuid() == 0)
+	}
+	if file, err := os.Open("/sys/class/powercap/intel-rapl:0/energy_uj"); err == nil {
+		_, err = file.Read(make([]byte, 32))
+		c.RAPL = err == nil
+		_ = file.Close()
+	}
+	if master, slave, err := pty.Open(); err == nil {
+		c.PTY = true
+		_ = slave.Close()
+		_ = master.Close()
+	}
+	c.CgroupV2 = cgroupV2Dir() != ""
+	return c
+})
@@ -0,0 +1,231 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// cgroupCPUPeriodUs is the cpu.max period a --cgroup-cpus quota is given in.
+const cgroupCPUPeriodUs = 100000
+
+// CgroupLimits are the limits of a --cgroup: MemoryBytes for memory.max and
+// CPUs, in cores, for cpu.max. Zero leaves a resource unlimited.
+type CgroupLimits struct {
+	MemoryBytes int64
+	CPUs        float64
+}
+
+// Cgroup is the transient cgroup v2 a --cgroup run moves the whole process
+// into, so its memory and CPU are accounted apart from whatever else shares
+// the cgroup it started in. Memory touched before the move stays charged to
+// that cgroup; Close moves the process back and removes the transient one.
+type Cgroup struct {
+	dir    string
+	origin string
+	limits CgroupLimits
+	peak   *cgroupPeak
+}
+
+// CgroupReport is the "cgroup" field of a ResultFile: the limits of the
+// --cgroup and its accounting read when the document is written.
+// MemoryPeakSource is "memory.peak" when the peak was reset on entering the
+// cgroup, else "memory.current", the only usage known then.
+// MemoryMaxEvents counts the times usage hit memory.max and was reclaimed;
+// OOMKills is nonzero when the limit killed a process in it.
+type CgroupReport struct {
+	Path             string  `json:"path"`
+	MemoryMaxBytes   int64   `json:"memoryMaxBytes,omitempty"`
+	CPUs             float64 `json:"cpus,omitempty"`
+	MemoryCurrentKb  int64   `json:"memoryCurrentKb"`
+	MemoryPeakKb     int64   `json:"memoryPeakKb"`
+	MemoryPeakSource string  `json:"memoryPeakSource"`
+	MemoryMaxEvents  int64   `json:"memoryMaxEvents"`
+	OOMKills         int64   `json:"oomKills"`
+	CPUUsageUs       int64   `json:"cpuUsageUs"`
+	CPUUserUs        int64   `json:"cpuUserUs"`
+	CPUSystemUs      int64   `json:"cpuSystemUs"`
+	ThrottledPeriods int64   `json:"throttledPeriods"`
+	ThrottledUs      int64   `json:"throttledUs"`
+}
+
+// EnterCgroup creates cgroup name under parent with limits and moves the
+// process into it. An empty parent is the parent of the process's own
+// cgroup, where the no-internal-processes rule lets controllers be enabled;
+// the process needs write access there, as root or through a delegated
+// subtree.
+func EnterCgroup(parent string, name string, limits CgroupLimits) (*Cgroup, error) {
+	origin, root := cgroupV2Self()
+	if origin == "" {
+		return nil, errors.New("--cgroup needs the cgroup v2 unified hierarchy")
+	}
+	if parent == "" {
+		parent = origin
+		if origin != root {
+			parent = filepath.Dir(origin)
+		}
+	}
+	controllers := []string{"memory"}
+	if limits.CPUs > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+
+	c := &Cgroup{dir: filepath.Join(parent, name), origin: origin, limits: limits}
+	if err := os.Mkdir(c.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create --cgroup: %w", err)
+	}
+	if err := c.apply(); err != nil {
+		_ = os.Remove(c.dir)
+		return nil, err
+	}
+	if err := writeCgroupFile(c.dir, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+		_ = os.Remove(c.dir)
+		return nil, fmt.Errorf("move into --cgroup: %w", err)
+	}
+	c.peak = newCgroupPeak(c.dir, 0)
+	return c, nil
+}
+
+// cgroupV2Self is the unified-hierarchy directory of the current process and
+// the root of the hierarchy it is under, "" when there is none.
+func cgroupV2Self() (string, string) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return "", ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		rel, ok := strings.CutPrefix(line, "0::")
+		if !ok {
+			continue
+		}
+		for _, root := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+			if _, err := os.Stat(root + "/cgroup.subtree_control"); err == nil {
+				return filepath.Join(root, rel), root
+			}
+		}
+	}
+	return "", ""
+}
+
+// enableControllers turns on the controllers parent's children need, those
+// not on already.
+func enableControllers(parent string, controllers []string) error {
+	data, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("read --cgroup parent: %w", err)
+	}
+	enabled := strings.Fields(string(data))
+	data, _ = os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	available := strings.Fields(string(data))
+	for _, controller := range controllers {
+		if slices.Contains(enabled, controller) {
+			continue
+		}
+		if !slices.Contains(available, controller) {
+			return fmt.Errorf("--cgroup: the %s controller is not available in %s", controller, parent)
+		}
+		if err := writeCgroupFile(parent, "cgroup.subtree_control", "+"+controller); err != nil {
+			return fmt.Errorf("enable the %s controller in %s for --cgroup: %w", controller, parent, err)
+		}
+	}
+	return nil
+}
+
+func (c *Cgroup) apply() error {
+	if c.limits.MemoryBytes > 0 {
+		value := "max"
+		if c.limits.MemoryBytes < math.MaxInt64 {
+			value = strconv.FormatInt(c.limits.MemoryBytes, 10)
+		}
+		if err := writeCgroupFile(c.dir, "memory.max", value); err != nil {
+			return fmt.Errorf("set --cgroup-memory: %w", err)
+		}
+		// A limited run swapping out would compare against runs that did
+		// not; memory.swap.max is absent without swap accounting.
+		_ = writeCgroupFile(c.dir, "memory.swap.max", "0")
+	}
+	if c.limits.CPUs > 0 {
+		quota := max(int64(math.Round(c.limits.CPUs*cgroupCPUPeriodUs)), 1000)
+		value := fmt.Sprintf("%d %d", quota, cgroupCPUPeriodUs)
+		if err := writeCgroupFile(c.dir, "cpu.max", value); err != nil {
+			return fmt.Errorf("set --cgroup-cpus: %w", err)
+		}
+	}
+	return nil
+}
+
+// Report reads the accounting of c; a nil Cgroup reports nothing.
+func (c *Cgroup) Report() *CgroupReport {
+	if c == nil {
+		return nil
+	}
+	report := &CgroupReport{
+		Path:            c.dir,
+		CPUs:            c.limits.CPUs,
+		MemoryCurrentKb: readCgroupInt(c.dir, "memory.current") / 1024,
+	}
+	if c.limits.MemoryBytes < math.MaxInt64 {
+		report.MemoryMaxBytes = c.limits.MemoryBytes
+	}
+	c.peak.sample(report.MemoryCurrentKb)
+	report.MemoryPeakKb, report.MemoryPeakSource = c.peak.read()
+	events := readCgroupKeyed(c.dir, "memory.events")
+	report.MemoryMaxEvents = events["max"]
+	report.OOMKills = events["oom_kill"]
+	cpu := readCgroupKeyed(c.dir, "cpu.stat")
+	report.CPUUsageUs = cpu["usage_usec"]
+	report.CPUUserUs = cpu["user_usec"]
+	report.CPUSystemUs = cpu["system_usec"]
+	report.ThrottledPeriods = cpu["nr_throttled"]
+	report.ThrottledUs = cpu["throttled_usec"]
+	return report
+}
+
+// Close moves the process back to the cgroup it started in and removes the
+// transient one. Removal fails while a child process, such as a tmux server,
+// is still in it.
+func (c *Cgroup) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.peak.close()
+	if err := writeCgroupFile(c.origin, "cgroup.procs", strconv.Itoa(os.Getpid())); err != nil {
+		return fmt.Errorf("leave --cgroup: %w", err)
+	}
+	if err := os.Remove(c.dir); err != nil {
+		return fmt.Errorf("remove --cgroup: %w", err)
+	}
+	return nil
+}
+
+func writeCgroupFile(dir string, name string, value string) error {
+	return os.WriteFile(filepath.Join(dir, name), []byte(value), 0o644)
+}
+
+// readCgroupKeyed reads a flat-keyed cgroup file such as cpu.stat, one
+// "key value" pair per line; a missing file reads as empty.
+func readCgroupKeyed(dir string, name string) map[string]int64 {
+	out := map[string]int64{}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return out
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			out[key] = n
+		}
+	}
+	return out
+}
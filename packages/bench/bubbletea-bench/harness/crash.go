@@ -0,0 +1,137 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"runtime"
+	"runtime/debug"
+)
+
+// ErrProgramPanic is wrapped by a Session whose program panicked.
+var ErrProgramPanic = errors.New("program panicked")
+
+// goroutineDumpLimit caps the goroutine dump of a hung program.
+const goroutineDumpLimit = 256 << 10
+
+// stderrTailSize is how much captured stderr a failed result keeps.
+const stderrTailSize = 16 << 10
+
+// CrashReport is the "crash" field of a failed ResultFile: what the program
+// left behind when it panicked or stopped responding.
+type CrashReport struct {
+	Panic      string `json:"panic,omitempty"`
+	Stack      string `json:"stack,omitempty"`
+	Goroutines string `json:"goroutines,omitempty"`
+	Stderr     string `json:"stderr,omitempty"`
+}
+
+// CrashError is a Session failure that carries a CrashReport.
+type CrashError struct {
+	kind   string
+	err    error
+	report CrashReport
+}
+
+func (e *CrashError) Error() string { return e.err.Error() }
+func (e *CrashError) Unwrap() error { return e.err }
+
+// NewPanicError records a panic recovered from the program under test; call
+// it from the deferred recover so the stack is the panicking goroutine's.
+func NewPanicError(recovered any) error {
+	return &CrashError{
+		kind:   "panic",
+		err:    fmt.Errorf("%w: %v", ErrProgramPanic, recovered),
+		report: CrashReport{Panic: fmt.Sprint(recovered), Stack: string(debug.Stack())},
+	}
+}
+
+// NewHangError wraps a timeout with a dump of every goroutine, which shows
+// where the program was stuck.
+func NewHangError(err error) error {
+	buf := make([]byte, goroutineDumpLimit)
+	buf = buf[:runtime.Stack(buf, true)]
+	return &CrashError{kind: "hang", err: err, report: CrashReport{Goroutines: string(buf)}}
+}
+
+// errorKind classifies a run failure for the "errorKind" field.
+func errorKind(err error) string {
+	var crash *CrashError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "interrupted"
+	case errors.As(err, &crash):
+		return crash.kind
+	case errors.Is(err, ErrRenderTimeout):
+		return "hang"
+	case errors.Is(err, ErrProgramExited):
+		return "exited"
+	}
+	return "error"
+}
+
+// StderrCapture tees everything written through os.Stderr, by the harness,
+// the program under test and any generator subprocess, so a failed result
+// can carry the tail. Writes straight to file descriptor 2, like the
+// runtime's own fatal errors, are not captured and still reach the terminal.
+type StderrCapture struct {
+	orig *os.File
+	w    *os.File
+	tail *outputRing
+	done chan struct{}
+}
+
+// CaptureStderr starts capturing; Close restores os.Stderr.
+func CaptureStderr() (*StderrCapture, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &StderrCapture{orig: os.Stderr, w: w, tail: newOutputRing(stderrTailSize), done: make(chan struct{})}
+	go func() {
+		defer close(c.done)
+		_, _ = io.Copy(io.MultiWriter(c.orig, ringWriter{c.tail}), r)
+		_ = r.Close()
+	}()
+	os.Stderr = w
+	log.SetOutput(w)
+	return c, nil
+}
+
+// Tail returns the last captured stderr output.
+func (c *StderrCapture) Tail() string {
+	if c == nil {
+		return ""
+	}
+	return string(c.tail.bytes())
+}
+
+// Close restores os.Stderr once everything written so far has been copied.
+func (c *StderrCapture) Close() error {
+	os.Stderr = c.orig
+	log.SetOutput(c.orig)
+	err := c.w.Close()
+	<-c.done
+	return err
+}
+
+type ringWriter struct{ ring *outputRing }
+
+func (w ringWriter) Write(p []byte) (int, error) {
+	w.ring.write(p)
+	return len(p), nil
+}
+
+// AttachStderr adds captured stderr to a failed document's crash report.
+func (f *ResultFile) AttachStderr(stderr string) {
+	if f.OK || stderr == "" {
+		return
+	}
+	if f.Crash == nil {
+		f.Crash = &CrashReport{}
+	}
+	f.Crash.Stderr = stderr
+}
@@ -0,0 +1,148 @@
+package harness
+
+import "math"
+
+// DiffMetrics are the metrics diff compares, in table order, with their
+// units. Each is a --fail-on metric.
+var DiffMetrics = []DiffMetric{
+	{Name: "mean", Unit: "ms"},
+	{Name: "median", Unit: "ms"},
+	{Name: "p95", Unit: "ms"},
+	{Name: "p99", Unit: "ms"},
+	{Name: "totalWall", Unit: "ms"},
+	{Name: "fps", Unit: "fps", HigherIsBetter: true},
+	{Name: "bytesPerFrame", Unit: "B"},
+	{Name: "cpuPerFrame", Unit: "ms"},
+	{Name: "rssPeak", Unit: "KiB"},
+}
+
+// DiffMetric is one row kind of a diff.
+type DiffMetric struct {
+	Name           string
+	Unit           string
+	HigherIsBetter bool
+}
+
+// DiffReport is the "diff" field of a ResultFile: every DiffMetrics metric
+// of every scenario two results share, old to new. Scenarios only one side
+// ran, or ran successfully, are listed apart.
+type DiffReport struct {
+	Old     string    `json:"old"`
+	New     string    `json:"new"`
+	Rows    []DiffRow `json:"rows"`
+	OnlyOld []string  `json:"onlyOld,omitempty"`
+	OnlyNew []string  `json:"onlyNew,omitempty"`
+}
+
+// DiffRow is one metric of one scenario. Change is Delta relative to Old,
+// left out when Old is 0. Significant is whether the delta exceeds run to
+// run noise, and left out where the results hold nothing to tell that by:
+// bootstrap confidence intervals of the mean and p95 that do not overlap,
+// for the mean without them a Welch z above 1.96, and for --repeats results
+// run ranges that do not overlap.
+type DiffRow struct {
+	Scenario    string   `json:"scenario"`
+	Metric      string   `json:"metric"`
+	Unit        string   `json:"unit"`
+	Old         float64  `json:"old"`
+	New         float64  `json:"new"`
+	Delta       float64  `json:"delta"`
+	Change      *float64 `json:"change,omitempty"`
+	Significant *bool    `json:"significant,omitempty"`
+
+	// Worse is whether the delta goes the wrong way for the metric, such
+	// as a higher p95 or a lower fps.
+	Worse bool `json:"worse"`
+}
+
+// DiffResults compares the scenarios of newDoc against those of oldDoc,
+// matched as CheckGate matches them. Two single runs are compared as is,
+// whatever their scenarios.
+func DiffResults(oldPath string, oldDoc ResultFile, newPath string, newDoc ResultFile) DiffReport {
+	report := DiffReport{Old: oldPath, New: newPath, Rows: []DiffRow{}}
+	olds, news := gateDocuments(oldDoc), gateDocuments(newDoc)
+	if len(oldDoc.Suite) == 0 && len(newDoc.Suite) == 0 && oldDoc.OK && newDoc.OK {
+		olds, news = map[string]ResultFile{newDoc.Scenario: oldDoc}, map[string]ResultFile{newDoc.Scenario: newDoc}
+	}
+	for _, scenario := range sortedKeys(olds) {
+		if _, ok := news[scenario]; !ok {
+			report.OnlyOld = append(report.OnlyOld, scenario)
+		}
+	}
+	for _, scenario := range sortedKeys(news) {
+		prev, ok := olds[scenario]
+		if !ok {
+			report.OnlyNew = append(report.OnlyNew, scenario)
+			continue
+		}
+		next := news[scenario]
+		for _, metric := range DiffMetrics {
+			row := DiffRow{Scenario: scenario, Metric: metric.Name, Unit: metric.Unit}
+			row.Old, row.New = gateValue(metric.Name, prev), gateValue(metric.Name, next)
+			row.Delta = row.New - row.Old
+			if row.Old != 0 {
+				change := row.Delta / row.Old
+				row.Change = &change
+			}
+			row.Worse = row.Delta != 0 && (row.Delta > 0) != metric.HigherIsBetter
+			row.Significant = significant(metric.Name, prev, next)
+			report.Rows = append(report.Rows, row)
+		}
+	}
+	return report
+}
+
+// significant tells whether metric differs beyond noise between two
+// results, or nil when they hold no spread for it.
+func significant(metric string, prev ResultFile, next ResultFile) *bool {
+	apart := func(lowA, highA, lowB, highB float64) *bool {
+		out := highA < lowB || highB < lowA
+		return &out
+	}
+	if prev.Aggregate != nil && next.Aggregate != nil {
+		a, b := aggregateSpread(metric, prev.Aggregate), aggregateSpread(metric, next.Aggregate)
+		if a == nil || b == nil || len(a.Runs) < 2 || len(b.Runs) < 2 {
+			return nil
+		}
+		return apart(a.Min, a.Max, b.Min, b.Max)
+	}
+	if prev.Data == nil || next.Data == nil {
+		return nil
+	}
+	a, b := prev.Data.Summary, next.Data.Summary
+	switch {
+	case metric == "mean" && a.CI != nil && b.CI != nil:
+		return apart(a.CI.MeanLow, a.CI.MeanHigh, b.CI.MeanLow, b.CI.MeanHigh)
+	case metric == "p95" && a.CI != nil && b.CI != nil:
+		return apart(a.CI.P95Low, a.CI.P95High, b.CI.P95Low, b.CI.P95High)
+	case metric == "mean" && a.N > 1 && b.N > 1:
+		se := math.Sqrt(a.Stddev*a.Stddev/float64(a.N) + b.Stddev*b.Stddev/float64(b.N))
+		out := se > 0 && math.Abs(b.Mean-a.Mean)/se > 1.96
+		return &out
+	}
+	return nil
+}
+
+func aggregateSpread(metric string, a *RepeatAggregate) *runSpread {
+	switch metric {
+	case "mean":
+		return &a.MeanMs
+	case "median":
+		return &a.MedianMs
+	case "p95":
+		return &a.P95Ms
+	case "p99":
+		return &a.P99Ms
+	case "totalWall":
+		return &a.TotalWallMs
+	case "fps":
+		return &a.FramesPerSec
+	case "bytesPerFrame":
+		return &a.BytesPerFrame
+	case "cpuPerFrame":
+		return &a.CPUMsPerFrame
+	case "rssPeak":
+		return &a.RSSPeakKb
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package harness
+
+import (
+	"slices"
+	"testing"
+)
+
+func diffDoc(scenario string, summary sampleSummary) ResultFile {
+	return ResultFile{OK: true, Scenario: scenario, Data: &Result{Summary: summary, FramesPerSecond: 1000 / max(summary.Mean, 1)}}
+}
+
+func diffSuite(docs ...ResultFile) ResultFile {
+	return ResultFile{OK: true, Suite: docs}
+}
+
+func findRow(report DiffReport, scenario string, metric string) (DiffRow, bool) {
+	for _, row := range report.Rows {
+		if row.Scenario == scenario && row.Metric == metric {
+			return row, true
+		}
+	}
+	return DiffRow{}, false
+}
+
+func TestDiffResultsMatchesScenarios(t *testing.T) {
+	old := diffSuite(diffDoc("a", sampleSummary{Mean: 2}), diffDoc("b", sampleSummary{Mean: 2}), ResultFile{OK: false, Scenario: "c"})
+	next := diffSuite(diffDoc("a", sampleSummary{Mean: 3}), diffDoc("c", sampleSummary{Mean: 1}))
+
+	report := DiffResults("old.json", old, "new.json", next)
+	if !slices.Equal(report.OnlyOld, []string{"b"}) || !slices.Equal(report.OnlyNew, []string{"c"}) {
+		t.Fatalf("onlyOld %v onlyNew %v, want [b] [c]", report.OnlyOld, report.OnlyNew)
+	}
+	if len(report.Rows) != len(DiffMetrics) {
+		t.Fatalf("%d rows, want one per metric of a: %d", len(report.Rows), len(DiffMetrics))
+	}
+	row, _ := findRow(report, "a", "mean")
+	if row.Old != 2 || row.New != 3 || row.Delta != 1 || row.Change == nil || *row.Change != 0.5 || !row.Worse {
+		t.Errorf("mean row %+v, want 2 -> 3, +50%%, worse", row)
+	}
+	if row, _ := findRow(report, "a", "fps"); !row.Worse {
+		t.Errorf("fps fell from %g to %g but is not worse", row.Old, row.New)
+	}
+	if row, _ := findRow(report, "a", "rssPeak"); row.Change != nil || row.Worse {
+		t.Errorf("rssPeak row %+v, want no change from 0", row)
+	}
+}
+
+func TestDiffResultsSingleRuns(t *testing.T) {
+	report := DiffResults("old.json", diffDoc("x", sampleSummary{Mean: 1}), "new.json", diffDoc("y", sampleSummary{Mean: 1}))
+	if _, ok := findRow(report, "y", "mean"); !ok || len(report.OnlyOld)+len(report.OnlyNew) > 0 {
+		t.Errorf("two single runs are not compared as one scenario: %+v", report)
+	}
+}
+
+func TestDiffSignificance(t *testing.T) {
+	ci := func(low, high float64) *summaryCI {
+		return &summaryCI{MeanLow: low, MeanHigh: high, P95Low: low, P95High: high}
+	}
+	spread := func(runs ...float64) runSpread { return newRunSpread(runs) }
+	repeats := func(runs ...float64) ResultFile {
+		return ResultFile{OK: true, Scenario: "s", Aggregate: &RepeatAggregate{Runs: len(runs), MeanMs: spread(runs...)}}
+	}
+	yes, no := true, false
+
+	cases := []struct {
+		name     string
+		metric   string
+		old, new ResultFile
+		want     *bool
+	}{
+		{"confidence intervals apart", "mean", diffDoc("s", sampleSummary{Mean: 1, CI: ci(0.9, 1.1)}), diffDoc("s", sampleSummary{Mean: 2, CI: ci(1.9, 2.1)}), &yes},
+		{"confidence intervals overlap", "p95", diffDoc("s", sampleSummary{P95: 1, CI: ci(0.9, 1.5)}), diffDoc("s", sampleSummary{P95: 1.2, CI: ci(1.1, 1.3)}), &no},
+		{"welch z above 1.96", "mean", diffDoc("s", sampleSummary{N: 100, Mean: 1, Stddev: 0.1}), diffDoc("s", sampleSummary{N: 100, Mean: 1.1, Stddev: 0.1}), &yes},
+		{"welch z below 1.96", "mean", diffDoc("s", sampleSummary{N: 100, Mean: 1, Stddev: 1}), diffDoc("s", sampleSummary{N: 100, Mean: 1.1, Stddev: 1}), &no},
+		{"no spread for p99", "p99", diffDoc("s", sampleSummary{N: 100, P99: 1, Stddev: 1}), diffDoc("s", sampleSummary{N: 100, P99: 2, Stddev: 1}), nil},
+		{"repeat ranges apart", "mean", repeats(1, 1.1, 1.2), repeats(2, 2.1), &yes},
+		{"repeat ranges overlap", "mean", repeats(1, 1.5), repeats(1.4, 1.6), &no},
+		{"a single repeat", "mean", repeats(1), repeats(2, 2.1), nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := significant(tc.metric, tc.old, tc.new)
+			switch {
+			case tc.want == nil && got != nil:
+				t.Errorf("significant = %t, want unknown", *got)
+			case tc.want != nil && (got == nil || *got != *tc.want):
+				t.Errorf("significant = %v, want %t", got, *tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/vtverify"
+)
+
+// DumpScreen renders cfg.Scenario through tick in stub mode, as verify
+// does, and returns the screen a terminal shows afterwards as text: a
+// header, then one numbered line per row with trailing blanks trimmed.
+// With styles, each row holding styled cells is followed by a line naming
+// their columns and looks.
+func DumpScreen(ctx context.Context, cfg Config, tick int, styles bool) (string, error) {
+	if err := ValidateScenario(cfg.Scenario, cfg.Params); err != nil {
+		return "", err
+	}
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+	cfg.ReplayTicks = nil
+	cfg.VerifyTicks = tick + 1
+	data, err := recordScenario(ctx, cfg, rows, cols)
+	if err != nil {
+		return "", err
+	}
+	preamble, frames, err := ParseSession(data)
+	if err != nil {
+		return "", err
+	}
+
+	term := vtverify.New(rows, cols)
+	_, _ = term.Write(preamble)
+	for i, frame := range frames {
+		for _, p := range frame.Writes {
+			_, _ = term.Write(p)
+		}
+		// The last trailer is the program shutting down, which would
+		// leave the alternate screen the frame was drawn on.
+		if i < len(frames)-1 {
+			_, _ = term.Write(frame.Trailer)
+		}
+	}
+
+	runs := map[int][]vtverify.StyleRun{}
+	if styles {
+		for _, run := range term.StyleRuns() {
+			runs[run.Row] = append(runs[run.Row], run)
+		}
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "# %s tick %d, %dx%d, seed %d\n", cfg.Scenario, tick, rows, cols, cfg.Seed)
+	width := len(strconv.Itoa(rows - 1))
+	for r, line := range term.Screen() {
+		fmt.Fprintf(&out, "%*d │%s\n", width, r, strings.TrimRight(line, " "))
+		if len(runs[r]) == 0 {
+			continue
+		}
+		notes := make([]string, len(runs[r]))
+		for i, run := range runs[r] {
+			notes[i] = fmt.Sprintf("%d-%d %s", run.Col, run.Col+run.Width-1, run.Style)
+		}
+		fmt.Fprintf(&out, "%*s ╰ %s\n", width, "", strings.Join(notes, "; "))
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,103 @@
+package harness
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// FrameExportReport is the "exportFrames" or "fixtures" field of a
+// ResultFile, and the manifest.json export-frames and fixtures write beside
+// the frames. Each scenario gets a directory under Dir holding one file per
+// tick: tick-NNNNNN.txt from export-frames, the layout verify --golden reads
+// so other engines can check that they produce the same logical frames from
+// the same scenario, params and seed, or tick-NNNNNN.bin from fixtures.
+type FrameExportReport struct {
+	Dir       string                `json:"dir"`
+	Seed      uint64                `json:"seed"`
+	Scenarios []frameExportScenario `json:"scenarios"`
+	Frames    int                   `json:"frames"`
+}
+
+type frameExportScenario struct {
+	Scenario string            `json:"scenario"`
+	Params   map[string]string `json:"params"`
+	Rows     int               `json:"rows"`
+	Cols     int               `json:"cols"`
+	Frames   []exportedFrame   `json:"frames"`
+}
+
+// exportedFrame is one written frame; Path is relative to the export's Dir
+// and SHA256 is the hex digest of the file's bytes.
+type exportedFrame struct {
+	Tick   int    `json:"tick"`
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+}
+
+// ExportFrames writes the plain-text frames of scenarios at cfg.ReplayTicks,
+// or else the first cfg.VerifyTicks ticks, straight from their generators
+// with cfg.Params and cfg.Seed, under dir. A frame is its lines with any
+// ANSI stripped, each ending in a newline. No program is started.
+func ExportFrames(ctx context.Context, cfg Config, scenarios []string, dir string) (FrameExportReport, error) {
+	return exportScenarios(ctx, cfg, scenarios, dir, "txt", func(lines []string, rows int, cols int) []byte {
+		return frameText(lines)
+	})
+}
+
+// frameText is a frame as export-frames writes it and parity hashes it.
+func frameText(lines []string) []byte {
+	var frame strings.Builder
+	for _, line := range lines {
+		frame.WriteString(ansi.Strip(line))
+		frame.WriteByte('\n')
+	}
+	return []byte(frame.String())
+}
+
+// exportScenarios writes encode's file for each frame of scenarios as
+// dir/<scenario>/tick-NNNNNN.<ext>, then the manifest.
+func exportScenarios(ctx context.Context, cfg Config, scenarios []string, dir string, ext string, encode func(lines []string, rows int, cols int) []byte) (FrameExportReport, error) {
+	report := FrameExportReport{Dir: dir, Seed: cfg.Seed, Scenarios: []frameExportScenario{}}
+	for _, scenario := range scenarios {
+		s, ok := lookupScenario(scenario)
+		if !ok {
+			return report, fmt.Errorf("unknown scenario %q (run list to see them)", scenario)
+		}
+		params := suiteParams(scenario, cfg.Params)
+		rows, cols := viewportOf(s.meta, params)
+		out := frameExportScenario{Scenario: scenario, Params: params, Rows: rows, Cols: cols, Frames: []exportedFrame{}}
+		if err := os.MkdirAll(filepath.Join(dir, scenario), 0o755); err != nil {
+			return report, fmt.Errorf("export frames: %w", err)
+		}
+		for _, tick := range cfg.verifyTicks() {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			data := encode(ScenarioLines(scenario, params, cfg.Seed, tick, cols), rows, cols)
+			name := filepath.Join(scenario, fmt.Sprintf("tick-%06d.%s", tick, ext))
+			if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+				return report, fmt.Errorf("export frames: %w", err)
+			}
+			sum := sha256.Sum256(data)
+			out.Frames = append(out.Frames, exportedFrame{Tick: tick, Path: filepath.ToSlash(name), SHA256: hex.EncodeToString(sum[:])})
+			report.Frames++
+		}
+		report.Scenarios = append(report.Scenarios, out)
+	}
+	manifest, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return report, err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "manifest.json"), append(manifest, '\n'), 0o644); err != nil {
+		return report, fmt.Errorf("export frames: %w", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,168 @@
+package harness
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"plugin"
+	"sync"
+)
+
+// LoadPlugin opens a Go plugin whose init functions call RegisterScenario.
+// The plugin must be built with the same toolchain and harness version as the
+// binary loading it.
+func LoadPlugin(path string) error {
+	before := len(Scenarios())
+	if _, err := plugin.Open(path); err != nil {
+		return fmt.Errorf("load scenario plugin: %w", err)
+	}
+	if len(Scenarios()) == before {
+		return fmt.Errorf("scenario plugin %s registered no scenarios", path)
+	}
+	return nil
+}
+
+// Generator subprocesses speak NDJSON over stdin/stdout, one request line and
+// one response line at a time:
+//
+//	> {"op":"describe"}
+//	< {"scenarios":[{"name":"my-app","description":"...","params":[...],"rows":40,"cols":120}]}
+//	> {"op":"frame","scenario":"my-app","params":{"rows":"40"},"seed":42,"tick":7,"cols":120}
+//	< {"lines":["...","..."]}
+//
+// Generators derive varying content from seed and tick only, so frames are
+// reproducible across runs; both are always sent, zero included.
+// A response may carry "error" instead. The subprocess runs for the life of
+// the harness and gets EOF on stdin when it should exit.
+type execRequest struct {
+	Op       string            `json:"op"`
+	Scenario string            `json:"scenario,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+	Seed     uint64            `json:"seed"`
+	Tick     int               `json:"tick"`
+	Cols     int               `json:"cols,omitempty"`
+}
+
+type execResponse struct {
+	Scenarios []ScenarioInfo `json:"scenarios,omitempty"`
+	Lines     []string       `json:"lines,omitempty"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// ExecGenerator is a running generator subprocess.
+type ExecGenerator struct {
+	cmd *exec.Cmd
+	enc *json.Encoder
+	dec *json.Decoder
+	in  io.Closer
+
+	mu sync.Mutex
+}
+
+// StartExecGenerator starts argv as a generator subprocess and registers the
+// scenarios it describes. Close it once the run is over.
+func StartExecGenerator(argv []string) (*ExecGenerator, error) {
+	if len(argv) == 0 {
+		return nil, errors.New("empty generator command")
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("start scenario generator: %w", err)
+	}
+	g := &ExecGenerator{
+		cmd: cmd,
+		enc: json.NewEncoder(stdin),
+		dec: json.NewDecoder(bufio.NewReader(stdout)),
+		in:  stdin,
+	}
+
+	resp, err := g.call(execRequest{Op: "describe"})
+	if err != nil {
+		_ = g.Close()
+		return nil, fmt.Errorf("describe scenario generator: %w", err)
+	}
+	if err := checkDescribed(resp.Scenarios); err != nil {
+		_ = g.Close()
+		return nil, err
+	}
+	for _, info := range resp.Scenarios {
+		name := info.Name
+		RegisterScenario(name, info.ScenarioMeta, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+			return g.frame(name, params, rng.Seed(), tick, cols)
+		})
+	}
+	return g, nil
+}
+
+// checkDescribed rejects what RegisterScenario would panic on, before any
+// of the described scenarios is registered.
+func checkDescribed(scenarios []ScenarioInfo) error {
+	if len(scenarios) == 0 {
+		return errors.New("scenario generator described no scenarios")
+	}
+	seen := map[string]bool{}
+	for _, info := range scenarios {
+		if info.Name == "" {
+			return errors.New("scenario generator described a scenario with no name")
+		}
+		if _, ok := lookupScenario(info.Name); ok || seen[info.Name] {
+			return fmt.Errorf("scenario generator redefines scenario %q", info.Name)
+		}
+		seen[info.Name] = true
+		for _, p := range info.Params {
+			if p.Type != "" && p.Type != "int" && p.Type != "bool" {
+				return fmt.Errorf("scenario generator: scenario %q param %q has unknown type %q", info.Name, p.Name, p.Type)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *ExecGenerator) call(req execRequest) (execResponse, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var resp execResponse
+	if err := g.enc.Encode(req); err != nil {
+		return resp, err
+	}
+	if err := g.dec.Decode(&resp); err != nil {
+		if err == io.EOF {
+			err = errors.New("generator exited")
+		}
+		return resp, err
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// frame asks the subprocess for one frame. Generators cannot fail, so an
+// error is rendered in place of the frame, where verify and the recorded
+// session will show it.
+func (g *ExecGenerator) frame(scenario string, params map[string]string, seed uint64, tick int, cols int) []string {
+	resp, err := g.call(execRequest{Op: "frame", Scenario: scenario, Params: params, Seed: seed, Tick: tick, Cols: cols})
+	if err != nil {
+		return []string{clipPad(fmt.Sprintf("scenario generator error: %v", err), cols)}
+	}
+	return resp.Lines
+}
+
+// Close ends the subprocess by closing its stdin and waits for it to exit.
+func (g *ExecGenerator) Close() error {
+	_ = g.in.Close()
+	return g.cmd.Wait()
+}
@@ -0,0 +1,46 @@
+package harness
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStartExecGeneratorRejectsBadDescribe(t *testing.T) {
+	cases := []struct {
+		name     string
+		describe string
+		err      string
+	}{
+		{name: "no scenarios", describe: `{"scenarios":[]}`, err: "described no scenarios"},
+		{name: "no name", describe: `{"scenarios":[{"name":""}]}`, err: "with no name"},
+		{name: "registered name", describe: `{"scenarios":[{"name":"rerender"}]}`, err: `redefines scenario "rerender"`},
+		{name: "repeated name", describe: `{"scenarios":[{"name":"ext-a"},{"name":"ext-a"}]}`, err: `redefines scenario "ext-a"`},
+		{name: "unknown param type", describe: `{"scenarios":[{"name":"ext-b","params":[{"name":"n","type":"float"}]}]}`, err: `unknown type "float"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := StartExecGenerator([]string{"sh", "-c", "read line; echo '" + tc.describe + "'; cat >/dev/null"})
+			if err == nil {
+				_ = g.Close()
+				t.Fatal("started, want an error")
+			}
+			if !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("err %v, want %q", err, tc.err)
+			}
+			if _, ok := lookupScenario("ext-a"); ok {
+				t.Error("a rejected generator registered ext-a")
+			}
+		})
+	}
+}
+
+func TestExecRequestSendsZeroSeedAndTick(t *testing.T) {
+	data, err := json.Marshal(execRequest{Op: "frame", Scenario: "s", Cols: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"seed":0`) || !strings.Contains(string(data), `"tick":0`) {
+		t.Errorf("request %s, want seed and tick sent", data)
+	}
+}
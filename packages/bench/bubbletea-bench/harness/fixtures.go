@@ -0,0 +1,193 @@
+package harness
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/vtverify"
+)
+
+// ExportFixtures writes the frames ExportFrames would, as ZRDL v1 drawlists
+// (docs/protocol/zrdl.md) the Rezi engine's renderer benchmarks replay, so
+// they draw the same content as the programs benched here. A frame's lines
+// are laid out on a rows x cols screen and each stretch of a row in one
+// style becomes a DRAW_TEXT after a CLEAR.
+func ExportFixtures(ctx context.Context, cfg Config, scenarios []string, dir string) (FrameExportReport, error) {
+	return exportScenarios(ctx, cfg, scenarios, dir, "bin", encodeDrawlist)
+}
+
+// ZRDL v1 constants, from packages/core/src/abi.ts and
+// scripts/drawlist-spec.ts.
+const (
+	zrdlMagic      = 0x4c44525a
+	zrdlVersion    = 1
+	zrdlHeaderSize = 64
+
+	opClear     = 1
+	opDrawText  = 3
+	opDefString = 10
+
+	drawTextSize  = 60
+	defStringSize = 16
+)
+
+// Style attribute bits of a drawlist style, as the core builder packs them.
+const (
+	attrBold      = 1 << 0
+	attrItalic    = 1 << 1
+	attrUnderline = 1 << 2
+	attrInverse   = 1 << 3
+	attrBlink     = 1 << 7
+)
+
+// drawlist builds a command stream the way the core builder does: each
+// distinct text is defined once with DEF_STRING ahead of the commands that
+// draw it.
+type drawlist struct {
+	defs    []byte
+	cmds    []byte
+	count   uint32
+	strings map[string]uint32
+}
+
+func (d *drawlist) clear() {
+	d.cmds = appendCommand(d.cmds, opClear, 8)
+	d.count++
+}
+
+func (d *drawlist) drawText(x int, y int, text string, style vtverify.Style) {
+	id, ok := d.strings[text]
+	if !ok {
+		id = uint32(len(d.strings) + 1)
+		d.strings[text] = id
+		size := defStringSize + (len(text)+3)&^3
+		d.defs = appendCommand(d.defs, opDefString, size)
+		d.defs = binary.LittleEndian.AppendUint32(d.defs, id)
+		d.defs = binary.LittleEndian.AppendUint32(d.defs, uint32(len(text)))
+		d.defs = append(d.defs, text...)
+		d.defs = append(d.defs, make([]byte, size-defStringSize-len(text))...)
+		d.count++
+	}
+	fg, bg := style.FG, style.BG
+	var attrs uint32
+	for _, attr := range []struct {
+		on  bool
+		bit uint32
+	}{{style.Bold, attrBold}, {style.Italic, attrItalic}, {style.Underline, attrUnderline}, {style.Reverse, attrInverse}, {style.Blink, attrBlink}} {
+		if attr.on {
+			attrs |= attr.bit
+		}
+	}
+	if style.Reverse {
+		// The emulator has swapped them already; the engine swaps them
+		// itself for an inverse style.
+		fg, bg = bg, fg
+	}
+	d.cmds = appendCommand(d.cmds, opDrawText, drawTextSize)
+	for _, v := range []uint32{uint32(int32(x)), uint32(int32(y)), id, 0, uint32(len(text)), rgbOf(fg), rgbOf(bg), attrs, 0, 0, 0, 0, 0} {
+		d.cmds = binary.LittleEndian.AppendUint32(d.cmds, v)
+	}
+	d.count++
+}
+
+// bytes is the drawlist with its header. Strings and blobs are only ever
+// defined by commands, so their header tables stay empty.
+func (d *drawlist) bytes() []byte {
+	cmdBytes := len(d.defs) + len(d.cmds)
+	out := make([]byte, zrdlHeaderSize, zrdlHeaderSize+cmdBytes)
+	for i, v := range []uint32{zrdlMagic, zrdlVersion, zrdlHeaderSize, uint32(zrdlHeaderSize + cmdBytes), zrdlHeaderSize, uint32(cmdBytes), d.count} {
+		binary.LittleEndian.PutUint32(out[i*4:], v)
+	}
+	out = append(out, d.defs...)
+	return append(out, d.cmds...)
+}
+
+func appendCommand(b []byte, opcode uint16, size int) []byte {
+	b = binary.LittleEndian.AppendUint16(b, opcode)
+	b = binary.LittleEndian.AppendUint16(b, 0)
+	return binary.LittleEndian.AppendUint32(b, uint32(size))
+}
+
+// encodeDrawlist lays lines out on an emulated screen, so their SGRs resolve
+// to the styles a terminal would show, and draws the result. Unstyled
+// blanks are left to the CLEAR.
+func encodeDrawlist(lines []string, rows int, cols int) []byte {
+	term := vtverify.New(rows, cols)
+	for r, line := range lines {
+		if r >= rows {
+			break
+		}
+		_, _ = fmt.Fprintf(term, "\x1b[%d;1H%s\x1b[m", r+1, line)
+	}
+	plain := vtverify.Style{FG: -1, BG: -1}
+	styles := make([][]vtverify.Style, rows)
+	for r := range styles {
+		styles[r] = make([]vtverify.Style, cols)
+		for c := range styles[r] {
+			styles[r][c] = plain
+		}
+	}
+	for _, run := range term.StyleRuns() {
+		for c := run.Col; c < run.Col+run.Width; c++ {
+			styles[run.Row][c] = run.Style
+		}
+	}
+
+	d := &drawlist{strings: map[string]uint32{}}
+	d.clear()
+	for r, line := range term.Screen() {
+		cells := vtverify.Cells(line)
+		for c := 0; c < len(cells); {
+			style, start := styles[r][c], c
+			var text strings.Builder
+			for c < len(cells) && styles[r][c] == style {
+				// The cell a wide character covers is "" and adds nothing.
+				text.WriteString(cells[c])
+				c++
+			}
+			s := text.String()
+			if style == plain {
+				trimmed := strings.TrimLeft(s, " ")
+				start += len(s) - len(trimmed)
+				s = strings.TrimRight(trimmed, " ")
+			}
+			if s != "" {
+				d.drawText(start, r, s, style)
+			}
+		}
+	}
+	return d.bytes()
+}
+
+// rgbOf packs a Style colour as the engine's 0xRRGGBB, turning a palette
+// index into the xterm colour it stands for. The default colour is 0, as
+// the core builder encodes an unset one.
+func rgbOf(color int32) uint32 {
+	switch {
+	case color < 0:
+		return 0
+	case color < 16:
+		return xtermBase[color]
+	case color < 232:
+		i := color - 16
+		return cubeLevel(i/36)<<16 | cubeLevel(i/6%6)<<8 | cubeLevel(i%6)
+	case color < 256:
+		gray := uint32(8 + 10*(color-232))
+		return gray<<16 | gray<<8 | gray
+	}
+	return uint32(color) & 0xffffff
+}
+
+var xtermBase = [16]uint32{
+	0x000000, 0xcd0000, 0x00cd00, 0xcdcd00, 0x0000ee, 0xcd00cd, 0x00cdcd, 0xe5e5e5,
+	0x7f7f7f, 0xff0000, 0x00ff00, 0xffff00, 0x5c5cff, 0xff00ff, 0x00ffff, 0xffffff,
+}
+
+func cubeLevel(i int32) uint32 {
+	if i == 0 {
+		return 0
+	}
+	return uint32(55 + 40*i)
+}
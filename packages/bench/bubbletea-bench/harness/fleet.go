@@ -0,0 +1,165 @@
+package harness
+
+import "fmt"
+
+// FleetReport is the "fleet" field of a ResultFile: the results of several
+// hosts combined scenario by scenario. With a calibration scenario, each
+// host's timings are first scaled by how its calibration mean compares with
+// the fleet's median calibration mean, so a slower machine does not read as
+// a slower scenario.
+type FleetReport struct {
+	Calibration string          `json:"calibration,omitempty"`
+	Hosts       []FleetHost     `json:"hosts"`
+	Scenarios   []FleetScenario `json:"scenarios"`
+}
+
+// FleetHost is one machine. Factor is its calibration mean over the fleet
+// median, 1 without calibration: timings are divided by it and fps is
+// multiplied.
+type FleetHost struct {
+	Host      string   `json:"host"`
+	Files     []string `json:"files"`
+	Scenarios int      `json:"scenarios"`
+	Factor    float64  `json:"factor"`
+}
+
+// FleetScenario is one scenario across the hosts that ran it.
+type FleetScenario struct {
+	Scenario string        `json:"scenario"`
+	Hosts    int           `json:"hosts"`
+	Metrics  []FleetMetric `json:"metrics"`
+}
+
+// FleetMetric is one DiffMetrics metric of a scenario: the median across
+// hosts, their range and Spread, the range over the median, and each
+// host's value. A host that ran a scenario more than once counts with the
+// median of its runs. Normalized is whether the values were scaled by
+// calibration; bytes and memory are not.
+type FleetMetric struct {
+	Metric     string             `json:"metric"`
+	Unit       string             `json:"unit"`
+	Normalized bool               `json:"normalized"`
+	Median     float64            `json:"median"`
+	Min        float64            `json:"min"`
+	Max        float64            `json:"max"`
+	Spread     float64            `json:"spread"`
+	ByHost     map[string]float64 `json:"byHost"`
+}
+
+// AggregateFleet combines the result files at paths, read with ReadResults,
+// by host: a document's "host" label if it has one, else the host its run
+// recorded, else the file it came from. Failed runs are left out. With
+// calibration set, every host must have run that scenario.
+func AggregateFleet(paths []string, calibration string) (FleetReport, error) {
+	report := FleetReport{Calibration: calibration, Hosts: []FleetHost{}, Scenarios: []FleetScenario{}}
+	hosts := map[string]*FleetHost{}
+	// runs[scenario][host] are the documents of a host for a scenario.
+	runs := map[string]map[string][]ResultFile{}
+	for _, path := range paths {
+		docs, err := ReadResults(path)
+		if err != nil {
+			return report, err
+		}
+		for _, doc := range MergeResults(docs).Suite {
+			if !doc.OK || (doc.Data == nil && doc.Aggregate == nil) {
+				continue
+			}
+			host := doc.Labels["host"]
+			if host == "" && doc.Run != nil {
+				host = doc.Run.Host
+			}
+			if host == "" {
+				host = path
+			}
+			h := hosts[host]
+			if h == nil {
+				h = &FleetHost{Host: host, Files: []string{}, Factor: 1}
+				hosts[host] = h
+			}
+			if len(h.Files) == 0 || h.Files[len(h.Files)-1] != path {
+				h.Files = append(h.Files, path)
+			}
+			if runs[doc.Scenario] == nil {
+				runs[doc.Scenario] = map[string][]ResultFile{}
+			}
+			if len(runs[doc.Scenario][host]) == 0 {
+				h.Scenarios++
+			}
+			runs[doc.Scenario][host] = append(runs[doc.Scenario][host], doc)
+		}
+	}
+	if len(hosts) == 0 {
+		return report, fmt.Errorf("no successful results in %d files", len(paths))
+	}
+
+	if calibration != "" {
+		means := map[string]float64{}
+		for _, host := range sortedKeys(hosts) {
+			docs := runs[calibration][host]
+			if len(docs) == 0 {
+				return report, fmt.Errorf("host %s has no successful %s run to calibrate by", host, calibration)
+			}
+			means[host] = hostValue("mean", docs)
+		}
+		fleet := medianOf(mapValues(means))
+		for host, mean := range means {
+			if fleet > 0 && mean > 0 {
+				hosts[host].Factor = mean / fleet
+			}
+		}
+	}
+	for _, host := range sortedKeys(hosts) {
+		report.Hosts = append(report.Hosts, *hosts[host])
+	}
+
+	for _, scenario := range sortedKeys(runs) {
+		byHost := runs[scenario]
+		out := FleetScenario{Scenario: scenario, Hosts: len(byHost), Metrics: []FleetMetric{}}
+		for _, metric := range DiffMetrics {
+			m := FleetMetric{Metric: metric.Name, Unit: metric.Unit, ByHost: map[string]float64{}}
+			m.Normalized = calibration != "" && (metric.Unit == "ms" || metric.Unit == "fps")
+			for host, docs := range byHost {
+				value := hostValue(metric.Name, docs)
+				switch {
+				case !m.Normalized:
+				case metric.Unit == "fps":
+					value *= hosts[host].Factor
+				default:
+					value /= hosts[host].Factor
+				}
+				m.ByHost[host] = value
+			}
+			s := summarize(mapValues(m.ByHost))
+			m.Median, m.Min, m.Max = s.Median, s.Min, s.Max
+			if s.Median > 0 {
+				m.Spread = (s.Max - s.Min) / s.Median
+			}
+			out.Metrics = append(out.Metrics, m)
+		}
+		report.Scenarios = append(report.Scenarios, out)
+	}
+	return report, nil
+}
+
+// hostValue is the median of a metric over one host's runs of a scenario.
+func hostValue(metric string, docs []ResultFile) float64 {
+	values := make([]float64, len(docs))
+	for i, doc := range docs {
+		values[i] = gateValue(metric, doc)
+	}
+	return medianOf(values)
+}
+
+func medianOf(values []float64) float64 {
+	return summarize(values).Median
+}
+
+// mapValues returns m's values in key order.
+func mapValues(m map[string]float64) []float64 {
+	keys := sortedKeys(m)
+	out := make([]float64, len(keys))
+	for i, key := range keys {
+		out[i] = m[key]
+	}
+	return out
+}
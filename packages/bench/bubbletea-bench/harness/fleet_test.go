@@ -0,0 +1,110 @@
+package harness
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeResults writes docs to a result file of their own and returns its
+// path.
+func writeResults(t *testing.T, docs ...ResultFile) string {
+	t.Helper()
+	data, err := json.Marshal(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "results.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func hostDoc(host string, scenario string, mean float64) ResultFile {
+	return ResultFile{OK: true, Scenario: scenario, Run: &RunInfo{Host: host}, Data: &Result{Summary: sampleSummary{Mean: mean}, RSSPeakKb: 1000}}
+}
+
+func fleetMetric(t *testing.T, report FleetReport, scenario string, metric string) FleetMetric {
+	t.Helper()
+	for _, s := range report.Scenarios {
+		if s.Scenario != scenario {
+			continue
+		}
+		for _, m := range s.Metrics {
+			if m.Metric == metric {
+				return m
+			}
+		}
+	}
+	t.Fatalf("no %s %s in the report", scenario, metric)
+	return FleetMetric{}
+}
+
+func TestAggregateFleet(t *testing.T) {
+	// fast runs everything in half the time slow does.
+	fast := writeResults(t, hostDoc("fast", "calib", 1), hostDoc("fast", "work", 2))
+	slow := writeResults(t, hostDoc("slow", "calib", 2), hostDoc("slow", "work", 4), ResultFile{OK: false, Scenario: "broken"})
+	labelled := hostDoc("fast", "work", 6)
+	labelled.Labels = map[string]string{"host": "third"}
+	third := writeResults(t, labelled, hostDoc("", "calib", 3))
+
+	cases := []struct {
+		name        string
+		paths       []string
+		calibration string
+		hosts       []string
+		median      float64
+		spread      float64
+		normalized  bool
+	}{
+		{"raw", []string{fast, slow}, "", []string{"fast", "slow"}, 3, 2.0 / 3, false},
+		{"calibrated", []string{fast, slow}, "calib", []string{"fast", "slow"}, 3, 0, true},
+		// The labelled document is its own host; the unlabelled one in the
+		// same file falls back to the file.
+		{"labels and files", []string{fast, third}, "", []string{third, "fast", "third"}, 4, 1, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			report, err := AggregateFleet(tc.paths, tc.calibration)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var hosts []string
+			for _, h := range report.Hosts {
+				hosts = append(hosts, h.Host)
+			}
+			if strings.Join(hosts, ",") != strings.Join(tc.hosts, ",") {
+				t.Fatalf("hosts %v, want %v", hosts, tc.hosts)
+			}
+			m := fleetMetric(t, report, "work", "mean")
+			if math.Abs(m.Median-tc.median) > 1e-9 || math.Abs(m.Spread-tc.spread) > 1e-9 || m.Normalized != tc.normalized {
+				t.Errorf("work mean: median %g spread %g normalized %t, want %g %g %t", m.Median, m.Spread, m.Normalized, tc.median, tc.spread, tc.normalized)
+			}
+			if rss := fleetMetric(t, report, "work", "rssPeak"); rss.Normalized || rss.Median != 1000 {
+				t.Errorf("rssPeak %+v, want 1000 and never normalized", rss)
+			}
+			for _, s := range report.Scenarios {
+				if s.Scenario == "broken" {
+					t.Errorf("failed run was aggregated")
+				}
+			}
+		})
+	}
+}
+
+func TestAggregateFleetErrors(t *testing.T) {
+	fast := writeResults(t, hostDoc("fast", "calib", 1))
+	slow := writeResults(t, hostDoc("slow", "work", 4))
+	failed := writeResults(t, ResultFile{OK: false, Scenario: "work"})
+
+	if _, err := AggregateFleet([]string{fast, slow}, "calib"); err == nil || !strings.Contains(err.Error(), "slow") {
+		t.Errorf("host without the calibration scenario: err %v", err)
+	}
+	if _, err := AggregateFleet([]string{failed}, ""); err == nil {
+		t.Errorf("only failed runs: no error")
+	}
+}
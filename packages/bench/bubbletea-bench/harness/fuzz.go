@@ -0,0 +1,135 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sort"
+	"strconv"
+)
+
+// fuzzWorst is how many trials the Worst list of a FuzzReport names.
+const fuzzWorst = 5
+
+// FuzzReport is the "fuzz" field of a ResultFile: the scenario run at
+// params drawn from Seed. Trials are in run order; Worst indexes the failed
+// trials and then the slowest by p95, the configurations to look at first.
+// Params set on the command line are left as given.
+type FuzzReport struct {
+	Seed   uint64      `json:"seed"`
+	Fuzzed []string    `json:"fuzzed"`
+	Trials []FuzzTrial `json:"trials"`
+	Worst  []int       `json:"worst"`
+}
+
+// FuzzTrial is one draw. Params are only the fuzzed ones; MedianMs, P95Ms
+// and FramesPerSecond are copied out of Result as for a SweepCell.
+type FuzzTrial struct {
+	Params          map[string]string `json:"params"`
+	OK              bool              `json:"ok"`
+	MedianMs        float64           `json:"medianMs"`
+	P95Ms           float64           `json:"p95Ms"`
+	FramesPerSecond float64           `json:"framesPerSecond"`
+	Result          ResultFile        `json:"result"`
+}
+
+// fuzzSpecs are the params of scenario --fuzz-params draws, those not set
+// in params.
+func fuzzSpecs(scenario string, params map[string]string) ([]ParamSpec, error) {
+	s, ok := lookupScenario(scenario)
+	if !ok {
+		return nil, fmt.Errorf("unknown scenario %q (run list to see them)", scenario)
+	}
+	specs := []ParamSpec{}
+	for _, spec := range s.meta.Params {
+		if _, set := params[spec.Name]; !set {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("--fuzz-params: scenario %q has no unset parameters to fuzz", scenario)
+	}
+	return specs, nil
+}
+
+// drawParam picks a value for spec: either boolean, or an integer uniform
+// over Min..Max. A param without a Max is drawn up to four times its
+// default, far enough past it to find where it starts to hurt.
+func drawParam(rng *rand.Rand, spec ParamSpec) string {
+	if spec.Type == "bool" {
+		return strconv.FormatBool(rng.IntN(2) == 1)
+	}
+	hi := spec.Max
+	if hi == 0 {
+		hi = max(spec.Min, 4*spec.Default)
+	}
+	return strconv.Itoa(spec.Min + rng.IntN(hi-spec.Min+1))
+}
+
+// ValidateFuzz checks scenario has params left to fuzz.
+func ValidateFuzz(scenario string, params map[string]string) error {
+	if err := ValidateScenario(scenario, params); err != nil {
+		return err
+	}
+	_, err := fuzzSpecs(scenario, params)
+	return err
+}
+
+// RunFuzz runs cfg with n draws of the unset params, seeded by cfg.Seed so
+// the same command draws the same trials, returning memory to the OS
+// between trials like RunSweep. Trials not yet started when ctx is
+// cancelled are left out.
+func RunFuzz(ctx context.Context, cfg Config, n int) FuzzReport {
+	report := FuzzReport{Seed: cfg.Seed, Fuzzed: []string{}, Trials: []FuzzTrial{}, Worst: []int{}}
+	specs, err := fuzzSpecs(cfg.Scenario, cfg.Params)
+	if err != nil {
+		return report
+	}
+	for _, spec := range specs {
+		report.Fuzzed = append(report.Fuzzed, spec.Name)
+	}
+	rng := rand.New(rand.NewPCG(cfg.Seed, 0x66757a7a))
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		trial := FuzzTrial{Params: map[string]string{}}
+		params := map[string]string{}
+		for key, value := range cfg.Params {
+			params[key] = value
+		}
+		for _, spec := range specs {
+			value := drawParam(rng, spec)
+			trial.Params[spec.Name] = value
+			params[spec.Name] = value
+		}
+		debug.FreeOSMemory()
+		run := cfg
+		run.Params = params
+		trial.Result = RunRepeated(ctx, run)
+		trial.OK = trial.Result.OK
+		switch {
+		case trial.Result.Data != nil:
+			trial.MedianMs = trial.Result.Data.Summary.Median
+			trial.P95Ms = trial.Result.Data.Summary.P95
+			trial.FramesPerSecond = trial.Result.Data.FramesPerSecond
+		case trial.Result.Aggregate != nil:
+			trial.MedianMs = trial.Result.Aggregate.MedianMs.Median
+			trial.P95Ms = trial.Result.Aggregate.P95Ms.Median
+			trial.FramesPerSecond = trial.Result.Aggregate.FramesPerSec.Median
+		}
+		report.Trials = append(report.Trials, trial)
+	}
+
+	order := make([]int, len(report.Trials))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ta, tb := report.Trials[order[a]], report.Trials[order[b]]
+		if ta.OK != tb.OK {
+			return !ta.OK
+		}
+		return ta.P95Ms > tb.P95Ms
+	})
+	report.Worst = order[:min(len(order), fuzzWorst)]
+	return report
+}
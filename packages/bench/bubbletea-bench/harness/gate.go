@@ -0,0 +1,227 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// gateMetrics are the result metrics a --fail-on rule may name. Timings are
+// milliseconds, memory is KiB.
+var gateMetrics = map[string]struct {
+	data      func(d *Result) float64
+	aggregate func(a *RepeatAggregate) float64
+}{
+	"mean":          {func(d *Result) float64 { return d.Summary.Mean }, func(a *RepeatAggregate) float64 { return a.MeanMs.Median }},
+	"median":        {func(d *Result) float64 { return d.Summary.Median }, func(a *RepeatAggregate) float64 { return a.MedianMs.Median }},
+	"p95":           {func(d *Result) float64 { return d.Summary.P95 }, func(a *RepeatAggregate) float64 { return a.P95Ms.Median }},
+	"p99":           {func(d *Result) float64 { return d.Summary.P99 }, func(a *RepeatAggregate) float64 { return a.P99Ms.Median }},
+	"totalWall":     {func(d *Result) float64 { return d.TotalWallMs }, func(a *RepeatAggregate) float64 { return a.TotalWallMs.Median }},
+	"fps":           {func(d *Result) float64 { return d.FramesPerSecond }, func(a *RepeatAggregate) float64 { return a.FramesPerSec.Median }},
+	"bytesPerFrame": {(*Result).bytesPerFrame, func(a *RepeatAggregate) float64 { return a.BytesPerFrame.Median }},
+	"cpuPerFrame":   {(*Result).cpuMsPerFrame, func(a *RepeatAggregate) float64 { return a.CPUMsPerFrame.Median }},
+	"rssPeak":       {func(d *Result) float64 { return float64(d.RSSPeakKb) }, func(a *RepeatAggregate) float64 { return a.RSSPeakKb.Median }},
+}
+
+// GateRule is one --fail-on threshold: the change in metric from the
+// baseline may not be above (">") or below ("<") Limit, a fraction of the
+// baseline when Relative and an absolute amount otherwise. Limit is signed:
+// "p95>+5%" allows p95 to rise by 5%, "p95>-5%" requires it to fall by 5%.
+type GateRule struct {
+	Metric   string  `json:"metric"`
+	Op       string  `json:"op"`
+	Limit    float64 `json:"limit"`
+	Relative bool    `json:"relative"`
+	Text     string  `json:"rule"`
+}
+
+// ParseGateRules parses a --fail-on list like "p95>+5%,rssPeak>+10%,fps<-3%".
+// A limit without "%" is in the metric's own unit: "p95>+0.5" allows half a
+// millisecond. An unsigned limit takes the sign of its direction, "+" after
+// ">" and "-" after "<", so "fps<3%" is "fps<-3%".
+func ParseGateRules(spec string) ([]GateRule, error) {
+	rules := []GateRule{}
+	for _, text := range strings.Split(spec, ",") {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		i := strings.IndexAny(text, "<>")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid --fail-on rule %q (expected metric>+N%% or metric<-N%%)", text)
+		}
+		rule := GateRule{Metric: text[:i], Op: text[i : i+1], Text: text}
+		if _, ok := gateMetrics[rule.Metric]; !ok {
+			return nil, fmt.Errorf("unknown --fail-on metric %q (expected %s)", rule.Metric, strings.Join(gateMetricNames(), "|"))
+		}
+		limit, sign := text[i+1:], 1.0
+		if rule.Op == "<" {
+			sign = -1
+		}
+		if rest, ok := strings.CutPrefix(limit, "+"); ok {
+			limit, sign = rest, 1
+		} else if rest, ok := strings.CutPrefix(limit, "-"); ok {
+			limit, sign = rest, -1
+		}
+		if strings.HasSuffix(limit, "%") {
+			rule.Relative = true
+			limit = strings.TrimSuffix(limit, "%")
+		}
+		n, err := strconv.ParseFloat(limit, 64)
+		if err != nil || n < 0 || strings.HasPrefix(limit, "+") {
+			return nil, fmt.Errorf("invalid --fail-on limit in %q", text)
+		}
+		rule.Limit = sign * n
+		if rule.Relative {
+			rule.Limit /= 100
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("--fail-on names no rules")
+	}
+	return rules, nil
+}
+
+func gateMetricNames() []string {
+	names := make([]string, 0, len(gateMetrics))
+	for name := range gateMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadBaseline reads a result document to gate against. For an --emit ndjson
+// file the last line, the summary, is the document.
+func LoadBaseline(path string) (ResultFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ResultFile{}, fmt.Errorf("read --baseline: %w", err)
+	}
+	data = bytes.TrimSpace(data)
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[i+1:]
+	}
+	var baseline ResultFile
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return ResultFile{}, fmt.Errorf("parse --baseline: %w", err)
+	}
+	if !baseline.OK {
+		return ResultFile{}, fmt.Errorf("--baseline %s records a failed run", path)
+	}
+	return baseline, nil
+}
+
+// GateReport is the "gate" field of a ResultFile: every rule checked against
+// every scenario the baseline and the run share.
+type GateReport struct {
+	Baseline   string      `json:"baseline"`
+	Passed     bool        `json:"passed"`
+	Checks     []gateCheck `json:"checks"`
+	Violations int         `json:"violations"`
+	Missing    []string    `json:"missing,omitempty"`
+}
+
+type gateCheck struct {
+	Scenario string  `json:"scenario,omitempty"`
+	Rule     string  `json:"rule"`
+	Metric   string  `json:"metric"`
+	Baseline float64 `json:"baseline"`
+	Current  float64 `json:"current"`
+	Change   float64 `json:"change"`
+	Violated bool    `json:"violated"`
+}
+
+// CheckGate compares current against baseline under rules. Suite documents
+// are matched scenario by scenario; scenarios the baseline lacks are listed
+// as Missing and do not fail the gate. Two single runs are compared as is,
+// whatever scenarios they name.
+func CheckGate(baselinePath string, baseline ResultFile, current ResultFile, rules []GateRule) *GateReport {
+	report := &GateReport{Baseline: baselinePath, Passed: true, Checks: []gateCheck{}}
+	base, docs := gateDocuments(baseline), gateDocuments(current)
+	if len(baseline.Suite) == 0 && len(current.Suite) == 0 {
+		base = map[string]ResultFile{current.Scenario: baseline}
+	}
+	for _, scenario := range sortedKeys(docs) {
+		doc := docs[scenario]
+		prev, ok := base[scenario]
+		if !ok {
+			report.Missing = append(report.Missing, scenario)
+			continue
+		}
+		for _, rule := range rules {
+			check := rule.check(prev, doc)
+			check.Scenario = scenario
+			if check.Violated {
+				report.Violations++
+				report.Passed = false
+			}
+			report.Checks = append(report.Checks, check)
+		}
+	}
+	return report
+}
+
+func (rule GateRule) check(baseline ResultFile, current ResultFile) gateCheck {
+	check := gateCheck{Rule: rule.Text, Metric: rule.Metric}
+	check.Baseline = gateValue(rule.Metric, baseline)
+	check.Current = gateValue(rule.Metric, current)
+	delta := check.Current - check.Baseline
+	check.Change = delta
+	if rule.Relative {
+		if check.Baseline == 0 {
+			check.Change = 0
+		} else {
+			check.Change = delta / check.Baseline
+		}
+	}
+	if rule.Op == ">" {
+		check.Violated = check.Change > rule.Limit
+	} else {
+		check.Violated = check.Change < rule.Limit
+	}
+	return check
+}
+
+func gateValue(metric string, doc ResultFile) float64 {
+	m := gateMetrics[metric]
+	if doc.Aggregate != nil {
+		return m.aggregate(doc.Aggregate)
+	}
+	if doc.Data != nil {
+		return m.data(doc.Data)
+	}
+	return 0
+}
+
+// gateDocuments indexes the successful per-scenario documents of a result by
+// scenario name; a single run is indexed under its own name or "".
+func gateDocuments(doc ResultFile) map[string]ResultFile {
+	out := map[string]ResultFile{}
+	if len(doc.Suite) > 0 {
+		for _, run := range doc.Suite {
+			if run.OK {
+				out[run.Scenario] = run
+			}
+		}
+		return out
+	}
+	if doc.OK {
+		out[doc.Scenario] = doc
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
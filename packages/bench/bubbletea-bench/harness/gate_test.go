@@ -0,0 +1,73 @@
+package harness
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGateRules(t *testing.T) {
+	cases := []struct {
+		spec  string
+		op    string
+		limit float64
+		err   string
+	}{
+		{spec: "p95>+5%", op: ">", limit: 0.05},
+		{spec: "p95>5%", op: ">", limit: 0.05},
+		{spec: "p95>-5%", op: ">", limit: -0.05},
+		{spec: "fps<-3%", op: "<", limit: -0.03},
+		{spec: "fps<3%", op: "<", limit: -0.03},
+		{spec: "fps<+3%", op: "<", limit: 0.03},
+		{spec: "p95>+0.5", op: ">", limit: 0.5},
+		{spec: "p95>--5%", err: "invalid --fail-on limit"},
+		{spec: "p95>+-5%", err: "invalid --fail-on limit"},
+		{spec: "p95>++5%", err: "invalid --fail-on limit"},
+		{spec: "nope>5%", err: "unknown --fail-on metric"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.spec, func(t *testing.T) {
+			rules, err := ParseGateRules(tc.spec)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rules[0].Op != tc.op || rules[0].Limit != tc.limit {
+				t.Errorf("op %q limit %v, want %q %v", rules[0].Op, rules[0].Limit, tc.op, tc.limit)
+			}
+		})
+	}
+}
+
+func TestGateRuleSign(t *testing.T) {
+	doc := func(p95 float64) ResultFile {
+		d := &Result{}
+		d.Summary.P95 = p95
+		return ResultFile{OK: true, Data: d}
+	}
+	cases := []struct {
+		spec     string
+		current  float64
+		violated bool
+	}{
+		{spec: "p95>+5%", current: 10.4, violated: false},
+		{spec: "p95>+5%", current: 10.6, violated: true},
+		{spec: "p95>-5%", current: 9.6, violated: true},
+		{spec: "p95>-5%", current: 9.4, violated: false},
+		{spec: "p95<-5%", current: 9.4, violated: true},
+		{spec: "p95<+5%", current: 10.4, violated: true},
+	}
+	for _, tc := range cases {
+		rules, err := ParseGateRules(tc.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check := rules[0].check(doc(10), doc(tc.current)); check.Violated != tc.violated {
+			t.Errorf("%s at p95 %v: violated %t, want %t", tc.spec, tc.current, check.Violated, tc.violated)
+		}
+	}
+}
@@ -0,0 +1,884 @@
+// Package harness is the measurement loop shared by the terminal UI benchmark
+// binaries. A binary supplies a StartFunc that runs its framework against the
+// instrumented Writer; the harness owns scenario content, output sinks and
+// fault injection, sampling, session recording, and the result schema.
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"time"
+)
+
+// Config describes one benchmark run. Start is the only framework-specific
+// piece: it launches the program under test writing to the given Writer.
+type Config struct {
+	Scenario   string
+	RunID      string
+	Warmup     int
+	Iterations int
+
+	// Duration, when set, measures as many frames as fit in it instead of
+	// Iterations, which then only sizes the sample buffers.
+	Duration time.Duration
+
+	// WarmupAuto warms until the rolling CV of the last warmupWindow frames
+	// drops to WarmupCV (warmupCVThreshold when zero), for at most
+	// WarmupMax frames. Warmup is ignored.
+	WarmupAuto bool
+	WarmupMax  int
+	WarmupCV   float64
+
+	// Soak, when set, runs the steady-state loop for this long with bounded
+	// bookkeeping, taking a checkpoint every SoakInterval.
+	Soak         time.Duration
+	SoakInterval time.Duration
+
+	// Throughput, when set, floods one program with ticks for this long
+	// without waiting for frames, measuring its maximum frame rate.
+	Throughput time.Duration
+
+	// ResizeSchedule resizes the terminal at the given measured iterations
+	// of the steady-state loop.
+	ResizeSchedule []ResizeStep
+
+	// Sessions, when above 1, runs that many programs concurrently, each
+	// ticked by its own goroutine through its own Writer.
+	Sessions int
+
+	// LoadCPUPercent and LoadAllocMBps run background load alongside the
+	// measured loop: every P spinning for that share of the time, and
+	// allocation at that rate. The process CPU figures include the load.
+	LoadCPUPercent float64
+	LoadAllocMBps  float64
+
+	// Cooldown sleeps between measured iterations, outside the samples, so
+	// one frame's writer drain and CPU boost do not carry into the next.
+	// TotalWallMs and FramesPerSecond include the sleeps.
+	Cooldown time.Duration
+
+	// BetweenIterations is one of BetweenPolicies ("none" when empty), and
+	// ScratchMB the size of a buffer written between measured iterations to
+	// push the last iteration's data out of the CPU caches. Both run
+	// outside the samples, like Cooldown.
+	BetweenIterations string
+	ScratchMB         int
+
+	// PaceFPS sends steady-state ticks on a wall-clock ticker at this rate
+	// instead of each as soon as the last was acknowledged.
+	PaceFPS int
+
+	// Retries reruns an iteration that failed transiently up to this many
+	// times, recording it as retried, before the error aborts the run.
+	Retries int
+
+	Repeats     int
+	FPS         int
+	Params      map[string]string
+	Seed        uint64
+	Start       StartFunc
+	IO          string
+	SinkPath    string
+	ConsumerCPS int64
+
+	OutlierFactor float64
+	// OutlierPolicy is how the summary treats samples over OutlierFactor
+	// times the median: keep (the default), trim or winsorize. SamplesMs is
+	// never changed.
+	OutlierPolicy string
+	ColdFrames    int
+	ThrottleBps   int64
+	IOLatency     time.Duration
+	IOJitter      time.Duration
+
+	BackpressureBytes int
+	ReaderStall       time.Duration
+	ReaderStallEvery  time.Duration
+	ShortWriteProb    float64
+	WriteErrors       []WriteErrorPoint
+	RecordPath        string
+	EmitStream        string
+	SamplesCSV        string
+
+	// Records, when set, receives one NDJSON iteration record per frame as
+	// the run goes; RecordsName names it in the result.
+	Records     io.Writer
+	RecordsName string
+
+	// Metrics, when set, is fed every iteration record.
+	Metrics *MetricsExporter
+	TailKb  int
+
+	// Progress, when set with ProgressEvery, gets a human-readable progress
+	// line that often during the run.
+	Progress      io.Writer
+	ProgressEvery time.Duration
+
+	// Heartbeat, when set, is told of every frame for --heartbeat.
+	Heartbeat *Heartbeat
+
+	// ReplayTicks, when set, are the ticks verify and export-frames render
+	// instead of 0..VerifyTicks-1, from a run's ReplayInfo or --tick-list.
+	ReplayTicks []int
+
+	SessionPath string
+	GoldenDir   string
+	VerifyTicks int
+}
+
+func (cfg Config) warmupLimit() int {
+	if cfg.WarmupAuto {
+		return cfg.WarmupMax
+	}
+	return cfg.Warmup
+}
+
+func (cfg Config) warmupThreshold() float64 {
+	if cfg.WarmupCV > 0 {
+		return cfg.WarmupCV
+	}
+	return warmupCVThreshold
+}
+
+// measuring reports whether the measured loop that began at start should run
+// iteration i.
+func (cfg Config) measuring(i int, start time.Time) bool {
+	if cfg.Duration > 0 {
+		return time.Since(start) < cfg.Duration
+	}
+	return i < cfg.Iterations
+}
+
+func (cfg Config) outlierPolicy() string {
+	if cfg.OutlierPolicy == "" {
+		return "keep"
+	}
+	return cfg.OutlierPolicy
+}
+
+// cooldown sleeps for cfg.Cooldown before every measured iteration but the
+// first and returns how long it actually slept, in milliseconds.
+func (cfg Config) cooldown(i int) float64 {
+	if i == 0 || cfg.Cooldown <= 0 {
+		return 0
+	}
+	start := time.Now()
+	time.Sleep(cfg.Cooldown)
+	return MsSince(start)
+}
+
+// coldFrames is how many of the measured frames to report as cold. A run
+// interrupted by ctx keeps what it measured, leaving at least one frame warm.
+func (cfg Config) coldFrames(ctx context.Context, measured int, interrupted bool) (int, error) {
+	if interrupted {
+		if measured == 0 {
+			return 0, ctx.Err()
+		}
+		return min(cfg.ColdFrames, measured-1), nil
+	}
+	if measured <= cfg.ColdFrames && cfg.Duration > 0 {
+		return 0, fmt.Errorf("--duration %s fit only %d frames, not more than --cold-frames %d", cfg.Duration, measured, cfg.ColdFrames)
+	}
+	if measured <= cfg.ColdFrames {
+		return 0, fmt.Errorf("--iterations %d measured only %d frames, not more than --cold-frames %d", cfg.Iterations, measured, cfg.ColdFrames)
+	}
+	return cfg.ColdFrames, nil
+}
+
+// warmedUp reports whether auto warmup has converged on samples.
+func (cfg Config) warmedUp(samples []float64) bool {
+	if !cfg.WarmupAuto || len(samples) < warmupWindow {
+		return false
+	}
+	return coefficientOfVariation(samples[len(samples)-warmupWindow:]) <= cfg.warmupThreshold()
+}
+
+// StartFunc launches a program rendering scenario, with content seeded by seed,
+// into w at the given viewport size and frame rate, returning once it is ready
+// for ticks.
+type StartFunc func(scenario string, params map[string]string, seed uint64, rows int, cols int, fps int, w *Writer) (Session, error)
+
+// Sessions wrap these in RenderTick errors so write-error injection can
+// tell a program that quit from one that stopped rendering.
+var (
+	ErrProgramExited = errors.New("program exited")
+	ErrRenderTimeout = errors.New("timeout waiting for render")
+)
+
+// Session is a running program under test.
+type Session interface {
+	// RenderTick delivers tick to the program and returns once the frame
+	// has been written. eventLoop delivers it from another goroutine, like
+	// input arriving while the program is busy.
+	RenderTick(tick int, eventLoop bool) (TickPhases, error)
+	Close() error
+}
+
+// TickPhases splits one rendered tick into model update (scenario content
+// generation), view string construction, and renderer flush until the frame's
+// bytes reach the writer. NoFlush marks a tick whose bytes never reached the
+// writer while the session waited, so FlushMs is unset.
+type TickPhases struct {
+	UpdateMs float64
+	ViewMs   float64
+	FlushMs  float64
+	NoFlush  bool
+	ViewEnd  time.Time
+
+	WriteBlockMs    float64
+	WriteBlockMaxMs float64
+}
+
+// RunScenario runs cfg.Scenario through warmup and measured iterations.
+// Failures after the run has started are returned as a *RunError.
+func RunScenario(ctx context.Context, cfg Config) (Result, error) {
+	if cfg.Start == nil {
+		return Result{}, errors.New("harness: Config.Start is nil")
+	}
+	if err := ValidateScenario(cfg.Scenario, cfg.Params); err != nil {
+		return Result{}, err
+	}
+	return runBench(ctx, cfg)
+}
+
+func runStartupBench(ctx context.Context, cfg Config, output benchOutput) (Result, error) {
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+
+	var writeBlockTotalMs float64
+	runIteration := func(seed int) (float64, int64, TickPhases, error) {
+		writer := output.newWriter()
+		session, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, writer)
+		if err != nil {
+			return 0, 0, TickPhases{}, err
+		}
+
+		start := time.Now()
+		phases, err := session.RenderTick(seed, false)
+		elapsed := MsSince(start)
+		bytesWritten, _ := writer.Snapshot()
+		closeErr := session.Close()
+		writeBlockTotalMs += writer.blockedMs()
+
+		if err != nil {
+			return 0, 0, TickPhases{}, err
+		}
+		if closeErr != nil {
+			return 0, 0, TickPhases{}, closeErr
+		}
+		return elapsed, bytesWritten, phases, nil
+	}
+
+	// Every startup iteration is a fresh program, so any failure may be retried.
+	retries := newRetrier(cfg.Retries, func(error) bool { return true })
+	warmupSamples := make([]float64, 0, cfg.warmupLimit())
+	for i := 0; i < cfg.warmupLimit() && !cfg.warmedUp(warmupSamples); i++ {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		var elapsed float64
+		var warmBytes int64
+		retried, err := retries.do("warmup", i+1, func() (err error) {
+			elapsed, warmBytes, _, err = runIteration(i + 1)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		warmupSamples = append(warmupSamples, elapsed)
+		output.record(streamRecord{Type: "iteration", Phase: "warmup", Iteration: i, Tick: i + 1, SampleMs: elapsed, Bytes: warmBytes, Retries: retried})
+	}
+
+	tryGC()
+	memBefore := takeMemory()
+	cpuBefore := takeCPU()
+	memPeak := memBefore
+	schedBefore := takeSched()
+	schedPeak := schedBefore
+	cgroupDir := cgroupV2Dir()
+	var cgroupBefore cgroupMemorySnapshot
+	if cgroupDir != "" {
+		cgroupBefore = takeCgroupMemory(cgroupDir)
+	}
+	cgroupPeak := newCgroupPeak(cgroupDir, cgroupBefore.currentKb)
+	defer cgroupPeak.close()
+
+	samples := make([]float64, 0, cfg.Iterations)
+	phases := newPhaseSamples(cfg.Iterations)
+	var bytesWritten int64
+	writeBlockTotalMs = 0
+	load := startBackgroundLoad(cfg.LoadCPUPercent, cfg.LoadAllocMBps)
+	start := time.Now()
+	memLimit := newMemoryLimitTracker(start)
+
+	trace := newIterationTrace(cfg.Iterations)
+	marks := newColdMarks(cfg.ColdFrames, 0, cpuBefore)
+	interrupted := false
+	var cooldownTotalMs, settleTotalMs float64
+	settler := newSettler(cfg)
+	for i := 0; cfg.measuring(i, start); i++ {
+		cooldownTotalMs += cfg.cooldown(i)
+		settleTotalMs += settler.settle(i)
+		if ctx.Err() != nil {
+			interrupted = true
+			break
+		}
+		tick := len(warmupSamples) + i + 1
+		var ts time.Time
+		var elapsed float64
+		var bytesNow int64
+		var tickPhases TickPhases
+		var gcStart uint64
+		retried, err := retries.do("measure", tick, func() (err error) {
+			gcStart = trace.gcCount()
+			ts = time.Now()
+			elapsed, bytesNow, tickPhases, err = runIteration(tick)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		samples = append(samples, elapsed)
+		phases.add(tickPhases)
+		trace.add(ts, tick, bytesNow, gcStart)
+		bytesWritten += bytesNow
+		marks.add(i)
+
+		rec := streamRecord{Type: "iteration", Phase: "measure", Iteration: i, Tick: tick, SampleMs: elapsed, Bytes: bytesNow, Retries: retried}
+		if i%50 == 49 {
+			mem := takeMemory()
+			memPeak = peakMemory(memPeak, mem)
+			rec.RSSKb, rec.HeapKb = mem.rssKb, mem.heapUsedKb
+			schedPeak = peakSched(schedPeak, takeSched())
+			if cgroupDir != "" {
+				cgroupPeak.sample(readCgroupInt(cgroupDir, "memory.current") / 1024)
+			}
+		}
+		output.record(rec)
+		memLimit.sample(i)
+	}
+
+	totalWallMs := MsSince(start)
+	loadResult := load.finish()
+	memoryLimit := memLimit.finish(len(samples))
+	cpuAfter := takeCPU()
+	memAfter := takeMemory()
+	memPeak = peakMemory(memPeak, memAfter)
+	schedAfter := takeSched()
+	schedPeak = peakSched(schedPeak, schedAfter)
+	var cgroupAfter cgroupMemorySnapshot
+	if cgroupDir != "" {
+		cgroupAfter = takeCgroupMemory(cgroupDir)
+		cgroupPeak.sample(cgroupAfter.currentKb)
+	}
+	allSamples := samples
+	coldFrames, err := cfg.coldFrames(ctx, len(allSamples), interrupted)
+	if err != nil {
+		return Result{}, err
+	}
+	// Every startup iteration paints a fresh screen.
+	changedCellSamples := []int{}
+	if !interrupted {
+		changedCellSamples, err = emulatedChangedCells(ctx, cfg, rows, cols, tickSpan(len(warmupSamples)+1, len(allSamples)), 0, true, nil)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	warmStart := marks.at(coldFrames)
+	samples, cold := splitCold(allSamples, trace, changedCellSamples, coldFrames, diffCPU(cpuBefore, warmStart))
+	cpu := diffCPU(warmStart, cpuAfter)
+	totalChangedCells := sumCells(changedCellSamples)
+	if cold != nil {
+		bytesWritten -= cold.BytesWritten
+		totalChangedCells -= cold.ChangedCells
+	}
+
+	return Result{
+		Replay:                 newReplayInfo(cfg, tickRanges(false, len(warmupSamples), len(allSamples)-len(samples), len(samples))),
+		SamplesMs:              samples,
+		Summary:                summarize(samples),
+		Cold:                   cold,
+		UpdateSamplesMs:        phases.updateMs,
+		ViewSamplesMs:          phases.viewMs,
+		FlushSamplesMs:         phases.flushMs,
+		MissingFlushes:         phases.missingFlushes,
+		WriteBlockSamplesMs:    phases.writeBlockMs,
+		WriteBlockMaxSamplesMs: phases.writeBlockMaxMs,
+		WriteBlockTotalMs:      writeBlockTotalMs,
+		TotalWallMs:            totalWallMs,
+		CPUUserMs:              cpu.userMs,
+		CPUSysMs:               cpu.systemMs,
+		MinorFaults:            cpu.minorFaults,
+		MajorFaults:            cpu.majorFaults,
+		VolCtxSwitches:         cpu.volCtxSw,
+		InvolCtxSwitches:       cpu.involCtxSw,
+		VolCtxSwPerFrame:       perFrame(cpu.volCtxSw, len(samples)),
+		InvolCtxSwPerFrame:     perFrame(cpu.involCtxSw, len(samples)),
+		RSSBeforeKb:            memBefore.rssKb,
+		RSSAfterKb:             memAfter.rssKb,
+		RSSPeakKb:              memPeak.rssKb,
+		HeapBeforeKb:           memBefore.heapUsedKb,
+		HeapAfterKb:            memAfter.heapUsedKb,
+		HeapPeakKb:             memPeak.heapUsedKb,
+		BytesWritten:           bytesWritten,
+		Seed:                   cfg.Seed,
+		Interrupted:            interrupted,
+		Retries:                retries.result(),
+		Frames:                 len(allSamples),
+		FramesPerSecond:        framesPerSecond(len(allSamples), totalWallMs),
+		DurationBudgetMs:       nsToMs(cfg.Duration.Nanoseconds()),
+		CooldownMs:             nsToMs(cfg.Cooldown.Nanoseconds()),
+		CooldownTotalMs:        cooldownTotalMs,
+		BetweenIterations:      cfg.betweenPolicy(),
+		ScratchMB:              cfg.ScratchMB,
+		SettleTotalMs:          settleTotalMs,
+		ThreadsBefore:          schedBefore.threads,
+		ThreadsAfter:           schedAfter.threads,
+		ThreadsPeak:            schedPeak.threads,
+		GoroutinesAfter:        schedAfter.goroutines,
+		GOMAXPROCS:             runtime.GOMAXPROCS(0),
+		GOGC:                   gcPercent(),
+		NumCPU:                 runtime.NumCPU(),
+		CgroupMemory:           cgroupMemoryDelta(cgroupDir, cgroupBefore, cgroupAfter, cgroupPeak),
+		MemoryLimit:            memoryLimit,
+		Load:                   loadResult,
+		Outliers:               findOutliers(allSamples, trace, cfg.OutlierFactor),
+		Warmup:                 buildWarmupReport(warmupSamples, cfg),
+		ChangedCellSamples:     changedCellSamples,
+		ChangedCells:           totalChangedCells,
+		BytesPerChangedCell:    bytesPerChangedCell(bytesWritten, totalChangedCells),
+	}, nil
+}
+
+func runSteadyStateBench(ctx context.Context, cfg Config, output benchOutput) (Result, error) {
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+	writer := output.newWriter()
+
+	session, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, writer)
+	if err != nil {
+		return Result{}, err
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = session.Close()
+		}
+	}()
+
+	renderTickDirect := func(tick int) (TickPhases, error) {
+		return session.RenderTick(tick, false)
+	}
+	renderTick := func(tick int) (TickPhases, error) {
+		if usesEventLoopScheduling(cfg.Scenario) {
+			return session.RenderTick(tick, true)
+		}
+		return renderTickDirect(tick)
+	}
+
+	if _, err := renderTickDirect(0); err != nil {
+		return Result{}, err
+	}
+	retries := newRetrier(cfg.Retries, renderRetryable)
+	warmupSamples := make([]float64, 0, cfg.warmupLimit())
+	for i := 0; i < cfg.warmupLimit() && !cfg.warmedUp(warmupSamples); i++ {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		var warmBytesBase int64
+		var ts time.Time
+		retried, err := retries.do("warmup", i+1, func() (err error) {
+			warmBytesBase, _ = writer.Snapshot()
+			ts = time.Now()
+			_, err = renderTick(i + 1)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		elapsed := MsSince(ts)
+		warmupSamples = append(warmupSamples, elapsed)
+		warmBytes, _ := writer.Snapshot()
+		output.record(streamRecord{Type: "iteration", Phase: "warmup", Iteration: i, Tick: i + 1, SampleMs: elapsed, Bytes: warmBytes - warmBytesBase, Retries: retried})
+	}
+
+	tryGC()
+	proc := usageProcOf(session)
+	memBefore := proc.memory()
+	cpuBefore := proc.cpu()
+	memPeak := memBefore
+	schedBefore := takeSched()
+	schedPeak := schedBefore
+	cgroupDir := cgroupV2Dir()
+	var cgroupBefore cgroupMemorySnapshot
+	if cgroupDir != "" {
+		cgroupBefore = takeCgroupMemory(cgroupDir)
+	}
+	cgroupPeak := newCgroupPeak(cgroupDir, cgroupBefore.currentKb)
+	defer cgroupPeak.close()
+
+	bytesBase, _ := writer.Snapshot()
+	blockedBase := writer.blockedMs()
+	samples := make([]float64, 0, cfg.Iterations)
+	phases := newPhaseSamples(cfg.Iterations)
+	load := startBackgroundLoad(cfg.LoadCPUPercent, cfg.LoadAllocMBps)
+	start := time.Now()
+	memLimit := newMemoryLimitTracker(start)
+	pace := newPacer(cfg.PaceFPS, start)
+	resizes, err := newResizer(cfg.ResizeSchedule, session, output)
+	if err != nil {
+		return Result{}, err
+	}
+
+	trace := newIterationTrace(cfg.Iterations)
+	viewEnds := make([]time.Time, 0, cfg.Iterations)
+	tickEnds := make([]time.Time, 0, cfg.Iterations)
+	writeMark := writer.writeMark()
+	marks := newColdMarks(cfg.ColdFrames, proc, cpuBefore)
+	interrupted := false
+	var cooldownTotalMs, settleTotalMs float64
+	settler := newSettler(cfg)
+	for i := 0; cfg.measuring(i, start); i++ {
+		cooldownTotalMs += cfg.cooldown(i)
+		settleTotalMs += settler.settle(i)
+		if ctx.Err() != nil {
+			interrupted = true
+			break
+		}
+		tick := len(warmupSamples) + i + 1
+		var tickBytesBase int64
+		var ts time.Time
+		var tickPhases TickPhases
+		var gcStart uint64
+		due := pace.wait()
+		resizeStart, resized, err := resizes.before(i)
+		if err != nil {
+			return Result{}, err
+		}
+		retried, err := retries.do("measure", tick, func() (err error) {
+			tickBytesBase, _ = writer.Snapshot()
+			gcStart = trace.gcCount()
+			ts = time.Now()
+			tickPhases, err = renderTick(tick)
+			return err
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		elapsed := MsSince(ts)
+		pace.done(due)
+		samples = append(samples, elapsed)
+		tickEnds = append(tickEnds, time.Now())
+		viewEnds = append(viewEnds, tickPhases.ViewEnd)
+		phases.add(tickPhases)
+		tickBytes, _ := writer.Snapshot()
+		trace.add(ts, tick, tickBytes-tickBytesBase, gcStart)
+		if resized {
+			resizes.after(tick, resizeStart, tickBytes-tickBytesBase)
+		}
+		marks.add(i)
+		rec := streamRecord{Type: "iteration", Phase: "measure", Iteration: i, Tick: tick, SampleMs: elapsed, Bytes: tickBytes - tickBytesBase, Retries: retried}
+		if i%100 == 99 {
+			mem := proc.memory()
+			memPeak = peakMemory(memPeak, mem)
+			rec.RSSKb, rec.HeapKb = mem.rssKb, mem.heapUsedKb
+			schedPeak = peakSched(schedPeak, takeSched())
+			if cgroupDir != "" {
+				cgroupPeak.sample(readCgroupInt(cgroupDir, "memory.current") / 1024)
+			}
+		}
+		output.record(rec)
+		memLimit.sample(i)
+	}
+
+	totalWallMs := MsSince(start)
+	loadResult := load.finish()
+	pacing := pace.finish()
+	memoryLimit := memLimit.finish(len(samples))
+	cpuAfter := proc.cpu()
+	memAfter := proc.memory()
+	memPeak = peakMemory(memPeak, memAfter)
+	schedAfter := takeSched()
+	schedPeak = peakSched(schedPeak, schedAfter)
+	var cgroupAfter cgroupMemorySnapshot
+	if cgroupDir != "" {
+		cgroupAfter = takeCgroupMemory(cgroupDir)
+		cgroupPeak.sample(cgroupAfter.currentKb)
+	}
+	bytesAfter, _ := writer.Snapshot()
+	bytesWritten := bytesAfter - bytesBase
+	frames := segmentWrites(writer.writesSince(writeMark), trace.ticks, viewEnds, tickEnds)
+	for i := range frames {
+		trace.bytes[i] = frames[i].Bytes
+	}
+	allSamples := samples
+	coldFrames, err := cfg.coldFrames(ctx, len(allSamples), interrupted)
+	if err != nil {
+		return Result{}, err
+	}
+	writeBlockTotalMs := writer.blockedMs() - blockedBase
+
+	if err := session.Close(); err != nil {
+		return Result{}, err
+	}
+	closed = true
+
+	// The last warmup tick, or the initial frame, sets up the screen the
+	// first measured frame is drawn over.
+	changedCellSamples := []int{}
+	if !interrupted {
+		changedCellSamples, err = emulatedChangedCells(ctx, cfg, rows, cols, tickSpan(len(warmupSamples), len(allSamples)+1), 1, false, cfg.ResizeSchedule)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	warmStart := marks.at(coldFrames)
+	samples, cold := splitCold(allSamples, trace, changedCellSamples, coldFrames, diffCPU(cpuBefore, warmStart))
+	cpu := diffCPU(warmStart, cpuAfter)
+	totalChangedCells := sumCells(changedCellSamples)
+	if cold != nil {
+		bytesWritten -= cold.BytesWritten
+		totalChangedCells -= cold.ChangedCells
+	}
+
+	return Result{
+		Replay:                 newReplayInfo(cfg, tickRanges(true, len(warmupSamples), len(allSamples)-len(samples), len(samples))),
+		SamplesMs:              samples,
+		Summary:                summarize(samples),
+		Cold:                   cold,
+		UpdateSamplesMs:        phases.updateMs,
+		ViewSamplesMs:          phases.viewMs,
+		FlushSamplesMs:         phases.flushMs,
+		MissingFlushes:         phases.missingFlushes,
+		WriteBlockSamplesMs:    phases.writeBlockMs,
+		WriteBlockMaxSamplesMs: phases.writeBlockMaxMs,
+		WriteBlockTotalMs:      writeBlockTotalMs,
+		TotalWallMs:            totalWallMs,
+		CPUUserMs:              cpu.userMs,
+		CPUSysMs:               cpu.systemMs,
+		MinorFaults:            cpu.minorFaults,
+		MajorFaults:            cpu.majorFaults,
+		VolCtxSwitches:         cpu.volCtxSw,
+		InvolCtxSwitches:       cpu.involCtxSw,
+		VolCtxSwPerFrame:       perFrame(cpu.volCtxSw, len(samples)),
+		InvolCtxSwPerFrame:     perFrame(cpu.involCtxSw, len(samples)),
+		RSSBeforeKb:            memBefore.rssKb,
+		RSSAfterKb:             memAfter.rssKb,
+		RSSPeakKb:              memPeak.rssKb,
+		HeapBeforeKb:           memBefore.heapUsedKb,
+		HeapAfterKb:            memAfter.heapUsedKb,
+		HeapPeakKb:             memPeak.heapUsedKb,
+		BytesWritten:           bytesWritten,
+		Seed:                   cfg.Seed,
+		Interrupted:            interrupted,
+		Retries:                retries.result(),
+		Frames:                 len(allSamples),
+		FramesPerSecond:        framesPerSecond(len(allSamples), totalWallMs),
+		DurationBudgetMs:       nsToMs(cfg.Duration.Nanoseconds()),
+		CooldownMs:             nsToMs(cfg.Cooldown.Nanoseconds()),
+		CooldownTotalMs:        cooldownTotalMs,
+		BetweenIterations:      cfg.betweenPolicy(),
+		ScratchMB:              cfg.ScratchMB,
+		SettleTotalMs:          settleTotalMs,
+		ThreadsBefore:          schedBefore.threads,
+		ThreadsAfter:           schedAfter.threads,
+		ThreadsPeak:            schedPeak.threads,
+		GoroutinesAfter:        schedAfter.goroutines,
+		GOMAXPROCS:             runtime.GOMAXPROCS(0),
+		GOGC:                   gcPercent(),
+		NumCPU:                 runtime.NumCPU(),
+		ProgramUsage:           proc != 0,
+		CgroupMemory:           cgroupMemoryDelta(cgroupDir, cgroupBefore, cgroupAfter, cgroupPeak),
+		MemoryLimit:            memoryLimit,
+		Load:                   loadResult,
+		Pacing:                 pacing,
+		Resizes:                resizes.result(samples),
+		Outliers:               findOutliers(allSamples, trace, cfg.OutlierFactor),
+		Warmup:                 buildWarmupReport(warmupSamples, cfg),
+		ChangedCellSamples:     changedCellSamples,
+		ChangedCells:           totalChangedCells,
+		BytesPerChangedCell:    bytesPerChangedCell(bytesWritten, totalChangedCells),
+		FrameWrites:            frames,
+	}, nil
+}
+
+func runBench(ctx context.Context, cfg Config) (Result, error) {
+	// The null sink renders into the in-process discard path with no
+	// terminal at all, matching the Rezi harness's BenchBackend: bytes are
+	// still counted.
+	var out ioWriter = discardWriter{}
+	var sink *outputSink
+	var consumer *vtConsumer
+	if cfg.IO != "null" {
+		var err error
+		rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+		if cfg.ConsumerCPS > 0 {
+			consumer = newVTConsumer(cfg.ConsumerCPS, rows, cols)
+		}
+		sink, err = openOutputSink(cfg.IO, cfg.SinkPath, rows, cols, consumer)
+		if err != nil {
+			return Result{}, err
+		}
+		out = sink
+	}
+	var throttle *throttledWriter
+	if cfg.ThrottleBps > 0 {
+		throttle = newThrottledWriter(out, cfg.ThrottleBps)
+		out = throttle
+	}
+	var latency *latencyWriter
+	if cfg.IOLatency > 0 || cfg.IOJitter > 0 {
+		latency = newLatencyWriter(out, cfg.IOLatency, cfg.IOJitter)
+		out = latency
+	}
+	var backpressure *backpressureWriter
+	if cfg.BackpressureBytes > 0 {
+		backpressure = newBackpressureWriter(out, cfg.BackpressureBytes, cfg.ReaderStall, cfg.ReaderStallEvery)
+		out = backpressure
+	}
+	var short *shortWriter
+	if cfg.ShortWriteProb > 0 {
+		short = newShortWriter(out, cfg.ShortWriteProb)
+		out = short
+	}
+	var failing *writeErrorWriter
+	if len(cfg.WriteErrors) > 0 {
+		failing = newWriteErrorWriter(out, cfg.WriteErrors)
+		out = failing
+	}
+
+	output := benchOutput{out: out, sink: sink, metrics: cfg.Metrics, progress: newProgressReporter(cfg), heartbeat: cfg.Heartbeat, scenario: cfg.Scenario}
+	if cfg.RecordPath != "" {
+		recorder, err := openSessionRecorder(cfg.RecordPath)
+		if err != nil {
+			if backpressure != nil {
+				backpressure.close()
+			}
+			if sink != nil {
+				sink.close()
+			}
+			return Result{}, err
+		}
+		output.recorder = recorder
+	}
+
+	if cfg.TailKb > 0 {
+		output.tail = newOutputRing(cfg.TailKb * 1024)
+	}
+
+	if cfg.EmitStream != "" {
+		stream, err := dialStream(cfg.EmitStream)
+		if err != nil {
+			if output.recorder != nil {
+				_ = output.recorder.close()
+			}
+			if backpressure != nil {
+				backpressure.close()
+			}
+			if sink != nil {
+				sink.close()
+			}
+			return Result{}, err
+		}
+		stream.runID = cfg.RunID
+		output.stream = stream
+	}
+
+	if cfg.Records != nil {
+		output.records = newStreamEmitter(cfg.RecordsName, cfg.Records, nil)
+		output.records.runID = cfg.RunID
+	}
+	if cfg.SamplesCSV != "" {
+		output.samples = newSampleTable(cfg.SamplesCSV, cfg.warmupLimit()+cfg.Iterations)
+	}
+
+	cfg.Metrics.begin(cfg.Scenario)
+	defer cfg.Metrics.end()
+
+	calibration := calibrateTimer()
+	capabilities := probeCapabilities()
+	run := runSteadyStateBench
+	if cfg.Scenario == "startup" {
+		run = runStartupBench
+	} else if cfg.Soak > 0 {
+		run = runSoakBench
+	} else if cfg.Throughput > 0 {
+		run = runThroughputBench
+	} else if cfg.Sessions > 1 {
+		run = runSessionsBench
+	}
+	data, err := run(ctx, cfg, output)
+	var stream, records *streamResult
+	if output.stream != nil {
+		stream = output.stream.close(err)
+	}
+	if output.records != nil {
+		records = output.records.close(err)
+	}
+	if backpressure != nil {
+		backpressure.close()
+	}
+	var sinkBytes int64
+	var nested *nestedResult
+	if sink != nil {
+		sinkBytes = sink.close()
+		nested = sink.nested()
+	}
+	if output.recorder != nil {
+		if closeErr := output.recorder.close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("write --record file: %w", closeErr)
+		}
+		data.RecordPath = cfg.RecordPath
+	}
+	// Samples are written even for a failed run; they show where it stopped.
+	if output.samples != nil {
+		if writeErr := output.samples.write(); err == nil && writeErr != nil {
+			err = fmt.Errorf("write --samples-csv file: %w", writeErr)
+		}
+		data.SamplesCSVPath = cfg.SamplesCSV
+	}
+	var writeErrors *WriteErrorReport
+	if failing != nil {
+		writeErrors = failing.report(err)
+	}
+	if err != nil {
+		runErr := &RunError{err: err, writeErrors: writeErrors}
+		if output.tail != nil {
+			runErr.tail = output.tail.dump()
+		}
+		return Result{}, runErr
+	}
+	data.Timer = calibration
+	data.Capabilities = &capabilities
+	data.OutlierPolicy = cfg.outlierPolicy()
+	summarized, adjusted := applyOutlierPolicy(data.SamplesMs, data.OutlierPolicy, cfg.OutlierFactor)
+	data.Summary, data.OutliersAdjusted = summarize(summarized), adjusted
+	data.Summary.CI, data.Summary.CISkipped = bootstrapCI(summarized, cfg.Seed)
+	data.Sink = cfg.IO
+	if cfg.IO == "pty" {
+		data.PtyBytesRead = sinkBytes
+	} else {
+		data.SinkBytes = sinkBytes
+	}
+	data.Nested = nested
+	data.Stream = stream
+	data.Records = records
+	if consumer != nil {
+		data.Consumer = consumer.result()
+	}
+	if throttle != nil {
+		data.Throttle = throttle.result(cfg.ThrottleBps)
+	}
+	if latency != nil {
+		data.Latency = latency.result()
+	}
+	if backpressure != nil {
+		data.Backpressure = backpressure.result()
+	}
+	if short != nil {
+		data.ShortWrites = short.result()
+	}
+	data.writeErrors = writeErrors
+	return data, nil
+}
@@ -0,0 +1,80 @@
+package harness
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColdFrames(t *testing.T) {
+	cases := []struct {
+		name        string
+		cfg         Config
+		measured    int
+		interrupted bool
+		want        int
+		err         string
+	}{
+		{name: "enough frames", cfg: Config{Iterations: 10, ColdFrames: 3}, measured: 10, want: 3},
+		{name: "too few iterations", cfg: Config{Iterations: 3, ColdFrames: 3}, measured: 3, err: "--iterations 3 measured only 3 frames"},
+		{name: "too short a duration", cfg: Config{Duration: time.Second, ColdFrames: 5}, measured: 2, err: "--duration 1s fit only 2 frames"},
+		{name: "interrupted keeps a warm frame", cfg: Config{Iterations: 10, ColdFrames: 5}, measured: 3, interrupted: true, want: 2},
+		{name: "interrupted before any frame", cfg: Config{Iterations: 10}, interrupted: true, err: context.Canceled.Error()},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := tc.cfg.coldFrames(ctx, tc.measured, tc.interrupted)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("cold frames %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExpectedScreen(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines []string
+		rows  int
+		cols  int
+		want  []string
+	}{
+		{name: "cut at the width", lines: []string{"abcdef"}, rows: 1, cols: 4, want: []string{"abcd"}},
+		{name: "styles stripped", lines: []string{"\x1b[1mab\x1b[0m"}, rows: 1, cols: 4, want: []string{"ab"}},
+		{name: "wide runes cut by cells", lines: []string{"字字字"}, rows: 1, cols: 4, want: []string{"字字"}},
+		{name: "wide rune straddling the edge dropped", lines: []string{"a字字"}, rows: 1, cols: 4, want: []string{"a字"}},
+		{name: "bottom rows kept", lines: []string{"1", "2", "3"}, rows: 2, cols: 4, want: []string{"2", "3"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := expectedScreen(tc.lines, tc.rows, tc.cols)
+			if strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("expectedScreen = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunRepeatedInterruptedBeforeAnyRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	got := RunRepeated(ctx, Config{Scenario: "rerender", Iterations: 10, Repeats: 3})
+	if got.OK || !got.Interrupted || got.ErrorKind != "interrupted" {
+		t.Errorf("ok %v, interrupted %v, errorKind %q; want a failed, interrupted result", got.OK, got.Interrupted, got.ErrorKind)
+	}
+	if got.Error != context.Canceled.Error() {
+		t.Errorf("error %q, want %q", got.Error, context.Canceled.Error())
+	}
+}
@@ -0,0 +1,163 @@
+package harness
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// heartbeatRecord is one NDJSON line of --heartbeat. A parent runner reads
+// liveness from the lines arriving at all and progress from SinceFrameMs: a
+// slow run keeps it near one frame time, a hung program lets it grow while
+// the heartbeats go on. It is -1 until the first frame. The last line has
+// State "done".
+type heartbeatRecord struct {
+	Type         string  `json:"type"`
+	RunID        string  `json:"runId,omitempty"`
+	Seq          int64   `json:"seq"`
+	State        string  `json:"state"`
+	UptimeMs     float64 `json:"uptimeMs"`
+	Scenario     string  `json:"scenario,omitempty"`
+	Phase        string  `json:"phase,omitempty"`
+	Iteration    int     `json:"iteration"`
+	Frames       int64   `json:"frames"`
+	SinceFrameMs float64 `json:"sinceFrameMs"`
+}
+
+// Heartbeat writes a heartbeatRecord every interval for the life of the
+// process, from its own goroutine so a stalled reader cannot hold up the
+// render loop, which only takes a short lock to note each frame.
+type Heartbeat struct {
+	w     io.Writer
+	close func() error
+	runID string
+	start time.Time
+
+	mu        sync.Mutex
+	seq       int64
+	scenario  string
+	phase     string
+	iteration int
+	frames    int64
+	lastFrame time.Time
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// OpenHeartbeat opens a --heartbeat target: a file descriptor the parent
+// passed down, given by number, or else a path, which is truncated.
+func OpenHeartbeat(target string) (io.WriteCloser, error) {
+	if fd, err := strconv.Atoi(target); err == nil {
+		if fd < 0 {
+			return nil, fmt.Errorf("invalid --heartbeat fd %d", fd)
+		}
+		file := os.NewFile(uintptr(fd), "heartbeat")
+		if file == nil {
+			return nil, fmt.Errorf("--heartbeat fd %d is not open", fd)
+		}
+		if _, err := file.Stat(); err != nil {
+			return nil, fmt.Errorf("--heartbeat fd %d is not open: %w", fd, err)
+		}
+		return file, nil
+	}
+	file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open --heartbeat: %w", err)
+	}
+	return file, nil
+}
+
+// StartHeartbeat writes the first heartbeat to w at once and then one
+// every interval until Close.
+func StartHeartbeat(w io.WriteCloser, interval time.Duration, runID string) (*Heartbeat, error) {
+	if interval <= 0 {
+		return nil, errors.New("harness: heartbeat interval must be positive")
+	}
+	h := &Heartbeat{
+		w:     w,
+		close: w.Close,
+		runID: runID,
+		start: time.Now(),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if err := h.write("running"); err != nil {
+		return nil, fmt.Errorf("write --heartbeat: %w", err)
+	}
+	go func() {
+		defer close(h.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-h.stop:
+				return
+			case <-ticker.C:
+				_ = h.write("running")
+			}
+		}
+	}()
+	return h, nil
+}
+
+// beat notes a frame of scenario; a nil Heartbeat ignores it.
+func (h *Heartbeat) beat(scenario string, phase string, iteration int) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.scenario = scenario
+	h.phase = phase
+	h.iteration = iteration
+	h.frames++
+	h.lastFrame = time.Now()
+	h.mu.Unlock()
+}
+
+func (h *Heartbeat) write(state string) error {
+	h.mu.Lock()
+	now := time.Now()
+	h.seq++
+	rec := heartbeatRecord{
+		Type:         "heartbeat",
+		RunID:        h.runID,
+		Seq:          h.seq,
+		State:        state,
+		UptimeMs:     nsToMs(now.Sub(h.start).Nanoseconds()),
+		Scenario:     h.scenario,
+		Phase:        h.phase,
+		Iteration:    h.iteration,
+		Frames:       h.frames,
+		SinceFrameMs: -1,
+	}
+	if !h.lastFrame.IsZero() {
+		rec.SinceFrameMs = nsToMs(now.Sub(h.lastFrame).Nanoseconds())
+	}
+	h.mu.Unlock()
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = h.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close stops the heartbeats, writes the "done" line and closes the target.
+func (h *Heartbeat) Close() error {
+	if h == nil {
+		return nil
+	}
+	close(h.stop)
+	<-h.done
+	err := h.write("done")
+	if closeErr := h.close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
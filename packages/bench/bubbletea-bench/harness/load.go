@@ -0,0 +1,121 @@
+package harness
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// loadPeriod is the duty cycle of a CPU load worker: it spins for its share
+// of each period and sleeps the rest.
+const loadPeriod = 10 * time.Millisecond
+
+// loadAllocChunk is the allocation size of the alloc load; loadAllocLive of
+// them are kept reachable so the collector has real work to do.
+const (
+	loadAllocChunk = 4 << 10
+	loadAllocLive  = 4096
+)
+
+// loadResult is the "load" field of a Result run with --load-cpu or
+// --load-alloc: the background load that ran alongside the measured loop,
+// as asked for and as achieved.
+type loadResult struct {
+	CPUPercent         float64 `json:"cpuPercent"`
+	CPUWorkers         int     `json:"cpuWorkers"`
+	AllocMBps          float64 `json:"allocMBps"`
+	AchievedCPUPercent float64 `json:"achievedCpuPercent"`
+	AchievedAllocMBps  float64 `json:"achievedAllocMBps"`
+	AllocatedBytes     int64   `json:"allocatedBytes"`
+}
+
+// backgroundLoad burns CPU and allocates on its own goroutines. They share
+// the process's Ps with the program under test, so the load contends for
+// the same scheduler and collector a busy neighbouring goroutine would.
+type backgroundLoad struct {
+	result    loadResult
+	start     time.Time
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	busyNs    atomic.Int64
+	allocated atomic.Int64
+}
+
+func startBackgroundLoad(cpuPercent float64, allocMBps float64) *backgroundLoad {
+	if cpuPercent <= 0 && allocMBps <= 0 {
+		return nil
+	}
+	l := &backgroundLoad{
+		result: loadResult{CPUPercent: cpuPercent, AllocMBps: allocMBps},
+		start:  time.Now(),
+		stop:   make(chan struct{}),
+	}
+	if cpuPercent > 0 {
+		l.result.CPUWorkers = runtime.GOMAXPROCS(0)
+		busy := time.Duration(float64(loadPeriod) * cpuPercent / 100)
+		for i := 0; i < l.result.CPUWorkers; i++ {
+			l.wg.Add(1)
+			go l.burn(busy)
+		}
+	}
+	if allocMBps > 0 {
+		l.wg.Add(1)
+		go l.alloc(allocMBps * 1e6)
+	}
+	return l
+}
+
+func (l *backgroundLoad) burn(busy time.Duration) {
+	defer l.wg.Done()
+	for {
+		select {
+		case <-l.stop:
+			return
+		default:
+		}
+		periodStart := time.Now()
+		for time.Since(periodStart) < busy {
+		}
+		l.busyNs.Add(time.Since(periodStart).Nanoseconds())
+		time.Sleep(loadPeriod - time.Since(periodStart))
+	}
+}
+
+func (l *backgroundLoad) alloc(bytesPerSec float64) {
+	defer l.wg.Done()
+	live := make([][]byte, loadAllocLive)
+	next := 0
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+		}
+		// Catch up to the rate since start, so a late tick is made good.
+		due := int64(bytesPerSec*time.Since(l.start).Seconds()) - l.allocated.Load()
+		for ; due > 0; due -= loadAllocChunk {
+			live[next] = make([]byte, loadAllocChunk)
+			next = (next + 1) % len(live)
+			l.allocated.Add(loadAllocChunk)
+		}
+	}
+}
+
+// finish stops the load and reports it.
+func (l *backgroundLoad) finish() *loadResult {
+	if l == nil {
+		return nil
+	}
+	close(l.stop)
+	l.wg.Wait()
+	elapsed := time.Since(l.start)
+	if l.result.CPUWorkers > 0 {
+		l.result.AchievedCPUPercent = float64(l.busyNs.Load()) / float64(elapsed.Nanoseconds()*int64(l.result.CPUWorkers)) * 100
+	}
+	l.result.AllocatedBytes = l.allocated.Load()
+	l.result.AchievedAllocMBps = float64(l.result.AllocatedBytes) / 1e6 / elapsed.Seconds()
+	return &l.result
+}
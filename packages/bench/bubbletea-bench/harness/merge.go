@@ -0,0 +1,121 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ReadResults reads the result documents in path: a single document, a
+// JSON array of them, or NDJSON as --append and --emit ndjson write it. Of
+// NDJSON lines only documents are kept; iteration records and stream ends
+// are skipped.
+func ReadResults(path string) ([]ResultFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	raws, err := splitResults(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	docs := []ResultFile{}
+	for _, raw := range raws {
+		var doc ResultFile
+		if err := json.Unmarshal(raw.data, &doc); err != nil {
+			return nil, fmt.Errorf("parse %s line %d: %w", path, raw.line, err)
+		}
+		if doc.Type != "" && doc.Type != "summary" {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	if len(docs) == 0 {
+		return nil, fmt.Errorf("%s has no results", path)
+	}
+	return docs, nil
+}
+
+// rawResult is one JSON value of a result file and the line it starts on.
+type rawResult struct {
+	line int
+	data []byte
+}
+
+// splitResults splits a result file into its JSON values without decoding
+// them: the elements of an array, a single (possibly indented) document, or
+// one value per non-empty NDJSON line.
+func splitResults(data []byte) ([]rawResult, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0:
+		return nil, nil
+	case data[0] == '[':
+		elems := []json.RawMessage{}
+		if err := json.Unmarshal(data, &elems); err != nil {
+			return nil, err
+		}
+		out := make([]rawResult, len(elems))
+		for i, elem := range elems {
+			out[i] = rawResult{line: 1, data: elem}
+		}
+		return out, nil
+	case json.Valid(data):
+		return []rawResult{{line: 1, data: data}}, nil
+	}
+	if first, _, _ := bytes.Cut(data, []byte("\n")); !json.Valid(first) {
+		// Not NDJSON either: report it as one broken document, not a
+		// failure per line.
+		return []rawResult{{line: 1, data: data}}, nil
+	}
+	out := []rawResult{}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			out = append(out, rawResult{line: i + 1, data: line})
+		}
+	}
+	return out, nil
+}
+
+// MergeResults combines docs into one suite document, in order, with nested
+// suites flattened so each entry is one scenario's result. It can be gated
+// against like a suite run; where a scenario appears more than once the
+// gate compares its last entry.
+func MergeResults(docs []ResultFile) ResultFile {
+	merged := ResultFile{OK: true, Suite: []ResultFile{}}
+	var add func(doc ResultFile)
+	add = func(doc ResultFile) {
+		if len(doc.Suite) > 0 {
+			for _, entry := range doc.Suite {
+				if entry.Run == nil {
+					entry.Run = doc.Run
+				}
+				if entry.Labels == nil {
+					entry.Labels = doc.Labels
+				}
+				add(entry)
+			}
+			return
+		}
+		doc.SchemaVersion = 0
+		doc.Type = ""
+		merged.Suite = append(merged.Suite, doc)
+	}
+	for _, doc := range docs {
+		add(doc)
+	}
+
+	failed := 0
+	for _, doc := range merged.Suite {
+		if !doc.OK {
+			failed++
+		}
+		merged.Interrupted = merged.Interrupted || doc.Interrupted
+	}
+	if failed > 0 {
+		merged.OK = false
+		merged.Error = fmt.Sprintf("%d of %d merged results failed", failed, len(merged.Suite))
+	}
+	return merged
+}
@@ -0,0 +1,913 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/vtverify"
+)
+
+type cpuUsage struct {
+	userMs      float64
+	systemMs    float64
+	minorFaults int64
+	majorFaults int64
+	volCtxSw    int64
+	involCtxSw  int64
+}
+
+type memorySnapshot struct {
+	rssKb      int64
+	heapUsedKb int64
+}
+
+type cgroupMemorySnapshot struct {
+	currentKb         int64
+	someStallTotalUs  int64
+	fullStallTotalUs  int64
+	someStallAvg10Pct float64
+}
+
+type schedSnapshot struct {
+	threads    int64
+	goroutines int
+}
+
+// iterationTrace keeps per-iteration bookkeeping that is only needed after the
+// measured loop, so the loop itself stays allocation-free. gcCycles is how
+// many GC cycles completed while each iteration ran.
+type iterationTrace struct {
+	starts   []time.Time
+	ticks    []int
+	bytes    []int64
+	gcCycles []uint64
+	gcSample []metrics.Sample
+}
+
+func newIterationTrace(capacity int) iterationTrace {
+	return iterationTrace{
+		starts:   make([]time.Time, 0, capacity),
+		ticks:    make([]int, 0, capacity),
+		bytes:    make([]int64, 0, capacity),
+		gcCycles: make([]uint64, 0, capacity),
+		gcSample: []metrics.Sample{{Name: "/gc/cycles/total:gc-cycles"}},
+	}
+}
+
+// gcCount reads the runtime's completed GC cycle count without allocating.
+func (t *iterationTrace) gcCount() uint64 {
+	metrics.Read(t.gcSample)
+	return t.gcSample[0].Value.Uint64()
+}
+
+// segmentWrites assigns write events to frames by View end time. viewEnds and
+// tickEnds are per frame; writes before the first View end are dropped.
+func segmentWrites(events []writeEvent, ticks []int, viewEnds []time.Time, tickEnds []time.Time) []frameWrites {
+	frames := make([]frameWrites, len(ticks))
+	for i := range frames {
+		frames[i] = frameWrites{Tick: ticks[i], Writes: []frameWrite{}}
+	}
+	frame := -1
+	for _, ev := range events {
+		for frame+1 < len(viewEnds) && !ev.at.Before(viewEnds[frame+1]) {
+			frame++
+		}
+		if frame < 0 {
+			continue
+		}
+		f := &frames[frame]
+		f.Bytes += int64(ev.bytes)
+		if ev.at.After(tickEnds[frame]) {
+			f.LateBytes += int64(ev.bytes)
+		}
+		f.Writes = append(f.Writes, frameWrite{
+			AtMs:  float64(ev.at.Sub(viewEnds[frame]).Microseconds()) / 1000,
+			Bytes: ev.bytes,
+		})
+	}
+	return frames
+}
+
+// add records an iteration that began at start with gcStart cycles done.
+func (t *iterationTrace) add(start time.Time, tick int, bytes int64, gcStart uint64) {
+	t.starts = append(t.starts, start)
+	t.ticks = append(t.ticks, tick)
+	t.bytes = append(t.bytes, bytes)
+	t.gcCycles = append(t.gcCycles, t.gcCount()-gcStart)
+}
+
+// phaseSamples are the TickPhases of the measured ticks. Ticks without a
+// flush are counted in missingFlushes instead of adding to flushMs.
+type phaseSamples struct {
+	updateMs       []float64
+	viewMs         []float64
+	flushMs        []float64
+	missingFlushes int
+
+	writeBlockMs    []float64
+	writeBlockMaxMs []float64
+}
+
+func newPhaseSamples(capacity int) phaseSamples {
+	return phaseSamples{
+		updateMs: make([]float64, 0, capacity),
+		viewMs:   make([]float64, 0, capacity),
+		flushMs:  make([]float64, 0, capacity),
+
+		writeBlockMs:    make([]float64, 0, capacity),
+		writeBlockMaxMs: make([]float64, 0, capacity),
+	}
+}
+
+func (s *phaseSamples) add(p TickPhases) {
+	s.updateMs = append(s.updateMs, p.UpdateMs)
+	s.viewMs = append(s.viewMs, p.ViewMs)
+	if p.NoFlush {
+		s.missingFlushes++
+	} else {
+		s.flushMs = append(s.flushMs, p.FlushMs)
+	}
+	s.writeBlockMs = append(s.writeBlockMs, p.WriteBlockMs)
+	s.writeBlockMaxMs = append(s.writeBlockMaxMs, p.WriteBlockMaxMs)
+}
+
+func takeCPU() cpuUsage {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return cpuUsage{}
+	}
+	return cpuUsage{
+		userMs:      float64(ru.Utime.Sec)*1000 + float64(ru.Utime.Usec)/1000,
+		systemMs:    float64(ru.Stime.Sec)*1000 + float64(ru.Stime.Usec)/1000,
+		minorFaults: int64(ru.Minflt),
+		majorFaults: int64(ru.Majflt),
+		volCtxSw:    int64(ru.Nvcsw),
+		involCtxSw:  int64(ru.Nivcsw),
+	}
+}
+
+func diffCPU(before, after cpuUsage) cpuUsage {
+	return cpuUsage{
+		userMs:      after.userMs - before.userMs,
+		systemMs:    after.systemMs - before.systemMs,
+		minorFaults: after.minorFaults - before.minorFaults,
+		majorFaults: after.majorFaults - before.majorFaults,
+		volCtxSw:    after.volCtxSw - before.volCtxSw,
+		involCtxSw:  after.involCtxSw - before.involCtxSw,
+	}
+}
+
+func readRSSKb() int64 {
+	return readProcStatusInt("VmRSS:")
+}
+
+func readThreadCount() int64 {
+	return readProcStatusInt("Threads:")
+}
+
+func readProcStatusInt(field string) int64 {
+	return readStatusInt("/proc/self/status", field)
+}
+
+// readStatusInt reads the number after field in a /proc status file.
+func readStatusInt(path string, field string) int64 {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasPrefix(line, field) {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			return 0
+		}
+		n, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return n
+	}
+	return 0
+}
+
+func takeMemory() memorySnapshot {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return memorySnapshot{
+		rssKb:      readRSSKb(),
+		heapUsedKb: int64(ms.HeapAlloc / 1024),
+	}
+}
+
+var memoryLimitMetrics = []metrics.Sample{
+	{Name: "/memory/classes/total:bytes"},
+	{Name: "/memory/classes/heap/released:bytes"},
+	{Name: "/gc/cycles/total:gc-cycles"},
+	{Name: "/gc/heap/goal:bytes"},
+	{Name: "/gc/heap/live:bytes"},
+	{Name: "/gc/scan/stack:bytes"},
+	{Name: "/gc/scan/globals:bytes"},
+	{Name: "/gc/gogc:percent"},
+}
+
+type memoryLimitReading struct {
+	used     int64
+	cycles   uint64
+	pressure bool
+}
+
+// memoryLimitTracker samples the runtime's memory against its soft limit
+// after every measured iteration, outside the timed span. It is nil when no
+// limit is set.
+type memoryLimitTracker struct {
+	start  time.Time
+	gcBase uint64
+	result memoryLimitResult
+}
+
+func newMemoryLimitTracker(start time.Time) *memoryLimitTracker {
+	limit := debug.SetMemoryLimit(-1)
+	if limit == math.MaxInt64 {
+		return nil
+	}
+	t := &memoryLimitTracker{start: start, result: memoryLimitResult{LimitBytes: limit, FirstPressureIteration: -1}}
+	t.gcBase = readMemoryLimit().cycles
+	return t
+}
+
+// readMemoryLimit reads the runtime's memory and whether the limit is
+// pressuring the collector: the heap goal sits below the goal GOGC alone
+// would set, or GOGC is off and only the limit triggers collections.
+func readMemoryLimit() memoryLimitReading {
+	samples := append([]metrics.Sample(nil), memoryLimitMetrics...)
+	metrics.Read(samples)
+	value := func(i int) uint64 { return samples[i].Value.Uint64() }
+	reading := memoryLimitReading{used: int64(value(0) - value(1)), cycles: value(2)}
+	gogc := int64(value(7))
+	if gogc < 0 {
+		reading.pressure = true
+		return reading
+	}
+	goal, live, roots := float64(value(3)), float64(value(4)), float64(value(5)+value(6))
+	reading.pressure = goal < 0.98*(live+(live+roots)*float64(gogc)/100)
+	return reading
+}
+
+func (t *memoryLimitTracker) sample(iteration int) {
+	if t == nil {
+		return
+	}
+	reading := readMemoryLimit()
+	t.result.Samples++
+	t.result.PeakBytes = max(t.result.PeakBytes, reading.used)
+	if !reading.pressure {
+		return
+	}
+	t.result.PressuredSamples++
+	if t.result.FirstPressureIteration < 0 {
+		t.result.FirstPressureIteration = iteration
+		t.result.FirstPressureMs = MsSince(t.start)
+	}
+}
+
+func (t *memoryLimitTracker) finish(iteration int) *memoryLimitResult {
+	if t == nil {
+		return nil
+	}
+	t.sample(iteration)
+	t.result.GCCycles = readMemoryLimit().cycles - t.gcBase
+	return &t.result
+}
+
+func peakMemory(a, b memorySnapshot) memorySnapshot {
+	out := a
+	if b.rssKb > out.rssKb {
+		out.rssKb = b.rssKb
+	}
+	if b.heapUsedKb > out.heapUsedKb {
+		out.heapUsedKb = b.heapUsedKb
+	}
+	return out
+}
+
+// cgroupV2Dir resolves the unified-hierarchy directory of the current process,
+// returning "" when not running under cgroup v2 with memory accounting.
+func cgroupV2Dir() string {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return ""
+	}
+	rel := ""
+	found := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "0::") {
+			rel = strings.TrimPrefix(line, "0::")
+			found = true
+			break
+		}
+	}
+	if !found {
+		return ""
+	}
+	for _, root := range []string{"/sys/fs/cgroup", "/sys/fs/cgroup/unified"} {
+		dir := root + rel
+		if _, err := os.Stat(dir + "/memory.current"); err == nil {
+			return dir
+		}
+	}
+	return ""
+}
+
+func readCgroupInt(dir string, name string) int64 {
+	data, err := os.ReadFile(dir + "/" + name)
+	if err != nil {
+		return 0
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func takeCgroupMemory(dir string) cgroupMemorySnapshot {
+	out := cgroupMemorySnapshot{
+		currentKb: readCgroupInt(dir, "memory.current") / 1024,
+	}
+	data, err := os.ReadFile(dir + "/memory.pressure")
+	if err != nil {
+		return out
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		for _, field := range fields[1:] {
+			key, value, ok := strings.Cut(field, "=")
+			if !ok {
+				continue
+			}
+			switch {
+			case fields[0] == "some" && key == "total":
+				out.someStallTotalUs, _ = strconv.ParseInt(value, 10, 64)
+			case fields[0] == "full" && key == "total":
+				out.fullStallTotalUs, _ = strconv.ParseInt(value, 10, 64)
+			case fields[0] == "some" && key == "avg10":
+				out.someStallAvg10Pct, _ = strconv.ParseFloat(value, 64)
+			}
+		}
+	}
+	return out
+}
+
+// cgroupPeak tracks a cgroup's memory high-water mark over a run. The
+// cgroup's memory.peak is its lifetime mark, which can predate the run and
+// belong to another process in the cgroup, so it is only used through a
+// descriptor the run reset it on, which Linux 6.12 and later allow.
+// Without one the peak is the largest memory.current sampled.
+type cgroupPeak struct {
+	file      *os.File
+	sampledKb int64
+}
+
+func newCgroupPeak(dir string, currentKb int64) *cgroupPeak {
+	p := &cgroupPeak{sampledKb: currentKb}
+	if dir == "" {
+		return p
+	}
+	file, err := os.OpenFile(dir+"/memory.peak", os.O_RDWR, 0)
+	if err != nil {
+		return p
+	}
+	if _, err := file.WriteString("reset\n"); err != nil {
+		file.Close()
+		return p
+	}
+	p.file = file
+	return p
+}
+
+func (p *cgroupPeak) sample(currentKb int64) {
+	p.sampledKb = max(p.sampledKb, currentKb)
+}
+
+// read is the peak and the file it came from, memory.peak or
+// memory.current.
+func (p *cgroupPeak) read() (int64, string) {
+	if p.file != nil {
+		buf := make([]byte, 32)
+		if n, err := p.file.ReadAt(buf, 0); n > 0 && (err == nil || err == io.EOF) {
+			if v, err := strconv.ParseInt(strings.TrimSpace(string(buf[:n])), 10, 64); err == nil {
+				return max(v/1024, p.sampledKb), "memory.peak"
+			}
+		}
+	}
+	return p.sampledKb, "memory.current"
+}
+
+func (p *cgroupPeak) close() {
+	if p.file != nil {
+		p.file.Close()
+	}
+}
+
+func cgroupMemoryDelta(dir string, before, after cgroupMemorySnapshot, peak *cgroupPeak) *cgroupMemoryResult {
+	if dir == "" {
+		return nil
+	}
+	peakKb, source := peak.read()
+	return &cgroupMemoryResult{
+		Path:              dir,
+		CurrentBeforeKb:   before.currentKb,
+		CurrentAfterKb:    after.currentKb,
+		PeakKb:            peakKb,
+		PeakSource:        source,
+		SomeStallUs:       after.someStallTotalUs - before.someStallTotalUs,
+		FullStallUs:       after.fullStallTotalUs - before.fullStallTotalUs,
+		SomeStallAvg10Pct: after.someStallAvg10Pct,
+	}
+}
+
+func takeSched() schedSnapshot {
+	return schedSnapshot{
+		threads:    readThreadCount(),
+		goroutines: runtime.NumGoroutine(),
+	}
+}
+
+func peakSched(a, b schedSnapshot) schedSnapshot {
+	out := a
+	if b.threads > out.threads {
+		out.threads = b.threads
+	}
+	if b.goroutines > out.goroutines {
+		out.goroutines = b.goroutines
+	}
+	return out
+}
+
+// framesPerSecond is measured throughput over the wall time of the loop.
+func framesPerSecond(frames int, wallMs float64) float64 {
+	if wallMs <= 0 {
+		return 0
+	}
+	return float64(frames) / wallMs * 1000
+}
+
+func perFrame(total int64, frames int) float64 {
+	if frames <= 0 {
+		return 0
+	}
+	return float64(total) / float64(frames)
+}
+
+// warmFrames is how many of d's frames BytesWritten and the CPU times
+// cover: all of them but the --cold-frames split off into d.Cold.
+func (d *Result) warmFrames() int {
+	if d.Cold != nil {
+		return d.Frames - d.Cold.Frames
+	}
+	return d.Frames
+}
+
+func (d *Result) bytesPerFrame() float64 {
+	return perFrame(d.BytesWritten, d.warmFrames())
+}
+
+func (d *Result) cpuMsPerFrame() float64 {
+	return (d.CPUUserMs + d.CPUSysMs) / float64(max(d.warmFrames(), 1))
+}
+
+const (
+	warmupWindow      = 20
+	warmupCVThreshold = 0.10
+)
+
+func meanVariance(samples []float64) (float64, float64) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range samples {
+		sum += v
+	}
+	mean := sum / float64(len(samples))
+	sq := 0.0
+	for _, v := range samples {
+		sq += (v - mean) * (v - mean)
+	}
+	return mean, sq / float64(len(samples))
+}
+
+func coefficientOfVariation(samples []float64) float64 {
+	mean, variance := meanVariance(samples)
+	if mean <= 0 {
+		return 0
+	}
+	return math.Sqrt(variance) / mean
+}
+
+func buildWarmupReport(samples []float64, cfg Config) warmupReport {
+	threshold := cfg.warmupThreshold()
+	report := warmupReport{
+		Mode:            "fixed",
+		Frames:          len(samples),
+		Window:          warmupWindow,
+		CVThreshold:     threshold,
+		ConvergedAt:     -1,
+		SamplesMs:       samples,
+		RollingVariance: []float64{},
+		RollingCV:       []float64{},
+	}
+	for end := warmupWindow; end <= len(samples); end++ {
+		window := samples[end-warmupWindow : end]
+		_, variance := meanVariance(window)
+		cv := coefficientOfVariation(window)
+		report.RollingVariance = append(report.RollingVariance, variance)
+		report.RollingCV = append(report.RollingCV, cv)
+		if report.ConvergedAt < 0 && cv <= threshold {
+			report.ConvergedAt = end - 1
+		}
+	}
+	report.Converged = report.ConvergedAt >= 0
+	if cfg.WarmupAuto {
+		report.Mode = "auto"
+		report.Max = cfg.WarmupMax
+	}
+	return report
+}
+
+// emulatedChangedCells counts the cells each of ticks[skip:] changed on a
+// VT-emulated screen. It runs once the measured window is over, so counting
+// costs the run nothing: like verify, it renders the ticks again in stub
+// mode and draws their output through a vtverify.Terminal. The skipped
+// ticks only bring the screen to where the run left it. With fresh, each
+// frame is counted against a blank screen, as a new program paints one.
+// resizes are applied before the counted frames they are scheduled at.
+func emulatedChangedCells(ctx context.Context, cfg Config, rows int, cols int, ticks []int, skip int, fresh bool, resizes []ResizeStep) ([]int, error) {
+	out := &frameCollector{}
+	session, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, newWriter(out))
+	if err != nil {
+		return nil, err
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = session.Close()
+		}
+	}()
+
+	term := vtverify.New(rows, cols)
+	for _, p := range out.take() {
+		_, _ = term.Write(p)
+	}
+	cells := make([]int, 0, max(len(ticks)-skip, 0))
+	for n, tick := range ticks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if len(resizes) > 0 && resizes[0].At == n-skip {
+			step := resizes[0]
+			resizes = resizes[1:]
+			if resizable, ok := session.(ResizableSession); ok {
+				if err := resizable.Resize(step.Rows, step.Cols); err != nil {
+					return nil, err
+				}
+			}
+			term.Resize(step.Rows, step.Cols)
+		}
+		if _, err := session.RenderTick(tick, false); err != nil {
+			return nil, err
+		}
+		frame := term.DrawFrame(out.take())
+		switch {
+		case n < skip:
+		case fresh:
+			cells = append(cells, vtverify.ChangedCells(nil, frame.After))
+		default:
+			cells = append(cells, frame.ChangedCells)
+		}
+	}
+	closed = true
+	return cells, session.Close()
+}
+
+// tickSpan is the n ticks from first on.
+func tickSpan(first int, n int) []int {
+	ticks := make([]int, n)
+	for i := range ticks {
+		ticks[i] = first + i
+	}
+	return ticks
+}
+
+// frameCollector keeps copies of the writes made since the last take.
+type frameCollector struct {
+	mu     sync.Mutex
+	writes [][]byte
+}
+
+func (c *frameCollector) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writes = append(c.writes, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (c *frameCollector) take() [][]byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writes := c.writes
+	c.writes = nil
+	return writes
+}
+
+func sumCells(cells []int) int64 {
+	var total int64
+	for _, n := range cells {
+		total += int64(n)
+	}
+	return total
+}
+
+func bytesPerChangedCell(bytes int64, cells int64) float64 {
+	if cells <= 0 {
+		return 0
+	}
+	return float64(bytes) / float64(cells)
+}
+
+func summarize(samples []float64) sampleSummary {
+	n := len(samples)
+	if n == 0 {
+		return sampleSummary{}
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mean, variance := meanVariance(sorted)
+	stddev := math.Sqrt(variance)
+	cv := 0.0
+	if mean > 0 {
+		cv = stddev / mean
+	}
+	return sampleSummary{
+		N:      n,
+		Mean:   mean,
+		Median: median(sorted),
+		P95:    sorted[minInt(int(math.Ceil(float64(n)*0.95))-1, n-1)],
+		P99:    sorted[minInt(int(math.Ceil(float64(n)*0.99))-1, n-1)],
+		Min:    sorted[0],
+		Max:    sorted[n-1],
+		Stddev: stddev,
+		CV:     cv,
+	}
+}
+
+// bootstrapBudget bounds the work of bootstrapCI: resamples shrink, down to
+// bootstrapMinResamples, so that resamples times samples stays under it.
+// Runs with more than bootstrapBudget/bootstrapMinResamples samples get no
+// interval at all rather than one from too few resamples.
+const (
+	bootstrapResamples    = 1000
+	bootstrapMinResamples = 200
+	bootstrapBudget       = 20_000_000
+)
+
+// bootstrapCI resamples samples with replacement, seeded by seed so a run
+// reproduces its own intervals, and takes the 2.5th and 97.5th percentiles
+// of the resampled means and p95s. When there are too many samples to stay
+// within bootstrapBudget it returns no interval and says why.
+func bootstrapCI(samples []float64, seed uint64) (*summaryCI, string) {
+	n := len(samples)
+	if n < 2 {
+		return nil, ""
+	}
+	if n > bootstrapBudget/bootstrapMinResamples {
+		return nil, fmt.Sprintf("%d samples exceed the bootstrap budget of %d", n, bootstrapBudget/bootstrapMinResamples)
+	}
+	resamples := min(bootstrapResamples, bootstrapBudget/n)
+	rng := rand.New(rand.NewPCG(seed, 0xb007))
+	means := make([]float64, resamples)
+	p95s := make([]float64, resamples)
+	draw := make([]float64, n)
+	for r := range resamples {
+		var sum float64
+		for i := range draw {
+			draw[i] = samples[rng.IntN(n)]
+			sum += draw[i]
+		}
+		sort.Float64s(draw)
+		means[r] = sum / float64(n)
+		p95s[r] = draw[minInt(int(math.Ceil(float64(n)*0.95))-1, n-1)]
+	}
+	sort.Float64s(means)
+	sort.Float64s(p95s)
+	low, high := int(0.025*float64(resamples)), minInt(int(0.975*float64(resamples)), resamples-1)
+	return &summaryCI{
+		Level:     0.95,
+		Resamples: resamples,
+		MeanLow:   means[low],
+		MeanHigh:  means[high],
+		P95Low:    p95s[low],
+		P95High:   p95s[high],
+	}, ""
+}
+
+// applyOutlierPolicy applies an --outlier-policy to samples over factor
+// times their median, the same ones reported as outliers: "trim" drops
+// them, "winsorize" clamps them to that bound and anything else keeps them.
+// It also returns how many samples it changed.
+func applyOutlierPolicy(samples []float64, policy string, factor float64) ([]float64, int) {
+	bound := median(samples) * factor
+	if policy != "trim" && policy != "winsorize" || bound <= 0 {
+		return samples, 0
+	}
+	adjusted := make([]float64, 0, len(samples))
+	changed := 0
+	for _, sample := range samples {
+		if sample <= bound {
+			adjusted = append(adjusted, sample)
+			continue
+		}
+		changed++
+		if policy == "winsorize" {
+			adjusted = append(adjusted, bound)
+		}
+	}
+	return adjusted, changed
+}
+
+// coldMarks is the CPU usage before the measured loop and after each of its
+// first --cold-frames iterations, so usage can be split wherever the cold
+// block ends.
+type coldMarks struct {
+	proc  usageProc
+	marks []cpuUsage
+}
+
+func newColdMarks(coldFrames int, proc usageProc, before cpuUsage) *coldMarks {
+	marks := make([]cpuUsage, 1, coldFrames+1)
+	marks[0] = before
+	return &coldMarks{proc: proc, marks: marks}
+}
+
+// add records the usage after measured iteration i while it is cold.
+func (m *coldMarks) add(i int) {
+	if i < cap(m.marks)-1 {
+		m.marks = append(m.marks, m.proc.cpu())
+	}
+}
+
+// at is the usage after the first frames measured iterations.
+func (m *coldMarks) at(frames int) cpuUsage {
+	return m.marks[min(frames, len(m.marks)-1)]
+}
+
+// splitCold separates the first coldFrames samples into a cold report, with
+// their bytes, changed cells, if counted, and the CPU usage cpu, and returns
+// the remaining steady-state samples.
+func splitCold(samples []float64, trace iterationTrace, cells []int, coldFrames int, cpu cpuUsage) ([]float64, *coldReport) {
+	if coldFrames <= 0 {
+		return samples, nil
+	}
+	cold := samples[:coldFrames]
+	report := &coldReport{
+		Frames:           coldFrames,
+		SamplesMs:        cold,
+		Summary:          summarize(cold),
+		CPUUserMs:        cpu.userMs,
+		CPUSysMs:         cpu.systemMs,
+		MinorFaults:      cpu.minorFaults,
+		MajorFaults:      cpu.majorFaults,
+		VolCtxSwitches:   cpu.volCtxSw,
+		InvolCtxSwitches: cpu.involCtxSw,
+	}
+	for _, b := range trace.bytes[:coldFrames] {
+		report.BytesWritten += b
+	}
+	for _, n := range cells[:min(coldFrames, len(cells))] {
+		report.ChangedCells += int64(n)
+	}
+	return samples[coldFrames:], report
+}
+
+func median(samples []float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
+
+// findOutliers reports samples over factor times the median, each with the
+// GC cycles that completed during its own iteration.
+func findOutliers(samples []float64, trace iterationTrace, factor float64) []outlierSample {
+	out := []outlierSample{}
+	med := median(samples)
+	if med <= 0 {
+		return out
+	}
+	for i, sample := range samples {
+		if sample <= med*factor {
+			continue
+		}
+		out = append(out, outlierSample{
+			Iteration:    i,
+			Tick:         trace.ticks[i],
+			SampleMs:     sample,
+			MedianRatio:  sample / med,
+			BytesWritten: trace.bytes[i],
+			GCCycles:     trace.gcCycles[i],
+		})
+	}
+	return out
+}
+
+func tryGC() {
+	runtime.GC()
+}
+
+// gcPercent reports the GOGC in effect as GOGC would spell it.
+func gcPercent() string {
+	percent := debug.SetGCPercent(-1)
+	debug.SetGCPercent(percent)
+	if percent < 0 {
+		return "off"
+	}
+	return strconv.Itoa(percent)
+}
+
+// MsSince returns the milliseconds elapsed since start.
+func MsSince(start time.Time) float64 {
+	return float64(time.Since(start).Microseconds()) / 1000.0
+}
+
+const timerCalibrationRounds = 100_000
+
+func readClockSource() string {
+	data, err := os.ReadFile("/sys/devices/system/clocksource/clocksource0/current_clocksource")
+	if err != nil {
+		return "unknown"
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func calibrateTimer() timerCalibration {
+	start := time.Now()
+	for i := 0; i < timerCalibrationRounds; i++ {
+		_ = time.Now()
+	}
+	nowNs := float64(time.Since(start).Nanoseconds()) / timerCalibrationRounds
+
+	var sink time.Duration
+	start = time.Now()
+	for i := 0; i < timerCalibrationRounds; i++ {
+		sink += time.Since(start)
+	}
+	sinceNs := float64(time.Since(start).Nanoseconds()) / timerCalibrationRounds
+	_ = sink
+
+	// Smallest observable non-zero step between consecutive readings.
+	resolution := int64(math.MaxInt64)
+	prev := time.Now()
+	for i := 0; i < timerCalibrationRounds; i++ {
+		now := time.Now()
+		if d := now.Sub(prev).Nanoseconds(); d > 0 && d < resolution {
+			resolution = d
+		}
+		prev = now
+	}
+	if resolution == math.MaxInt64 {
+		resolution = 0
+	}
+
+	return timerCalibration{
+		ClockSource:      readClockSource(),
+		NowOverheadNs:    nowNs,
+		SinceOverheadNs:  sinceNs,
+		SampleOverheadNs: nowNs + sinceNs,
+		ResolutionNs:     resolution,
+		SampleQuantumNs:  int64(time.Microsecond),
+	}
+}
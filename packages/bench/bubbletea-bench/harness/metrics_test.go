@@ -0,0 +1,169 @@
+package harness
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+// scriptSession writes frames[tick] for each tick it is sent.
+type scriptSession struct {
+	w      *Writer
+	frames map[int]string
+}
+
+func (s scriptSession) RenderTick(tick int, _ bool) (TickPhases, error) {
+	_, err := s.w.Write([]byte(s.frames[tick]))
+	return TickPhases{}, err
+}
+
+func (s scriptSession) Close() error { return nil }
+
+func TestEmulatedChangedCells(t *testing.T) {
+	cases := []struct {
+		name   string
+		frames map[int]string
+		ticks  []int
+		skip   int
+		fresh  bool
+		want   []int
+	}{
+		{name: "one cell", frames: map[int]string{0: "\x1b[Habc", 1: "\x1b[Habd"}, ticks: []int{0, 1}, skip: 1, want: []int{1}},
+		{name: "style only", frames: map[int]string{0: "\x1b[Habc", 1: "\x1b[H\x1b[1mabc\x1b[0m"}, ticks: []int{0, 1}, skip: 1, want: []int{0}},
+		{name: "unchanged row redrawn", frames: map[int]string{0: "\x1b[Hab", 1: "\x1b[H\x1b[2Kab"}, ticks: []int{0, 1}, skip: 1, want: []int{0}},
+		{name: "wide rune covers two cells", frames: map[int]string{0: "\x1b[H  ", 1: "\x1b[H字"}, ticks: []int{0, 1}, skip: 1, want: []int{2}},
+		{name: "skipped ticks set up the screen", frames: map[int]string{0: "\x1b[Hab", 1: "\x1b[Hcd", 2: "\x1b[Hcx"}, ticks: []int{1, 2}, skip: 1, want: []int{1}},
+		{name: "fresh frames count every cell", frames: map[int]string{1: "\x1b[Hab", 2: "\x1b[Hab"}, ticks: []int{1, 2}, fresh: true, want: []int{2, 2}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := Config{Start: func(_ string, _ map[string]string, _ uint64, _ int, _ int, _ int, w *Writer) (Session, error) {
+				return scriptSession{w: w, frames: tc.frames}, nil
+			}}
+			got, err := emulatedChangedCells(context.Background(), cfg, 4, 10, tc.ticks, tc.skip, tc.fresh, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("changed cells %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSplitCold(t *testing.T) {
+	trace := iterationTrace{bytes: []int64{100, 50, 10, 10}}
+	samples := []float64{9, 5, 1, 1}
+	cells := []int{40, 20, 2, 2}
+	cpu := cpuUsage{userMs: 3, volCtxSw: 2}
+	cases := []struct {
+		name       string
+		coldFrames int
+		warm       []float64
+		bytes      int64
+		cells      int64
+	}{
+		{name: "no cold frames", coldFrames: 0, warm: samples},
+		{name: "first frame", coldFrames: 1, warm: samples[1:], bytes: 100, cells: 40},
+		{name: "first two frames", coldFrames: 2, warm: samples[2:], bytes: 150, cells: 60},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			warm, cold := splitCold(samples, trace, cells, tc.coldFrames, cpu)
+			if len(warm) != len(tc.warm) {
+				t.Fatalf("%d warm samples, want %d", len(warm), len(tc.warm))
+			}
+			if tc.coldFrames == 0 {
+				if cold != nil {
+					t.Fatalf("cold report %+v, want none", cold)
+				}
+				return
+			}
+			if cold.Frames != tc.coldFrames || cold.BytesWritten != tc.bytes || cold.ChangedCells != tc.cells {
+				t.Errorf("cold frames %d, bytes %d, cells %d; want %d, %d, %d", cold.Frames, cold.BytesWritten, cold.ChangedCells, tc.coldFrames, tc.bytes, tc.cells)
+			}
+			if cold.CPUUserMs != cpu.userMs || cold.VolCtxSwitches != cpu.volCtxSw {
+				t.Errorf("cold CPU %v ms, %d switches; want %v ms, %d switches", cold.CPUUserMs, cold.VolCtxSwitches, cpu.userMs, cpu.volCtxSw)
+			}
+		})
+	}
+}
+
+func TestColdMarks(t *testing.T) {
+	marks := newColdMarks(2, 0, cpuUsage{userMs: 1})
+	for i := 0; i < 5; i++ {
+		marks.add(i)
+	}
+	if len(marks.marks) != 3 {
+		t.Fatalf("%d marks, want 3: the usage before and after each cold frame", len(marks.marks))
+	}
+	if got := marks.at(0); got.userMs != 1 {
+		t.Errorf("at(0) = %+v, want the usage before the loop", got)
+	}
+	if marks.at(5) != marks.at(2) {
+		t.Errorf("at(5) = %+v, want the last mark %+v", marks.at(5), marks.at(2))
+	}
+}
+
+func TestBootstrapCIBudget(t *testing.T) {
+	samples := make([]float64, bootstrapBudget/bootstrapMinResamples)
+	for i := range samples {
+		samples[i] = float64(i % 7)
+	}
+	ci, skipped := bootstrapCI(samples, 1)
+	if ci == nil || skipped != "" || ci.Resamples != bootstrapMinResamples {
+		t.Fatalf("at the budget: ci %+v, skipped %q", ci, skipped)
+	}
+	ci, skipped = bootstrapCI(append(samples, 0), 1)
+	if ci != nil || skipped == "" {
+		t.Fatalf("over the budget: ci %+v, skipped %q", ci, skipped)
+	}
+}
+
+func TestFindOutliersGCCycles(t *testing.T) {
+	trace := iterationTrace{
+		ticks:    []int{1, 2, 3, 4},
+		bytes:    []int64{10, 10, 10, 10},
+		gcCycles: []uint64{1, 0, 2, 0},
+	}
+	out := findOutliers([]float64{1, 1, 5, 1}, trace, 3)
+	if len(out) != 1 || out[0].Iteration != 2 || out[0].GCCycles != 2 {
+		t.Fatalf("outliers %+v", out)
+	}
+}
+
+func TestPerFrameWarmOnly(t *testing.T) {
+	cases := []struct {
+		name  string
+		cold  *coldReport
+		bytes float64
+		cpu   float64
+	}{
+		{name: "no cold frames", bytes: 100, cpu: 2},
+		{name: "cold frames split off", cold: &coldReport{Frames: 5}, bytes: 200, cpu: 4},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := &Result{Frames: 10, BytesWritten: 1000, CPUUserMs: 15, CPUSysMs: 5, Cold: tc.cold}
+			if got := d.bytesPerFrame(); got != tc.bytes {
+				t.Errorf("bytesPerFrame %v, want %v", got, tc.bytes)
+			}
+			if got := d.cpuMsPerFrame(); got != tc.cpu {
+				t.Errorf("cpuMsPerFrame %v, want %v", got, tc.cpu)
+			}
+		})
+	}
+}
+
+func TestPhaseSamplesMissingFlush(t *testing.T) {
+	phases := newPhaseSamples(3)
+	phases.add(TickPhases{FlushMs: 2})
+	phases.add(TickPhases{NoFlush: true})
+	phases.add(TickPhases{FlushMs: 3})
+	if len(phases.updateMs) != 3 {
+		t.Errorf("update samples %d, want 3", len(phases.updateMs))
+	}
+	if len(phases.flushMs) != 2 || phases.missingFlushes != 1 {
+		t.Errorf("flush samples %v with %d missing, want 2 samples and 1 missing", phases.flushMs, phases.missingFlushes)
+	}
+}
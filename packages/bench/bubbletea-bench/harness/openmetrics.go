@@ -0,0 +1,196 @@
+package harness
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// frameBuckets are the frame-time histogram bounds in seconds, roughly doubling from
+// half a millisecond and passing the 60 and 30 fps budgets.
+var frameBuckets = []float64{0.0005, 0.001, 0.002, 0.004, 0.008, 0.0166, 0.0333, 0.0666, 0.133, 0.25, 0.5, 1}
+
+type phaseKey struct {
+	scenario string
+	phase    string
+}
+
+type frameHistogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type memoryGauge struct {
+	rssKb  int64
+	heapKb int64
+}
+
+// MetricsExporter publishes iteration records as an OpenMetrics endpoint for
+// the life of the process, so scrapes see every scenario of a suite or
+// repeated run. It is fed from the render loop and only ever takes a short
+// lock there.
+type MetricsExporter struct {
+	mu      sync.Mutex
+	frames  map[phaseKey]*frameHistogram
+	bytes   map[string]int64
+	memory  map[string]memoryGauge
+	running string
+	server  *http.Server
+}
+
+// NewMetricsExporter returns an exporter with no series yet.
+func NewMetricsExporter() *MetricsExporter {
+	return &MetricsExporter{
+		frames: map[phaseKey]*frameHistogram{},
+		bytes:  map[string]int64{},
+		memory: map[string]memoryGauge{},
+	}
+}
+
+// Serve listens on addr and serves /metrics until Close.
+func (e *MetricsExporter) Serve(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen --metrics-addr: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.serveHTTP)
+	e.server = &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := e.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "metrics server: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Close stops the server.
+func (e *MetricsExporter) Close() error {
+	if e.server == nil {
+		return nil
+	}
+	return e.server.Close()
+}
+
+func (e *MetricsExporter) begin(scenario string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.running = scenario
+	e.mu.Unlock()
+}
+
+func (e *MetricsExporter) end() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.running = ""
+	e.mu.Unlock()
+}
+
+func (e *MetricsExporter) observe(scenario string, rec streamRecord) {
+	if e == nil {
+		return
+	}
+	seconds := rec.SampleMs / 1000
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	key := phaseKey{scenario: scenario, phase: rec.Phase}
+	h := e.frames[key]
+	if h == nil {
+		h = &frameHistogram{counts: make([]uint64, len(frameBuckets))}
+		e.frames[key] = h
+	}
+	for i, bound := range frameBuckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += seconds
+	e.bytes[scenario] += rec.Bytes
+	if rec.RSSKb > 0 {
+		e.memory[scenario] = memoryGauge{rssKb: rec.RSSKb, heapKb: rec.HeapKb}
+	}
+}
+
+func (e *MetricsExporter) serveHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/openmetrics-text; version=1.0.0; charset=utf-8")
+	_, _ = w.Write(e.render())
+}
+
+// render writes the OpenMetrics text exposition of every series.
+func (e *MetricsExporter) render() []byte {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var b bytes.Buffer
+
+	keys := make([]phaseKey, 0, len(e.frames))
+	for key := range e.frames {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].scenario != keys[j].scenario {
+			return keys[i].scenario < keys[j].scenario
+		}
+		return keys[i].phase < keys[j].phase
+	})
+	b.WriteString("# TYPE tui_bench_frame_seconds histogram\n")
+	b.WriteString("# UNIT tui_bench_frame_seconds seconds\n")
+	b.WriteString("# HELP tui_bench_frame_seconds Time from sending a tick to its frame being written.\n")
+	for _, key := range keys {
+		h := e.frames[key]
+		labels := fmt.Sprintf("scenario=%q,phase=%q", key.scenario, key.phase)
+		for i, bound := range frameBuckets {
+			fmt.Fprintf(&b, "tui_bench_frame_seconds_bucket{%s,le=\"%s\"} %d\n", labels, strconv.FormatFloat(bound, 'g', -1, 64), h.counts[i])
+		}
+		fmt.Fprintf(&b, "tui_bench_frame_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		fmt.Fprintf(&b, "tui_bench_frame_seconds_sum{%s} %s\n", labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "tui_bench_frame_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	scenarios := make([]string, 0, len(e.bytes))
+	for scenario := range e.bytes {
+		scenarios = append(scenarios, scenario)
+	}
+	sort.Strings(scenarios)
+	b.WriteString("# TYPE tui_bench_output_bytes counter\n")
+	b.WriteString("# UNIT tui_bench_output_bytes bytes\n")
+	b.WriteString("# HELP tui_bench_output_bytes Bytes the renderer wrote, warmup included.\n")
+	for _, scenario := range scenarios {
+		fmt.Fprintf(&b, "tui_bench_output_bytes_total{scenario=%q} %d\n", scenario, e.bytes[scenario])
+	}
+	b.WriteString("# TYPE tui_bench_rss_bytes gauge\n")
+	b.WriteString("# UNIT tui_bench_rss_bytes bytes\n")
+	b.WriteString("# HELP tui_bench_rss_bytes Resident set size at the last memory sample.\n")
+	for _, scenario := range scenarios {
+		if mem, ok := e.memory[scenario]; ok {
+			fmt.Fprintf(&b, "tui_bench_rss_bytes{scenario=%q} %d\n", scenario, mem.rssKb*1024)
+		}
+	}
+	b.WriteString("# TYPE tui_bench_heap_bytes gauge\n")
+	b.WriteString("# UNIT tui_bench_heap_bytes bytes\n")
+	b.WriteString("# HELP tui_bench_heap_bytes Go heap in use at the last memory sample.\n")
+	for _, scenario := range scenarios {
+		if mem, ok := e.memory[scenario]; ok {
+			fmt.Fprintf(&b, "tui_bench_heap_bytes{scenario=%q} %d\n", scenario, mem.heapKb*1024)
+		}
+	}
+	b.WriteString("# TYPE tui_bench_running gauge\n")
+	b.WriteString("# HELP tui_bench_running 1 for the scenario being measured.\n")
+	if e.running != "" {
+		fmt.Fprintf(&b, "tui_bench_running{scenario=%q} 1\n", e.running)
+	}
+	b.WriteString("# EOF\n")
+	return b.Bytes()
+}
@@ -0,0 +1,78 @@
+package harness
+
+import "time"
+
+// pacingResult is the "pacing" field of a Result run with --pace: ticks sent
+// on a wall-clock ticker at TargetFPS instead of back to back.
+//
+// QueueDelay is how long a due tick waited before it was sent, because the
+// previous frame was still rendering. A tick that came due while an earlier
+// one was still queued is dropped, as a real-time program drops frames; a
+// sent frame that finished after the next tick came due missed its deadline.
+type pacingResult struct {
+	TargetFPS           int           `json:"targetFps"`
+	PeriodMs            float64       `json:"periodMs"`
+	Ticks               int           `json:"ticks"`
+	DroppedTicks        int           `json:"droppedTicks"`
+	DeadlineMisses      int           `json:"deadlineMisses"`
+	QueueDelay          sampleSummary `json:"queueDelay"`
+	QueueDelaySamplesMs []float64     `json:"queueDelaySamplesMs"`
+}
+
+// pacer hands out the measured loop's ticks at a fixed rate. It is nil when
+// the loop runs unpaced.
+type pacer struct {
+	ticker *time.Ticker
+	start  time.Time
+	period time.Duration
+	last   int64
+	result pacingResult
+}
+
+func newPacer(fps int, start time.Time) *pacer {
+	if fps <= 0 {
+		return nil
+	}
+	period := time.Second / time.Duration(fps)
+	return &pacer{
+		ticker: time.NewTicker(period),
+		start:  start,
+		period: period,
+		result: pacingResult{TargetFPS: fps, PeriodMs: nsToMs(period.Nanoseconds()), QueueDelaySamplesMs: []float64{}},
+	}
+}
+
+// wait blocks until the next tick is due and returns when it was due.
+func (p *pacer) wait() time.Time {
+	if p == nil {
+		return time.Time{}
+	}
+	due := <-p.ticker.C
+	slot := int64(due.Sub(p.start) / p.period)
+	if skipped := slot - p.last - 1; skipped > 0 && p.result.Ticks > 0 {
+		p.result.DroppedTicks += int(skipped)
+	}
+	p.last = slot
+	p.result.Ticks++
+	p.result.QueueDelaySamplesMs = append(p.result.QueueDelaySamplesMs, MsSince(due))
+	return due
+}
+
+// done records whether the frame sent at due finished before the next tick.
+func (p *pacer) done(due time.Time) {
+	if p == nil {
+		return
+	}
+	if time.Since(due) > p.period {
+		p.result.DeadlineMisses++
+	}
+}
+
+func (p *pacer) finish() *pacingResult {
+	if p == nil {
+		return nil
+	}
+	p.ticker.Stop()
+	p.result.QueueDelay = summarize(p.result.QueueDelaySamplesMs)
+	return &p.result
+}
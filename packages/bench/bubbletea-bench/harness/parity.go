@@ -0,0 +1,133 @@
+package harness
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// ParityReport is the "parity" field of a ResultFile: the frames of each
+// scenario in a hash manifest checked against what the Go generators render
+// for the same params and seed. A manifest has the shape of the
+// manifest.json export-frames writes, as does the one the TS harness's
+// src/export-frames.ts writes from its own generators, and frames are
+// hashed the same way: lines with ANSI stripped, each ending in a newline.
+type ParityReport struct {
+	Manifest   string           `json:"manifest"`
+	Seed       uint64           `json:"seed"`
+	Scenarios  []parityScenario `json:"scenarios"`
+	Frames     int              `json:"frames"`
+	Mismatches int              `json:"mismatches"`
+	Missing    int              `json:"missing"`
+	Errors     int              `json:"errors"`
+}
+
+// parityScenario is one manifest scenario. Missing are checked ticks the
+// manifest has no hash for; Error is why the scenario could not be
+// rendered or laid out as the manifest says, or that the manifest leaves
+// out a registered scenario.
+type parityScenario struct {
+	Scenario   string            `json:"scenario"`
+	Params     map[string]string `json:"params"`
+	Frames     int               `json:"frames"`
+	Mismatched []parityMismatch  `json:"mismatched"`
+	Missing    []int             `json:"missing,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type parityMismatch struct {
+	Tick     int    `json:"tick"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// CheckParity renders cfg.ReplayTicks, or else the first cfg.VerifyTicks
+// ticks, of every scenario in the manifest at path, or only of scenarios
+// when given, and compares each frame's hash with the manifest's. The
+// manifest's seed and params are used, not cfg's. Without scenarios, every
+// registered scenario the manifest leaves out counts as an error, so an
+// export that skips a scenario cannot pass.
+func CheckParity(ctx context.Context, cfg Config, path string, scenarios []string) (ParityReport, error) {
+	report := ParityReport{Manifest: path, Scenarios: []parityScenario{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return report, fmt.Errorf("read --manifest: %w", err)
+	}
+	var manifest FrameExportReport
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return report, fmt.Errorf("parse --manifest: %w", err)
+	}
+	report.Seed = manifest.Seed
+
+	for _, want := range manifest.Scenarios {
+		if len(scenarios) > 0 && !slices.Contains(scenarios, want.Scenario) {
+			continue
+		}
+		out := parityScenario{Scenario: want.Scenario, Params: want.Params, Mismatched: []parityMismatch{}}
+		report.Scenarios = append(report.Scenarios, out)
+		check := &report.Scenarios[len(report.Scenarios)-1]
+		if err := ValidateScenario(want.Scenario, want.Params); err != nil {
+			check.Error = err.Error()
+			report.Errors++
+			continue
+		}
+		s, _ := lookupScenario(want.Scenario)
+		rows, cols := viewportOf(s.meta, want.Params)
+		if rows != want.Rows || cols != want.Cols {
+			check.Error = fmt.Sprintf("viewport is %dx%d here, %dx%d in the manifest", rows, cols, want.Rows, want.Cols)
+			report.Errors++
+			continue
+		}
+		hashes := make(map[int]string, len(want.Frames))
+		for _, frame := range want.Frames {
+			hashes[frame.Tick] = frame.SHA256
+		}
+		for _, tick := range cfg.verifyTicks() {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			expected, ok := hashes[tick]
+			if !ok {
+				check.Missing = append(check.Missing, tick)
+				report.Missing++
+				continue
+			}
+			sum := sha256.Sum256(frameText(ScenarioLines(want.Scenario, want.Params, manifest.Seed, tick, cols)))
+			if actual := hex.EncodeToString(sum[:]); actual != expected {
+				check.Mismatched = append(check.Mismatched, parityMismatch{Tick: tick, Expected: expected, Actual: actual})
+				report.Mismatches++
+			}
+			check.Frames++
+			report.Frames++
+		}
+	}
+	if len(scenarios) == 0 {
+		for _, info := range Scenarios() {
+			if slices.ContainsFunc(manifest.Scenarios, func(s frameExportScenario) bool { return s.Scenario == info.Name }) {
+				continue
+			}
+			report.Scenarios = append(report.Scenarios, parityScenario{
+				Scenario:   info.Name,
+				Params:     map[string]string{},
+				Mismatched: []parityMismatch{},
+				Error:      "registered but not in the manifest",
+			})
+			report.Errors++
+		}
+	}
+	for _, scenario := range scenarios {
+		if !slices.ContainsFunc(report.Scenarios, func(s parityScenario) bool { return s.Scenario == scenario }) {
+			return report, fmt.Errorf("scenario %q is not in --manifest %s", scenario, path)
+		}
+	}
+	return report, nil
+}
+
+// OK is whether every checked frame matched.
+func (r ParityReport) OK() bool {
+	return r.Mismatches == 0 && r.Missing == 0 && r.Errors == 0 && r.Frames > 0
+}
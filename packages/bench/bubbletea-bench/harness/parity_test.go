@@ -0,0 +1,105 @@
+package harness
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckParity(t *testing.T) {
+	ctx := context.Background()
+	var all []string
+	for _, info := range Scenarios() {
+		all = append(all, info.Name)
+	}
+	exported, err := ExportFrames(ctx, Config{Seed: 7, VerifyTicks: 4}, all, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		edit       func(m *FrameExportReport)
+		cfg        Config
+		scenarios  []string
+		ok         bool
+		mismatches int
+		missing    int
+		errors     int
+		err        string
+	}{
+		{name: "matching", cfg: Config{VerifyTicks: 4}, ok: true},
+		{name: "manifest seed wins", cfg: Config{Seed: 99, VerifyTicks: 4}, ok: true},
+		{name: "one scenario", cfg: Config{VerifyTicks: 4}, scenarios: []string{"rerender"}, ok: true},
+		{
+			name: "tampered hash",
+			edit: func(m *FrameExportReport) { m.Scenarios[1].Frames[2].SHA256 = strings.Repeat("0", 64) },
+			cfg:  Config{VerifyTicks: 4}, mismatches: 1,
+		},
+		{
+			name: "other seed",
+			edit: func(m *FrameExportReport) { m.Seed = 8 },
+			cfg:  Config{VerifyTicks: 4}, scenarios: []string{"terminal-full-ui"}, mismatches: 4,
+		},
+		{name: "ticks past the manifest", cfg: Config{VerifyTicks: 6}, scenarios: []string{"rerender", "terminal-full-ui"}, missing: 4},
+		{name: "tick list", cfg: Config{ReplayTicks: []int{3, 1}}, ok: true},
+		{
+			name: "other viewport",
+			edit: func(m *FrameExportReport) { m.Scenarios[0].Rows = 10 },
+			cfg:  Config{VerifyTicks: 4}, errors: 1,
+		},
+		{
+			name: "unknown scenario in the manifest",
+			edit: func(m *FrameExportReport) {
+				extra := m.Scenarios[0]
+				extra.Scenario = "no-such-scenario"
+				m.Scenarios = append(m.Scenarios, extra)
+			},
+			cfg: Config{VerifyTicks: 4}, errors: 1,
+		},
+		{
+			name: "registered scenario missing from the manifest",
+			edit: func(m *FrameExportReport) { m.Scenarios = m.Scenarios[1:] },
+			cfg:  Config{VerifyTicks: 4}, errors: 1,
+		},
+		{
+			name: "filtered check ignores the rest of the registry",
+			edit: func(m *FrameExportReport) { m.Scenarios = m.Scenarios[:1] },
+			cfg:  Config{VerifyTicks: 4}, scenarios: all[:1], ok: true,
+		},
+		{name: "scenario not in the manifest", cfg: Config{VerifyTicks: 4}, scenarios: []string{"no-such-scenario"}, err: "not in --manifest"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var manifest FrameExportReport
+			data, _ := json.Marshal(exported)
+			_ = json.Unmarshal(data, &manifest)
+			if tc.edit != nil {
+				tc.edit(&manifest)
+			}
+			data, _ = json.Marshal(manifest)
+			path := filepath.Join(t.TempDir(), "manifest.json")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := CheckParity(ctx, tc.cfg, path, tc.scenarios)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.OK() != tc.ok || report.Mismatches != tc.mismatches || report.Missing != tc.missing || report.Errors != tc.errors {
+				t.Errorf("ok %t mismatches %d missing %d errors %d, want %t %d %d %d",
+					report.OK(), report.Mismatches, report.Missing, report.Errors, tc.ok, tc.mismatches, tc.missing, tc.errors)
+			}
+		})
+	}
+}
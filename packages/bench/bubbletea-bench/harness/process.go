@@ -0,0 +1,84 @@
+package harness
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProcessSession is a Session whose program runs in a process of its own,
+// such as a child the harness binary re-executes on a pty. Steady-state,
+// --soak and --throughput runs then report that process's CPU time, faults,
+// context switches and RSS instead of the harness's, and no heap figures.
+// Startup and --sessions runs still report the harness's own process.
+type ProcessSession interface {
+	Session
+	// Pid is the program's process, alive until Close.
+	Pid() int
+}
+
+// clockTicksPerSecond is the USER_HZ /proc/<pid>/stat counts CPU time in,
+// fixed at 100 on Linux whatever the kernel's own tick rate.
+const clockTicksPerSecond = 100
+
+// usageProc is the process whose CPU and memory a run reports: 0 for the
+// harness itself, else the pid of a ProcessSession's program.
+type usageProc int
+
+func usageProcOf(session Session) usageProc {
+	if p, ok := session.(ProcessSession); ok {
+		return usageProc(p.Pid())
+	}
+	return 0
+}
+
+func (p usageProc) cpu() cpuUsage {
+	if p == 0 {
+		return takeCPU()
+	}
+	dir := "/proc/" + strconv.Itoa(int(p))
+	data, err := os.ReadFile(dir + "/stat")
+	if err != nil {
+		return cpuUsage{}
+	}
+	// The fields after the parenthesized command name start at field 3,
+	// state; see proc(5).
+	text := string(data)
+	fields := strings.Fields(text[strings.LastIndexByte(text, ')')+1:])
+	if len(fields) < 13 {
+		return cpuUsage{}
+	}
+	field := func(n int) int64 {
+		v, _ := strconv.ParseInt(fields[n-3], 10, 64)
+		return v
+	}
+	usage := cpuUsage{
+		userMs:      float64(field(14)) * 1000 / clockTicksPerSecond,
+		systemMs:    float64(field(15)) * 1000 / clockTicksPerSecond,
+		minorFaults: field(10),
+		majorFaults: field(12),
+	}
+	// Context switches are counted per thread, so sum the live ones; those
+	// of threads that have exited are lost.
+	tasks, _ := os.ReadDir(dir + "/task")
+	for _, task := range tasks {
+		status := dir + "/task/" + task.Name() + "/status"
+		usage.volCtxSw += readStatusInt(status, "voluntary_ctxt_switches:")
+		usage.involCtxSw += readStatusInt(status, "nonvoluntary_ctxt_switches:")
+	}
+	return usage
+}
+
+func (p usageProc) memory() memorySnapshot {
+	if p == 0 {
+		return takeMemory()
+	}
+	return memorySnapshot{rssKb: p.rssKb()}
+}
+
+func (p usageProc) rssKb() int64 {
+	if p == 0 {
+		return readRSSKb()
+	}
+	return readStatusInt("/proc/"+strconv.Itoa(int(p))+"/status", "VmRSS:")
+}
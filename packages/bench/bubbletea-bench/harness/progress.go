@@ -0,0 +1,109 @@
+package harness
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// progressWindow is how many recent frames the rolling p50 and p95 of a
+// progress line cover.
+const progressWindow = 256
+
+// progressReporter prints a line every cfg.ProgressEvery while a run goes:
+// the iteration, the rolling p50 and p95 of recent frames and, once
+// measuring, the time left. It is fed iteration records like --emit-stream,
+// and soak checkpoints, so the throughput and sessions loops report nothing.
+type progressReporter struct {
+	w        io.Writer
+	every    time.Duration
+	scenario string
+	total    int
+	budget   time.Duration
+
+	start  time.Time
+	last   time.Time
+	window []float64
+	next   int
+}
+
+func newProgressReporter(cfg Config) *progressReporter {
+	if cfg.Progress == nil || cfg.ProgressEvery <= 0 {
+		return nil
+	}
+	p := &progressReporter{
+		w:        cfg.Progress,
+		every:    cfg.ProgressEvery,
+		scenario: cfg.Scenario,
+		total:    cfg.Iterations,
+		budget:   cfg.Duration,
+		last:     time.Now(),
+		window:   make([]float64, 0, progressWindow),
+	}
+	if cfg.Soak > 0 {
+		p.budget = cfg.Soak
+	}
+	return p
+}
+
+func (p *progressReporter) observe(rec streamRecord) {
+	if p == nil {
+		return
+	}
+	if rec.Phase != "warmup" && p.start.IsZero() {
+		p.start = time.Now()
+		p.window = p.window[:0]
+		p.next = 0
+	}
+	if rec.Checkpoint == nil {
+		if len(p.window) < progressWindow {
+			p.window = append(p.window, rec.SampleMs)
+		} else {
+			p.window[p.next] = rec.SampleMs
+			p.next = (p.next + 1) % progressWindow
+		}
+	}
+	now := time.Now()
+	if rec.Checkpoint == nil && now.Sub(p.last) < p.every {
+		return
+	}
+	p.last = now
+
+	done := rec.Iteration + 1
+	p50, p95 := 0.0, 0.0
+	if rec.Checkpoint != nil {
+		done = rec.Iteration
+		p50, p95 = rec.Checkpoint.Summary.Median, rec.Checkpoint.Summary.P95
+	} else {
+		s := summarize(p.window)
+		p50, p95 = s.Median, s.P95
+	}
+	line := fmt.Sprintf("%s: %s %d", p.scenario, rec.Phase, done)
+	if rec.Phase == "measure" && p.budget == 0 && p.total > 0 {
+		line += fmt.Sprintf("/%d (%.0f%%)", p.total, 100*float64(done)/float64(p.total))
+	}
+	line += fmt.Sprintf("  p50 %.2fms  p95 %.2fms", p50, p95)
+	if rec.Phase != "warmup" {
+		elapsed := now.Sub(p.start)
+		if rec.Checkpoint != nil {
+			elapsed = time.Duration(rec.Checkpoint.ElapsedMs * float64(time.Millisecond))
+		}
+		line += "  elapsed " + elapsed.Round(time.Second).String()
+		if eta, ok := p.eta(done, elapsed); ok {
+			line += "  ETA " + eta.Round(time.Second).String()
+		}
+	}
+	_, _ = fmt.Fprintln(p.w, line)
+}
+
+// eta is the time left after done frames in elapsed: the rest of the budget
+// of a timed run, or of the iterations at the rate so far.
+func (p *progressReporter) eta(done int, elapsed time.Duration) (time.Duration, bool) {
+	if p.budget > 0 {
+		return max(p.budget-elapsed, 0), true
+	}
+	if done <= 0 || p.total <= done {
+		return 0, false
+	}
+	return time.Duration(float64(elapsed) / float64(done) * float64(p.total-done)), true
+}
@@ -0,0 +1,264 @@
+package harness
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Generator builds a scenario's logical frame at tick, one string per row,
+// for a terminal cols wide. Varying content comes from rng, never from the
+// clock or shared state, so a seed and tick always give the same frame.
+type Generator func(params map[string]string, tick int, cols int, rng *FrameRand) []string
+
+// FrameRand is the PRNG generators draw frame content from. It is SplitMix64
+// seeded from the run seed and the tick alone, so a tick renders the same
+// content however often and in whatever order it is generated, and other
+// harnesses can reproduce the stream exactly.
+type FrameRand struct {
+	seed  uint64
+	state uint64
+}
+
+// NewFrameRand returns the PRNG for tick of a run seeded with seed.
+func NewFrameRand(seed uint64, tick int) *FrameRand {
+	return &FrameRand{seed: seed, state: seed ^ uint64(tick)*0x9e3779b97f4a7c15}
+}
+
+// Seed is the run seed the PRNG was derived from.
+func (r *FrameRand) Seed() uint64 { return r.seed }
+
+// Uint64 returns the next value in the stream.
+func (r *FrameRand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Uint32 returns the high 32 bits of the next value.
+func (r *FrameRand) Uint32() uint32 { return uint32(r.Uint64() >> 32) }
+
+// Intn returns a value in [0, n), or 0 when n <= 0. It reduces by modulo
+// rather than rejection so the stream is trivial to port.
+func (r *FrameRand) Intn(n int) int {
+	if n <= 0 {
+		return 0
+	}
+	return int(r.Uint64() % uint64(n))
+}
+
+// ParamSpec documents one scenario parameter, passed as --<name> <value>.
+// Type is "int" (the default) or "bool", whose Default is 0 or 1. An int must
+// lie in [Min, Max]; a zero Max leaves it unbounded above.
+type ParamSpec struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Default int    `json:"default"`
+	Min     int    `json:"min"`
+	Max     int    `json:"max,omitempty"`
+	Doc     string `json:"doc,omitempty"`
+}
+
+// check validates one command-line value against the spec.
+func (p ParamSpec) check(scenario string, value string) error {
+	if p.Type == "bool" {
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("scenario %q parameter --%s must be true or false, got %q", scenario, p.Name, value)
+		}
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("scenario %q parameter --%s must be an integer, got %q", scenario, p.Name, value)
+	}
+	if n < p.Min || (p.Max != 0 && n > p.Max) {
+		bound := "in " + p.Range()
+		if p.Max == 0 {
+			bound = p.Range()
+		}
+		return fmt.Errorf("scenario %q parameter --%s must be %s, got %d", scenario, p.Name, bound, n)
+	}
+	return nil
+}
+
+// Range is the accepted values as text, like "1..1000" or ">= 0".
+func (p ParamSpec) Range() string {
+	switch {
+	case p.Type == "bool":
+		return "true|false"
+	case p.Max == 0:
+		return fmt.Sprintf(">= %d", p.Min)
+	}
+	return fmt.Sprintf("%d..%d", p.Min, p.Max)
+}
+
+// ScenarioMeta describes a scenario to the harness. Rows and Cols size the
+// terminal (40x120 when zero); ViewportRows, when set, overrides Rows for
+// scenarios whose height depends on their params. Screen scenarios take the
+// --rows and --cols screen params instead, and every frame fills exactly
+// that viewport. EventLoop delivers ticks from another goroutine, like input
+// arriving while the program is busy.
+type ScenarioMeta struct {
+	Description  string                             `json:"description"`
+	Params       []ParamSpec                        `json:"params"`
+	Rows         int                                `json:"rows"`
+	Cols         int                                `json:"cols"`
+	ViewportRows func(params map[string]string) int `json:"-"`
+	Screen       bool                               `json:"screen,omitempty"`
+	EventLoop    bool                               `json:"eventLoop,omitempty"`
+}
+
+// ScenarioInfo is a registered scenario as listed by Scenarios. Rows is the
+// viewport at default params; ViewportFromParams marks scenarios whose
+// height follows their params.
+type ScenarioInfo struct {
+	Name string `json:"name"`
+	ScenarioMeta
+	ViewportFromParams bool `json:"viewportFromParams,omitempty"`
+}
+
+type registeredScenario struct {
+	meta      ScenarioMeta
+	generator Generator
+	// dirty, for scenarios read from a spec, is the rows the spec declares
+	// change every tick.
+	dirty func(params map[string]string) []int
+}
+
+var registry = struct {
+	mu        sync.RWMutex
+	scenarios map[string]registeredScenario
+	suites    map[string][]string
+}{scenarios: map[string]registeredScenario{}, suites: map[string][]string{}}
+
+// RegisterScenario adds a scenario under name. It panics on a duplicate name,
+// like registering the same flag twice.
+func RegisterScenario(name string, meta ScenarioMeta, generator Generator) {
+	registerScenario(name, meta, generator, nil)
+}
+
+func registerScenario(name string, meta ScenarioMeta, generator Generator, dirty func(map[string]string) []int) {
+	if meta.Rows == 0 {
+		meta.Rows = 40
+	}
+	if meta.Cols == 0 {
+		meta.Cols = 120
+	}
+	params := make([]ParamSpec, len(meta.Params))
+	for i, p := range meta.Params {
+		switch p.Type {
+		case "":
+			p.Type = "int"
+		case "int", "bool":
+		default:
+			panic(fmt.Sprintf("harness: scenario %q parameter %q has unknown type %q", name, p.Name, p.Type))
+		}
+		params[i] = p
+	}
+	meta.Params = params
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.scenarios[name]; ok {
+		panic(fmt.Sprintf("harness: scenario %q registered twice", name))
+	}
+	registry.scenarios[name] = registeredScenario{meta: meta, generator: generator, dirty: dirty}
+}
+
+func lookupScenario(name string) (registeredScenario, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	s, ok := registry.scenarios[name]
+	return s, ok
+}
+
+// Scenarios lists every registered scenario sorted by name.
+func Scenarios() []ScenarioInfo {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	out := make([]ScenarioInfo, 0, len(registry.scenarios))
+	for name, s := range registry.scenarios {
+		info := ScenarioInfo{Name: name, ScenarioMeta: s.meta}
+		if info.Params == nil {
+			info.Params = []ParamSpec{}
+		}
+		if s.meta.ViewportRows != nil || s.meta.Screen {
+			info.Rows, info.Cols = viewportOf(s.meta, map[string]string{})
+			info.ViewportFromParams = true
+		}
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// ValidateScenario checks that scenario is registered and that params only
+// names parameters it declares, each with a value in its declared range.
+func ValidateScenario(scenario string, params map[string]string) error {
+	s, ok := lookupScenario(scenario)
+	if !ok {
+		return fmt.Errorf("unknown scenario %q (run list to see them)", scenario)
+	}
+	declared := make(map[string]ParamSpec, len(s.meta.Params))
+	names := make([]string, 0, len(s.meta.Params))
+	for _, p := range s.meta.Params {
+		declared[p.Name] = p
+		names = append(names, p.Name)
+	}
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		spec, ok := declared[key]
+		if !ok {
+			if len(names) == 0 {
+				return fmt.Errorf("scenario %q takes no parameters (got --%s)", scenario, key)
+			}
+			return fmt.Errorf("scenario %q has no parameter --%s (expected %s)", scenario, key, strings.Join(names, "|"))
+		}
+		if err := spec.check(scenario, params[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScenarioLines is the logical frame a scenario shows at tick of a run seeded
+// with seed, one string per row. Every harness renders the same content so
+// results stay comparable.
+func ScenarioLines(scenario string, params map[string]string, seed uint64, tick int, cols int) []string {
+	s, ok := lookupScenario(scenario)
+	if !ok {
+		return []string{clipPad(fmt.Sprintf("unsupported scenario: %s", scenario), cols)}
+	}
+	return s.generator(params, tick, cols, NewFrameRand(seed, tick))
+}
+
+func scenarioViewport(scenario string, params map[string]string) (int, int) {
+	s, ok := lookupScenario(scenario)
+	if !ok {
+		return 40, 120
+	}
+	return viewportOf(s.meta, params)
+}
+
+func viewportOf(meta ScenarioMeta, params map[string]string) (int, int) {
+	if meta.Screen {
+		return intParam(params, rowsParam.Name, rowsParam.Default), intParam(params, colsParam.Name, colsParam.Default)
+	}
+	rows := meta.Rows
+	if meta.ViewportRows != nil {
+		rows = meta.ViewportRows(params)
+	}
+	return rows, meta.Cols
+}
+
+func usesEventLoopScheduling(scenario string) bool {
+	s, ok := lookupScenario(scenario)
+	return ok && s.meta.EventLoop
+}
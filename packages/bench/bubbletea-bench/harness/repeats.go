@@ -0,0 +1,105 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// runSpread is one metric across repeated runs. Spread is the range relative
+// to the median, so 0.1 means the slowest and fastest runs are 10% apart.
+type runSpread struct {
+	Median float64   `json:"median"`
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Spread float64   `json:"spread"`
+	Runs   []float64 `json:"runs"`
+}
+
+func newRunSpread(values []float64) runSpread {
+	s := summarize(values)
+	out := runSpread{Median: s.Median, Min: s.Min, Max: s.Max, Runs: append([]float64{}, values...)}
+	if s.Median > 0 {
+		out.Spread = (s.Max - s.Min) / s.Median
+	}
+	return out
+}
+
+// RepeatAggregate summarizes the successful runs of a --repeats invocation.
+// Gate on the median-of-runs; Spread says how far to trust it.
+type RepeatAggregate struct {
+	Runs   int `json:"runs"`
+	Failed int `json:"failed"`
+
+	MeanMs        runSpread `json:"meanMs"`
+	MedianMs      runSpread `json:"medianMs"`
+	P95Ms         runSpread `json:"p95Ms"`
+	P99Ms         runSpread `json:"p99Ms"`
+	TotalWallMs   runSpread `json:"totalWallMs"`
+	FramesPerSec  runSpread `json:"framesPerSecond"`
+	BytesPerFrame runSpread `json:"bytesPerFrame"`
+	CPUMsPerFrame runSpread `json:"cpuMsPerFrame"`
+	RSSPeakKb     runSpread `json:"rssPeakKb"`
+}
+
+func aggregateRuns(runs []ResultFile) *RepeatAggregate {
+	var mean, p50, p95, p99, wall, fps, bytes, cpu, rss []float64
+	agg := &RepeatAggregate{Runs: len(runs)}
+	for _, run := range runs {
+		if !run.OK || run.Data == nil {
+			agg.Failed++
+			continue
+		}
+		d := run.Data
+		mean = append(mean, d.Summary.Mean)
+		p50 = append(p50, d.Summary.Median)
+		p95 = append(p95, d.Summary.P95)
+		p99 = append(p99, d.Summary.P99)
+		wall = append(wall, d.TotalWallMs)
+		fps = append(fps, d.FramesPerSecond)
+		bytes = append(bytes, d.bytesPerFrame())
+		cpu = append(cpu, d.cpuMsPerFrame())
+		rss = append(rss, float64(d.RSSPeakKb))
+	}
+	agg.MeanMs = newRunSpread(mean)
+	agg.MedianMs = newRunSpread(p50)
+	agg.P95Ms = newRunSpread(p95)
+	agg.P99Ms = newRunSpread(p99)
+	agg.TotalWallMs = newRunSpread(wall)
+	agg.FramesPerSec = newRunSpread(fps)
+	agg.BytesPerFrame = newRunSpread(bytes)
+	agg.CPUMsPerFrame = newRunSpread(cpu)
+	agg.RSSPeakKb = newRunSpread(rss)
+	return agg
+}
+
+// RunRepeated runs cfg.Repeats independent measured loops, each with fresh
+// sessions and sinks, and aggregates them. With Repeats <= 1 it is a plain
+// RunScenario. Interrupted before any run starts, it fails with the
+// context's error.
+func RunRepeated(ctx context.Context, cfg Config) ResultFile {
+	if cfg.Repeats <= 1 {
+		data, err := RunScenario(ctx, cfg)
+		return NewResultFile(data, err)
+	}
+	runs := make([]ResultFile, 0, cfg.Repeats)
+	for i := 0; i < cfg.Repeats; i++ {
+		// Repeats not yet started when interrupted are dropped rather than
+		// failed, so the aggregate covers the runs that did happen.
+		if ctx.Err() != nil {
+			break
+		}
+		debug.FreeOSMemory()
+		data, err := RunScenario(ctx, cfg)
+		runs = append(runs, NewResultFile(data, err))
+	}
+	if len(runs) == 0 {
+		return NewResultFile(Result{}, ctx.Err())
+	}
+	agg := aggregateRuns(runs)
+	out := ResultFile{OK: agg.Failed == 0, Runs: runs, Aggregate: agg, Interrupted: ctx.Err() != nil}
+	if agg.Failed > 0 {
+		out.Error = fmt.Sprintf("%d of %d runs failed", agg.Failed, agg.Runs)
+	}
+	return out
+}
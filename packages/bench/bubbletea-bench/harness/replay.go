@@ -0,0 +1,102 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TickRange is a run of consecutive ticks rendered in one phase, First to
+// Last inclusive.
+type TickRange struct {
+	Phase string `json:"phase"`
+	First int    `json:"first"`
+	Last  int    `json:"last"`
+}
+
+// ReplayInfo is the "replay" field of a Result: everything that decided
+// what the program drew, so verify --replay can render exactly the frames
+// the run did. Ticks are in render order; before --outlier-policy trim,
+// sample i of samplesMs was rendered at the i-th tick of the "measure"
+// ranges. With --sessions they are the first session's.
+type ReplayInfo struct {
+	Scenario string            `json:"scenario"`
+	Params   map[string]string `json:"params"`
+	Seed     uint64            `json:"seed"`
+	Ticks    []TickRange       `json:"ticks"`
+}
+
+func newReplayInfo(cfg Config, ticks []TickRange) *ReplayInfo {
+	params := map[string]string{}
+	for key, value := range cfg.Params {
+		params[key] = value
+	}
+	return &ReplayInfo{Scenario: cfg.Scenario, Params: params, Seed: cfg.Seed, Ticks: ticks}
+}
+
+// tickRanges is the sequence the loops render: tick 0 first when initial,
+// then warmup ticks from 1 and the cold and measured ticks straight after.
+// Empty phases are left out.
+func tickRanges(initial bool, warmup int, cold int, measured int) []TickRange {
+	ranges := []TickRange{}
+	if initial {
+		ranges = append(ranges, TickRange{Phase: "initial", First: 0, Last: 0})
+	}
+	next := 1
+	for _, phase := range []struct {
+		name  string
+		count int
+	}{{"warmup", warmup}, {"cold", cold}, {"measure", measured}} {
+		if phase.count > 0 {
+			ranges = append(ranges, TickRange{Phase: phase.name, First: next, Last: next + phase.count - 1})
+			next += phase.count
+		}
+	}
+	return ranges
+}
+
+// TickList expands the ranges into the ticks in render order.
+func (r ReplayInfo) TickList() []int {
+	ticks := []int{}
+	for _, span := range r.Ticks {
+		for tick := span.First; tick <= span.Last; tick++ {
+			ticks = append(ticks, tick)
+		}
+	}
+	return ticks
+}
+
+// LoadReplay reads the ReplayInfo of the run in a result file. A file with
+// several runs, such as a suite, needs scenario to pick one; the last run of
+// it is used.
+func LoadReplay(path string, scenario string) (ReplayInfo, error) {
+	docs, err := ReadResults(path)
+	if err != nil {
+		return ReplayInfo{}, fmt.Errorf("read --replay: %w", err)
+	}
+	var found []ReplayInfo
+	var collect func(doc ResultFile)
+	collect = func(doc ResultFile) {
+		for _, nested := range append(append([]ResultFile{}, doc.Suite...), doc.Runs...) {
+			collect(nested)
+		}
+		if doc.Data != nil && doc.Data.Replay != nil && (scenario == "" || doc.Data.Replay.Scenario == scenario) {
+			found = append(found, *doc.Data.Replay)
+		}
+	}
+	for _, doc := range docs {
+		collect(doc)
+	}
+	if len(found) == 0 {
+		if scenario != "" {
+			return ReplayInfo{}, fmt.Errorf("--replay %s has no recorded ticks for %s", path, scenario)
+		}
+		return ReplayInfo{}, fmt.Errorf("--replay %s has no recorded ticks", path)
+	}
+	info := found[len(found)-1]
+	for _, other := range found {
+		if scenario == "" && other.Scenario != info.Scenario {
+			return ReplayInfo{}, errors.New("--replay file has several scenarios; pick one with --scenario")
+		}
+	}
+	return info, nil
+}
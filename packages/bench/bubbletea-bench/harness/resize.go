@@ -0,0 +1,144 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// ResizeStep resizes the terminal before measured iteration At, counted
+// from 0 like the "iteration" field of stream records.
+type ResizeStep struct {
+	At   int
+	Cols int
+	Rows int
+}
+
+// ResizableSession is a Session whose program can be told the terminal
+// changed size, for --resize-schedule.
+type ResizableSession interface {
+	Session
+	Resize(rows int, cols int) error
+}
+
+// resizeFrame is one entry of the "resizes" field of a Result: a frame
+// that followed a scheduled resize. ReflowMs runs from the resize to the
+// frame being written, so it covers the full repaint a resize forces;
+// RatioToMedian compares it with the run's median frame.
+type resizeFrame struct {
+	Iteration     int     `json:"iteration"`
+	Tick          int     `json:"tick"`
+	Cols          int     `json:"cols"`
+	Rows          int     `json:"rows"`
+	ReflowMs      float64 `json:"reflowMs"`
+	Bytes         int64   `json:"bytes"`
+	RatioToMedian float64 `json:"ratioToMedian"`
+}
+
+// ParseResizeSchedule reads a --resize-schedule value: comma-separated
+// iteration:COLSxROWS steps, like "100:80x24,200:200x50".
+func ParseResizeSchedule(spec string) ([]ResizeStep, error) {
+	steps := []ResizeStep{}
+	for _, part := range strings.Split(spec, ",") {
+		at, size, ok := strings.Cut(strings.TrimSpace(part), ":")
+		cols, rows, okSize := strings.Cut(size, "x")
+		step := ResizeStep{}
+		var errAt, errCols, errRows error
+		step.At, errAt = strconv.Atoi(at)
+		step.Cols, errCols = strconv.Atoi(cols)
+		step.Rows, errRows = strconv.Atoi(rows)
+		if !ok || !okSize || errors.Join(errAt, errCols, errRows) != nil || step.At < 0 ||
+			step.Cols < 1 || step.Rows < 1 || step.Cols > 65535 || step.Rows > 65535 {
+			return nil, fmt.Errorf("invalid --resize-schedule step %q (expected iteration:COLSxROWS)", part)
+		}
+		steps = append(steps, step)
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].At < steps[j].At })
+	for i := 1; i < len(steps); i++ {
+		if steps[i].At == steps[i-1].At {
+			return nil, fmt.Errorf("--resize-schedule resizes twice at iteration %d", steps[i].At)
+		}
+	}
+	return steps, nil
+}
+
+// resizer applies a schedule to a session and the sink behind it.
+type resizer struct {
+	steps   []ResizeStep
+	session ResizableSession
+	output  benchOutput
+	frames  []resizeFrame
+}
+
+func newResizer(steps []ResizeStep, session Session, output benchOutput) (*resizer, error) {
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	resizable, ok := session.(ResizableSession)
+	if !ok {
+		return nil, errors.New("harness: session does not support --resize-schedule")
+	}
+	return &resizer{steps: steps, session: resizable, output: output, frames: []resizeFrame{}}, nil
+}
+
+// before resizes if a step is due at iteration i, returning when it started
+// and whether it did.
+func (r *resizer) before(i int) (time.Time, bool, error) {
+	if r == nil || len(r.steps) == 0 || r.steps[0].At != i {
+		return time.Time{}, false, nil
+	}
+	step := r.steps[0]
+	r.steps = r.steps[1:]
+	start := time.Now()
+	if err := r.output.sink.resize(step.Rows, step.Cols); err != nil {
+		return start, true, fmt.Errorf("resize terminal to %dx%d: %w", step.Cols, step.Rows, err)
+	}
+	if err := r.session.Resize(step.Rows, step.Cols); err != nil {
+		return start, true, err
+	}
+	r.frames = append(r.frames, resizeFrame{Iteration: i, Cols: step.Cols, Rows: step.Rows})
+	return start, true, nil
+}
+
+// after records the frame rendered since the resize that started at start.
+func (r *resizer) after(tick int, start time.Time, bytes int64) {
+	frame := &r.frames[len(r.frames)-1]
+	frame.Tick = tick
+	frame.ReflowMs = MsSince(start)
+	frame.Bytes = bytes
+}
+
+func (r *resizer) result(samples []float64) []resizeFrame {
+	if r == nil {
+		return nil
+	}
+	if m := median(samples); m > 0 {
+		for i := range r.frames {
+			r.frames[i].RatioToMedian = r.frames[i].ReflowMs / m
+		}
+	}
+	return r.frames
+}
+
+// resize sets the terminal size of a PTY sink, or of the outer PTY tmux
+// draws into, the TIOCSWINSZ a real terminal issues when its window
+// changes; the kernel signals SIGWINCH to whatever is attached. Other
+// sinks have no size.
+func (s *outputSink) resize(rows int, cols int) error {
+	if s == nil {
+		return nil
+	}
+	if s.vt != nil {
+		s.vt.term.Resize(rows, cols)
+	}
+	switch s.kind {
+	case "pty", "tmux":
+		return pty.Setsize(s.reader, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)})
+	}
+	return nil
+}
@@ -0,0 +1,469 @@
+package harness
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"slices"
+	"time"
+)
+
+// Result is the data of a successful run, serialized as the "data" field of
+// a ResultFile.
+type Result struct {
+	// Replay is what the run rendered, for verify --replay.
+	Replay *ReplayInfo `json:"replay,omitempty"`
+
+	SamplesMs              []float64 `json:"samplesMs"`
+	UpdateSamplesMs        []float64 `json:"updateSamplesMs"`
+	ViewSamplesMs          []float64 `json:"viewSamplesMs"`
+	FlushSamplesMs         []float64 `json:"flushSamplesMs"`
+	MissingFlushes         int       `json:"missingFlushes,omitempty"`
+	WriteBlockSamplesMs    []float64 `json:"writeBlockSamplesMs"`
+	WriteBlockMaxSamplesMs []float64 `json:"writeBlockMaxSamplesMs"`
+	WriteBlockTotalMs      float64   `json:"writeBlockTotalMs"`
+	TotalWallMs            float64   `json:"totalWallMs"`
+	CPUUserMs              float64   `json:"cpuUserMs"`
+	CPUSysMs               float64   `json:"cpuSysMs"`
+	MinorFaults            int64     `json:"minorFaults"`
+	MajorFaults            int64     `json:"majorFaults"`
+	VolCtxSwitches         int64     `json:"voluntaryCtxSwitches"`
+	InvolCtxSwitches       int64     `json:"involuntaryCtxSwitches"`
+	VolCtxSwPerFrame       float64   `json:"voluntaryCtxSwitchesPerFrame"`
+	InvolCtxSwPerFrame     float64   `json:"involuntaryCtxSwitchesPerFrame"`
+	RSSBeforeKb            int64     `json:"rssBeforeKb"`
+	RSSAfterKb             int64     `json:"rssAfterKb"`
+	RSSPeakKb              int64     `json:"rssPeakKb"`
+	HeapBeforeKb           int64     `json:"heapBeforeKb"`
+	HeapAfterKb            int64     `json:"heapAfterKb"`
+	HeapPeakKb             int64     `json:"heapPeakKb"`
+	BytesWritten           int64     `json:"bytesWritten"`
+	Seed                   uint64    `json:"seed"`
+	Interrupted            bool      `json:"interrupted,omitempty"`
+	Frames                 int       `json:"frames"`
+	FramesPerSecond        float64   `json:"framesPerSecond"`
+	DurationBudgetMs       float64   `json:"durationBudgetMs,omitempty"`
+	CooldownMs             float64   `json:"cooldownMs,omitempty"`
+	CooldownTotalMs        float64   `json:"cooldownTotalMs,omitempty"`
+	ThreadsBefore          int64     `json:"threadsBefore"`
+	ThreadsAfter           int64     `json:"threadsAfter"`
+	ThreadsPeak            int64     `json:"threadsPeak"`
+	GoroutinesAfter        int       `json:"goroutinesAfter"`
+	GOMAXPROCS             int       `json:"gomaxprocs"`
+	GOGC                   string    `json:"gogc"`
+	NumCPU                 int       `json:"numCpu"`
+
+	// ProgramUsage marks the CPU, fault, context-switch and RSS figures as
+	// those of a ProcessSession's own process rather than the harness's.
+	// The program's heap is not known, so the heap figures are zero.
+	ProgramUsage bool `json:"programUsage,omitempty"`
+
+	// BetweenIterations and ScratchMB are the --between-iterations policy
+	// and --scratch-mb buffer applied between measured iterations of the
+	// startup and steady-state loops; SettleTotalMs is the time they took,
+	// outside the samples.
+	BetweenIterations string  `json:"betweenIterations,omitempty"`
+	ScratchMB         int     `json:"scratchMb,omitempty"`
+	SettleTotalMs     float64 `json:"settleTotalMs,omitempty"`
+
+	CgroupMemory        *cgroupMemoryResult `json:"cgroupMemory,omitempty"`
+	MemoryLimit         *memoryLimitResult  `json:"memoryLimit,omitempty"`
+	Load                *loadResult         `json:"load,omitempty"`
+	Pacing              *pacingResult       `json:"pacing,omitempty"`
+	Soak                *soakResult         `json:"soak,omitempty"`
+	Throughput          *throughputResult   `json:"throughput,omitempty"`
+	Sessions            *sessionsResult     `json:"sessions,omitempty"`
+	Resizes             []resizeFrame       `json:"resizes,omitempty"`
+	Timer               timerCalibration    `json:"timer"`
+	Capabilities        *Capabilities       `json:"capabilities,omitempty"`
+	Outliers            []outlierSample     `json:"outliers"`
+	Warmup              warmupReport        `json:"warmup"`
+	ChangedCellSamples  []int               `json:"changedCellSamples"`
+	ChangedCells        int64               `json:"changedCells"`
+	BytesPerChangedCell float64             `json:"bytesPerChangedCell"`
+
+	// Sink is the --io output sink. SinkBytes is what reached it over the
+	// whole run, warmup included: drained from the pipe, or the final file
+	// size. PtyBytesRead is the same for the PTY master, after
+	// line-discipline output processing.
+	Sink         string `json:"sink"`
+	SinkBytes    int64  `json:"sinkBytes,omitempty"`
+	PtyBytesRead int64  `json:"ptyBytesRead,omitempty"`
+
+	Nested   *nestedResult   `json:"nested,omitempty"`
+	Consumer *consumerResult `json:"consumer,omitempty"`
+
+	Throttle *throttleResult `json:"throttle,omitempty"`
+	Latency  *latencyResult  `json:"latency,omitempty"`
+
+	Backpressure *backpressureResult `json:"backpressure,omitempty"`
+	ShortWrites  *shortWriteResult   `json:"shortWrites,omitempty"`
+
+	RecordPath     string `json:"recordPath,omitempty"`
+	SamplesCSVPath string `json:"samplesCsvPath,omitempty"`
+	ArchivePath    string `json:"archivePath,omitempty"`
+
+	Stream  *streamResult `json:"stream,omitempty"`
+	Records *streamResult `json:"records,omitempty"`
+
+	Summary sampleSummary `json:"summary"`
+	Cold    *coldReport   `json:"cold,omitempty"`
+	Retries *retryReport  `json:"retries,omitempty"`
+
+	// OutlierPolicy is the --outlier-policy Summary was computed under and
+	// OutliersAdjusted how many samples it trimmed or clamped.
+	OutlierPolicy    string `json:"outlierPolicy"`
+	OutliersAdjusted int    `json:"outliersAdjusted"`
+
+	// FrameWrites segments steady-state output into frames by write time.
+	FrameWrites []frameWrites `json:"frameWrites,omitempty"`
+
+	// writeErrors is reported next to the data rather than inside it.
+	writeErrors *WriteErrorReport
+}
+
+// sampleSummary mirrors computeStats in packages/bench/src/measure.ts so Go-side
+// summaries agree with what the TypeScript runner derives from samplesMs.
+type sampleSummary struct {
+	N      int     `json:"n"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P95    float64 `json:"p95"`
+	P99    float64 `json:"p99"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Stddev float64 `json:"stddev"`
+	CV     float64 `json:"cv"`
+
+	// CI is only computed for the headline summary of a Result. CISkipped
+	// says why it is missing when there were too many samples to resample.
+	CI        *summaryCI `json:"ci,omitempty"`
+	CISkipped string     `json:"ciSkipped,omitempty"`
+}
+
+// summaryCI holds percentile bootstrap confidence intervals at Level for
+// the mean and p95 of the samples a summary was computed from: how far
+// either could move on a rerun of the same length, to size a delta against.
+type summaryCI struct {
+	Level     float64 `json:"level"`
+	Resamples int     `json:"resamples"`
+	MeanLow   float64 `json:"meanLow"`
+	MeanHigh  float64 `json:"meanHigh"`
+	P95Low    float64 `json:"p95Low"`
+	P95High   float64 `json:"p95High"`
+}
+
+type throttleResult struct {
+	BitsPerSec    int64   `json:"bitsPerSec"`
+	WaitMs        float64 `json:"waitMs"`
+	DelayedWrites int64   `json:"delayedWrites"`
+}
+
+type latencyResult struct {
+	LatencyMs     float64 `json:"latencyMs"`
+	JitterMs      float64 `json:"jitterMs"`
+	DelayedWrites int64   `json:"delayedWrites"`
+	TotalDelayMs  float64 `json:"totalDelayMs"`
+	MaxDelayMs    float64 `json:"maxDelayMs"`
+}
+
+type backpressureResult struct {
+	CapacityBytes      int     `json:"capacityBytes"`
+	BlockedMs          float64 `json:"blockedMs"`
+	BlockedWrites      int64   `json:"blockedWrites"`
+	MaxQueueBytes      int     `json:"maxQueueBytes"`
+	ReaderStalls       int64   `json:"readerStalls"`
+	ReaderStallMs      float64 `json:"readerStallMs"`
+	ReaderStallEveryMs float64 `json:"readerStallEveryMs"`
+}
+
+// shortWriteResult reports injected short writes. A short write counts as
+// retried when the program's next write starts with the bytes that were cut
+// off; anything else is LostBytes, i.e. output silently dropped.
+type shortWriteResult struct {
+	Probability   float64 `json:"probability"`
+	Writes        int64   `json:"writes"`
+	ShortWrites   int64   `json:"shortWrites"`
+	RetriedWrites int64   `json:"retriedWrites"`
+	CutBytes      int64   `json:"cutBytes"`
+	LostBytes     int64   `json:"lostBytes"`
+}
+
+// WriteErrorReport classifies how the program handled injected write errors:
+// "recovered" means every remaining tick still rendered, "hung" means a tick
+// timed out, and "exited" means the program stopped on its own.
+type WriteErrorReport struct {
+	Outcome            string               `json:"outcome"`
+	Injected           []injectedWriteError `json:"injected"`
+	WritesAfterFailure int64                `json:"writesAfterFailure"`
+	Error              string               `json:"error,omitempty"`
+}
+
+// nestedResult compares what the program wrote into a multiplexer pane with
+// what the multiplexer re-emitted to its own client terminal.
+type nestedResult struct {
+	Multiplexer   string  `json:"multiplexer"`
+	Version       string  `json:"version"`
+	InnerBytes    int64   `json:"innerBytes"`
+	OuterBytes    int64   `json:"outerBytes"`
+	Amplification float64 `json:"amplification"`
+}
+
+// frameWrites is one frame's write timeline. A frame owns every write from
+// the end of its View until the next frame's View ends, which is when the
+// renderer can next pick up new output. AtMs is relative to that View end;
+// LateBytes arrived after the harness had already moved on to the next tick.
+type frameWrites struct {
+	Tick      int          `json:"tick"`
+	Bytes     int64        `json:"bytes"`
+	LateBytes int64        `json:"lateBytes"`
+	Writes    []frameWrite `json:"writes"`
+}
+
+type frameWrite struct {
+	AtMs  float64 `json:"atMs"`
+	Bytes int     `json:"bytes"`
+}
+
+// consumerResult reports the emulated terminal on the PTY master. PacedMs is
+// time spent waiting out the cell budget, ParseMs time inside the emulator.
+type consumerResult struct {
+	CellsPerSecond int64   `json:"cellsPerSecond"`
+	Bytes          int64   `json:"bytes"`
+	Cells          int64   `json:"cells"`
+	PacedMs        float64 `json:"pacedMs"`
+	ParseMs        float64 `json:"parseMs"`
+}
+
+// coldReport holds the first --cold-frames measured frames. They are excluded
+// from samplesMs and summary, and their bytes, CPU time, faults, context
+// switches and changed cells from the run's totals and per-frame ratios,
+// which then describe the warm frames alone. Per-iteration arrays still
+// cover every measured frame, with the cold frames first, and frames,
+// totalWallMs and framesPerSecond the whole measured loop.
+type coldReport struct {
+	Frames           int           `json:"frames"`
+	SamplesMs        []float64     `json:"samplesMs"`
+	Summary          sampleSummary `json:"summary"`
+	BytesWritten     int64         `json:"bytesWritten"`
+	CPUUserMs        float64       `json:"cpuUserMs"`
+	CPUSysMs         float64       `json:"cpuSysMs"`
+	MinorFaults      int64         `json:"minorFaults"`
+	MajorFaults      int64         `json:"majorFaults"`
+	VolCtxSwitches   int64         `json:"voluntaryCtxSwitches"`
+	InvolCtxSwitches int64         `json:"involuntaryCtxSwitches"`
+	ChangedCells     int64         `json:"changedCells"`
+}
+
+// warmupReport traces how warmup frame times settled. Convergence is the first
+// warmup frame at which the rolling coefficient of variation over the last
+// Window samples drops to CVThreshold or below. In auto mode warmup stops
+// there, so Frames is how many warmup frames were used, capped at Max.
+type warmupReport struct {
+	Mode            string    `json:"mode"`
+	Max             int       `json:"max,omitempty"`
+	Frames          int       `json:"frames"`
+	Window          int       `json:"window"`
+	CVThreshold     float64   `json:"cvThreshold"`
+	Converged       bool      `json:"converged"`
+	ConvergedAt     int       `json:"convergedAt"`
+	SamplesMs       []float64 `json:"samplesMs"`
+	RollingVariance []float64 `json:"rollingVariance"`
+	RollingCV       []float64 `json:"rollingCv"`
+}
+
+type outlierSample struct {
+	Iteration    int     `json:"iteration"`
+	Tick         int     `json:"tick"`
+	SampleMs     float64 `json:"sampleMs"`
+	MedianRatio  float64 `json:"medianRatio"`
+	BytesWritten int64   `json:"bytesWritten"`
+	// GCCycles is how many GC cycles completed during the iteration.
+	GCCycles uint64 `json:"gcCycles"`
+}
+
+// timerCalibration annotates samples with the cost and granularity of the
+// clock used to take them. SampleOverheadNs is the fixed cost a single
+// time.Now + MsSince pair adds to every sample.
+type timerCalibration struct {
+	ClockSource      string  `json:"clockSource"`
+	NowOverheadNs    float64 `json:"nowOverheadNs"`
+	SinceOverheadNs  float64 `json:"sinceOverheadNs"`
+	SampleOverheadNs float64 `json:"sampleOverheadNs"`
+	ResolutionNs     int64   `json:"resolutionNs"`
+	SampleQuantumNs  int64   `json:"sampleQuantumNs"`
+}
+
+// cgroupMemoryResult is the cgroup v2 memory accounting of a run. PeakSource
+// names where PeakKb came from: "memory.peak" reset at the start of the run,
+// or "memory.current" sampled through it where the kernel cannot reset the
+// peak.
+type cgroupMemoryResult struct {
+	Path              string  `json:"path"`
+	CurrentBeforeKb   int64   `json:"currentBeforeKb"`
+	CurrentAfterKb    int64   `json:"currentAfterKb"`
+	PeakKb            int64   `json:"peakKb"`
+	PeakSource        string  `json:"peakSource"`
+	SomeStallUs       int64   `json:"someStallUs"`
+	FullStallUs       int64   `json:"fullStallUs"`
+	SomeStallAvg10Pct float64 `json:"someStallAvg10Pct"`
+}
+
+// memoryLimitResult reports a run under a Go soft memory limit (--memlimit or
+// GOMEMLIMIT), sampled after every measured iteration. A sample is pressured
+// when the limit, not GOGC, set the heap goal; FirstPressure* is the first
+// such sample of the measured run, -1 and 0 when there was none.
+type memoryLimitResult struct {
+	LimitBytes             int64   `json:"limitBytes"`
+	PeakBytes              int64   `json:"peakBytes"`
+	GCCycles               uint64  `json:"gcCycles"`
+	Samples                int     `json:"samples"`
+	PressuredSamples       int     `json:"pressuredSamples"`
+	FirstPressureIteration int     `json:"firstPressureIteration"`
+	FirstPressureMs        float64 `json:"firstPressureMs"`
+}
+
+// ResultFile is the JSON document a harness binary emits for a run. A suite
+// run nests one document per scenario in Suite, each naming its Scenario; a
+// repeated run nests one per repeat in Runs next to their Aggregate. Only the
+// top-level document sets SchemaVersion.
+type ResultFile struct {
+	SchemaVersion int `json:"schemaVersion,omitempty"`
+
+	// Type is "summary" when the document ends an NDJSON record stream.
+	Type string   `json:"type,omitempty"`
+	Run  *RunInfo `json:"run,omitempty"`
+
+	// Interrupted marks a run cut short by its context, such as on SIGINT.
+	// Whatever was measured up to then is still reported.
+	Interrupted bool `json:"interrupted,omitempty"`
+
+	OK           bool                `json:"ok"`
+	Scenario     string              `json:"scenario,omitempty"`
+	Suite        []ResultFile        `json:"suite,omitempty"`
+	Runs         []ResultFile        `json:"runs,omitempty"`
+	Aggregate    *RepeatAggregate    `json:"aggregate,omitempty"`
+	Data         *Result             `json:"data,omitempty"`
+	Verify       *VerifyReport       `json:"verify,omitempty"`
+	SelfTest     *SelfTestReport     `json:"selftest,omitempty"`
+	ExportFrames *FrameExportReport  `json:"exportFrames,omitempty"`
+	Fixtures     *FrameExportReport  `json:"fixtures,omitempty"`
+	Parity       *ParityReport       `json:"parity,omitempty"`
+	Sweep        *SweepReport        `json:"sweep,omitempty"`
+	Fuzz         *FuzzReport         `json:"fuzz,omitempty"`
+	Validate     *ValidateReport     `json:"validate,omitempty"`
+	Gate         *GateReport         `json:"gate,omitempty"`
+	Assert       *AssertReport       `json:"assert,omitempty"`
+	History      *HistoryReport      `json:"history,omitempty"`
+	Diff         *DiffReport         `json:"diff,omitempty"`
+	Fleet        *FleetReport        `json:"fleet,omitempty"`
+	Scenarios    []ScenarioInfo      `json:"scenarios,omitempty"`
+	Suites       map[string][]string `json:"suites,omitempty"`
+	WriteErrors  *WriteErrorReport   `json:"writeErrors,omitempty"`
+	OutputTail   *OutputTail         `json:"outputTail,omitempty"`
+	Cgroup       *CgroupReport       `json:"cgroup,omitempty"`
+	Error        string              `json:"error,omitempty"`
+
+	// ErrorKind classifies Error: panic, hang, exited, interrupted or error.
+	ErrorKind string       `json:"errorKind,omitempty"`
+	Crash     *CrashReport `json:"crash,omitempty"`
+
+	// Labels are free-form tags the caller attached to the run.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// NewResultFile builds the document for a RunScenario outcome.
+func NewResultFile(data Result, err error) ResultFile {
+	if err == nil {
+		return ResultFile{OK: true, Data: &data, WriteErrors: data.writeErrors, Interrupted: data.Interrupted}
+	}
+	payload := ResultFile{OK: false, Error: err.Error(), ErrorKind: errorKind(err), Interrupted: errors.Is(err, context.Canceled)}
+	var runErr *RunError
+	if errors.As(err, &runErr) {
+		payload.WriteErrors = runErr.writeErrors
+		payload.OutputTail = runErr.tail
+	}
+	var crash *CrashError
+	if errors.As(err, &crash) {
+		report := crash.report
+		payload.Crash = &report
+	}
+	return payload
+}
+
+// RunInfo identifies one harness invocation. ID joins the result to the
+// stream records, archive and history rows of the same run; StartedAt and
+// EndedAt are wall-clock, while MonotonicMs is the same span measured on the
+// monotonic clock, which wall-clock steps cannot skew. Modules maps each
+// benched framework module linked into the binary to its resolved version,
+// so results from different harness builds can be told apart.
+type RunInfo struct {
+	ID          string            `json:"id"`
+	StartedAt   time.Time         `json:"startedAt"`
+	EndedAt     time.Time         `json:"endedAt"`
+	MonotonicMs float64           `json:"monotonicMs"`
+	Modules     map[string]string `json:"modules,omitempty"`
+
+	// Host is the machine's hostname, which aggregate groups results by.
+	Host string `json:"host,omitempty"`
+
+	start time.Time
+}
+
+// NewRunInfo starts a run with a fresh random (version 4) UUID, recording
+// the host and the versions of the benched framework modules.
+func NewRunInfo(modules []string) *RunInfo {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	now := time.Now()
+	host, _ := os.Hostname()
+	return &RunInfo{
+		ID:        fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16]),
+		StartedAt: now.Round(0),
+		Modules:   frameworkModules(modules),
+		Host:      host,
+		start:     now,
+	}
+}
+
+// frameworkModules reads the versions of modules from the binary's build
+// info. A replaced module reports its replacement, as "version => path" for
+// a local directory, since that is the code that was benched.
+func frameworkModules(modules []string) map[string]string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+	out := map[string]string{}
+	for _, dep := range info.Deps {
+		if !slices.Contains(modules, dep.Path) {
+			continue
+		}
+		version := dep.Version
+		if dep.Replace != nil {
+			version = dep.Replace.Version
+			if version == "" {
+				version = dep.Version + " => " + dep.Replace.Path
+			}
+		}
+		out[dep.Path] = version
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// Ended is a copy of r closed at the current time.
+func (r *RunInfo) Ended() *RunInfo {
+	out := *r
+	now := time.Now()
+	out.EndedAt = now.Round(0)
+	out.MonotonicMs = nsToMs(now.Sub(r.start).Nanoseconds())
+	return &out
+}
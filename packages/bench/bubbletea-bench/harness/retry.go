@@ -0,0 +1,79 @@
+package harness
+
+import "errors"
+
+// maxReportedRetries caps the per-iteration list in a retryReport; the
+// counts still cover every retry.
+const maxReportedRetries = 100
+
+// retryReport is the "retries" field of a Result run with --retries: which
+// iterations failed transiently and were measured again instead of aborting
+// the run.
+type retryReport struct {
+	Limit      int                `json:"limit"`
+	Iterations int                `json:"iterations"`
+	Retries    int                `json:"retries"`
+	Retried    []retriedIteration `json:"retried"`
+}
+
+type retriedIteration struct {
+	Phase    string `json:"phase"`
+	Tick     int    `json:"tick"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// retrier reruns a failed iteration up to limit more times while its error
+// is retryable. A retried iteration keeps only the sample of the attempt that
+// succeeded.
+type retrier struct {
+	limit     int
+	retryable func(error) bool
+	report    retryReport
+}
+
+func newRetrier(limit int, retryable func(error) bool) *retrier {
+	return &retrier{limit: limit, retryable: retryable, report: retryReport{Limit: limit, Retried: []retriedIteration{}}}
+}
+
+// renderRetryable is the steady-state policy: a tick that timed out may
+// still render on a second delivery, a program that exited will not.
+func renderRetryable(err error) bool {
+	return errors.Is(err, ErrRenderTimeout)
+}
+
+// do runs attempt, retrying as allowed, and reports how many retries the
+// iteration took.
+func (r *retrier) do(phase string, tick int, attempt func() error) (int, error) {
+	var first error
+	for n := 0; ; n++ {
+		err := attempt()
+		if err == nil {
+			if n > 0 {
+				r.record(phase, tick, n, first)
+			}
+			return n, nil
+		}
+		if n >= r.limit || !r.retryable(err) {
+			return n, err
+		}
+		if first == nil {
+			first = err
+		}
+	}
+}
+
+func (r *retrier) record(phase string, tick int, retries int, err error) {
+	r.report.Iterations++
+	r.report.Retries += retries
+	if len(r.report.Retried) < maxReportedRetries {
+		r.report.Retried = append(r.report.Retried, retriedIteration{Phase: phase, Tick: tick, Attempts: retries + 1, Error: err.Error()})
+	}
+}
+
+func (r *retrier) result() *retryReport {
+	if r.limit == 0 {
+		return nil
+	}
+	return &r.report
+}
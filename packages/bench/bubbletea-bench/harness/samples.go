@@ -0,0 +1,65 @@
+package harness
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+)
+
+var samplesHeader = []string{"phase", "iteration", "tick", "at_ms", "frame_ms", "bytes", "rss_kb", "heap_kb"}
+
+// sampleTable collects the per-iteration records for --samples-csv and writes
+// them once the run is over, so the render loop never touches the disk.
+// Memory columns are empty on iterations where the loop did not sample it.
+type sampleTable struct {
+	path  string
+	start time.Time
+	rows  []streamRecord
+}
+
+func newSampleTable(path string, capacity int) *sampleTable {
+	return &sampleTable{path: path, start: time.Now(), rows: make([]streamRecord, 0, capacity)}
+}
+
+func (t *sampleTable) add(rec streamRecord) {
+	if t == nil {
+		return
+	}
+	rec.AtMs = MsSince(t.start)
+	t.rows = append(t.rows, rec)
+}
+
+func (t *sampleTable) write() error {
+	file, err := os.Create(t.path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(file)
+	_ = w.Write(samplesHeader)
+	for _, rec := range t.rows {
+		_ = w.Write([]string{
+			rec.Phase,
+			strconv.Itoa(rec.Iteration),
+			strconv.Itoa(rec.Tick),
+			strconv.FormatFloat(rec.AtMs, 'f', 3, 64),
+			strconv.FormatFloat(rec.SampleMs, 'f', 4, 64),
+			strconv.FormatInt(rec.Bytes, 10),
+			optionalKb(rec.RSSKb),
+			optionalKb(rec.HeapKb),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func optionalKb(kb int64) string {
+	if kb == 0 {
+		return ""
+	}
+	return strconv.FormatInt(kb, 10)
+}
@@ -0,0 +1,1266 @@
+package harness
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	startupTreeSize       = 50
+	contentUpdateListSize = 500
+)
+
+var (
+	rowsParam     = ParamSpec{Name: "rows", Default: 40, Min: 16, Max: 1000, Doc: "screen rows"}
+	colsParam     = ParamSpec{Name: "cols", Default: 120, Min: 100, Max: 1000, Doc: "screen columns"}
+	itemsParam    = ParamSpec{Name: "items", Default: 100000, Min: 1, Doc: "list length"}
+	viewportParam = ParamSpec{Name: "viewport", Default: 40, Min: 1, Max: 1000, Doc: "visible list rows"}
+	servicesParam = ParamSpec{Name: "services", Default: 24, Min: 1, Doc: "services in the fleet"}
+	dwellParam    = ParamSpec{Name: "dwell", Default: 8, Min: 1, Doc: "ticks spent on each page"}
+)
+
+func init() {
+	RegisterScenario("startup", ScenarioMeta{
+		Description: "fresh program per iteration painting a 50-item list",
+		Rows:        maxInt(40, startupTreeSize+5),
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return benchmarkLines(startupTreeSize, tick, cols)
+	})
+	RegisterScenario("tree-construction", ScenarioMeta{
+		Description: "list of --items rows rebuilt every tick",
+		Params:      []ParamSpec{{Name: "items", Default: 100, Min: 1, Doc: "list length"}},
+		ViewportRows: func(params map[string]string) int {
+			return maxInt(40, intParam(params, "items", 100)+5)
+		},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return benchmarkLines(intParam(params, "items", 100), tick, cols)
+	})
+	RegisterScenario("content-update", ScenarioMeta{
+		Description: "500-item file list with a moving selection",
+		Rows:        540,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return contentUpdateLines(safeMod(tick, contentUpdateListSize), cols)
+	})
+	RegisterScenario("layout-stress", ScenarioMeta{
+		Description: "grid of --rows x --cols padded cells",
+		Params: []ParamSpec{
+			{Name: "rows", Default: 40, Min: 1, Doc: "grid rows"},
+			{Name: "cols", Default: 4, Min: 1, Doc: "grid columns"},
+		},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return layoutStressLines(intParam(params, "rows", 40), intParam(params, "cols", 4), tick, rng, cols)
+	})
+	RegisterScenario("scroll-stress", ScenarioMeta{
+		Description: "scrolling window over --items rows",
+		Params:      []ParamSpec{{Name: "items", Default: 2000, Min: 1, Doc: "list length"}},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		items := intParam(params, "items", 2000)
+		return scrollStressLines(items, safeMod(tick, items), tick, rng, cols)
+	})
+	RegisterScenario("virtual-list", ScenarioMeta{
+		Description: "--viewport rows of a virtualized --items list",
+		Params:      []ParamSpec{itemsParam, viewportParam},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return virtualListLines(intParam(params, "items", 100000), intParam(params, "viewport", 40), tick, rng, cols)
+	})
+	RegisterScenario("tables", ScenarioMeta{
+		Description: "--rows x --cols table with churning cells",
+		Params: []ParamSpec{
+			{Name: "rows", Default: 100, Min: 1, Doc: "table rows"},
+			{Name: "cols", Default: 8, Min: 1, Doc: "table columns"},
+		},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return tablesLines(intParam(params, "rows", 100), intParam(params, "cols", 8), tick, rng, cols)
+	})
+	RegisterScenario("terminal-frame-fill", ScenarioMeta{
+		Description: "full frame with the first --dirtyLines rows changing",
+		Params: []ParamSpec{
+			rowsParam,
+			colsParam,
+			{Name: "dirtyLines", Default: 1, Doc: "rows that change every tick"},
+		},
+		Screen: true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		rows := intParam(params, "rows", 40)
+		dirtyLines := intParam(params, "dirtyLines", 1)
+		lines := make([]string, 0, rows)
+		for r := 0; r < rows; r++ {
+			if r < dirtyLines {
+				lines = append(lines, makeLineContent(r, tick, rng, cols))
+			} else {
+				lines = append(lines, makeStaticLine(r, cols))
+			}
+		}
+		return lines
+	})
+	RegisterScenario("terminal-virtual-list", ScenarioMeta{
+		Description: "cross-framework virtualized list",
+		Params:      []ParamSpec{itemsParam, viewportParam},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalVirtualListLines(intParam(params, "items", 100000), intParam(params, "viewport", 40), tick, rng, cols)
+	})
+	RegisterScenario("terminal-table", ScenarioMeta{
+		Description: "cross-framework --rows x --cols table",
+		Params: []ParamSpec{
+			{Name: "rows", Default: 40, Min: 1, Doc: "table rows"},
+			{Name: "cols", Default: 8, Min: 1, Doc: "table columns"},
+		},
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		base := tableLines(intParam(params, "rows", 40), intParam(params, "cols", 8), tick)
+		lines := make([]string, 0, len(base))
+		for _, ln := range base {
+			lines = append(lines, clipPad(ln, cols))
+		}
+		return lines
+	})
+	RegisterScenario("terminal-screen-transition", ScenarioMeta{
+		Description: "cycles dashboard, table, and log screens",
+		Params:      []ParamSpec{rowsParam, colsParam},
+		Screen:      true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalScreenTransitionLines(tick, rng, params)
+	})
+	RegisterScenario("terminal-fps-stream", ScenarioMeta{
+		Description: "telemetry bars for --channels channels",
+		Params: []ParamSpec{
+			rowsParam,
+			colsParam,
+			{Name: "channels", Default: 12, Min: 1, Doc: "telemetry channels"},
+		},
+		Screen: true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalFpsStreamLines(tick, rng, params)
+	})
+	RegisterScenario("terminal-input-latency", ScenarioMeta{
+		Description: "key-event log with ticks delivered through the event loop",
+		Params:      []ParamSpec{rowsParam, colsParam},
+		Screen:      true,
+		EventLoop:   true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalInputLatencyLines(tick, rng, params)
+	})
+	RegisterScenario("terminal-memory-soak", ScenarioMeta{
+		Description: "pool table for long-running memory soaks",
+		Params:      []ParamSpec{rowsParam, colsParam},
+		Screen:      true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalMemorySoakLines(tick, rng, params)
+	})
+	RegisterScenario("terminal-full-ui", ScenarioMeta{
+		Description: "three-pane operations dashboard",
+		Params:      []ParamSpec{rowsParam, colsParam, servicesParam},
+		Screen:      true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalFullUiLines(tick, rng, params)
+	})
+	RegisterScenario("terminal-full-ui-navigation", ScenarioMeta{
+		Description: "three-pane dashboard switching page every --dwell ticks",
+		Params:      []ParamSpec{rowsParam, colsParam, servicesParam, dwellParam},
+		Screen:      true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalFullUiNavigationLines(tick, rng, params)
+	})
+	RegisterScenario("terminal-strict-ui", ScenarioMeta{
+		Description: "bordered three-pane dashboard",
+		Params:      []ParamSpec{rowsParam, colsParam, servicesParam, dwellParam},
+		Screen:      true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalStrictPaneLines(tick, rng, params, false)
+	})
+	RegisterScenario("terminal-strict-ui-navigation", ScenarioMeta{
+		Description: "bordered dashboard switching page every --dwell ticks",
+		Params:      []ParamSpec{rowsParam, colsParam, servicesParam, dwellParam},
+		Screen:      true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		return terminalStrictPaneLines(tick, rng, params, true)
+	})
+	RegisterScenario("terminal-mouse-zones", ScenarioMeta{
+		Description: "grid of --zoneWidth cells hovered by --moves pointer moves a tick",
+		Params: []ParamSpec{
+			rowsParam,
+			colsParam,
+			{Name: "zoneWidth", Default: 10, Min: 4, Max: 100, Doc: "cell width in columns"},
+			{Name: "moves", Default: 64, Min: 1, Doc: "pointer moves per tick"},
+		},
+		Screen:    true,
+		EventLoop: true,
+	}, func(params map[string]string, tick int, cols int, rng *FrameRand) []string {
+		hover := MouseZone{Row: -1, Col: -1}
+		for _, move := range MouseZoneMoves(params, rng) {
+			if zone, ok := MouseZoneAt(params, move); ok {
+				hover = zone
+			}
+		}
+		return MouseZoneLines(params, tick, hover)
+	})
+
+	RegisterSuite("core",
+		"startup", "tree-construction", "rerender", "content-update", "layout-stress",
+		"scroll-stress", "virtual-list", "tables", "memory-profile")
+	RegisterSuite("terminal",
+		"terminal-rerender", "terminal-frame-fill", "terminal-virtual-list", "terminal-table",
+		"terminal-screen-transition", "terminal-fps-stream", "terminal-input-latency",
+		"terminal-memory-soak", "terminal-full-ui", "terminal-full-ui-navigation",
+		"terminal-strict-ui", "terminal-strict-ui-navigation")
+}
+
+func padTo(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) >= width {
+		return string(runes[:width])
+	}
+	return s + strings.Repeat(" ", width-len(runes))
+}
+
+func clipPad(s string, cols int) string {
+	runes := []rune(s)
+	if len(runes) >= cols {
+		return string(runes[:cols])
+	}
+	return s + strings.Repeat(" ", cols-len(runes))
+}
+
+func bar(value float64, width int) string {
+	filled := int(math.Round(value * float64(width)))
+	if filled < 0 {
+		filled = 0
+	}
+	if filled > width {
+		filled = width
+	}
+	return strings.Repeat("#", filled) + strings.Repeat("-", width-filled)
+}
+
+func safeMod(value int, denom int) int {
+	if denom <= 0 {
+		return 0
+	}
+	return value % denom
+}
+
+func intParam(params map[string]string, key string, fallback int) int {
+	raw, ok := params[key]
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return fallback
+	}
+	return n
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func formatWithCommas(v int) string {
+	s := strconv.Itoa(v)
+	if len(s) <= 3 {
+		return s
+	}
+	n := len(s)
+	first := n % 3
+	if first == 0 {
+		first = 3
+	}
+	var b strings.Builder
+	b.WriteString(s[:first])
+	for i := first; i < n; i += 3 {
+		b.WriteByte(',')
+		b.WriteString(s[i : i+3])
+	}
+	return b.String()
+}
+
+func makeLineContent(row int, tick int, rng *FrameRand, cols int) string {
+	v := rng.Uint32()
+	return padTo(fmt.Sprintf("row=%02d tick=%d v=%x", row, tick, v), cols)
+}
+
+func makeStaticLine(row int, cols int) string {
+	return padTo(fmt.Sprintf("row=%02d static", row), cols)
+}
+
+func cellValue(row int, col int, tick int, hotRow int, hotCol int) string {
+	if row == hotRow && col == hotCol {
+		return fmt.Sprintf("v=%d", tick)
+	}
+	return fmt.Sprintf("r%dc%d", row, col)
+}
+
+func tableLines(rows int, cols int, tick int) []string {
+	hotRow := safeMod(tick, rows)
+	hotCol := safeMod(tick, cols)
+	lines := make([]string, 0, rows+2)
+
+	headerCells := make([]string, 0, cols)
+	for c := 0; c < cols; c++ {
+		headerCells = append(headerCells, fmt.Sprintf("%-10s", fmt.Sprintf("C%d", c)))
+	}
+	header := strings.Join(headerCells, "")
+	lines = append(lines, header)
+	lines = append(lines, strings.Repeat("-", minInt(120, len([]rune(header)))))
+
+	for r := 0; r < rows; r++ {
+		cells := make([]string, 0, cols)
+		for c := 0; c < cols; c++ {
+			cells = append(cells, fmt.Sprintf("%-10s", cellValue(r, c, tick, hotRow, hotCol)))
+		}
+		line := strings.Join(cells, "")
+		r := []rune(line)
+		if len(r) > 120 {
+			line = string(r[:120])
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func tableUpdateCellValue(r int, c int, tick int, rng *FrameRand) string {
+	v := rng.Intn(10_000)
+	wide := safeMod(tick+r+c, 13) == 0
+	if wide {
+		return fmt.Sprintf("val=%04d (row=%d)", v, r)
+	}
+	return strconv.Itoa(v)
+}
+
+func terminalScreenTransitionLines(tick int, rng *FrameRand, params map[string]string) []string {
+	rows := intParam(params, "rows", 40)
+	cols := intParam(params, "cols", 120)
+	mode := safeMod(tick, 3)
+	lines := make([]string, 0, rows)
+
+	if mode == 0 {
+		lines = append(lines, clipPad("terminal-screen-transition [dashboard]", cols))
+		for i := 0; i < rows-1; i++ {
+			v := float64(rng.Intn(1000)) / 1000.0
+			lines = append(lines, clipPad(fmt.Sprintf("svc-%02d %s %.1f%%", i, bar(v, 24), v*100.0), cols))
+		}
+		return lines
+	}
+
+	if mode == 1 {
+		lines = append(lines, clipPad("terminal-screen-transition [table]", cols))
+		lines = append(lines, clipPad("ID        NAME                 STATE     LAT(ms)   ERR", cols))
+		for i := 0; i < rows-2; i++ {
+			id := fmt.Sprintf("node-%03d", safeMod(tick+i, 512))
+			state := "healthy "
+			if safeMod(tick+i, 7) == 0 {
+				state = "degraded"
+			}
+			lat := 10 + rng.Intn(190)
+			errText := "no "
+			if rng.Intn(53) == 0 {
+				errText = "yes"
+			}
+			lines = append(lines, clipPad(fmt.Sprintf("%-8s service-%03d        %-8s %7d   %s", id, i, state, lat, errText), cols))
+		}
+		return lines
+	}
+
+	lines = append(lines, clipPad("terminal-screen-transition [logs]", cols))
+	for i := 0; i < rows-1; i++ {
+		level := []string{"INFO", "WARN", "ERROR"}[safeMod(tick+i, 3)]
+		code := rng.Intn(10_000)
+		lines = append(lines, clipPad(fmt.Sprintf("%s t=%d i=%d code=%04d message=frame-transition", level, tick, i, code), cols))
+	}
+	return lines
+}
+
+func terminalFpsStreamLines(tick int, rng *FrameRand, params map[string]string) []string {
+	rows := intParam(params, "rows", 40)
+	cols := intParam(params, "cols", 120)
+	channels := intParam(params, "channels", 12)
+	lines := make([]string, 0, rows)
+
+	lines = append(lines, clipPad(fmt.Sprintf("terminal-fps-stream tick=%d target=60fps channels=%d", tick, channels), cols))
+	for i := 0; i < channels && len(lines) < rows; i++ {
+		base := 0.5 + 0.45*math.Sin(float64(tick+i*7)/8.0)
+		spike := 0.0
+		if rng.Intn(23) == 0 {
+			spike = 0.4
+		}
+		value := math.Min(1.0, base+spike)
+		lines = append(lines, clipPad(fmt.Sprintf("ch-%02d %s %5.1f%%", i, bar(value, 24), value*100.0), cols))
+	}
+
+	for len(lines) < rows {
+		spark := make([]string, 0, 16)
+		for j := 0; j < 16; j++ {
+			v := safeMod(tick*3+len(lines)*7+j*11, 10)
+			spark = append(spark, strconv.Itoa(v))
+		}
+		lines = append(lines, clipPad(strings.Join(spark, ""), cols))
+	}
+	return lines
+}
+
+func terminalInputLatencyLines(tick int, rng *FrameRand, params map[string]string) []string {
+	rows := intParam(params, "rows", 40)
+	cols := intParam(params, "cols", 120)
+	lines := make([]string, 0, rows)
+
+	lines = append(lines, clipPad("terminal-input-latency synthetic-key-event -> frame", cols))
+	for i := 0; i < rows-1; i++ {
+		latencyMs := 1 + rng.Intn(20)
+		queueDepth := rng.Intn(9)
+		focus := "blurred"
+		if safeMod(tick+i, 4) == 0 {
+			focus = "focused"
+		}
+		lines = append(lines, clipPad(fmt.Sprintf("evt=%04d key=%s latency=%2dms queue=%d", tick*rows+i, focus, latencyMs, queueDepth), cols))
+	}
+	return lines
+}
+
+func terminalMemorySoakLines(tick int, rng *FrameRand, params map[string]string) []string {
+	rows := intParam(params, "rows", 40)
+	cols := intParam(params, "cols", 120)
+	lines := make([]string, 0, rows)
+
+	lines = append(lines, clipPad(fmt.Sprintf("terminal-memory-soak tick=%d", tick), cols))
+	for i := 0; i < rows-1; i++ {
+		size := 32 + rng.Intn(512)
+		ref := rng.Intn(97)
+		lines = append(lines, clipPad(fmt.Sprintf("pool[%02d] size=%4dKiB refs=%2d checksum=%08x", i, size, ref, tick*rows+i), cols))
+	}
+	return lines
+}
+
+func benchmarkLines(items int, seed int, cols int) []string {
+	lines := make([]string, 0, items+2)
+	lines = append(lines, clipPad(fmt.Sprintf("Benchmark: %d items (#%d)", items, seed), cols))
+	lines = append(lines, clipPad(fmt.Sprintf("Total: %d  Page 1", items), cols))
+	for i := 0; i < items; i++ {
+		lines = append(lines, clipPad(fmt.Sprintf("%d. Item %d details", i, i), cols))
+	}
+	return lines
+}
+
+func contentUpdateLines(selected int, cols int) []string {
+	lines := make([]string, 0, contentUpdateListSize+1)
+	lines = append(lines, clipPad(fmt.Sprintf("Files  %d items  Selected: %d", contentUpdateListSize, selected), cols))
+	for i := 0; i < contentUpdateListSize; i++ {
+		marker := " "
+		if i == selected {
+			marker = ">"
+		}
+		lines = append(lines, clipPad(fmt.Sprintf("%s %3d. entry-%d.log %s B", marker, i, i, formatWithCommas(i*1024+512)), cols))
+	}
+	return lines
+}
+
+func layoutStressLines(rows int, cols int, tick int, rng *FrameRand, termCols int) []string {
+	lines := []string{clipPad("Layout stress", termCols), clipPad(fmt.Sprintf("tick=%d", tick), termCols)}
+	for r := 0; r < rows; r++ {
+		labels := make([]string, 0, cols)
+		values := make([]string, 0, cols)
+		for c := 0; c < cols; c++ {
+			v := rng.Intn(1000)
+			wide := safeMod(tick+r+c, 7) == 0
+			value := fmt.Sprintf("v=%d", v)
+			if wide {
+				value = fmt.Sprintf("value=%d (%04d)", v, v)
+			}
+			labels = append(labels, fmt.Sprintf("C%d", c))
+			values = append(values, value)
+		}
+		lines = append(lines, clipPad(strings.Join(labels, " | "), termCols))
+		lines = append(lines, clipPad(strings.Join(values, " | "), termCols))
+	}
+	return lines
+}
+
+func scrollStressLines(items int, active int, tick int, rng *FrameRand, cols int) []string {
+	lines := []string{
+		clipPad("Scroll stress (non-virtualized)", cols),
+		clipPad(fmt.Sprintf("items=%d active=%d tick=%d", items, active, tick), cols),
+	}
+	for i := 0; i < items; i++ {
+		marker := " "
+		if i == active {
+			marker = "▶"
+		}
+		lines = append(lines, clipPad(fmt.Sprintf("%5d %s Item %d v=%d", i, marker, i, rng.Intn(1000)), cols))
+	}
+	return lines
+}
+
+func virtualListLines(totalItems int, viewport int, tick int, rng *FrameRand, cols int) []string {
+	offset := safeMod(tick, totalItems-viewport)
+	end := minInt(totalItems, offset+viewport)
+	lines := []string{
+		clipPad("Virtual list", cols),
+		clipPad(fmt.Sprintf("total=%d viewport=%d offset=%d tick=%d", totalItems, viewport, offset, tick), cols),
+	}
+	for i := offset; i < end; i++ {
+		lines = append(lines, clipPad(fmt.Sprintf("%6d • Item %d v=%d", i, i, rng.Intn(1000)), cols))
+	}
+	return lines
+}
+
+func tablesLines(rows int, cols int, tick int, rng *FrameRand, termCols int) []string {
+	lines := []string{
+		clipPad("Table update", termCols),
+		clipPad(fmt.Sprintf("rows=%d cols=%d tick=%d", rows, cols, tick), termCols),
+	}
+	header := make([]string, 0, cols+1)
+	header = append(header, "row")
+	for c := 0; c < cols; c++ {
+		header = append(header, fmt.Sprintf("Col %d", c))
+	}
+	lines = append(lines, clipPad(strings.Join(header, "  "), termCols))
+
+	for r := 0; r < rows; r++ {
+		cells := make([]string, 0, cols)
+		for c := 0; c < cols; c++ {
+			cells = append(cells, tableUpdateCellValue(r, c, tick, rng))
+		}
+		lines = append(lines, clipPad(fmt.Sprintf("%4d  %s", r, strings.Join(cells, "  ")), termCols))
+	}
+	return lines
+}
+
+func terminalVirtualListLines(totalItems int, viewport int, tick int, rng *FrameRand, cols int) []string {
+	offset := safeMod(tick, totalItems-viewport)
+	end := minInt(totalItems, offset+viewport)
+	lines := []string{
+		clipPad("terminal-virtual-list", cols),
+		clipPad(fmt.Sprintf("total=%d viewport=%d offset=%d tick=%d", totalItems, viewport, offset, tick), cols),
+	}
+	for i := offset; i < end; i++ {
+		active := i == offset+safeMod(tick, viewport)
+		suffix := ""
+		if active {
+			suffix = " <"
+		}
+		lines = append(lines, clipPad(fmt.Sprintf("%6d • Item %d v=%d%s", i, i, rng.Intn(1000), suffix), cols))
+	}
+	return lines
+}
+
+func fullUiPaneWidths(cols int) (int, int, int) {
+	left := maxInt(22, int(float64(cols)*0.24))
+	right := maxInt(24, int(float64(cols)*0.28))
+	center := maxInt(24, cols-left-right-6)
+	return left, center, right
+}
+
+func paneLine(cols int, leftW int, centerW int, rightW int, left string, center string, right string) string {
+	return clipPad(fmt.Sprintf("%s │ %s │ %s", clipPad(left, leftW), clipPad(center, centerW), clipPad(right, rightW)), cols)
+}
+
+func spark(seed int, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.Grow(width)
+	for i := 0; i < width; i++ {
+		if safeMod(seed+i*3, 7) > 2 {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('.')
+		}
+	}
+	return b.String()
+}
+
+func terminalFullUiLines(tick int, rng *FrameRand, params map[string]string) []string {
+	rows := maxInt(12, intParam(params, "rows", 40))
+	cols := maxInt(80, intParam(params, "cols", 120))
+	services := maxInt(12, intParam(params, "services", 24))
+	leftW, centerW, rightW := fullUiPaneWidths(cols)
+	modes := []string{"overview", "services", "deploy", "incidents"}
+	mode := modes[safeMod(tick, len(modes))]
+	navItems := []string{"Dashboard", "Services", "Deployments", "Incidents", "Queues", "Logs", "Audit", "Settings"}
+
+	lines := make([]string, 0, rows)
+	lines = append(lines, clipPad(fmt.Sprintf("terminal-full-ui mode=%s tick=%d", mode, tick), cols))
+	lines = append(lines, clipPad(fmt.Sprintf("cluster=prod-us-east budget=16.6ms cpu=%d%% mem=%d%% qps=%d", 35+rng.Intn(40), 42+rng.Intn(49), 900+rng.Intn(1500)), cols))
+
+	bodyRows := maxInt(1, rows-4)
+	activeNav := safeMod(tick, len(navItems))
+	visibleTableRows := maxInt(6, minInt(18, bodyRows-6))
+	viewportOffset := safeMod(tick, maxInt(1, services-visibleTableRows+1))
+	activeSvc := safeMod(tick, services)
+
+	for r := 0; r < bodyRows; r++ {
+		left := ""
+		center := ""
+		right := ""
+
+		if r == 0 {
+			left = "NAV"
+		} else if r <= len(navItems) {
+			idx := r - 1
+			if idx == activeNav {
+				left = fmt.Sprintf("> %s", navItems[idx])
+			} else {
+				left = fmt.Sprintf("  %s", navItems[idx])
+			}
+		} else if r == len(navItems)+1 {
+			envs := []string{"prod", "stage", "dev"}
+			regions := []string{"use1", "usw2", "euw1"}
+			left = fmt.Sprintf("env=%s region=%s", envs[safeMod(tick, len(envs))], regions[safeMod(tick, len(regions))])
+		} else if r == len(navItems)+2 {
+			left = fmt.Sprintf("focus=svc-%03d alerts=%d", activeSvc, rng.Intn(19))
+		} else {
+			left = fmt.Sprintf("saved-view-%02d %s", safeMod(tick+r, 12), spark(tick+r, 10))
+		}
+
+		if r == 0 {
+			center = "SERVICES"
+		} else if r == 1 {
+			center = "id      state      lat   rps   err"
+		} else if r >= 2 && r < 2+visibleTableRows {
+			svc := viewportOffset + (r - 2)
+			degraded := rng.Intn(17) == 0
+			lat := 12 + rng.Intn(180)
+			rps := 100 + rng.Intn(2500)
+			errPct := float64(rng.Intn(70)) / 10.0
+			state := "healthy "
+			if degraded {
+				state = "degraded"
+			}
+			marker := " "
+			if svc == activeSvc {
+				marker = ">"
+			}
+			center = fmt.Sprintf("%s svc-%03d %s %3dms %4d %.1f%%", marker, svc, state, lat, rps, errPct)
+		} else if r == 2+visibleTableRows {
+			cpu := float64(rng.Intn(1000)) / 1000.0
+			center = fmt.Sprintf("cpu %s %.1f%%  io %2d%%", bar(cpu, 20), cpu*100.0, 45+rng.Intn(50))
+		} else if r == 3+visibleTableRows {
+			mem := float64(rng.Intn(1000)) / 1000.0
+			center = fmt.Sprintf("mem %s %.1f%%  gc %dms", bar(mem, 20), mem*100.0, rng.Intn(999))
+		} else if r == 4+visibleTableRows {
+			center = fmt.Sprintf("queue depth=%d retries=%d dropped=%d", rng.Intn(180), rng.Intn(37), rng.Intn(9))
+		} else {
+			center = fmt.Sprintf("timeline %s", spark(rng.Intn(7), maxInt(16, centerW-10)))
+		}
+
+		if r == 0 {
+			right = "INSPECTOR"
+		} else if r == 1 {
+			right = fmt.Sprintf("service=svc-%03d owner=team-%d", activeSvc, safeMod(activeSvc, 7))
+		} else if r == 2 {
+			right = fmt.Sprintf("slo p95<120ms  now=%dms", 45+rng.Intn(110))
+		} else if r == 3 {
+			deploy := "canary"
+			if rng.Intn(2) == 0 {
+				deploy = "green"
+			}
+			right = fmt.Sprintf("deploy=%s zone=az-%d", deploy, safeMod(activeSvc, 3)+1)
+		} else {
+			seq := tick*bodyRows + r
+			level := "INFO "
+			if safeMod(seq, 19) == 0 {
+				level = "ERROR"
+			} else if safeMod(seq, 11) == 0 {
+				level = "WARN "
+			}
+			right = fmt.Sprintf("%s t+%05d op=%02d msg=event-%d", level, seq, safeMod(seq*7, 97), seq)
+		}
+
+		lines = append(lines, paneLine(cols, leftW, centerW, rightW, left, center, right))
+	}
+
+	lines = append(lines, clipPad(fmt.Sprintf("status=online conn=%d sync=%d pending=%d diff=%d", 1200+rng.Intn(800), rng.Intn(9999), rng.Intn(48), rng.Intn(21)), cols))
+	lines = append(lines, clipPad("hotkeys: [1]overview [2]services [3]deploy [4]incidents [/]filter [enter]open [q]quit", cols))
+	if len(lines) > rows {
+		return lines[:rows]
+	}
+	return lines
+}
+
+func terminalFullUiNavigationLines(tick int, rng *FrameRand, params map[string]string) []string {
+	rows := maxInt(12, intParam(params, "rows", 40))
+	cols := maxInt(80, intParam(params, "cols", 120))
+	services := maxInt(10, intParam(params, "services", 24))
+	dwell := maxInt(2, intParam(params, "dwell", 8))
+	pages := []string{"overview", "services", "deployments", "incidents", "logs", "command"}
+	pageIndex := safeMod(tick/dwell, len(pages))
+	page := pages[pageIndex]
+	localTick := safeMod(tick, dwell)
+
+	lines := make([]string, 0, rows)
+	lines = append(lines, clipPad(fmt.Sprintf("terminal-full-ui-navigation page=%s tick=%d local=%d/%d", page, tick, localTick, dwell-1), cols))
+	tabParts := make([]string, 0, len(pages))
+	for i, p := range pages {
+		if i == pageIndex {
+			tabParts = append(tabParts, fmt.Sprintf("[%s]", p))
+		} else {
+			tabParts = append(tabParts, p)
+		}
+	}
+	lines = append(lines, clipPad(fmt.Sprintf("tabs: %s", strings.Join(tabParts, " | ")), cols))
+
+	bodyRows := maxInt(1, rows-4)
+	for i := 0; i < bodyRows; i++ {
+		line := ""
+
+		switch page {
+		case "overview":
+			if i == 0 {
+				line = "overview: global health + throughput + alerts"
+			} else if i <= 8 {
+				svc := i - 1
+				healthy := rng.Intn(9) != 0
+				v := float64(rng.Intn(1000)) / 1000.0
+				state := "degraded"
+				if healthy {
+					state = "healthy "
+				}
+				line = fmt.Sprintf("card svc-%02d %s %s %.1f%%", svc, state, bar(v, 24), v*100.0)
+			} else if i == 9 {
+				line = fmt.Sprintf("alerts open=%d acked=%d muted=%d", rng.Intn(11), rng.Intn(17), rng.Intn(5))
+			} else {
+				line = fmt.Sprintf("trend %s", spark(tick+i*3, maxInt(16, cols-10)))
+			}
+		case "services":
+			if i == 0 {
+				line = "services: inventory + selection + per-row telemetry"
+			} else if i == 1 {
+				line = "id      state      lat   rps   err"
+			} else {
+				row := i - 2
+				svc := safeMod(tick+row, services)
+				selected := row == safeMod(tick, maxInt(1, bodyRows-2))
+				degraded := rng.Intn(15) == 0
+				lat := 10 + rng.Intn(220)
+				rps := 80 + rng.Intn(3000)
+				errPct := float64(rng.Intn(80)) / 10.0
+				state := "healthy "
+				if degraded {
+					state = "degraded"
+				}
+				marker := " "
+				if selected {
+					marker = ">"
+				}
+				line = fmt.Sprintf("%s svc-%03d %s %3dms %4d %.1f%%", marker, svc, state, lat, rps, errPct)
+			}
+		case "deployments":
+			if i == 0 {
+				line = "deployments: staged rollout + promotion gates"
+			} else {
+				step := safeMod(i, 12)
+				pct := rng.Intn(101)
+				gate := "ready  "
+				if safeMod(tick+step, 5) == 0 {
+					gate = "blocked"
+				}
+				canary := "off"
+				if safeMod(tick+step, 2) == 0 {
+					canary = "on"
+				}
+				line = fmt.Sprintf("pipeline-%02d %s %s %3d%% canary=%s", step, gate, bar(float64(pct)/100.0, 18), pct, canary)
+			}
+		case "incidents":
+			if i == 0 {
+				line = "incidents: queue + assignee + response status"
+			} else {
+				incident := tick*bodyRows + i
+				sev := "sev3"
+				if safeMod(incident, 13) == 0 {
+					sev = "sev1"
+				} else if safeMod(incident, 7) == 0 {
+					sev = "sev2"
+				}
+				state := "open      "
+				if safeMod(incident, 5) == 0 {
+					state = "mitigating"
+				} else if safeMod(incident, 3) == 0 {
+					state = "triaging  "
+				}
+				line = fmt.Sprintf("%s inc-%04d %s owner=oncall-%d age=%dm", sev, safeMod(incident, 10000), state, safeMod(incident, 9), safeMod(incident*3, 180))
+			}
+		case "logs":
+			seq := tick*bodyRows + i
+			level := "INFO "
+			if safeMod(seq, 17) == 0 {
+				level = "ERROR"
+			} else if safeMod(seq, 9) == 0 {
+				level = "WARN "
+			}
+			line = fmt.Sprintf("%s trace=%05d shard=%d msg=stream-%d", level, safeMod(seq*19, 100000), safeMod(seq, 12), seq)
+		default:
+			if i < 2 {
+				line = "command palette: type to filter actions"
+			} else if i < 10 {
+				cmd := i - 2
+				selected := cmd == safeMod(tick, 8)
+				marker := " "
+				if selected {
+					marker = ">"
+				}
+				preview := "risky"
+				if safeMod(tick+cmd, 2) == 0 {
+					preview = "safe"
+				}
+				line = fmt.Sprintf("%s /command-%02d target=svc-%03d preview=%s", marker, cmd, safeMod(tick+cmd, services), preview)
+			} else {
+				line = fmt.Sprintf("preview: %s", spark(rng.Intn(7), maxInt(16, cols-10)))
+			}
+		}
+
+		lines = append(lines, clipPad(line, cols))
+	}
+
+	lines = append(lines, clipPad(fmt.Sprintf("route=%s navLatency=%dms commit=%d pending=%d", page, 1+rng.Intn(9), rng.Intn(10000), rng.Intn(33)), cols))
+	lines = append(lines, clipPad("flow: [tab]next-page [shift+tab]prev-page [enter]open [esc]close [/]command [ctrl+c]quit", cols))
+	if len(lines) > rows {
+		return lines[:rows]
+	}
+	return lines
+}
+
+func strictPaneWidths(cols int) (int, int, int) {
+	left := 24
+	right := 32
+	center := maxInt(28, cols-left-right-6)
+	return left, center, right
+}
+
+func strictPaneLine(cols int, leftW int, centerW int, rightW int, left string, center string, right string) string {
+	return clipPad(fmt.Sprintf("%s | %s | %s", clipPad(left, leftW), clipPad(center, centerW), clipPad(right, rightW)), cols)
+}
+
+func strictNavLines(page string, tick int, rng *FrameRand) []string {
+	tabs := []string{"dashboard", "services", "deploy", "incidents", "logs", "settings"}
+	active := 0
+	for i, tab := range tabs {
+		if strings.HasPrefix(page, tab) || page == tab {
+			active = i
+			break
+		}
+	}
+	lines := make([]string, 0, len(tabs)+2)
+	for i, tab := range tabs {
+		marker := " "
+		if i == active {
+			marker = ">"
+		}
+		lines = append(lines, fmt.Sprintf("%s %s", marker, tab))
+	}
+	lines = append(lines, fmt.Sprintf("env=%s region=%s", []string{"prod", "stage", "dev"}[safeMod(tick, 3)], []string{"use1", "usw2", "euw1"}[safeMod(tick, 3)]))
+	lines = append(lines, fmt.Sprintf("window=%dm filter=%s", 15+rng.Intn(30), map[bool]string{true: "on", false: "off"}[safeMod(tick, 2) == 0]))
+	return lines
+}
+
+func strictServiceLines(services int, tick int, rng *FrameRand, rowBudget int) []string {
+	lines := []string{"id      state      lat   rps   err"}
+	viewportRows := maxInt(4, rowBudget-4)
+	offset := safeMod(tick, maxInt(1, services-viewportRows+1))
+	active := safeMod(tick, services)
+	for r := 0; r < viewportRows; r++ {
+		svc := offset + r
+		degraded := rng.Intn(17) == 0
+		lat := 10 + rng.Intn(220)
+		rps := 80 + rng.Intn(3000)
+		errPct := float64(rng.Intn(90)) / 10.0
+		state := "healthy "
+		if degraded {
+			state = "degraded"
+		}
+		marker := " "
+		if svc == active {
+			marker = ">"
+		}
+		lines = append(lines, fmt.Sprintf("%s svc-%03d %s %3dms %4d %.1f%%", marker, svc, state, lat, rps, errPct))
+	}
+	cpu := float64(rng.Intn(1000)) / 1000.0
+	mem := float64(rng.Intn(1000)) / 1000.0
+	lines = append(lines, fmt.Sprintf("cpu %s %.1f%% io %2d%%", bar(cpu, 18), cpu*100.0, 30+rng.Intn(60)))
+	lines = append(lines, fmt.Sprintf("mem %s %.1f%% gc %dms", bar(mem, 18), mem*100.0, rng.Intn(999)))
+	lines = append(lines, fmt.Sprintf("queue=%d retry=%d drop=%d", rng.Intn(200), rng.Intn(40), rng.Intn(9)))
+	return lines
+}
+
+func strictDeploymentLines(tick int, rng *FrameRand, rowBudget int) []string {
+	lines := []string{"pipeline rollout and gate state"}
+	for i := 1; i < rowBudget; i++ {
+		step := safeMod(i, 12)
+		pct := rng.Intn(101)
+		gate := "ready  "
+		if safeMod(tick+step, 5) == 0 {
+			gate = "blocked"
+		}
+		canary := "off"
+		if safeMod(tick+step, 2) == 0 {
+			canary = "on"
+		}
+		lines = append(lines, fmt.Sprintf("pipe-%02d %s %s %3d%% canary=%s", step, gate, bar(float64(pct)/100.0, 16), pct, canary))
+	}
+	return lines
+}
+
+func strictIncidentLines(tick int, rowBudget int) []string {
+	lines := []string{"incident queue and ownership"}
+	for i := 1; i < rowBudget; i++ {
+		seq := tick*rowBudget + i
+		sev := "sev3"
+		if safeMod(seq, 13) == 0 {
+			sev = "sev1"
+		} else if safeMod(seq, 7) == 0 {
+			sev = "sev2"
+		}
+		state := "open      "
+		if safeMod(seq, 5) == 0 {
+			state = "mitigating"
+		} else if safeMod(seq, 3) == 0 {
+			state = "triaging  "
+		}
+		lines = append(lines, fmt.Sprintf("%s inc-%04d %s owner=oncall-%d age=%dm", sev, safeMod(seq, 10000), state, safeMod(seq, 9), safeMod(seq*3, 180)))
+	}
+	return lines
+}
+
+func strictLogLines(tick int, rowBudget int) []string {
+	lines := []string{"streamed logs"}
+	for i := 1; i < rowBudget; i++ {
+		seq := tick*rowBudget + i
+		level := "INFO "
+		if safeMod(seq, 17) == 0 {
+			level = "ERROR"
+		} else if safeMod(seq, 9) == 0 {
+			level = "WARN "
+		}
+		lines = append(lines, fmt.Sprintf("%s trace=%05d shard=%d msg=event-%d", level, safeMod(seq*19, 100000), safeMod(seq, 12), seq))
+	}
+	return lines
+}
+
+func strictCommandLines(services int, tick int, rowBudget int) []string {
+	lines := []string{"command palette actions"}
+	for i := 1; i < rowBudget; i++ {
+		cmd := i - 1
+		selected := cmd == safeMod(tick, maxInt(1, rowBudget-1))
+		preview := "risky"
+		if safeMod(tick+cmd, 2) == 0 {
+			preview = "safe"
+		}
+		marker := " "
+		if selected {
+			marker = ">"
+		}
+		lines = append(lines, fmt.Sprintf("%s /command-%02d target=svc-%03d preview=%s", marker, cmd, safeMod(tick+cmd, services), preview))
+	}
+	return lines
+}
+
+func strictRightLines(page string, tick int, rng *FrameRand, rowBudget int) []string {
+	lines := []string{
+		fmt.Sprintf("page=%s focus=svc-%03d", page, rng.Intn(24)),
+		fmt.Sprintf("slo p95<120ms now=%dms", 40+rng.Intn(120)),
+		fmt.Sprintf("deploy=%s zone=az-%d", map[bool]string{true: "green", false: "canary"}[safeMod(tick, 2) == 0], safeMod(tick, 3)+1),
+	}
+	for i := 3; i < rowBudget; i++ {
+		seq := tick*rowBudget + i
+		level := "INFO "
+		if safeMod(seq, 19) == 0 {
+			level = "ERROR"
+		} else if safeMod(seq, 11) == 0 {
+			level = "WARN "
+		}
+		lines = append(lines, fmt.Sprintf("%s t+%05d op=%02d note=%s", level, seq, safeMod(seq*7, 97), spark(seq, 10)))
+	}
+	return lines
+}
+
+func strictFitLines(lines []string, target int) []string {
+	if target <= 0 {
+		return []string{}
+	}
+	if len(lines) >= target {
+		return lines[:target]
+	}
+	for len(lines) < target {
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+func atOrEmpty(lines []string, idx int) string {
+	if idx < 0 || idx >= len(lines) {
+		return ""
+	}
+	return lines[idx]
+}
+
+type strictSections struct {
+	rows        int
+	cols        int
+	header      string
+	leftTitle   string
+	leftLines   []string
+	centerTitle string
+	centerLines []string
+	rightTitle  string
+	rightLines  []string
+	status      string
+	footer      string
+}
+
+func buildStrictSections(tick int, rng *FrameRand, params map[string]string, navigation bool) strictSections {
+	rows := maxInt(16, intParam(params, "rows", 40))
+	cols := maxInt(100, intParam(params, "cols", 120))
+	services := maxInt(12, intParam(params, "services", 24))
+	dwell := maxInt(2, intParam(params, "dwell", 8))
+	pages := []string{"dashboard", "services", "deployments", "incidents", "logs", "commands"}
+	page := "dashboard"
+	if navigation {
+		page = pages[safeMod(tick/dwell, len(pages))]
+	}
+	bodyRows := maxInt(4, rows-5)
+	leftRows := maxInt(1, bodyRows-1)
+	centerRows := maxInt(1, bodyRows-1)
+	rightRows := maxInt(1, bodyRows-1)
+
+	var center []string
+	switch page {
+	case "deployments":
+		center = strictDeploymentLines(tick, rng, centerRows)
+	case "incidents":
+		center = strictIncidentLines(tick, centerRows)
+	case "logs":
+		center = strictLogLines(tick, centerRows)
+	case "commands":
+		center = strictCommandLines(services, tick, centerRows)
+	default:
+		center = strictServiceLines(services, tick, rng, centerRows)
+	}
+
+	header := fmt.Sprintf("terminal-strict-ui%s page=%s tick=%d", map[bool]string{true: "-navigation", false: ""}[navigation], page, tick)
+	if !navigation {
+		header = fmt.Sprintf("%s cpu=%d%% mem=%d%% qps=%d", header, 35+rng.Intn(40), 42+rng.Intn(49), 900+rng.Intn(1500))
+	} else {
+		header = fmt.Sprintf("%s local=%d/%d", header, safeMod(tick, dwell), dwell-1)
+	}
+	var status string
+	if navigation {
+		status = fmt.Sprintf("route=%s navLatency=%dms commit=%d pending=%d", page, 1+rng.Intn(9), rng.Intn(10000), rng.Intn(33))
+	} else {
+		status = fmt.Sprintf("status=online conn=%d sync=%d pending=%d", 1200+rng.Intn(800), rng.Intn(9999), rng.Intn(48))
+	}
+	footer := "keys: [tab] move [enter] open [/] command [q] quit"
+	if navigation {
+		footer = "flow: [tab] next-page [shift+tab] prev-page [enter] open [esc] close"
+	}
+
+	leftTitle := "NAV"
+	if navigation {
+		leftTitle = "NAVIGATION"
+	}
+	centerTitle := "SERVICES"
+	if navigation {
+		centerTitle = strings.ToUpper(page)
+	}
+
+	left := strictFitLines(strictNavLines(page, tick, rng), leftRows)
+	center = strictFitLines(center, centerRows)
+	right := strictFitLines(strictRightLines(page, tick, rng, rightRows), rightRows)
+
+	return strictSections{
+		rows:        rows,
+		cols:        cols,
+		header:      header,
+		leftTitle:   leftTitle,
+		leftLines:   left,
+		centerTitle: centerTitle,
+		centerLines: center,
+		rightTitle:  "DETAILS",
+		rightLines:  right,
+		status:      status,
+		footer:      footer,
+	}
+}
+
+func strictPanelBlock(title string, lines []string, width int, height int) string {
+	innerRows := maxInt(1, height-2)
+	content := make([]string, 0, innerRows)
+	content = append(content, lipgloss.NewStyle().Bold(true).Render(clipPad(title, maxInt(1, width-2))))
+	content = append(content, lines...)
+	content = strictFitLines(content, innerRows)
+	if len(content) > innerRows {
+		content = content[:innerRows]
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Width(width).
+		Height(height).
+		Render(strings.Join(content, "\n"))
+}
+
+func strictFrameLines(sections strictSections) []string {
+	headerHeight := 3
+	footerHeight := 4
+	bodyHeight := maxInt(3, sections.rows-headerHeight-footerHeight)
+	leftWidth := 24
+	rightWidth := 32
+	centerWidth := maxInt(28, sections.cols-leftWidth-rightWidth)
+	totalWidth := leftWidth + centerWidth + rightWidth
+	if totalWidth != sections.cols {
+		centerWidth += sections.cols - totalWidth
+		if centerWidth < 12 {
+			centerWidth = 12
+		}
+	}
+
+	headerBox := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Width(sections.cols).
+		Height(headerHeight).
+		Render(clipPad(sections.header, maxInt(1, sections.cols-2)))
+
+	leftPanel := strictPanelBlock(sections.leftTitle, sections.leftLines, leftWidth, bodyHeight)
+	centerPanel := strictPanelBlock(sections.centerTitle, sections.centerLines, centerWidth, bodyHeight)
+	rightPanel := strictPanelBlock(sections.rightTitle, sections.rightLines, rightWidth, bodyHeight)
+	body := lipgloss.JoinHorizontal(lipgloss.Top, leftPanel, centerPanel, rightPanel)
+
+	footerContent := strings.Join(
+		[]string{
+			clipPad(sections.status, maxInt(1, sections.cols-2)),
+			clipPad(sections.footer, maxInt(1, sections.cols-2)),
+		},
+		"\n",
+	)
+	footerBox := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		Width(sections.cols).
+		Height(footerHeight).
+		Render(footerContent)
+
+	joined := lipgloss.JoinVertical(lipgloss.Left, headerBox, body, footerBox)
+	rawLines := strings.Split(joined, "\n")
+	lines := make([]string, 0, sections.rows)
+	for i := 0; i < sections.rows; i++ {
+		if i < len(rawLines) {
+			lines = append(lines, clipPad(rawLines[i], sections.cols))
+		} else {
+			lines = append(lines, strings.Repeat(" ", sections.cols))
+		}
+	}
+	return lines
+}
+
+func terminalStrictPaneLines(tick int, rng *FrameRand, params map[string]string, navigation bool) []string {
+	sections := buildStrictSections(tick, rng, params, navigation)
+	return strictFrameLines(sections)
+}
+
+// StrictUIFrame is the content of a terminal-strict-ui frame before lipgloss
+// lays it out, so the two can be timed apart.
+type StrictUIFrame struct {
+	sections strictSections
+}
+
+// NewStrictUIFrame builds the content of tick for terminal-strict-ui, or for
+// terminal-strict-ui-navigation when navigation is set.
+func NewStrictUIFrame(params map[string]string, seed uint64, tick int, navigation bool) StrictUIFrame {
+	return StrictUIFrame{sections: buildStrictSections(tick, NewFrameRand(seed, tick), params, navigation)}
+}
+
+// Lines lays the frame out with lipgloss: the bordered panels, their
+// joins and the final clip to the screen. The result is what ScenarioLines
+// returns for the same tick.
+func (f StrictUIFrame) Lines() []string {
+	return strictFrameLines(f.sections)
+}
+
+// MouseMove is a pointer position in terminal-mouse-zones, in 0-based screen
+// cells.
+type MouseMove struct {
+	X, Y int
+}
+
+// MouseZone is a cell of the terminal-mouse-zones grid; Row and Col are -1
+// when the pointer is over none.
+type MouseZone struct {
+	Row, Col int
+}
+
+// MouseZoneWidth is the width in columns of a terminal-mouse-zones cell.
+func MouseZoneWidth(params map[string]string) int {
+	return intParam(params, "zoneWidth", 10)
+}
+
+// MouseZoneGrid is the number of rows and columns of cells in the
+// terminal-mouse-zones grid, which fills the screen below its header.
+func MouseZoneGrid(params map[string]string) (int, int) {
+	rows := intParam(params, "rows", 40)
+	cols := intParam(params, "cols", 120)
+	return rows - 1, cols / MouseZoneWidth(params)
+}
+
+// MouseZoneMoves is the pointer path of a terminal-mouse-zones tick, drawn
+// first from the tick's rng so a harness that hit-tests the moves itself can
+// replay it with NewFrameRand.
+func MouseZoneMoves(params map[string]string, rng *FrameRand) []MouseMove {
+	rows := intParam(params, "rows", 40)
+	cols := intParam(params, "cols", 120)
+	moves := make([]MouseMove, intParam(params, "moves", 64))
+	for i := range moves {
+		moves[i] = MouseMove{X: rng.Intn(cols), Y: rng.Intn(rows)}
+	}
+	return moves
+}
+
+// MouseZoneAt is the cell under move, if any: the header row and the columns
+// right of the last whole cell are outside the grid.
+func MouseZoneAt(params map[string]string, move MouseMove) (MouseZone, bool) {
+	gridRows, gridCols := MouseZoneGrid(params)
+	row, col := move.Y-1, move.X/MouseZoneWidth(params)
+	if move.X < 0 || row < 0 || row >= gridRows || col >= gridCols {
+		return MouseZone{Row: -1, Col: -1}, false
+	}
+	return MouseZone{Row: row, Col: col}, true
+}
+
+// MouseZoneLines is the terminal-mouse-zones frame at tick with the pointer
+// last over hover, which is bracketed.
+func MouseZoneLines(params map[string]string, tick int, hover MouseZone) []string {
+	cols := intParam(params, "cols", 120)
+	width := MouseZoneWidth(params)
+	gridRows, gridCols := MouseZoneGrid(params)
+	lines := make([]string, 0, gridRows+1)
+
+	hovered := "-"
+	if hover.Row >= 0 {
+		hovered = fmt.Sprintf("%d.%d", hover.Row, hover.Col)
+	}
+	lines = append(lines, clipPad(fmt.Sprintf("terminal-mouse-zones tick=%d zones=%d moves=%d hover=%s",
+		tick, gridRows*gridCols, intParam(params, "moves", 64), hovered), cols))
+
+	var b strings.Builder
+	for r := 0; r < gridRows; r++ {
+		b.Reset()
+		for c := 0; c < gridCols; c++ {
+			label := padTo(fmt.Sprintf("%d.%d", r, c), width-2)
+			if r == hover.Row && c == hover.Col {
+				b.WriteString("[" + label + "]")
+			} else {
+				b.WriteString(" " + label + " ")
+			}
+		}
+		lines = append(lines, clipPad(b.String(), cols))
+	}
+	return lines
+}
@@ -0,0 +1,110 @@
+package harness
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the "schemaVersion" of emitted result documents. Bump it
+// whenever a field is renamed, removed or changes meaning; adding an optional
+// field does not need a bump.
+const SchemaVersion = 2
+
+// ResultSchema is a JSON Schema (draft 2020-12) for ResultFile, derived from
+// the Go types and their json tags so it cannot drift from what is emitted.
+// Fields without omitempty are required; only the top-level document
+// carries schemaVersion.
+func ResultSchema() map[string]any {
+	g := schemaGen{defs: map[string]any{}}
+	root := g.schemaFor(reflect.TypeOf(ResultFile{}))
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "bubbletea-bench result"
+	root["$id"] = "bubbletea-bench/result/v" + strconv.Itoa(SchemaVersion)
+	root["properties"] = map[string]any{"schemaVersion": map[string]any{"const": SchemaVersion}}
+	root["required"] = []string{"schemaVersion"}
+	root["$defs"] = g.defs
+	return root
+}
+
+type schemaGen struct {
+	defs map[string]any
+}
+
+func (g *schemaGen) schemaFor(t reflect.Type) map[string]any {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": []string{"array", "null"}, "items": g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	}
+	return map[string]any{}
+}
+
+// structRef defines named structs once under $defs, which also keeps the
+// self-nesting ResultFile finite.
+func (g *schemaGen) structRef(t reflect.Type) map[string]any {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+	ref := map[string]any{"$ref": "#/$defs/" + t.Name()}
+	if _, ok := g.defs[t.Name()]; ok {
+		return ref
+	}
+	g.defs[t.Name()] = nil
+	g.defs[t.Name()] = g.structSchema(t)
+	return ref
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	required := []string{}
+	g.addFields(t, properties, &required)
+	out := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+func (g *schemaGen) addFields(t reflect.Type, properties map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.addFields(f.Type, properties, required)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		properties[name] = g.schemaFor(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
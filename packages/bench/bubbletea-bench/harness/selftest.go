@@ -0,0 +1,147 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// selfTestTicks are the ticks --selftest renders: the first frames, which
+// generators often special-case, and far ones whose counters print wider.
+var selfTestTicks = []int{0, 1, 2, 3, 4, 5, 6, 7, 99, 1000, 99999}
+
+const maxReportedViolations = 20
+
+// SelfTestReport is the "selftest" field of a ResultFile: every scenario
+// rendered straight from its generator, at the given params and at the
+// bounds of each param left unset, and checked against its viewport.
+type SelfTestReport struct {
+	Seed       uint64         `json:"seed"`
+	Cases      []selfTestCase `json:"cases"`
+	Frames     int            `json:"frames"`
+	Violations int            `json:"violations"`
+}
+
+type selfTestCase struct {
+	Scenario   string              `json:"scenario"`
+	Params     map[string]string   `json:"params"`
+	Rows       int                 `json:"rows"`
+	Cols       int                 `json:"cols"`
+	Frames     int                 `json:"frames"`
+	Violations int                 `json:"violations"`
+	Failures   []selfTestViolation `json:"failures,omitempty"`
+}
+
+// selfTestViolation is one broken invariant: "rows" when a Screen scenario's
+// frame does not have exactly the viewport's rows, "width" when a line is
+// wider than the viewport, "utf8" when a line is not valid UTF-8, and
+// "dirty" when a line of a spec scenario changes by the next tick against
+// its spec's dirty declaration. Line is -1 for a whole-frame violation. Other scenarios may run past the viewport's
+// last row on purpose; the renderer crops them.
+type selfTestViolation struct {
+	Tick      int    `json:"tick"`
+	Line      int    `json:"line"`
+	Invariant string `json:"invariant"`
+	Detail    string `json:"detail"`
+}
+
+// RunSelfTest checks the frames of scenarios against their invariants with
+// cfg.Params and cfg.Seed. No program is started.
+func RunSelfTest(ctx context.Context, cfg Config, scenarios []string) (SelfTestReport, error) {
+	report := SelfTestReport{Seed: cfg.Seed, Cases: []selfTestCase{}}
+	for _, scenario := range scenarios {
+		s, ok := lookupScenario(scenario)
+		if !ok {
+			return report, fmt.Errorf("unknown scenario %q (run list to see them)", scenario)
+		}
+		for _, params := range selfTestParams(s.meta, suiteParams(scenario, cfg.Params)) {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			c := runSelfTestCase(scenario, s, params, cfg.Seed)
+			report.Frames += c.Frames
+			report.Violations += c.Violations
+			report.Cases = append(report.Cases, c)
+		}
+	}
+	return report, nil
+}
+
+// selfTestParams is base plus, for every int param base leaves unset, a
+// variant at its minimum and one at its maximum.
+func selfTestParams(meta ScenarioMeta, base map[string]string) []map[string]string {
+	out := []map[string]string{base}
+	for _, p := range meta.Params {
+		if _, set := base[p.Name]; set || p.Type != "int" {
+			continue
+		}
+		bounds := []int{p.Min}
+		if p.Max != 0 {
+			bounds = append(bounds, p.Max)
+		}
+		for _, bound := range bounds {
+			if bound == p.Default {
+				continue
+			}
+			variant := map[string]string{p.Name: strconv.Itoa(bound)}
+			for key, value := range base {
+				variant[key] = value
+			}
+			out = append(out, variant)
+		}
+	}
+	return out
+}
+
+func runSelfTestCase(scenario string, s registeredScenario, params map[string]string, seed uint64) selfTestCase {
+	meta := s.meta
+	rows, cols := viewportOf(meta, params)
+	c := selfTestCase{Scenario: scenario, Params: params, Rows: rows, Cols: cols}
+	fail := func(v selfTestViolation) {
+		c.Violations++
+		if len(c.Failures) < maxReportedViolations {
+			c.Failures = append(c.Failures, v)
+		}
+	}
+	for _, tick := range selfTestTicks {
+		lines := ScenarioLines(scenario, params, seed, tick, cols)
+		c.Frames++
+		if meta.Screen && len(lines) != rows {
+			fail(selfTestViolation{Tick: tick, Line: -1, Invariant: "rows", Detail: fmt.Sprintf("%d lines in a %d-row viewport", len(lines), rows)})
+		}
+		for i, line := range lines {
+			if !utf8.ValidString(line) {
+				fail(selfTestViolation{Tick: tick, Line: i, Invariant: "utf8", Detail: strconv.Quote(line)})
+				continue
+			}
+			if width := ansi.StringWidth(line); width > cols {
+				fail(selfTestViolation{Tick: tick, Line: i, Invariant: "width", Detail: fmt.Sprintf("%d cells in a %d-column viewport", width, cols)})
+			}
+		}
+		if s.dirty != nil {
+			checkDirty(tick, lines, ScenarioLines(scenario, params, seed, tick+1, cols), s.dirty(params), fail)
+		}
+	}
+	return c
+}
+
+// checkDirty fails every line that changes from lines to next without
+// being declared dirty, or is declared dirty and does not change.
+func checkDirty(tick int, lines []string, next []string, dirty []int, fail func(selfTestViolation)) {
+	declared := make(map[int]bool, len(dirty))
+	for _, row := range dirty {
+		declared[row] = true
+	}
+	for i := 0; i < max(len(lines), len(next)); i++ {
+		changed := i >= len(lines) || i >= len(next) || lines[i] != next[i]
+		switch {
+		case changed && !declared[i]:
+			fail(selfTestViolation{Tick: tick, Line: i, Invariant: "dirty", Detail: fmt.Sprintf("changes by tick %d but is not declared dirty", tick+1)})
+		case !changed && declared[i]:
+			fail(selfTestViolation{Tick: tick, Line: i, Invariant: "dirty", Detail: fmt.Sprintf("is declared dirty but is unchanged at tick %d", tick+1)})
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package harness
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Session files are a magic line followed by records, each a header line and,
+// for writes, the raw payload bytes:
+//
+//	REZI-SESSION 1
+//	W <ns since start> <length>\n<length raw bytes>
+//	F <ns since start> <tick>\n
+//	E <ns since start> 0\n
+//
+// F marks the point a tick was sent to the program; every W that follows
+// belongs to that tick until the next F. E marks a program shutting down, so
+// writes after it are teardown output rather than part of the last frame.
+const sessionMagic = "REZI-SESSION 1\n"
+
+type sessionRecorder struct {
+	mu     sync.Mutex
+	closer io.Closer
+	buf    *bufio.Writer
+	start  time.Time
+	err    error
+}
+
+func openSessionRecorder(path string) (*sessionRecorder, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("open --record file: %w", err)
+	}
+	return newSessionRecorder(file, file), nil
+}
+
+func newSessionRecorder(w io.Writer, closer io.Closer) *sessionRecorder {
+	r := &sessionRecorder{closer: closer, buf: bufio.NewWriterSize(w, 256*1024), start: time.Now()}
+	_, r.err = r.buf.WriteString(sessionMagic)
+	return r
+}
+
+func (r *sessionRecorder) write(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if _, r.err = fmt.Fprintf(r.buf, "W %d %d\n", time.Since(r.start).Nanoseconds(), len(p)); r.err != nil {
+		return
+	}
+	_, r.err = r.buf.Write(p)
+}
+
+func (r *sessionRecorder) frame(tick int) {
+	r.marker('F', tick)
+}
+
+func (r *sessionRecorder) end() {
+	r.marker('E', 0)
+}
+
+func (r *sessionRecorder) marker(kind byte, value int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	_, r.err = fmt.Fprintf(r.buf, "%c %d %d\n", kind, time.Since(r.start).Nanoseconds(), value)
+}
+
+func (r *sessionRecorder) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.buf.Flush()
+	}
+	if r.closer != nil {
+		if err := r.closer.Close(); r.err == nil {
+			r.err = err
+		}
+	}
+	return r.err
+}
+
+// SessionFrame is one tick of a recorded session: the writes that drew it,
+// in order, and those made after the frame ended.
+type SessionFrame struct {
+	Tick    int
+	Writes  [][]byte
+	Trailer []byte
+}
+
+// IsSession reports whether data is a --record session rather than raw
+// output.
+func IsSession(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(sessionMagic))
+}
+
+// ParseSession splits a --record session into per-tick output. Bytes written
+// before the first frame marker (program startup) are returned as preamble.
+func ParseSession(data []byte) ([]byte, []SessionFrame, error) {
+	if !bytes.HasPrefix(data, []byte(sessionMagic)) {
+		return nil, nil, errors.New("not a recorded session (bad magic)")
+	}
+	rest := data[len(sessionMagic):]
+	var preamble []byte
+	frames := []SessionFrame{}
+	ended := false
+	for len(rest) > 0 {
+		nl := bytes.IndexByte(rest, '\n')
+		if nl < 0 {
+			return nil, nil, errors.New("truncated session record header")
+		}
+		fields := strings.Fields(string(rest[:nl]))
+		rest = rest[nl+1:]
+		if len(fields) != 3 {
+			return nil, nil, fmt.Errorf("malformed session record header %q", strings.Join(fields, " "))
+		}
+		n, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, nil, fmt.Errorf("malformed session record header: %w", err)
+		}
+		switch fields[0] {
+		case "F":
+			frames = append(frames, SessionFrame{Tick: n})
+			ended = false
+		case "E":
+			ended = true
+		case "W":
+			if n < 0 || n > len(rest) {
+				return nil, nil, errors.New("truncated session write payload")
+			}
+			switch {
+			case len(frames) == 0:
+				preamble = append(preamble, rest[:n]...)
+			case ended:
+				last := &frames[len(frames)-1]
+				last.Trailer = append(last.Trailer, rest[:n]...)
+			default:
+				last := &frames[len(frames)-1]
+				last.Writes = append(last.Writes, rest[:n:n])
+			}
+			rest = rest[n:]
+		default:
+			return nil, nil, fmt.Errorf("unknown session record type %q", fields[0])
+		}
+	}
+	return preamble, frames, nil
+}
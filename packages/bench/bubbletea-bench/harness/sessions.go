@@ -0,0 +1,250 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// sessionsResult is the "sessions" field of a Result run with --sessions:
+// N programs ticked concurrently in one process, each writing through its
+// own Writer, like panes under a multiplexer. The top-level samples and
+// summary pool every session's frames; the spreads are across sessions, so
+// a wide one means the scheduler served them unevenly.
+type sessionsResult struct {
+	Count                int            `json:"count"`
+	Sessions             []sessionStats `json:"sessions"`
+	MedianMs             runSpread      `json:"medianMs"`
+	P95Ms                runSpread      `json:"p95Ms"`
+	FramesPerSecond      runSpread      `json:"framesPerSecond"`
+	TotalFramesPerSecond float64        `json:"totalFramesPerSecond"`
+	TotalBytesPerSecond  float64        `json:"totalBytesPerSecond"`
+}
+
+type sessionStats struct {
+	Index           int           `json:"index"`
+	Frames          int           `json:"frames"`
+	Summary         sampleSummary `json:"summary"`
+	BytesWritten    int64         `json:"bytesWritten"`
+	WallMs          float64       `json:"wallMs"`
+	FramesPerSecond float64       `json:"framesPerSecond"`
+	SamplesMs       []float64     `json:"samplesMs"`
+}
+
+// sessionRun is one session's share of runSessionsBench.
+type sessionRun struct {
+	session   Session
+	writer    *Writer
+	warmup    []float64
+	samples   []float64
+	bytesBase int64
+	wallMs    float64
+	err       error
+}
+
+// runSessionsBench runs cfg.Sessions steady-state loops side by side. Every
+// session warms up, then all start measuring together; the first failure
+// stops the rest.
+func runSessionsBench(ctx context.Context, cfg Config, output benchOutput) (Result, error) {
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+	eventLoop := usesEventLoopScheduling(cfg.Scenario)
+	runs := make([]*sessionRun, cfg.Sessions)
+	defer func() {
+		for _, run := range runs {
+			if run != nil && run.session != nil {
+				_ = run.session.Close()
+			}
+		}
+	}()
+	for i := range runs {
+		writer := output.newWriter()
+		session, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, writer)
+		if err != nil {
+			return Result{}, fmt.Errorf("session %d: %w", i, err)
+		}
+		runs[i] = &sessionRun{session: session, writer: writer}
+		if _, err := session.RenderTick(0, false); err != nil {
+			return Result{}, fmt.Errorf("session %d: %w", i, err)
+		}
+	}
+
+	var failOnce sync.Once
+	failed := make(chan struct{})
+	fail := func(run *sessionRun, i int, err error) {
+		run.err = fmt.Errorf("session %d: %w", i, err)
+		failOnce.Do(func() { close(failed) })
+	}
+	stopped := func() bool {
+		select {
+		case <-failed:
+			return true
+		default:
+			return ctx.Err() != nil
+		}
+	}
+
+	var wg sync.WaitGroup
+	warmedUp := sync.WaitGroup{}
+	measure := make(chan time.Time)
+	warmedUp.Add(len(runs))
+	for i, run := range runs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			run.warmup = make([]float64, 0, cfg.warmupLimit())
+			for tick := 1; tick <= cfg.warmupLimit() && !cfg.warmedUp(run.warmup) && !stopped(); tick++ {
+				ts := time.Now()
+				if _, err := run.session.RenderTick(tick, eventLoop); err != nil {
+					fail(run, i, err)
+					break
+				}
+				run.warmup = append(run.warmup, MsSince(ts))
+				output.beat("warmup", tick-1)
+			}
+			warmedUp.Done()
+			start, ok := <-measure
+			if !ok || run.err != nil {
+				return
+			}
+			run.bytesBase, _ = run.writer.Snapshot()
+			run.samples = make([]float64, 0, cfg.Iterations)
+			for n := 0; cfg.measuring(n, start) && !stopped(); n++ {
+				ts := time.Now()
+				if _, err := run.session.RenderTick(len(run.warmup)+n+1, eventLoop); err != nil {
+					fail(run, i, err)
+					return
+				}
+				run.samples = append(run.samples, MsSince(ts))
+				output.beat("measure", n)
+			}
+			run.wallMs = MsSince(start)
+		}()
+	}
+
+	warmedUp.Wait()
+	if stopped() {
+		close(measure)
+		wg.Wait()
+		return Result{}, sessionsError(ctx, runs)
+	}
+	tryGC()
+	memBefore := takeMemory()
+	cpuBefore := takeCPU()
+	schedBefore := takeSched()
+	load := startBackgroundLoad(cfg.LoadCPUPercent, cfg.LoadAllocMBps)
+	start := time.Now()
+	for range runs {
+		measure <- start
+	}
+	wg.Wait()
+	totalWallMs := MsSince(start)
+	loadResult := load.finish()
+	interrupted := ctx.Err() != nil
+	for _, run := range runs {
+		if run.err != nil {
+			return Result{}, run.err
+		}
+	}
+	cpu := diffCPU(cpuBefore, takeCPU())
+	memAfter := takeMemory()
+	schedAfter := takeSched()
+
+	report := &sessionsResult{Count: len(runs), Sessions: make([]sessionStats, 0, len(runs))}
+	var medians, p95s, rates []float64
+	var allSamples, warmup []float64
+	var bytesWritten int64
+	for i, run := range runs {
+		bytesNow, _ := run.writer.Snapshot()
+		stats := sessionStats{
+			Index:           i,
+			Frames:          len(run.samples),
+			Summary:         summarize(run.samples),
+			BytesWritten:    bytesNow - run.bytesBase,
+			WallMs:          run.wallMs,
+			FramesPerSecond: framesPerSecond(len(run.samples), run.wallMs),
+			SamplesMs:       run.samples,
+		}
+		report.Sessions = append(report.Sessions, stats)
+		medians = append(medians, stats.Summary.Median)
+		p95s = append(p95s, stats.Summary.P95)
+		rates = append(rates, stats.FramesPerSecond)
+		allSamples = append(allSamples, run.samples...)
+		warmup = append(warmup, run.warmup...)
+		bytesWritten += stats.BytesWritten
+	}
+	if interrupted && len(allSamples) == 0 {
+		return Result{}, ctx.Err()
+	}
+	report.MedianMs = newRunSpread(medians)
+	report.P95Ms = newRunSpread(p95s)
+	report.FramesPerSecond = newRunSpread(rates)
+	report.TotalFramesPerSecond = framesPerSecond(len(allSamples), totalWallMs)
+	if totalWallMs > 0 {
+		report.TotalBytesPerSecond = float64(bytesWritten) / (totalWallMs / 1000)
+	}
+
+	for i, run := range runs {
+		err := run.session.Close()
+		run.session = nil
+		if err != nil {
+			return Result{}, fmt.Errorf("session %d: %w", i, err)
+		}
+	}
+
+	return Result{
+		Replay:                 newReplayInfo(cfg, tickRanges(true, len(runs[0].warmup), 0, len(runs[0].samples))),
+		SamplesMs:              allSamples,
+		Summary:                summarize(allSamples),
+		UpdateSamplesMs:        []float64{},
+		ViewSamplesMs:          []float64{},
+		FlushSamplesMs:         []float64{},
+		WriteBlockSamplesMs:    []float64{},
+		WriteBlockMaxSamplesMs: []float64{},
+		TotalWallMs:            totalWallMs,
+		CPUUserMs:              cpu.userMs,
+		CPUSysMs:               cpu.systemMs,
+		MinorFaults:            cpu.minorFaults,
+		MajorFaults:            cpu.majorFaults,
+		VolCtxSwitches:         cpu.volCtxSw,
+		InvolCtxSwitches:       cpu.involCtxSw,
+		VolCtxSwPerFrame:       perFrame(cpu.volCtxSw, len(allSamples)),
+		InvolCtxSwPerFrame:     perFrame(cpu.involCtxSw, len(allSamples)),
+		RSSBeforeKb:            memBefore.rssKb,
+		RSSAfterKb:             memAfter.rssKb,
+		RSSPeakKb:              max(memBefore.rssKb, memAfter.rssKb),
+		HeapBeforeKb:           memBefore.heapUsedKb,
+		HeapAfterKb:            memAfter.heapUsedKb,
+		HeapPeakKb:             max(memBefore.heapUsedKb, memAfter.heapUsedKb),
+		BytesWritten:           bytesWritten,
+		Seed:                   cfg.Seed,
+		Interrupted:            interrupted,
+		Frames:                 len(allSamples),
+		FramesPerSecond:        report.TotalFramesPerSecond,
+		DurationBudgetMs:       nsToMs(cfg.Duration.Nanoseconds()),
+		ThreadsBefore:          schedBefore.threads,
+		ThreadsAfter:           schedAfter.threads,
+		ThreadsPeak:            max(schedBefore.threads, schedAfter.threads),
+		GoroutinesAfter:        schedAfter.goroutines,
+		GOMAXPROCS:             runtime.GOMAXPROCS(0),
+		GOGC:                   gcPercent(),
+		NumCPU:                 runtime.NumCPU(),
+		Load:                   loadResult,
+		Sessions:               report,
+		Outliers:               []outlierSample{},
+		Warmup:                 buildWarmupReport(warmup, cfg),
+		ChangedCellSamples:     []int{},
+	}, nil
+}
+
+// sessionsError is why the sessions stopped before measuring: the first
+// session failure, or else the interrupt.
+func sessionsError(ctx context.Context, runs []*sessionRun) error {
+	for _, run := range runs {
+		if run.err != nil {
+			return run.err
+		}
+	}
+	return ctx.Err()
+}
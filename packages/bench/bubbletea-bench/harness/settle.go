@@ -0,0 +1,62 @@
+package harness
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// BetweenPolicies are the --between-iterations values: "none" leaves the
+// runtime alone, "gc" collects before every measured iteration but the
+// first, and "free" also returns the freed memory to the OS, so each
+// iteration starts from the same heap instead of inheriting the last one's
+// garbage.
+var BetweenPolicies = []string{"none", "gc", "free"}
+
+// cacheLine is the stride settle writes the scratch buffer at.
+const cacheLine = 64
+
+// settler resets process state between measured iterations, outside the
+// samples, for --between-iterations and --scratch-mb.
+type settler struct {
+	policy  string
+	scratch []byte
+	pass    byte
+}
+
+func newSettler(cfg Config) *settler {
+	s := &settler{policy: cfg.betweenPolicy()}
+	if cfg.ScratchMB > 0 {
+		s.scratch = make([]byte, cfg.ScratchMB<<20)
+	}
+	return s
+}
+
+// settle applies the policy before measured iteration i, then writes the
+// whole scratch buffer so the CPU caches hold it rather than whatever the
+// last iteration left warm. It returns how long that took, in
+// milliseconds.
+func (s *settler) settle(i int) float64 {
+	if i == 0 || (s.policy == "none" && s.scratch == nil) {
+		return 0
+	}
+	start := time.Now()
+	switch s.policy {
+	case "gc":
+		runtime.GC()
+	case "free":
+		debug.FreeOSMemory()
+	}
+	s.pass++
+	for j := 0; j < len(s.scratch); j += cacheLine {
+		s.scratch[j] = s.pass
+	}
+	return MsSince(start)
+}
+
+func (cfg Config) betweenPolicy() string {
+	if cfg.BetweenIterations == "" {
+		return "none"
+	}
+	return cfg.BetweenIterations
+}
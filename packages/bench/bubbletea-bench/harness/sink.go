@@ -0,0 +1,349 @@
+package harness
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/rezi-ui/bench/bubbletea-bench/ansistat"
+	"github.com/rezi-ui/bench/bubbletea-bench/vtverify"
+)
+
+// outputSink is where frames go for the pipe, file, pty, inherit and tmux
+// sinks. Pipes and PTYs are drained by a reader goroutine so writes never
+// stall on a full kernel buffer.
+type outputSink struct {
+	kind    string
+	writer  *os.File
+	reader  *os.File
+	path    string
+	temp    bool
+	tmux    *tmuxNest
+	vt      *vtConsumer
+	read    atomic.Int64
+	written atomic.Int64
+	done    chan struct{}
+}
+
+// tmuxNest runs a tmux client on a private server inside an outer PTY. The
+// pane runs cat on a FIFO, so frames written to the FIFO are parsed by tmux
+// and redrawn to the client the way a nested terminal sees them.
+type tmuxNest struct {
+	path    string
+	socket  string
+	dir     string
+	version string
+	client  *exec.Cmd
+	exited  chan struct{}
+}
+
+func openTmuxSink(sink *outputSink, rows int, cols int) error {
+	tmuxPath, err := exec.LookPath("tmux")
+	if err != nil {
+		return errors.New("--io tmux requires tmux in PATH")
+	}
+	version, _ := exec.Command(tmuxPath, "-V").Output()
+	dir, err := os.MkdirTemp("", "bubbletea-bench-tmux-*")
+	if err != nil {
+		return err
+	}
+	fifo := dir + "/frames"
+	if err := syscall.Mkfifo(fifo, 0o600); err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("create tmux fifo: %w", err)
+	}
+	master, slave, err := pty.Open()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("open pty: %w", err)
+	}
+	if err := pty.Setsize(master, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+		_ = master.Close()
+		_ = slave.Close()
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("set pty size: %w", err)
+	}
+
+	nest := &tmuxNest{
+		path:    tmuxPath,
+		socket:  dir + "/socket",
+		dir:     dir,
+		version: strings.TrimSpace(string(version)),
+		exited:  make(chan struct{}),
+	}
+	nest.client = exec.Command(tmuxPath,
+		"-S", nest.socket, "-f", "/dev/null",
+		"new-session", "cat "+fifo, ";",
+		"set-option", "status", "off",
+	)
+	nest.client.Env = append(os.Environ(), "TERM=xterm-256color", "TMUX=")
+	nest.client.Stdin, nest.client.Stdout, nest.client.Stderr = slave, slave, slave
+	nest.client.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true}
+	if err := nest.client.Start(); err != nil {
+		_ = master.Close()
+		_ = slave.Close()
+		_ = os.RemoveAll(dir)
+		return fmt.Errorf("start tmux: %w", err)
+	}
+	_ = slave.Close()
+	go func() {
+		_ = nest.client.Wait()
+		close(nest.exited)
+	}()
+	sink.reader, sink.tmux = master, nest
+	go sink.drain()
+
+	// Opening the FIFO for writing blocks until the pane's cat opens it.
+	opened := make(chan fifoOpen, 1)
+	go func() {
+		f, err := os.OpenFile(fifo, os.O_WRONLY, 0)
+		opened <- fifoOpen{f, err}
+	}()
+	select {
+	case result := <-opened:
+		sink.writer, err = result.file, result.err
+	case <-nest.exited:
+		err = errors.New("tmux exited before the pane opened its input")
+		abandonFifoOpen(fifo, opened)
+	case <-time.After(5 * time.Second):
+		err = errors.New("timeout waiting for the tmux pane to open its input")
+		abandonFifoOpen(fifo, opened)
+	}
+	if err != nil {
+		if sink.writer != nil {
+			_ = sink.writer.Close()
+		}
+		nest.stop()
+		_ = master.Close()
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	return nil
+}
+
+// fifoOpen is the outcome of opening the tmux FIFO for writing.
+type fifoOpen struct {
+	file *os.File
+	err  error
+}
+
+// abandonFifoOpen ends an open of fifo for writing that no pane will
+// complete: opening the FIFO for reading lets the open return, and the file
+// it returns is closed.
+func abandonFifoOpen(fifo string, opened <-chan fifoOpen) {
+	reader, err := os.OpenFile(fifo, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return
+	}
+	if result := <-opened; result.file != nil {
+		_ = result.file.Close()
+	}
+	_ = reader.Close()
+}
+
+// stop kills the private tmux server, which also ends the client.
+func (n *tmuxNest) stop() {
+	_ = exec.Command(n.path, "-S", n.socket, "kill-server").Run()
+	select {
+	case <-n.exited:
+	case <-time.After(3 * time.Second):
+		_ = n.client.Process.Kill()
+	}
+}
+
+// vtConsumer stands in for a terminal reading the PTY master: output is
+// parsed into an emulator and paced so at most cellsPerSecond printed cells
+// are consumed per second.
+type vtConsumer struct {
+	term   *vtverify.Terminal
+	stream *ansistat.Analyzer
+	cps    int64
+
+	mu      sync.Mutex
+	due     time.Time
+	bytes   int64
+	cells   int64
+	pacedNs int64
+	parseNs int64
+}
+
+func newVTConsumer(cps int64, rows int, cols int) *vtConsumer {
+	return &vtConsumer{term: vtverify.New(rows, cols), stream: ansistat.NewAnalyzer(), cps: cps}
+}
+
+func (c *vtConsumer) consume(p []byte) {
+	start := time.Now()
+	_, _ = c.term.Write(p)
+	parsed := time.Now()
+
+	c.mu.Lock()
+	printed := c.stream.Printed()
+	_, _ = c.stream.Write(p)
+	cells := c.stream.Printed() - printed
+	c.bytes += int64(len(p))
+	c.cells += cells
+	c.parseNs += parsed.Sub(start).Nanoseconds()
+	if c.due.Before(start) {
+		c.due = start
+	}
+	c.due = c.due.Add(time.Duration(float64(cells) / float64(c.cps) * float64(time.Second)))
+	wait := time.Until(c.due)
+	if wait > 0 {
+		c.pacedNs += wait.Nanoseconds()
+	}
+	c.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+func (c *vtConsumer) result() *consumerResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &consumerResult{
+		CellsPerSecond: c.cps,
+		Bytes:          c.bytes,
+		Cells:          c.cells,
+		PacedMs:        nsToMs(c.pacedNs),
+		ParseMs:        nsToMs(c.parseNs),
+	}
+}
+
+func (s *outputSink) Write(p []byte) (int, error) {
+	n, err := s.writer.Write(p)
+	s.written.Add(int64(n))
+	return n, err
+}
+
+func (s *outputSink) nested() *nestedResult {
+	if s.tmux == nil {
+		return nil
+	}
+	inner, outer := s.written.Load(), s.read.Load()
+	result := &nestedResult{Multiplexer: "tmux", Version: s.tmux.version, InnerBytes: inner, OuterBytes: outer}
+	if inner > 0 {
+		result.Amplification = float64(outer) / float64(inner)
+	}
+	return result
+}
+
+func openOutputSink(kind string, path string, rows int, cols int, vt *vtConsumer) (*outputSink, error) {
+	sink := &outputSink{kind: kind, vt: vt, done: make(chan struct{})}
+	switch kind {
+	case "pty":
+		master, slave, err := pty.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open pty: %w", err)
+		}
+		if err := pty.Setsize(master, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+			_ = master.Close()
+			_ = slave.Close()
+			return nil, fmt.Errorf("set pty size: %w", err)
+		}
+		sink.reader, sink.writer = master, slave
+	case "pipe":
+		r, w, err := os.Pipe()
+		if err != nil {
+			return nil, fmt.Errorf("open pipe: %w", err)
+		}
+		sink.reader, sink.writer = r, w
+	case "file":
+		var f *os.File
+		var err error
+		if path == "" {
+			f, err = os.CreateTemp("", "bubbletea-bench-sink-*")
+			sink.temp = true
+		} else {
+			f, err = os.Create(path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("open sink file: %w", err)
+		}
+		sink.writer, sink.path = f, f.Name()
+	case "inherit":
+		sink.writer = os.Stdout
+	case "tmux":
+		if err := openTmuxSink(sink, rows, cols); err != nil {
+			return nil, err
+		}
+		return sink, nil
+	}
+	if sink.reader != nil {
+		go sink.drain()
+	} else {
+		close(sink.done)
+	}
+	return sink, nil
+}
+
+func (s *outputSink) drain() {
+	defer close(s.done)
+	size := 64 * 1024
+	if s.vt != nil {
+		// Small reads leave the rest in the kernel buffer, so a slow
+		// consumer pushes back on the writer like a real terminal does.
+		size = 4 * 1024
+	}
+	buf := make([]byte, size)
+	for {
+		n, err := s.reader.Read(buf)
+		if n > 0 {
+			s.read.Add(int64(n))
+			if s.vt != nil {
+				s.vt.consume(buf[:n])
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// close hangs up the writer, waits for the reader to drain, and returns the
+// number of bytes that reached the sink. Stdout is left open.
+func (s *outputSink) close() int64 {
+	switch s.kind {
+	case "inherit":
+		return 0
+	case "file":
+		info, err := s.writer.Stat()
+		_ = s.writer.Close()
+		if s.temp {
+			_ = os.Remove(s.path)
+		}
+		if err != nil {
+			return 0
+		}
+		return info.Size()
+	case "tmux":
+		// Closing the FIFO ends cat, which ends the pane and the session;
+		// give tmux a moment to flush its last redraw before stopping it.
+		_ = s.writer.Close()
+		select {
+		case <-s.tmux.exited:
+		case <-time.After(time.Second):
+		}
+		s.tmux.stop()
+		select {
+		case <-s.done:
+		case <-time.After(3 * time.Second):
+		}
+		_ = s.reader.Close()
+		_ = os.RemoveAll(s.tmux.dir)
+		return s.read.Load()
+	}
+	_ = s.writer.Close()
+	select {
+	case <-s.done:
+	case <-time.After(3 * time.Second):
+	}
+	_ = s.reader.Close()
+	return s.read.Load()
+}
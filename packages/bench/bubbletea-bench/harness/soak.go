@@ -0,0 +1,383 @@
+package harness
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+	"time"
+)
+
+// soakReservoir is how many frame samples a soak keeps when Iterations does
+// not say: a uniform sample of the whole run, so samplesMs and summary still
+// describe it without memory growing with its length.
+const soakReservoir = 10000
+
+// soakResult is the "soak" field of a Result run with --soak. The run keeps
+// no per-frame bookkeeping beyond a fixed reservoir of samples; what it has
+// instead is a checkpoint every IntervalMs, each also sent to --emit-stream
+// and --emit ndjson as it is taken. The slopes fit a line through the
+// checkpoints, so a steady climb shows up whatever the noise.
+type soakResult struct {
+	DurationMs            float64          `json:"durationMs"`
+	IntervalMs            float64          `json:"intervalMs"`
+	ReservoirSize         int              `json:"reservoirSize"`
+	RSSSlopeKbPerHour     float64          `json:"rssSlopeKbPerHour"`
+	HeapSlopeKbPerHour    float64          `json:"heapSlopeKbPerHour"`
+	GoroutineSlopePerHour float64          `json:"goroutineSlopePerHour"`
+	Checkpoints           []soakCheckpoint `json:"checkpoints"`
+}
+
+// soakCheckpoint covers the frames since the previous checkpoint. Memory is
+// read at the checkpoint; GC and CPU figures are for the interval, except
+// GCCPUFraction, which the runtime keeps since the process started.
+type soakCheckpoint struct {
+	Index           int           `json:"index"`
+	ElapsedMs       float64       `json:"elapsedMs"`
+	Frames          int           `json:"frames"`
+	TotalFrames     int           `json:"totalFrames"`
+	FramesPerSecond float64       `json:"framesPerSecond"`
+	Summary         sampleSummary `json:"summary"`
+	Bytes           int64         `json:"bytes"`
+	CPUUserMs       float64       `json:"cpuUserMs"`
+	CPUSysMs        float64       `json:"cpuSysMs"`
+	RSSKb           int64         `json:"rssKb"`
+	HeapKb          int64         `json:"heapKb"`
+	HeapObjects     uint64        `json:"heapObjects"`
+	NextGCKb        int64         `json:"nextGcKb"`
+	CgroupKb        int64         `json:"cgroupKb,omitempty"`
+	Goroutines      int           `json:"goroutines"`
+	Threads         int64         `json:"threads"`
+	GCCycles        uint32        `json:"gcCycles"`
+	GCPauseMs       float64       `json:"gcPauseMs"`
+	GCPauseMaxMs    float64       `json:"gcPauseMaxMs"`
+	GCCPUFraction   float64       `json:"gcCpuFraction"`
+}
+
+// soakTracker takes checkpoints and keeps the reservoir.
+type soakTracker struct {
+	interval  time.Duration
+	start     time.Time
+	next      time.Time
+	cgroupDir string
+	proc      usageProc
+
+	window      []float64
+	windowStart time.Time
+	windowBytes int64
+	cpu         cpuUsage
+	numGC       uint32
+	pauseNs     uint64
+	frames      int
+
+	reservoir []float64
+	rng       *rand.Rand
+
+	result soakResult
+}
+
+func newSoakTracker(cfg Config, start time.Time, cgroupDir string, proc usageProc) *soakTracker {
+	size := soakReservoir
+	if cfg.Iterations > 0 {
+		size = cfg.Iterations
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return &soakTracker{
+		interval:    cfg.SoakInterval,
+		start:       start,
+		next:        start.Add(cfg.SoakInterval),
+		cgroupDir:   cgroupDir,
+		proc:        proc,
+		windowStart: start,
+		cpu:         proc.cpu(),
+		numGC:       ms.NumGC,
+		pauseNs:     ms.PauseTotalNs,
+		reservoir:   make([]float64, 0, size),
+		rng:         rand.New(rand.NewPCG(cfg.Seed, 0x50a4)),
+		result: soakResult{
+			DurationMs:    nsToMs(cfg.Soak.Nanoseconds()),
+			IntervalMs:    nsToMs(cfg.SoakInterval.Nanoseconds()),
+			ReservoirSize: size,
+			Checkpoints:   []soakCheckpoint{},
+		},
+	}
+}
+
+// add records one frame and reports whether a checkpoint is due.
+func (s *soakTracker) add(sampleMs float64, bytes int64) bool {
+	s.window = append(s.window, sampleMs)
+	s.windowBytes += bytes
+	if len(s.reservoir) < cap(s.reservoir) {
+		s.reservoir = append(s.reservoir, sampleMs)
+	} else if j := s.rng.IntN(s.frames + 1); j < len(s.reservoir) {
+		s.reservoir[j] = sampleMs
+	}
+	s.frames++
+	return !time.Now().Before(s.next)
+}
+
+// checkpoint closes the current window.
+func (s *soakTracker) checkpoint() soakCheckpoint {
+	now := time.Now()
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	cpu := s.proc.cpu()
+	delta := diffCPU(s.cpu, cpu)
+	cp := soakCheckpoint{
+		Index:           len(s.result.Checkpoints),
+		ElapsedMs:       nsToMs(now.Sub(s.start).Nanoseconds()),
+		Frames:          len(s.window),
+		TotalFrames:     s.frames,
+		FramesPerSecond: framesPerSecond(len(s.window), nsToMs(now.Sub(s.windowStart).Nanoseconds())),
+		Summary:         summarize(s.window),
+		Bytes:           s.windowBytes,
+		CPUUserMs:       delta.userMs,
+		CPUSysMs:        delta.systemMs,
+		RSSKb:           s.proc.rssKb(),
+		HeapKb:          int64(ms.HeapAlloc / 1024),
+		HeapObjects:     ms.HeapObjects,
+		NextGCKb:        int64(ms.NextGC / 1024),
+		Goroutines:      runtime.NumGoroutine(),
+		Threads:         readThreadCount(),
+		GCCycles:        ms.NumGC - s.numGC,
+		GCPauseMs:       nsToMs(int64(ms.PauseTotalNs - s.pauseNs)),
+		GCPauseMaxMs:    nsToMs(int64(maxPauseSince(&ms, s.numGC))),
+		GCCPUFraction:   ms.GCCPUFraction,
+	}
+	if s.cgroupDir != "" {
+		cp.CgroupKb = readCgroupInt(s.cgroupDir, "memory.current") / 1024
+	}
+	s.result.Checkpoints = append(s.result.Checkpoints, cp)
+
+	s.window = s.window[:0]
+	s.windowStart = now
+	s.windowBytes = 0
+	s.cpu = cpu
+	s.numGC = ms.NumGC
+	s.pauseNs = ms.PauseTotalNs
+	for !s.next.After(now) {
+		s.next = s.next.Add(s.interval)
+	}
+	return cp
+}
+
+// finish takes a last checkpoint for a partial window and fits the trends.
+func (s *soakTracker) finish() *soakResult {
+	if len(s.window) > 0 {
+		s.checkpoint()
+	}
+	hours := make([]float64, len(s.result.Checkpoints))
+	rss := make([]float64, len(hours))
+	heap := make([]float64, len(hours))
+	goroutines := make([]float64, len(hours))
+	for i, cp := range s.result.Checkpoints {
+		hours[i] = cp.ElapsedMs / float64(time.Hour/time.Millisecond)
+		rss[i] = float64(cp.RSSKb)
+		heap[i] = float64(cp.HeapKb)
+		goroutines[i] = float64(cp.Goroutines)
+	}
+	s.result.RSSSlopeKbPerHour = leastSquaresSlope(hours, rss)
+	s.result.HeapSlopeKbPerHour = leastSquaresSlope(hours, heap)
+	s.result.GoroutineSlopePerHour = leastSquaresSlope(hours, goroutines)
+	return &s.result
+}
+
+// maxPauseSince is the longest GC pause after cycle numGC that is still in
+// the runtime's ring of recent pauses.
+func maxPauseSince(ms *runtime.MemStats, numGC uint32) uint64 {
+	var longest uint64
+	for n := ms.NumGC; n > numGC && ms.NumGC-n < uint32(len(ms.PauseNs)); n-- {
+		longest = max(longest, ms.PauseNs[(n+uint32(len(ms.PauseNs))-1)%uint32(len(ms.PauseNs))])
+	}
+	return longest
+}
+
+func leastSquaresSlope(xs, ys []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+	var num, den float64
+	for i := range xs {
+		num += (xs[i] - meanX) * (ys[i] - meanY)
+		den += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if den == 0 {
+		return 0
+	}
+	return num / den
+}
+
+// runSoakBench is the steady-state loop for --soak: one program ticked for
+// the whole duration, with checkpoints in place of per-frame bookkeeping.
+// Frame writes are not segmented, so the Writer's write log is dropped at
+// every checkpoint; otherwise it would grow for hours.
+func runSoakBench(ctx context.Context, cfg Config, output benchOutput) (Result, error) {
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+	writer := output.newWriter()
+
+	session, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, writer)
+	if err != nil {
+		return Result{}, err
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = session.Close()
+		}
+	}()
+
+	eventLoop := usesEventLoopScheduling(cfg.Scenario)
+	if _, err := session.RenderTick(0, false); err != nil {
+		return Result{}, err
+	}
+	retries := newRetrier(cfg.Retries, renderRetryable)
+	warmupSamples := make([]float64, 0, cfg.warmupLimit())
+	for i := 0; i < cfg.warmupLimit() && !cfg.warmedUp(warmupSamples); i++ {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		var ts time.Time
+		if _, err := retries.do("warmup", i+1, func() (err error) {
+			ts = time.Now()
+			_, err = session.RenderTick(i+1, eventLoop)
+			return err
+		}); err != nil {
+			return Result{}, err
+		}
+		warmupSamples = append(warmupSamples, MsSince(ts))
+		output.beat("warmup", i)
+	}
+	writer.dropWrites()
+
+	tryGC()
+	proc := usageProcOf(session)
+	memBefore := proc.memory()
+	cpuBefore := proc.cpu()
+	memPeak := memBefore
+	schedBefore := takeSched()
+	schedPeak := schedBefore
+	cgroupDir := cgroupV2Dir()
+	bytesBase, _ := writer.Snapshot()
+	blockedBase := writer.blockedMs()
+	load := startBackgroundLoad(cfg.LoadCPUPercent, cfg.LoadAllocMBps)
+	start := time.Now()
+	soak := newSoakTracker(cfg, start, cgroupDir, proc)
+
+	interrupted := false
+	frames := 0
+	for ; time.Since(start) < cfg.Soak; frames++ {
+		if ctx.Err() != nil {
+			interrupted = true
+			break
+		}
+		tick := len(warmupSamples) + frames + 1
+		var tickBytesBase int64
+		var ts time.Time
+		if _, err := retries.do("measure", tick, func() (err error) {
+			tickBytesBase, _ = writer.Snapshot()
+			ts = time.Now()
+			_, err = session.RenderTick(tick, eventLoop)
+			return err
+		}); err != nil {
+			return Result{}, err
+		}
+		elapsed := MsSince(ts)
+		tickBytes, _ := writer.Snapshot()
+		output.beat("soak", frames)
+		if soak.add(elapsed, tickBytes-tickBytesBase) {
+			cp := soak.checkpoint()
+			mem := memorySnapshot{rssKb: cp.RSSKb}
+			if proc == 0 {
+				mem.heapUsedKb = cp.HeapKb
+			}
+			memPeak = peakMemory(memPeak, mem)
+			schedPeak = peakSched(schedPeak, schedSnapshot{threads: cp.Threads, goroutines: cp.Goroutines})
+			writer.dropWrites()
+			output.checkpoint(streamRecord{Type: "checkpoint", Phase: "soak", Iteration: frames, Tick: tick, SampleMs: cp.Summary.Median, Bytes: cp.Bytes, RSSKb: cp.RSSKb, HeapKb: cp.HeapKb, Checkpoint: &cp})
+		}
+	}
+
+	totalWallMs := MsSince(start)
+	loadResult := load.finish()
+	if interrupted && frames == 0 {
+		return Result{}, ctx.Err()
+	}
+	soakResult := soak.finish()
+	cpu := diffCPU(cpuBefore, proc.cpu())
+	memAfter := proc.memory()
+	memPeak = peakMemory(memPeak, memAfter)
+	schedAfter := takeSched()
+	schedPeak = peakSched(schedPeak, schedAfter)
+	bytesAfter, _ := writer.Snapshot()
+	writeBlockTotalMs := writer.blockedMs() - blockedBase
+
+	if err := session.Close(); err != nil {
+		return Result{}, err
+	}
+	closed = true
+
+	var totalChangedCells int64
+	if !interrupted {
+		cells, err := emulatedChangedCells(ctx, cfg, rows, cols, tickSpan(len(warmupSamples), frames+1), 1, false, nil)
+		if err != nil {
+			return Result{}, err
+		}
+		totalChangedCells = sumCells(cells)
+	}
+
+	return Result{
+		Replay:                 newReplayInfo(cfg, tickRanges(true, len(warmupSamples), 0, frames)),
+		SamplesMs:              soak.reservoir,
+		Summary:                summarize(soak.reservoir),
+		UpdateSamplesMs:        []float64{},
+		ViewSamplesMs:          []float64{},
+		FlushSamplesMs:         []float64{},
+		WriteBlockSamplesMs:    []float64{},
+		WriteBlockMaxSamplesMs: []float64{},
+		WriteBlockTotalMs:      writeBlockTotalMs,
+		TotalWallMs:            totalWallMs,
+		CPUUserMs:              cpu.userMs,
+		CPUSysMs:               cpu.systemMs,
+		MinorFaults:            cpu.minorFaults,
+		MajorFaults:            cpu.majorFaults,
+		VolCtxSwitches:         cpu.volCtxSw,
+		InvolCtxSwitches:       cpu.involCtxSw,
+		VolCtxSwPerFrame:       perFrame(cpu.volCtxSw, frames),
+		InvolCtxSwPerFrame:     perFrame(cpu.involCtxSw, frames),
+		RSSBeforeKb:            memBefore.rssKb,
+		RSSAfterKb:             memAfter.rssKb,
+		RSSPeakKb:              memPeak.rssKb,
+		HeapBeforeKb:           memBefore.heapUsedKb,
+		HeapAfterKb:            memAfter.heapUsedKb,
+		HeapPeakKb:             memPeak.heapUsedKb,
+		BytesWritten:           bytesAfter - bytesBase,
+		Seed:                   cfg.Seed,
+		Interrupted:            interrupted,
+		Retries:                retries.result(),
+		Frames:                 frames,
+		FramesPerSecond:        framesPerSecond(frames, totalWallMs),
+		DurationBudgetMs:       nsToMs(cfg.Soak.Nanoseconds()),
+		ThreadsBefore:          schedBefore.threads,
+		ThreadsAfter:           schedAfter.threads,
+		ThreadsPeak:            schedPeak.threads,
+		GoroutinesAfter:        schedAfter.goroutines,
+		GOMAXPROCS:             runtime.GOMAXPROCS(0),
+		GOGC:                   gcPercent(),
+		NumCPU:                 runtime.NumCPU(),
+		ProgramUsage:           proc != 0,
+		Load:                   loadResult,
+		Soak:                   soakResult,
+		Outliers:               []outlierSample{},
+		Warmup:                 buildWarmupReport(warmupSamples, cfg),
+		ChangedCellSamples:     []int{},
+		ChangedCells:           totalChangedCells,
+		BytesPerChangedCell:    bytesPerChangedCell(bytesAfter-bytesBase, totalChangedCells),
+	}, nil
+}
@@ -0,0 +1,582 @@
+package harness
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// SpecVersion is the scenario-spec format this harness reads.
+//
+// A spec is a JSON file in specs/ describing one scenario whose content
+// needs no FrameRand, named after it. The TypeScript harness reads the same
+// files, so the two cannot drift apart; --scenario-file loads one from
+// anywhere else. A spec holds the scenario's
+// ScenarioMeta fields (name, description, params, rows, cols, screen,
+// eventLoop) and "lines", the rules that build a frame top to bottom. A
+// rule is
+//
+//	{"text": "...", "repeat": "expr", "dirty": true}
+//
+// and adds one line, or "repeat" lines with i counting from 0. "dirty"
+// declares that its lines change every tick and the others never do, which
+// --selftest checks. Text is literal but for {expr} and {expr:fmt}
+// placeholders, with {{ and }} for braces. An expr is integer arithmetic
+// (+ - * / %, truncating like Go, with x/0 and x%0 being 0) over integer
+// literals, 'quoted' strings, tick, i, cols, the scenario's params, and the
+// functions rep(s, n), min(a, b) and max(a, b). A fmt is a width, right
+// aligned, with a 0 prefix to zero-pad or a - prefix to left-align. Every
+// line is clipped or padded to the terminal's width.
+const SpecVersion = 1
+
+//go:embed specs/*.json
+var specFiles embed.FS
+
+// ScenarioSpec is a scenario spec file as written.
+type ScenarioSpec struct {
+	SpecVersion int         `json:"specVersion"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Params      []ParamSpec `json:"params,omitempty"`
+	Rows        int         `json:"rows,omitempty"`
+	Cols        int         `json:"cols,omitempty"`
+	Screen      bool        `json:"screen,omitempty"`
+	EventLoop   bool        `json:"eventLoop,omitempty"`
+	Lines       []SpecLine  `json:"lines"`
+}
+
+// SpecLine is one content rule of a ScenarioSpec.
+type SpecLine struct {
+	Text   string `json:"text"`
+	Repeat string `json:"repeat,omitempty"`
+	Dirty  bool   `json:"dirty,omitempty"`
+}
+
+func init() {
+	names, err := specFiles.ReadDir("specs")
+	if err != nil {
+		panic(fmt.Sprintf("harness: reading scenario specs: %v", err))
+	}
+	for _, entry := range names {
+		data, err := specFiles.ReadFile(path.Join("specs", entry.Name()))
+		if err != nil {
+			panic(fmt.Sprintf("harness: reading scenario spec %s: %v", entry.Name(), err))
+		}
+		spec, err := compileSpec(data)
+		if err == nil && entry.Name() != spec.Name+".json" {
+			err = fmt.Errorf("describes %q", spec.Name)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("harness: scenario spec %s: %v", entry.Name(), err))
+		}
+		spec.register()
+	}
+}
+
+// LoadScenarioFile registers the scenario described by the spec file at
+// path, for --scenario-file, so users can bench their own app's shape
+// without rebuilding the harness. Its name must not be taken.
+func LoadScenarioFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("load scenario file: %w", err)
+	}
+	spec, err := compileSpec(data)
+	if err != nil {
+		return fmt.Errorf("scenario file %s: %w", path, err)
+	}
+	if _, ok := lookupScenario(spec.Name); ok {
+		return fmt.Errorf("scenario file %s redefines scenario %q", path, spec.Name)
+	}
+	spec.register()
+	return nil
+}
+
+// compiledSpec is a ScenarioSpec with its rules parsed.
+type compiledSpec struct {
+	ScenarioSpec
+	rules []specRule
+}
+
+type specRule struct {
+	parts  []specPart
+	repeat specExpr
+	dirty  bool
+}
+
+// specPart is a run of literal text, or a placeholder when expr is set.
+type specPart struct {
+	literal string
+	expr    specExpr
+	width   int
+	zero    bool
+	left    bool
+}
+
+func compileSpec(data []byte) (*compiledSpec, error) {
+	var spec compiledSpec
+	if err := json.Unmarshal(data, &spec.ScenarioSpec); err != nil {
+		return nil, err
+	}
+	if spec.SpecVersion != SpecVersion {
+		return nil, fmt.Errorf("specVersion %d, want %d", spec.SpecVersion, SpecVersion)
+	}
+	if spec.Name == "" {
+		return nil, fmt.Errorf("no name")
+	}
+	names := map[string]bool{"tick": true, "cols": true}
+	for _, p := range spec.Params {
+		switch {
+		case p.Name == "" || p.Name == "i" || names[p.Name]:
+			return nil, fmt.Errorf("param %q is empty, reserved or repeated", p.Name)
+		case p.Type != "" && p.Type != "int" && p.Type != "bool":
+			return nil, fmt.Errorf("param %q has unknown type %q", p.Name, p.Type)
+		}
+		names[p.Name] = true
+	}
+	for n, line := range spec.Lines {
+		rule := specRule{dirty: line.Dirty}
+		scope := names
+		if line.Repeat != "" {
+			repeat, str, err := parseSpecExpr(line.Repeat, names)
+			if err == nil && str {
+				err = fmt.Errorf("%q is a string", line.Repeat)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("lines[%d] repeat: %w", n, err)
+			}
+			rule.repeat = repeat
+			scope = map[string]bool{"i": true}
+			for name := range names {
+				scope[name] = true
+			}
+		}
+		parts, err := parseSpecTemplate(line.Text, scope)
+		if err != nil {
+			return nil, fmt.Errorf("lines[%d] text: %w", n, err)
+		}
+		rule.parts = parts
+		spec.rules = append(spec.rules, rule)
+	}
+	return &spec, nil
+}
+
+func (s *compiledSpec) register() {
+	registerScenario(s.Name, ScenarioMeta{
+		Description: s.Description,
+		Params:      s.Params,
+		Rows:        s.Rows,
+		Cols:        s.Cols,
+		Screen:      s.Screen,
+		EventLoop:   s.EventLoop,
+	}, s.lines, s.dirtyRows)
+}
+
+// env is the variables a spec's expressions see at tick.
+func (s *compiledSpec) env(params map[string]string, tick int, cols int) map[string]specValue {
+	env := map[string]specValue{"tick": {n: tick}, "cols": {n: cols}}
+	for _, p := range s.Params {
+		if p.Type == "bool" {
+			env[p.Name] = specValue{n: boolParamInt(params, p)}
+			continue
+		}
+		env[p.Name] = specValue{n: intParam(params, p.Name, p.Default)}
+	}
+	return env
+}
+
+func boolParamInt(params map[string]string, p ParamSpec) int {
+	raw, ok := params[p.Name]
+	if !ok {
+		return p.Default
+	}
+	if on, err := strconv.ParseBool(raw); err == nil && on {
+		return 1
+	}
+	return 0
+}
+
+// lines is the spec's Generator.
+func (s *compiledSpec) lines(params map[string]string, tick int, cols int, _ *FrameRand) []string {
+	env := s.env(params, tick, cols)
+	lines := []string{}
+	var b strings.Builder
+	for _, rule := range s.rules {
+		count := 1
+		if rule.repeat != nil {
+			count = rule.repeat.eval(env).n
+		}
+		for i := 0; i < count; i++ {
+			env["i"] = specValue{n: i}
+			b.Reset()
+			for _, part := range rule.parts {
+				if part.expr == nil {
+					b.WriteString(part.literal)
+					continue
+				}
+				b.WriteString(part.format(part.expr.eval(env)))
+			}
+			lines = append(lines, clipPad(b.String(), cols))
+		}
+	}
+	return lines
+}
+
+// dirtyRows is the rows the spec declares change every tick.
+func (s *compiledSpec) dirtyRows(params map[string]string) []int {
+	env := s.env(params, 0, 0)
+	rows := []int{}
+	row := 0
+	for _, rule := range s.rules {
+		count := 1
+		if rule.repeat != nil {
+			count = max(0, rule.repeat.eval(env).n)
+		}
+		for i := 0; i < count; i++ {
+			if rule.dirty {
+				rows = append(rows, row)
+			}
+			row++
+		}
+	}
+	return rows
+}
+
+func (p specPart) format(v specValue) string {
+	text := v.s
+	if !v.str {
+		text = strconv.Itoa(v.n)
+		if p.zero && v.n < 0 {
+			text = text[1:]
+			if pad := p.width - 1 - len(text); pad > 0 {
+				text = strings.Repeat("0", pad) + text
+			}
+			return "-" + text
+		}
+	}
+	pad := p.width - len([]rune(text))
+	switch {
+	case pad <= 0:
+		return text
+	case p.left:
+		return text + strings.Repeat(" ", pad)
+	case p.zero && !v.str:
+		return strings.Repeat("0", pad) + text
+	}
+	return strings.Repeat(" ", pad) + text
+}
+
+// parseSpecTemplate splits text into literal runs and placeholders.
+func parseSpecTemplate(text string, names map[string]bool) ([]specPart, error) {
+	var parts []specPart
+	var literal strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '{' && strings.HasPrefix(text[i:], "{{"), c == '}' && strings.HasPrefix(text[i:], "}}"):
+			literal.WriteByte(c)
+			i++
+		case c == '}':
+			return nil, fmt.Errorf("unmatched } at %d", i)
+		case c == '{':
+			end := placeholderEnd(text, i+1)
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed { at %d", i)
+			}
+			part, err := parsePlaceholder(text[i+1:end], names)
+			if err != nil {
+				return nil, err
+			}
+			if literal.Len() > 0 {
+				parts = append(parts, specPart{literal: literal.String()})
+				literal.Reset()
+			}
+			parts = append(parts, part)
+			i = end
+		default:
+			literal.WriteByte(c)
+		}
+	}
+	if literal.Len() > 0 {
+		parts = append(parts, specPart{literal: literal.String()})
+	}
+	return parts, nil
+}
+
+// placeholderEnd is the index of the } closing a placeholder opened before
+// from, skipping braces in quoted strings, or -1.
+func placeholderEnd(text string, from int) int {
+	quoted := false
+	for i := from; i < len(text); i++ {
+		switch text[i] {
+		case '\'':
+			quoted = !quoted
+		case '}':
+			if !quoted {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func parsePlaceholder(body string, names map[string]bool) (specPart, error) {
+	source, format := body, ""
+	if colon := strings.LastIndexByte(body, ':'); colon >= 0 && !strings.Contains(body[colon:], "'") {
+		source, format = body[:colon], body[colon+1:]
+	}
+	expr, _, err := parseSpecExpr(source, names)
+	if err != nil {
+		return specPart{}, fmt.Errorf("{%s}: %w", body, err)
+	}
+	part := specPart{expr: expr}
+	if format != "" {
+		switch format[0] {
+		case '-':
+			part.left, format = true, format[1:]
+		case '0':
+			part.zero = true
+		}
+		width, err := strconv.Atoi(format)
+		if err != nil || width < 0 {
+			return specPart{}, fmt.Errorf("{%s}: bad format %q", body, body[len(source)+1:])
+		}
+		part.width = width
+	}
+	return part, nil
+}
+
+// specValue is an integer, or a string when str is set.
+type specValue struct {
+	n   int
+	s   string
+	str bool
+}
+
+type specExpr interface {
+	eval(env map[string]specValue) specValue
+}
+
+type (
+	specLiteral  specValue
+	specVariable string
+	specNegate   struct{ x specExpr }
+	specBinary   struct {
+		op   byte
+		x, y specExpr
+	}
+	specCall struct {
+		name string
+		args []specExpr
+	}
+)
+
+func (e specLiteral) eval(map[string]specValue) specValue      { return specValue(e) }
+func (e specVariable) eval(env map[string]specValue) specValue { return env[string(e)] }
+func (e specNegate) eval(env map[string]specValue) specValue {
+	return specValue{n: -e.x.eval(env).n}
+}
+
+func (e specBinary) eval(env map[string]specValue) specValue {
+	x, y := e.x.eval(env).n, e.y.eval(env).n
+	switch e.op {
+	case '+':
+		return specValue{n: x + y}
+	case '-':
+		return specValue{n: x - y}
+	case '*':
+		return specValue{n: x * y}
+	case '/':
+		if y == 0 {
+			return specValue{}
+		}
+		return specValue{n: x / y}
+	}
+	return specValue{n: safeMod(x, y)}
+}
+
+func (e specCall) eval(env map[string]specValue) specValue {
+	a, b := e.args[0].eval(env), e.args[1].eval(env)
+	switch e.name {
+	case "rep":
+		return specValue{s: strings.Repeat(a.s, max(0, b.n)), str: true}
+	case "min":
+		return specValue{n: min(a.n, b.n)}
+	}
+	return specValue{n: max(a.n, b.n)}
+}
+
+// specFuncs are the functions an expression may call, by whether each
+// argument is a string.
+var specFuncs = map[string][]bool{
+	"rep": {true, false},
+	"min": {false, false},
+	"max": {false, false},
+}
+
+// specParser is a recursive-descent parser over one expression.
+type specParser struct {
+	src   string
+	pos   int
+	names map[string]bool
+}
+
+// parseSpecExpr parses src, which may name only names; the bool reports
+// whether it evaluates to a string.
+func parseSpecExpr(src string, names map[string]bool) (specExpr, bool, error) {
+	p := &specParser{src: src, names: names}
+	expr, str, err := p.sum()
+	if err != nil {
+		return nil, false, err
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, false, fmt.Errorf("unexpected %q in %q", p.src[p.pos:], src)
+	}
+	return expr, str, nil
+}
+
+func (p *specParser) skipSpace() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *specParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// sum and product parse the binary operators by precedence; the bool
+// results report a string operand, which only a placeholder or rep may
+// take.
+func (p *specParser) sum() (specExpr, bool, error) {
+	x, str, err := p.product()
+	for err == nil {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			break
+		}
+		p.pos++
+		var y specExpr
+		var ystr bool
+		if y, ystr, err = p.product(); err == nil && (str || ystr) {
+			err = fmt.Errorf("%c on a string in %q", op, p.src)
+		}
+		x = specBinary{op: op, x: x, y: y}
+	}
+	return x, str, err
+}
+
+func (p *specParser) product() (specExpr, bool, error) {
+	x, str, err := p.unary()
+	for err == nil {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			break
+		}
+		p.pos++
+		var y specExpr
+		var ystr bool
+		if y, ystr, err = p.unary(); err == nil && (str || ystr) {
+			err = fmt.Errorf("%c on a string in %q", op, p.src)
+		}
+		x = specBinary{op: op, x: x, y: y}
+	}
+	return x, str, err
+}
+
+func (p *specParser) unary() (specExpr, bool, error) {
+	if p.peek() != '-' {
+		return p.primary()
+	}
+	p.pos++
+	x, str, err := p.unary()
+	if err == nil && str {
+		err = fmt.Errorf("- on a string in %q", p.src)
+	}
+	return specNegate{x: x}, false, err
+}
+
+func (p *specParser) primary() (specExpr, bool, error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		x, str, err := p.sum()
+		if err == nil && p.peek() != ')' {
+			err = fmt.Errorf("missing ) in %q", p.src)
+		}
+		p.pos++
+		return x, str, err
+	case c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], '\'')
+		if end < 0 {
+			return nil, false, fmt.Errorf("unclosed string in %q", p.src)
+		}
+		s := p.src[p.pos+1 : p.pos+1+end]
+		p.pos += end + 2
+		return specLiteral{s: s, str: true}, true, nil
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		n, err := strconv.Atoi(p.src[start:p.pos])
+		return specLiteral{n: n}, false, err
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		start := p.pos
+		for p.pos < len(p.src) && isSpecIdent(p.src[p.pos]) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek() == '(' {
+			return p.call(name)
+		}
+		if !p.names[name] {
+			return nil, false, fmt.Errorf("unknown name %q in %q", name, p.src)
+		}
+		return specVariable(name), false, nil
+	}
+	return nil, false, fmt.Errorf("expected a value at %d in %q", p.pos, p.src)
+}
+
+func (p *specParser) call(name string) (specExpr, bool, error) {
+	kinds, ok := specFuncs[name]
+	if !ok {
+		return nil, false, fmt.Errorf("unknown function %q in %q", name, p.src)
+	}
+	p.pos++
+	call := specCall{name: name}
+	for i, wantStr := range kinds {
+		if i > 0 {
+			if p.peek() != ',' {
+				return nil, false, fmt.Errorf("%s takes %d arguments in %q", name, len(kinds), p.src)
+			}
+			p.pos++
+		}
+		arg, str, err := p.sum()
+		if err != nil {
+			return nil, false, err
+		}
+		if str != wantStr {
+			return nil, false, fmt.Errorf("%s argument %d has the wrong type in %q", name, i+1, p.src)
+		}
+		call.args = append(call.args, arg)
+	}
+	if p.peek() != ')' {
+		return nil, false, fmt.Errorf("%s takes %d arguments in %q", name, len(kinds), p.src)
+	}
+	p.pos++
+	return call, name == "rep", nil
+}
+
+func isSpecIdent(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
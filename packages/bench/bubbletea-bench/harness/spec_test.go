@@ -0,0 +1,147 @@
+package harness
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSpecExpr(t *testing.T) {
+	names := map[string]bool{"tick": true, "cols": true, "n": true}
+	env := map[string]specValue{"tick": {n: 7}, "cols": {n: 80}, "n": {n: -3}}
+
+	cases := []struct {
+		src  string
+		want specValue
+		err  string
+	}{
+		{src: "1 + 2 * 3", want: specValue{n: 7}},
+		{src: "(1 + 2) * 3", want: specValue{n: 9}},
+		{src: "tick % 4 - -n", want: specValue{n: 0}},
+		{src: "n / 2", want: specValue{n: -1}},
+		{src: "n % 2", want: specValue{n: -1}},
+		{src: "tick / 0 + tick % 0", want: specValue{n: 0}},
+		{src: "min(cols, 100) - max(tick, 10)", want: specValue{n: 70}},
+		{src: "rep('ab', tick - 5)", want: specValue{s: "abab", str: true}},
+		{src: "rep('x', n)", want: specValue{s: "", str: true}},
+		{src: "'a}b'", want: specValue{s: "a}b", str: true}},
+		{src: "rows", err: `unknown name "rows"`},
+		{src: "sqrt(4)", err: `unknown function "sqrt"`},
+		{src: "min(1)", err: "takes 2 arguments"},
+		{src: "rep(1, 2)", err: "wrong type"},
+		{src: "'a' + 1", err: "on a string"},
+		{src: "-'a'", err: "on a string"},
+		{src: "(1 + 2", err: "missing )"},
+		{src: "1 2", err: "unexpected"},
+		{src: "'open", err: "unclosed string"},
+		{src: "", err: "expected a value"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.src, func(t *testing.T) {
+			expr, _, err := parseSpecExpr(tc.src, names)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := expr.eval(env); got != tc.want {
+				t.Errorf("= %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseSpecTemplate(t *testing.T) {
+	names := map[string]bool{"tick": true}
+	env := map[string]specValue{"tick": {n: 42}}
+
+	cases := []struct {
+		text string
+		want string
+		err  string
+	}{
+		{text: "plain", want: "plain"},
+		{text: "tick {tick}!", want: "tick 42!"},
+		{text: "{{{tick}}}", want: "{42}"},
+		{text: "[{tick:5}]", want: "[   42]"},
+		{text: "[{tick:05}]", want: "[00042]"},
+		{text: "[{tick:-5}]", want: "[42   ]"},
+		{text: "[{0 - tick:05}]", want: "[-0042]"},
+		{text: "[{'ab':4}]", want: "[  ab]"},
+		{text: "[{'a:b'}]", want: "[a:b]"},
+		{text: "[{tick:1}]", want: "[42]"},
+		{text: "{tick", err: "unclosed {"},
+		{text: "tick}", err: "unmatched }"},
+		{text: "{tick:x}", err: "bad format"},
+		{text: "{row}", err: "unknown name"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.text, func(t *testing.T) {
+			parts, err := parseSpecTemplate(tc.text, names)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var b strings.Builder
+			for _, part := range parts {
+				if part.expr == nil {
+					b.WriteString(part.literal)
+					continue
+				}
+				b.WriteString(part.format(part.expr.eval(env)))
+			}
+			if b.String() != tc.want {
+				t.Errorf("= %q, want %q", b.String(), tc.want)
+			}
+		})
+	}
+}
+
+func TestCompileSpec(t *testing.T) {
+	spec, err := compileSpec([]byte(`{
+		"specVersion": 1,
+		"name": "spec-test",
+		"params": [{"name": "items", "default": 2}],
+		"lines": [
+			{"text": "header"},
+			{"text": "item {i} of {items}", "repeat": "items"},
+			{"text": "tick {tick}", "dirty": true}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := spec.lines(map[string]string{"items": "3"}, 5, 12, nil)
+	want := []string{"header      ", "item 0 of 3 ", "item 1 of 3 ", "item 2 of 3 ", "tick 5      "}
+	if !slices.Equal(lines, want) {
+		t.Errorf("lines %q, want %q", lines, want)
+	}
+	if rows := spec.dirtyRows(map[string]string{}); !slices.Equal(rows, []int{3}) {
+		t.Errorf("dirty rows %v at the default of 2 items, want [3]", rows)
+	}
+
+	errors := []struct {
+		spec string
+		err  string
+	}{
+		{`{"specVersion": 2, "name": "x"}`, "specVersion 2"},
+		{`{"specVersion": 1}`, "no name"},
+		{`{"specVersion": 1, "name": "x", "lines": [{"text": "{i}"}]}`, `lines[0] text: {i}: unknown name "i"`},
+		{`{"specVersion": 1, "name": "x", "lines": [{"text": "", "repeat": "'a'"}]}`, "lines[0] repeat"},
+		{`{"specVersion": 1, "name": "x", "lines": [`, "unexpected end"},
+	}
+	for _, tc := range errors {
+		if _, err := compileSpec([]byte(tc.spec)); err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("compileSpec(%s): err %v, want %q", tc.spec, err, tc.err)
+		}
+	}
+}
@@ -0,0 +1,270 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The history store is a SQLite database driven through the sqlite3 CLI, so
+// the harness stays free of cgo. Each scenario document of a run is one row
+// of runs, with the gate metrics as columns for trend queries and the full
+// document kept alongside.
+const storeSchema = `CREATE TABLE IF NOT EXISTS runs (
+	id INTEGER PRIMARY KEY,
+	run_id TEXT,
+	recorded_at TEXT NOT NULL,
+	scenario TEXT NOT NULL,
+	ok INTEGER NOT NULL,
+	error TEXT,
+	labels TEXT NOT NULL,
+	environment TEXT NOT NULL,
+	document TEXT NOT NULL%s
+);
+CREATE INDEX IF NOT EXISTS runs_scenario ON runs (scenario, id);
+`
+
+// RunEnvironment is the machine a stored run was measured on.
+type RunEnvironment struct {
+	Host       string `json:"host"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	Kernel     string `json:"kernel,omitempty"`
+	CPUModel   string `json:"cpuModel,omitempty"`
+	NumCPU     int    `json:"numCpu"`
+	GOMAXPROCS int    `json:"gomaxprocs"`
+	GOGC       string `json:"gogc"`
+	GoVersion  string `json:"goVersion"`
+}
+
+func takeEnvironment() RunEnvironment {
+	env := RunEnvironment{
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		NumCPU:     runtime.NumCPU(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		GOGC:       gcPercent(),
+		GoVersion:  runtime.Version(),
+	}
+	env.Host, _ = os.Hostname()
+	if release, err := os.ReadFile("/proc/sys/kernel/osrelease"); err == nil {
+		env.Kernel = strings.TrimSpace(string(release))
+	}
+	if cpuinfo, err := os.ReadFile("/proc/cpuinfo"); err == nil {
+		for _, line := range strings.Split(string(cpuinfo), "\n") {
+			if name, value, ok := strings.Cut(line, ":"); ok && strings.TrimSpace(name) == "model name" {
+				env.CPUModel = strings.TrimSpace(value)
+				break
+			}
+		}
+	}
+	return env
+}
+
+func runSQLite(dbPath string, script string, args ...string) ([]byte, error) {
+	sqlite, err := exec.LookPath("sqlite3")
+	if err != nil {
+		return nil, errors.New("--store requires sqlite3 in PATH")
+	}
+	cmd := exec.Command(sqlite, append(append([]string{"-bail"}, args...), dbPath)...)
+	cmd.Stdin = strings.NewReader(script)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("sqlite3: %s", msg)
+		}
+		return nil, fmt.Errorf("sqlite3: %w", err)
+	}
+	return out, nil
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+func sqlNumber(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "NULL"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func storeSchemaSQL() string {
+	columns := ""
+	for _, metric := range gateMetricNames() {
+		columns += fmt.Sprintf(",\n\t%q REAL", metric)
+	}
+	return fmt.Sprintf(storeSchema, columns)
+}
+
+// StoreRun appends payload to the history database at dbPath, one row per
+// scenario document, tagged with labels and the current environment. A
+// single-scenario payload is stored under scenario.
+func StoreRun(dbPath string, scenario string, payload ResultFile, labels map[string]string) error {
+	docs := payload.Suite
+	if len(docs) == 0 {
+		doc := payload
+		doc.Scenario = scenario
+		docs = []ResultFile{doc}
+	}
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labelsJSON, _ := json.Marshal(labels)
+	envJSON, _ := json.Marshal(takeEnvironment())
+	recordedAt := time.Now().UTC().Format(time.RFC3339)
+	metrics := gateMetricNames()
+	runID := "NULL"
+	if payload.Run != nil {
+		runID = sqlQuote(payload.Run.ID)
+	}
+
+	var script strings.Builder
+	script.WriteString(storeSchemaSQL())
+	script.WriteString("BEGIN;\n")
+	for _, doc := range docs {
+		document, err := json.Marshal(doc)
+		if err != nil {
+			return err
+		}
+		ok := 0
+		if doc.OK {
+			ok = 1
+		}
+		columns := []string{"run_id", "recorded_at", "scenario", "ok", "error", "labels", "environment", "document"}
+		values := []string{runID, sqlQuote(recordedAt), sqlQuote(doc.Scenario), strconv.Itoa(ok), sqlQuote(doc.Error),
+			sqlQuote(string(labelsJSON)), sqlQuote(string(envJSON)), sqlQuote(string(document))}
+		for _, metric := range metrics {
+			columns = append(columns, strconv.Quote(metric))
+			if doc.OK {
+				values = append(values, sqlNumber(gateValue(metric, doc)))
+			} else {
+				values = append(values, "NULL")
+			}
+		}
+		fmt.Fprintf(&script, "INSERT INTO runs (%s) VALUES (%s);\n", strings.Join(columns, ", "), strings.Join(values, ", "))
+	}
+	script.WriteString("COMMIT;\n")
+	_, err := runSQLite(dbPath, script.String())
+	return err
+}
+
+// HistoryReport is the "history" field of a ResultFile written by the query
+// subcommand: the latest successful runs of a scenario, oldest first, and how
+// each metric moved across them.
+type HistoryReport struct {
+	Store    string                 `json:"store"`
+	Scenario string                 `json:"scenario"`
+	Runs     []historyRun           `json:"runs"`
+	Trends   map[string]metricTrend `json:"trends"`
+}
+
+type historyRun struct {
+	ID          int64              `json:"id"`
+	RunID       string             `json:"runId,omitempty"`
+	RecordedAt  string             `json:"recordedAt"`
+	Labels      map[string]string  `json:"labels"`
+	Environment RunEnvironment     `json:"environment"`
+	Metrics     map[string]float64 `json:"metrics"`
+}
+
+// metricTrend separates drift from noise: Slope is the least-squares change
+// per run relative to the median, CV the run-to-run noise around it. A slope
+// well above CV/len(runs) is drift.
+type metricTrend struct {
+	Median float64 `json:"median"`
+	CV     float64 `json:"cv"`
+	Slope  float64 `json:"slope"`
+	First  float64 `json:"first"`
+	Last   float64 `json:"last"`
+}
+
+// QueryHistory reads the latest limit successful runs of scenario from the
+// store, optionally only those carrying every label in labels.
+func QueryHistory(dbPath string, scenario string, labels map[string]string, limit int) (HistoryReport, error) {
+	report := HistoryReport{Store: dbPath, Scenario: scenario, Runs: []historyRun{}, Trends: map[string]metricTrend{}}
+	if _, err := os.Stat(dbPath); err != nil {
+		return report, fmt.Errorf("open --store: %w", err)
+	}
+	metrics := gateMetricNames()
+	columns := []string{"id", "run_id", "recorded_at", "labels", "environment"}
+	for _, metric := range metrics {
+		columns = append(columns, strconv.Quote(metric))
+	}
+	where := []string{"ok = 1", "scenario = " + sqlQuote(scenario)}
+	for key, value := range labels {
+		where = append(where, fmt.Sprintf("json_extract(labels, %s) = %s", sqlQuote("$."+strconv.Quote(key)), sqlQuote(value)))
+	}
+	query := fmt.Sprintf("SELECT * FROM (SELECT %s FROM runs WHERE %s ORDER BY id DESC LIMIT %d) ORDER BY id;\n",
+		strings.Join(columns, ", "), strings.Join(where, " AND "), limit)
+	out, err := runSQLite(dbPath, query, "-json")
+	if err != nil {
+		return report, err
+	}
+	var rows []map[string]any
+	if len(bytes.TrimSpace(out)) > 0 {
+		if err := json.Unmarshal(out, &rows); err != nil {
+			return report, fmt.Errorf("parse sqlite3 output: %w", err)
+		}
+	}
+	for _, row := range rows {
+		run := historyRun{Metrics: map[string]float64{}}
+		if id, ok := row["id"].(float64); ok {
+			run.ID = int64(id)
+		}
+		run.RunID, _ = row["run_id"].(string)
+		run.RecordedAt, _ = row["recorded_at"].(string)
+		if text, ok := row["labels"].(string); ok {
+			_ = json.Unmarshal([]byte(text), &run.Labels)
+		}
+		if text, ok := row["environment"].(string); ok {
+			_ = json.Unmarshal([]byte(text), &run.Environment)
+		}
+		for _, metric := range metrics {
+			if v, ok := row[metric].(float64); ok {
+				run.Metrics[metric] = v
+			}
+		}
+		report.Runs = append(report.Runs, run)
+	}
+	for _, metric := range metrics {
+		values := []float64{}
+		for _, run := range report.Runs {
+			if v, ok := run.Metrics[metric]; ok {
+				values = append(values, v)
+			}
+		}
+		if len(values) > 0 {
+			report.Trends[metric] = newMetricTrend(values)
+		}
+	}
+	return report, nil
+}
+
+func newMetricTrend(values []float64) metricTrend {
+	s := summarize(values)
+	trend := metricTrend{Median: s.Median, CV: s.CV, First: values[0], Last: values[len(values)-1]}
+	n := float64(len(values))
+	if n < 2 || s.Median == 0 {
+		return trend
+	}
+	meanX := (n - 1) / 2
+	var num, den float64
+	for i, v := range values {
+		dx := float64(i) - meanX
+		num += dx * (v - s.Mean)
+		den += dx * dx
+	}
+	trend.Slope = num / den / s.Median
+	return trend
+}
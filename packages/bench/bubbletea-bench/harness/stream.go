@@ -0,0 +1,153 @@
+package harness
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// streamRecord is one NDJSON line sent to --emit-stream or --emit ndjson.
+// Memory fields are only set on iterations where the loop sampled memory.
+type streamRecord struct {
+	Type      string  `json:"type"`
+	RunID     string  `json:"runId,omitempty"`
+	Phase     string  `json:"phase"`
+	Iteration int     `json:"iteration"`
+	Tick      int     `json:"tick"`
+	AtMs      float64 `json:"atMs"`
+	SampleMs  float64 `json:"sampleMs"`
+	Bytes     int64   `json:"bytes"`
+	RSSKb     int64   `json:"rssKb,omitempty"`
+	HeapKb    int64   `json:"heapKb,omitempty"`
+	Retries   int     `json:"retries,omitempty"`
+
+	// Checkpoint is set on the "checkpoint" records of a --soak run.
+	Checkpoint *soakCheckpoint `json:"checkpoint,omitempty"`
+}
+
+type streamEnd struct {
+	Type    string `json:"type"`
+	RunID   string `json:"runId,omitempty"`
+	OK      bool   `json:"ok"`
+	Records int64  `json:"records"`
+	Dropped int64  `json:"dropped"`
+	Error   string `json:"error,omitempty"`
+}
+
+type streamResult struct {
+	Addr    string `json:"addr"`
+	Records int64  `json:"records"`
+	Dropped int64  `json:"dropped"`
+	Error   string `json:"error,omitempty"`
+}
+
+// streamBuffer bounds how far the collector may fall behind. The render loop
+// never waits on the network: records beyond it are dropped and counted.
+const streamBuffer = 4096
+
+// streamEmitter sends iteration records to a remote collector or a local
+// writer from its own goroutine, so a slow or stalled destination cannot
+// perturb frame timings.
+type streamEmitter struct {
+	addr    string
+	w       io.Writer
+	conn    io.Closer
+	records chan streamRecord
+	done    chan struct{}
+	start   time.Time
+	runID   string
+
+	dropped atomic.Int64
+	sent    int64
+	err     error
+}
+
+func dialStream(addr string) (*streamEmitter, error) {
+	conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("connect --emit-stream: %w", err)
+	}
+	return newStreamEmitter(addr, conn, conn), nil
+}
+
+// newStreamEmitter starts an emitter writing to w. A conn is closed after an
+// end record once the run is over; without one, w belongs to the caller,
+// which writes its own summary.
+func newStreamEmitter(addr string, w io.Writer, conn io.Closer) *streamEmitter {
+	e := &streamEmitter{
+		addr:    addr,
+		w:       w,
+		conn:    conn,
+		records: make(chan streamRecord, streamBuffer),
+		done:    make(chan struct{}),
+		start:   time.Now(),
+	}
+	go e.run()
+	return e
+}
+
+func (e *streamEmitter) run() {
+	defer close(e.done)
+	buf := bufio.NewWriter(e.w)
+	enc := json.NewEncoder(buf)
+	for rec := range e.records {
+		if e.err != nil {
+			e.dropped.Add(1)
+			continue
+		}
+		if e.err = enc.Encode(rec); e.err != nil {
+			e.dropped.Add(1)
+			continue
+		}
+		e.sent++
+		// Flush once the backlog is drained so records go out live without
+		// a syscall per record when the loop is outpacing the network.
+		if len(e.records) == 0 {
+			e.err = buf.Flush()
+		}
+	}
+	if e.err == nil {
+		e.err = buf.Flush()
+	}
+}
+
+func (e *streamEmitter) send(rec streamRecord) {
+	if e == nil {
+		return
+	}
+	rec.AtMs = MsSince(e.start)
+	rec.RunID = e.runID
+	select {
+	case e.records <- rec:
+	default:
+		e.dropped.Add(1)
+	}
+}
+
+// close drains pending records and, for a connection, sends an end record
+// carrying the run outcome and closes it.
+func (e *streamEmitter) close(runErr error) *streamResult {
+	close(e.records)
+	<-e.done
+	end := streamEnd{Type: "end", RunID: e.runID, OK: runErr == nil, Records: e.sent, Dropped: e.dropped.Load()}
+	if runErr != nil {
+		end.Error = runErr.Error()
+	}
+	if e.conn != nil {
+		if e.err == nil {
+			e.err = json.NewEncoder(e.w).Encode(end)
+		}
+		if err := e.conn.Close(); e.err == nil {
+			e.err = err
+		}
+	}
+	result := &streamResult{Addr: e.addr, Records: e.sent, Dropped: end.Dropped}
+	if e.err != nil {
+		result.Error = e.err.Error()
+	}
+	return result
+}
@@ -0,0 +1,114 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sort"
+	"strings"
+)
+
+// RegisterSuite names a list of scenarios that run together.
+func RegisterSuite(name string, scenarios ...string) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.suites[name]; ok {
+		panic(fmt.Sprintf("harness: suite %q registered twice", name))
+	}
+	registry.suites[name] = scenarios
+}
+
+// Suites returns every registered suite by name.
+func Suites() map[string][]string {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	out := make(map[string][]string, len(registry.suites))
+	for name, scenarios := range registry.suites {
+		out[name] = append([]string(nil), scenarios...)
+	}
+	return out
+}
+
+// ExpandScenarios resolves a --scenario value, a comma list of scenario and
+// suite names, into scenarios in run order. The bool reports whether the value
+// asked for a suite rather than one scenario.
+func ExpandScenarios(spec string) ([]string, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	names := strings.Split(spec, ",")
+	out := []string{}
+	isSuite := len(names) > 1
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if members, ok := registry.suites[name]; ok {
+			out = append(out, members...)
+			isSuite = true
+			continue
+		}
+		out = append(out, name)
+	}
+	return out, isSuite
+}
+
+// suiteParams picks out the params scenario declares, so one set of flags
+// can drive a suite whose scenarios take different parameters.
+func suiteParams(scenario string, params map[string]string) map[string]string {
+	s, ok := lookupScenario(scenario)
+	if !ok {
+		return params
+	}
+	out := map[string]string{}
+	for _, p := range s.meta.Params {
+		if value, ok := params[p.Name]; ok {
+			out[p.Name] = value
+		}
+	}
+	return out
+}
+
+// ValidateSuite checks every scenario exists and every param is declared by
+// at least one of them.
+func ValidateSuite(scenarios []string, params map[string]string) error {
+	used := map[string]bool{}
+	for _, scenario := range scenarios {
+		scoped := suiteParams(scenario, params)
+		if err := ValidateScenario(scenario, scoped); err != nil {
+			return err
+		}
+		for key := range scoped {
+			used[key] = true
+		}
+	}
+	unused := []string{}
+	for key := range params {
+		if !used[key] {
+			unused = append(unused, "--"+key)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return fmt.Errorf("no scenario in the suite takes %s", strings.Join(unused, ", "))
+	}
+	return nil
+}
+
+// RunSuite runs scenarios one after another with cfg, returning one result
+// document per scenario (aggregated over cfg.Repeats). Memory is returned to the OS between runs so one
+// scenario's heap does not inflate the next one's numbers.
+func RunSuite(ctx context.Context, cfg Config, scenarios []string) []ResultFile {
+	out := make([]ResultFile, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		if err := ctx.Err(); err != nil {
+			out = append(out, ResultFile{OK: false, Scenario: scenario, Error: err.Error()})
+			continue
+		}
+		debug.FreeOSMemory()
+		run := cfg
+		run.Scenario = scenario
+		run.Params = suiteParams(scenario, cfg.Params)
+		result := RunRepeated(ctx, run)
+		result.Scenario = scenario
+		out = append(out, result)
+	}
+	return out
+}
@@ -0,0 +1,148 @@
+package harness
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"strings"
+)
+
+// SweepAxis is one parameter of a --sweep and the values it takes.
+type SweepAxis struct {
+	Param  string   `json:"param"`
+	Values []string `json:"values"`
+}
+
+// SweepReport is the "sweep" field of a ResultFile: the scenario run at
+// every combination of the axes. Cells are in row-major order over Shape,
+// the last axis varying fastest, so a two-axis sweep reads as a matrix with
+// one row per value of the first.
+type SweepReport struct {
+	Axes  []SweepAxis `json:"axes"`
+	Shape []int       `json:"shape"`
+	Cells []SweepCell `json:"cells"`
+}
+
+// SweepCell is one combination. MedianMs, P95Ms and FramesPerSecond are
+// copied out of Result, the median across runs with --repeats, so a scaling
+// curve can be plotted without walking the nested documents.
+type SweepCell struct {
+	Params          map[string]string `json:"params"`
+	OK              bool              `json:"ok"`
+	MedianMs        float64           `json:"medianMs"`
+	P95Ms           float64           `json:"p95Ms"`
+	FramesPerSecond float64           `json:"framesPerSecond"`
+	Result          ResultFile        `json:"result"`
+}
+
+// ParseSweep reads a --sweep spec: semicolon-separated axes, each a param
+// name, "=", and comma-separated values, like "rows=20,40;cols=80,120".
+func ParseSweep(spec string) ([]SweepAxis, error) {
+	axes := []SweepAxis{}
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ";") {
+		name, list, ok := strings.Cut(strings.TrimSpace(part), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid --sweep axis %q (expected name=v1,v2)", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("--sweep axis %q given twice", name)
+		}
+		seen[name] = true
+		axis := SweepAxis{Param: name}
+		for _, value := range strings.Split(list, ",") {
+			if value = strings.TrimSpace(value); value == "" {
+				return nil, fmt.Errorf("--sweep axis %q has an empty value", name)
+			}
+			axis.Values = append(axis.Values, value)
+		}
+		axes = append(axes, axis)
+	}
+	return axes, nil
+}
+
+// sweepCombinations expands axes over base into every cell's params.
+func sweepCombinations(base map[string]string, axes []SweepAxis) []map[string]string {
+	cells := []map[string]string{{}}
+	for _, axis := range axes {
+		next := make([]map[string]string, 0, len(cells)*len(axis.Values))
+		for _, cell := range cells {
+			for _, value := range axis.Values {
+				params := map[string]string{axis.Param: value}
+				for key, v := range cell {
+					params[key] = v
+				}
+				next = append(next, params)
+			}
+		}
+		cells = next
+	}
+	out := make([]map[string]string, len(cells))
+	for i, swept := range cells {
+		params := map[string]string{}
+		for key, value := range base {
+			params[key] = value
+		}
+		for key, value := range swept {
+			params[key] = value
+		}
+		out[i] = params
+	}
+	return out
+}
+
+// ValidateSweep checks every cell of the sweep is a valid run of scenario,
+// so a bad value fails before the first cell is measured.
+func ValidateSweep(scenario string, params map[string]string, axes []SweepAxis) error {
+	for _, axis := range axes {
+		if _, set := params[axis.Param]; set {
+			return fmt.Errorf("--%s is both swept and set", axis.Param)
+		}
+	}
+	for _, cell := range sweepCombinations(params, axes) {
+		if err := ValidateScenario(scenario, cell); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RunSweep runs cfg at every cell of axes in turn, returning memory to the
+// OS between cells like RunSuite. Cells not yet started when ctx is
+// cancelled are reported as failed.
+func RunSweep(ctx context.Context, cfg Config, axes []SweepAxis) SweepReport {
+	report := SweepReport{Axes: axes, Shape: []int{}, Cells: []SweepCell{}}
+	for _, axis := range axes {
+		report.Shape = append(report.Shape, len(axis.Values))
+	}
+	for _, params := range sweepCombinations(cfg.Params, axes) {
+		swept := map[string]string{}
+		for _, axis := range axes {
+			swept[axis.Param] = params[axis.Param]
+		}
+		cell := SweepCell{Params: swept}
+		if err := ctx.Err(); err != nil {
+			cell.Result = ResultFile{OK: false, Error: err.Error()}
+			report.Cells = append(report.Cells, cell)
+			continue
+		}
+		debug.FreeOSMemory()
+		run := cfg
+		run.Params = params
+		cell.Result = RunRepeated(ctx, run)
+		cell.OK = cell.Result.OK
+		switch {
+		case cell.Result.Data != nil:
+			cell.MedianMs = cell.Result.Data.Summary.Median
+			cell.P95Ms = cell.Result.Data.Summary.P95
+			cell.FramesPerSecond = cell.Result.Data.FramesPerSecond
+		case cell.Result.Aggregate != nil:
+			cell.MedianMs = cell.Result.Aggregate.MedianMs.Median
+			cell.P95Ms = cell.Result.Aggregate.P95Ms.Median
+			cell.FramesPerSecond = cell.Result.Aggregate.FramesPerSec.Median
+		}
+		report.Cells = append(report.Cells, cell)
+	}
+	return report
+}
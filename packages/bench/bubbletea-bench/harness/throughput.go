@@ -0,0 +1,236 @@
+package harness
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// throughputWindow is the span the throughput loop buckets counters into;
+// the spread of per-window rates separates a sustained ceiling from a burst.
+const throughputWindow = 100 * time.Millisecond
+
+// ThroughputSession is a Session that can also take ticks without waiting
+// for each to render, for --throughput.
+type ThroughputSession interface {
+	Session
+	// SendTick delivers tick and returns once the program has accepted it.
+	SendTick(tick int) error
+	// Rendered reports how many ticks the program has applied and how many
+	// views it has built since it started.
+	Rendered() (updates int64, views int64)
+}
+
+// throughputResult is the "throughput" field of a Result run with
+// --throughput: ticks sent back to back for DurationMs with no ack or wait
+// for the writer in between, so the program coalesces frames as it would
+// under a flood of input. Updates and Views count the model's work, Writes
+// what the renderer flushed, all within DurationMs; DrainMs is the catch-up
+// after it, which no count includes. The per-window rates are over
+// throughputWindow buckets, and Sustained* is their median.
+type throughputResult struct {
+	DurationMs                float64       `json:"durationMs"`
+	FPS                       int           `json:"fps"`
+	TicksSent                 int64         `json:"ticksSent"`
+	Updates                   int64         `json:"updates"`
+	Views                     int64         `json:"views"`
+	Writes                    int64         `json:"writes"`
+	Bytes                     int64         `json:"bytes"`
+	UpdatesPerSecond          float64       `json:"updatesPerSecond"`
+	ViewsPerSecond            float64       `json:"viewsPerSecond"`
+	WritesPerSecond           float64       `json:"writesPerSecond"`
+	MBPerSecond               float64       `json:"mbPerSecond"`
+	SustainedUpdatesPerSecond float64       `json:"sustainedUpdatesPerSecond"`
+	SustainedWritesPerSecond  float64       `json:"sustainedWritesPerSecond"`
+	WindowMs                  float64       `json:"windowMs"`
+	UpdateRates               sampleSummary `json:"updateRates"`
+	WriteRates                sampleSummary `json:"writeRates"`
+	DrainMs                   float64       `json:"drainMs"`
+}
+
+// runThroughputBench floods one program with ticks for cfg.Throughput. No
+// frame is timed on its own, so SamplesMs holds the mean time per update in
+// each window instead.
+func runThroughputBench(ctx context.Context, cfg Config, output benchOutput) (Result, error) {
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+	writer := output.newWriter()
+
+	started, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, writer)
+	if err != nil {
+		return Result{}, err
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			_ = started.Close()
+		}
+	}()
+	session, ok := started.(ThroughputSession)
+	if !ok {
+		return Result{}, errors.New("harness: session does not support --throughput")
+	}
+
+	if _, err := session.RenderTick(0, false); err != nil {
+		return Result{}, err
+	}
+	// Nothing here segments output into frames, so the write log is
+	// dropped as it goes rather than left to grow with the flood.
+	writer.dropWrites()
+	warmupSamples := make([]float64, 0, cfg.warmupLimit())
+	for i := 0; i < cfg.warmupLimit() && !cfg.warmedUp(warmupSamples); i++ {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		ts := time.Now()
+		if _, err := session.RenderTick(i+1, false); err != nil {
+			return Result{}, err
+		}
+		warmupSamples = append(warmupSamples, MsSince(ts))
+		output.beat("warmup", i)
+	}
+
+	tryGC()
+	proc := usageProcOf(session)
+	memBefore := proc.memory()
+	cpuBefore := proc.cpu()
+	schedBefore := takeSched()
+	updatesBase, viewsBase := session.Rendered()
+	bytesBase, writesBase := writer.Snapshot()
+	blockedBase := writer.blockedMs()
+	load := startBackgroundLoad(cfg.LoadCPUPercent, cfg.LoadAllocMBps)
+	start := time.Now()
+
+	var updateRates, writeRates, samples []float64
+	windowEnd := start.Add(throughputWindow)
+	windowUpdates, windowWrites := updatesBase, writesBase
+	var sent int64
+	interrupted := false
+	for time.Since(start) < cfg.Throughput {
+		if ctx.Err() != nil {
+			interrupted = true
+			break
+		}
+		sent++
+		if err := session.SendTick(len(warmupSamples) + int(sent)); err != nil {
+			return Result{}, err
+		}
+		output.beat("throughput", int(sent)-1)
+		if now := time.Now(); !now.Before(windowEnd) {
+			updates, _ := session.Rendered()
+			_, writes := writer.Snapshot()
+			seconds := (throughputWindow + now.Sub(windowEnd)).Seconds()
+			updateRates = append(updateRates, float64(updates-windowUpdates)/seconds)
+			writeRates = append(writeRates, float64(writes-windowWrites)/seconds)
+			if updates > windowUpdates {
+				samples = append(samples, seconds*1000/float64(updates-windowUpdates))
+			}
+			windowUpdates, windowWrites = updates, writes
+			windowEnd = now.Add(throughputWindow)
+			writer.dropWrites()
+		}
+	}
+	// Counts and usage are taken as the flood ends, so they cover the same
+	// window as totalWallMs; ticks the program has yet to apply by then are
+	// not counted.
+	totalWallMs := MsSince(start)
+	updatesAfter, viewsAfter := session.Rendered()
+	bytesAfter, writesAfter := writer.Snapshot()
+	cpu := diffCPU(cpuBefore, proc.cpu())
+	memAfter := proc.memory()
+	schedAfter := takeSched()
+	writeBlockTotalMs := writer.blockedMs() - blockedBase
+	loadResult := load.finish()
+	if interrupted && sent == 0 {
+		return Result{}, ctx.Err()
+	}
+
+	// Let the program catch up with the ticks it accepted; one that cannot
+	// within the tick timeout is hung.
+	drainStart := time.Now()
+	if _, err := session.RenderTick(len(warmupSamples)+int(sent)+1, false); err != nil {
+		return Result{}, err
+	}
+	drainMs := MsSince(drainStart)
+
+	if err := session.Close(); err != nil {
+		return Result{}, err
+	}
+	closed = true
+	if len(samples) == 0 {
+		return Result{}, fmt.Errorf("--throughput %s was shorter than one %s window", cfg.Throughput, throughputWindow)
+	}
+
+	seconds := totalWallMs / 1000
+	updates := updatesAfter - updatesBase
+	tp := &throughputResult{
+		DurationMs:                totalWallMs,
+		FPS:                       cfg.FPS,
+		TicksSent:                 sent,
+		Updates:                   updates,
+		Views:                     viewsAfter - viewsBase,
+		Writes:                    writesAfter - writesBase,
+		Bytes:                     bytesAfter - bytesBase,
+		UpdatesPerSecond:          float64(updates) / seconds,
+		ViewsPerSecond:            float64(viewsAfter-viewsBase) / seconds,
+		WritesPerSecond:           float64(writesAfter-writesBase) / seconds,
+		MBPerSecond:               float64(bytesAfter-bytesBase) / 1e6 / seconds,
+		SustainedUpdatesPerSecond: median(updateRates),
+		SustainedWritesPerSecond:  median(writeRates),
+		WindowMs:                  nsToMs(throughputWindow.Nanoseconds()),
+		UpdateRates:               summarize(updateRates),
+		WriteRates:                summarize(writeRates),
+		DrainMs:                   drainMs,
+	}
+
+	ticks := tickRanges(true, len(warmupSamples), 0, int(sent))
+	drainTick := len(warmupSamples) + int(sent) + 1
+	ticks = append(ticks, TickRange{Phase: "drain", First: drainTick, Last: drainTick})
+
+	return Result{
+		Replay:                 newReplayInfo(cfg, ticks),
+		SamplesMs:              samples,
+		Summary:                summarize(samples),
+		UpdateSamplesMs:        []float64{},
+		ViewSamplesMs:          []float64{},
+		FlushSamplesMs:         []float64{},
+		WriteBlockSamplesMs:    []float64{},
+		WriteBlockMaxSamplesMs: []float64{},
+		WriteBlockTotalMs:      writeBlockTotalMs,
+		TotalWallMs:            totalWallMs,
+		CPUUserMs:              cpu.userMs,
+		CPUSysMs:               cpu.systemMs,
+		MinorFaults:            cpu.minorFaults,
+		MajorFaults:            cpu.majorFaults,
+		VolCtxSwitches:         cpu.volCtxSw,
+		InvolCtxSwitches:       cpu.involCtxSw,
+		VolCtxSwPerFrame:       perFrame(cpu.volCtxSw, int(updates)),
+		InvolCtxSwPerFrame:     perFrame(cpu.involCtxSw, int(updates)),
+		RSSBeforeKb:            memBefore.rssKb,
+		RSSAfterKb:             memAfter.rssKb,
+		RSSPeakKb:              max(memBefore.rssKb, memAfter.rssKb),
+		HeapBeforeKb:           memBefore.heapUsedKb,
+		HeapAfterKb:            memAfter.heapUsedKb,
+		HeapPeakKb:             max(memBefore.heapUsedKb, memAfter.heapUsedKb),
+		BytesWritten:           bytesAfter - bytesBase,
+		Seed:                   cfg.Seed,
+		Interrupted:            interrupted,
+		Frames:                 int(updates),
+		FramesPerSecond:        tp.UpdatesPerSecond,
+		DurationBudgetMs:       nsToMs(cfg.Throughput.Nanoseconds()),
+		ThreadsBefore:          schedBefore.threads,
+		ThreadsAfter:           schedAfter.threads,
+		ThreadsPeak:            max(schedBefore.threads, schedAfter.threads),
+		GoroutinesAfter:        schedAfter.goroutines,
+		GOMAXPROCS:             runtime.GOMAXPROCS(0),
+		GOGC:                   gcPercent(),
+		NumCPU:                 runtime.NumCPU(),
+		ProgramUsage:           proc != 0,
+		Load:                   loadResult,
+		Throughput:             tp,
+		Outliers:               []outlierSample{},
+		Warmup:                 buildWarmupReport(warmupSamples, cfg),
+		ChangedCellSamples:     []int{},
+	}, nil
+}
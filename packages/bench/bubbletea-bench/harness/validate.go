@@ -0,0 +1,253 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ValidateReport is the "validate" field of a ResultFile: what validate
+// found wrong with each result file it was given.
+type ValidateReport struct {
+	Files  []ValidatedFile `json:"files"`
+	Issues int             `json:"issues"`
+}
+
+// ValidatedFile is one file checked by validate. Documents counts the
+// result documents in it; NDJSON iteration records are not checked.
+type ValidatedFile struct {
+	Path      string        `json:"path"`
+	Documents int           `json:"documents"`
+	Issues    []ResultIssue `json:"issues"`
+}
+
+// ResultIssue is one problem in a result document. Kind is "parse" for a
+// line that is not JSON, "schema" for a value the result schema does not
+// allow and "semantic" for one no run could have measured, such as a
+// negative time or a summary whose percentiles are out of order. Path is a
+// JSON path into the document on Line.
+type ResultIssue struct {
+	Line    int    `json:"line"`
+	Path    string `json:"path"`
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+// ValidateResults checks the result files at paths against ResultSchema
+// and for values a run cannot produce, so a corrupt or hand-edited file is
+// caught before it is compared against.
+func ValidateResults(paths []string) ValidateReport {
+	report := ValidateReport{Files: []ValidatedFile{}}
+	schema := ResultSchema()
+	for _, path := range paths {
+		file := ValidatedFile{Path: path, Issues: []ResultIssue{}}
+		v := resultValidator{defs: schema["$defs"].(map[string]any), file: &file}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			v.add("parse", "$", "%s", err)
+		}
+		raws, err := splitResults(data)
+		if err != nil {
+			v.add("parse", "$", "%s", jsonError(data, err))
+		}
+		for _, raw := range raws {
+			v.line = raw.line
+			decoder := json.NewDecoder(bytes.NewReader(raw.data))
+			decoder.UseNumber()
+			var doc any
+			if err := decoder.Decode(&doc); err != nil {
+				v.add("parse", "$", "%s", jsonError(raw.data, err))
+				continue
+			}
+			if object, ok := doc.(map[string]any); ok {
+				if kind, _ := object["type"].(string); kind != "" && kind != "summary" {
+					continue
+				}
+			}
+			file.Documents++
+			v.schema(schema, doc, "$")
+			v.semantics(doc, "$")
+		}
+		if file.Documents == 0 && len(file.Issues) == 0 {
+			v.add("parse", "$", "no result documents")
+		}
+		report.Issues += len(file.Issues)
+		report.Files = append(report.Files, file)
+	}
+	return report
+}
+
+// jsonError explains a decode failure, naming the NaN and Infinity that
+// other tools write but JSON cannot hold.
+func jsonError(data []byte, err error) string {
+	if bytes.Contains(data, []byte("NaN")) || bytes.Contains(data, []byte("Infinity")) {
+		return "NaN or Infinity is not a JSON number: " + err.Error()
+	}
+	return err.Error()
+}
+
+type resultValidator struct {
+	defs map[string]any
+	file *ValidatedFile
+	line int
+}
+
+func (v *resultValidator) add(kind string, path string, format string, args ...any) {
+	v.file.Issues = append(v.file.Issues, ResultIssue{
+		Line: v.line, Path: path, Kind: kind, Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// schema checks value against the subset of JSON Schema ResultSchema uses.
+func (v *resultValidator) schema(schema map[string]any, value any, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		if def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any); ok {
+			v.schema(def, value, path)
+		}
+	}
+	if want, ok := schema["const"]; ok && fmt.Sprint(want) != fmt.Sprint(value) {
+		v.add("schema", path, "must be %v, got %v", want, value)
+	}
+	if types := schemaTypes(schema["type"]); len(types) > 0 {
+		got := jsonType(value)
+		if !typeAllowed(types, got) {
+			v.add("schema", path, "must be %s, got %s", strings.Join(types, " or "), got)
+			return
+		}
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]string); ok {
+			for _, name := range required {
+				if _, ok := value[name]; !ok {
+					v.add("schema", path+"."+name, "required field is missing")
+				}
+			}
+		}
+		properties, _ := schema["properties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		for _, name := range sortedKeys(value) {
+			if property, ok := properties[name].(map[string]any); ok {
+				v.schema(property, value[name], path+"."+name)
+			} else if additional != nil {
+				v.schema(additional, value[name], path+"."+name)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				v.schema(items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	}
+	return nil
+}
+
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if strings.ContainsAny(value.String(), ".eE") {
+			return "number"
+		}
+		return "integer"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+func typeAllowed(types []string, got string) bool {
+	for _, t := range types {
+		if t == got || (t == "number" && got == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// semantics checks what the schema cannot: times are never negative
+// (offsetMs alone is signed), summaries and spreads are ordered, and a
+// successful run measured something.
+func (v *resultValidator) semantics(value any, path string) {
+	switch value := value.(type) {
+	case map[string]any:
+		if ok, _ := value["ok"].(bool); ok {
+			if data, ok := value["data"].(map[string]any); ok {
+				if samples, ok := data["samplesMs"].([]any); ok && len(samples) == 0 {
+					v.add("semantic", path+".data.samplesMs", "successful run has no samples")
+				}
+			}
+		}
+		if _, summary := value["p95"]; summary {
+			v.ordered(value, path, "min", "median", "p95", "p99", "max")
+		} else {
+			v.ordered(value, path, "min", "median", "max")
+		}
+		for _, name := range sortedKeys(value) {
+			child := path + "." + name
+			if strings.HasSuffix(name, "Ms") && name != "offsetMs" {
+				v.nonNegative(value[name], child)
+			}
+			v.semantics(value[name], child)
+		}
+	case []any:
+		for i, item := range value {
+			v.semantics(item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// nonNegative reports negative times in a number or an array of them.
+func (v *resultValidator) nonNegative(value any, path string) {
+	switch value := value.(type) {
+	case json.Number:
+		if f, err := value.Float64(); err == nil && f < 0 {
+			v.add("semantic", path, "time is negative (%s)", value)
+		}
+	case []any:
+		for i, item := range value {
+			if n, ok := item.(json.Number); ok {
+				v.nonNegative(n, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+// ordered reports an object whose fields, when all present and numeric,
+// are not in non-decreasing order, like a p95 below the median. An empty
+// summary is all zeros and passes.
+func (v *resultValidator) ordered(object map[string]any, path string, names ...string) {
+	values := make([]float64, len(names))
+	for i, name := range names {
+		n, ok := object[name].(json.Number)
+		if !ok {
+			return
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return
+		}
+		values[i] = f
+	}
+	if !sort.Float64sAreSorted(values) {
+		v.add("semantic", path, "%s must not decrease, got %v", strings.Join(names, ", "), values)
+	}
+}
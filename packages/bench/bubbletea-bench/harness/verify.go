@@ -0,0 +1,174 @@
+package harness
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rezi-ui/bench/bubbletea-bench/vtverify"
+)
+
+// VerifyReport is the "verify" field of a ResultFile.
+type VerifyReport struct {
+	Scenario        string       `json:"scenario"`
+	Seed            uint64       `json:"seed"`
+	Source          string       `json:"source"`
+	Expected        string       `json:"expected"`
+	Rows            int          `json:"rows"`
+	Cols            int          `json:"cols"`
+	Ticks           int          `json:"ticks"`
+	MismatchedTicks int          `json:"mismatchedTicks"`
+	FlickerTicks    int          `json:"flickerTicks"`
+	Frames          []tickVerify `json:"frames"`
+}
+
+// tickVerify is one frame checked on the emulated screen. ChangedCells and
+// FlickerCells are vtverify.Frame's: cells the frame changed, and cells
+// that showed neither their old nor their new text between its writes.
+type tickVerify struct {
+	Tick            int                 `json:"tick"`
+	MismatchedCells int                 `json:"mismatchedCells"`
+	ChangedCells    int                 `json:"changedCells"`
+	FlickerCells    int                 `json:"flickerCells"`
+	Mismatches      []vtverify.Mismatch `json:"mismatches,omitempty"`
+}
+
+const maxReportedMismatches = 20
+
+// expectedScreen is the plain-text screen a correct renderer leaves behind for
+// a frame: like Bubble Tea's standard renderer it keeps the bottom rows when
+// the view is taller than the terminal and cuts lines at the terminal width,
+// in cells, so a wide rune that would straddle the edge is dropped.
+func expectedScreen(lines []string, rows int, cols int) []string {
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	screen := make([]string, rows)
+	for r := 0; r < rows; r++ {
+		screen[r] = ansi.Truncate(ansi.Strip(atOrEmpty(lines, r)), cols, "")
+	}
+	return screen
+}
+
+func readGoldenScreen(dir string, tick int, rows int, cols int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("%s/tick-%06d.txt", dir, tick))
+	if err != nil {
+		return nil, fmt.Errorf("read golden frame: %w", err)
+	}
+	return expectedScreen(strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), rows, cols), nil
+}
+
+// verifyTicks are cfg.ReplayTicks, or else the first cfg.VerifyTicks ticks.
+func (cfg Config) verifyTicks() []int {
+	if cfg.ReplayTicks != nil {
+		return cfg.ReplayTicks
+	}
+	ticks := make([]int, cfg.VerifyTicks)
+	for tick := range ticks {
+		ticks[tick] = tick
+	}
+	return ticks
+}
+
+// recordScenario re-runs a scenario in stub mode for cfg.ReplayTicks, or else
+// the first cfg.VerifyTicks ticks, and returns the recorded session bytes.
+func recordScenario(ctx context.Context, cfg Config, rows int, cols int) ([]byte, error) {
+	var buf bytes.Buffer
+	recorder := newSessionRecorder(&buf, nil)
+	writer := benchOutput{out: discardWriter{}, recorder: recorder}.newWriter()
+	if cfg.Start == nil {
+		return nil, errors.New("harness: Config.Start is nil")
+	}
+	session, err := cfg.Start(cfg.Scenario, cfg.Params, cfg.Seed, rows, cols, cfg.FPS, writer)
+	if err != nil {
+		return nil, err
+	}
+	for _, tick := range cfg.verifyTicks() {
+		if err := ctx.Err(); err != nil {
+			_ = session.Close()
+			return nil, err
+		}
+		if _, err := session.RenderTick(tick, false); err != nil {
+			_ = session.Close()
+			return nil, err
+		}
+	}
+	if err := session.Close(); err != nil {
+		return nil, err
+	}
+	if err := recorder.close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// RunVerify replays a recorded session, or a fresh rerun of cfg.Scenario when
+// cfg.SessionPath is empty, through a VT emulator and compares every frame
+// against the scenario generator or the golden frames in cfg.GoldenDir,
+// counting the cells each frame changed and any that flickered. A session
+// must be verified with the cfg.Seed it was recorded with.
+func RunVerify(ctx context.Context, cfg Config) (VerifyReport, error) {
+	if err := ValidateScenario(cfg.Scenario, cfg.Params); err != nil {
+		return VerifyReport{}, err
+	}
+	rows, cols := scenarioViewport(cfg.Scenario, cfg.Params)
+	report := VerifyReport{
+		Scenario: cfg.Scenario,
+		Seed:     cfg.Seed,
+		Source:   "rerun",
+		Expected: "generator",
+		Rows:     rows,
+		Cols:     cols,
+		Frames:   []tickVerify{},
+	}
+
+	var data []byte
+	var err error
+	if cfg.SessionPath != "" {
+		report.Source = "session"
+		data, err = os.ReadFile(cfg.SessionPath)
+	} else {
+		data, err = recordScenario(ctx, cfg, rows, cols)
+	}
+	if err != nil {
+		return report, err
+	}
+	preamble, frames, err := ParseSession(data)
+	if err != nil {
+		return report, err
+	}
+	if cfg.GoldenDir != "" {
+		report.Expected = "golden"
+	}
+
+	term := vtverify.New(rows, cols)
+	_, _ = term.Write(preamble)
+	for _, frame := range frames {
+		drawn := term.DrawFrame(frame.Writes)
+		var expected []string
+		if cfg.GoldenDir != "" {
+			expected, err = readGoldenScreen(cfg.GoldenDir, frame.Tick, rows, cols)
+			if err != nil {
+				return report, err
+			}
+		} else {
+			expected = expectedScreen(ScenarioLines(cfg.Scenario, cfg.Params, cfg.Seed, frame.Tick, cols), rows, cols)
+		}
+		result := tickVerify{Tick: frame.Tick, ChangedCells: drawn.ChangedCells, FlickerCells: drawn.FlickerCells}
+		result.MismatchedCells, result.Mismatches = vtverify.Compare(expected, drawn.After, maxReportedMismatches)
+		if result.MismatchedCells > 0 {
+			report.MismatchedTicks++
+		}
+		if result.FlickerCells > 0 {
+			report.FlickerTicks++
+		}
+		report.Frames = append(report.Frames, result)
+		_, _ = term.Write(frame.Trailer)
+	}
+	report.Ticks = len(frames)
+	return report, nil
+}
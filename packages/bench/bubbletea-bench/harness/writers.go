@@ -0,0 +1,723 @@
+package harness
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// WriteErrorPoint injects err on the at-th write (1-based). EPIPE is sticky:
+// once the pipe is broken every later write fails too.
+type WriteErrorPoint struct {
+	kind string
+	at   int64
+	err  error
+}
+
+type injectedWriteError struct {
+	Kind  string  `json:"kind"`
+	Write int64   `json:"write"`
+	AtMs  float64 `json:"atMs"`
+}
+
+// Writer is the instrumented output a Session hands its program. It counts
+// and timestamps every write and tees it into the recorder and tail ring.
+type Writer struct {
+	out ioWriter
+
+	mu         sync.Mutex
+	totalBytes int64
+	writeCount int64
+
+	// Time spent blocked inside out.Write, in total and as the longest single
+	// write since the last BeginFrame.
+	blockedNs  int64
+	frameMaxNs int64
+
+	recorder *sessionRecorder
+	tail     *outputRing
+
+	// writes timestamps every successful write so output can be segmented
+	// into frames after the run.
+	writes []writeEvent
+}
+
+type writeEvent struct {
+	at    time.Time
+	bytes int
+}
+
+type ioWriter interface {
+	Write(p []byte) (n int, err error)
+}
+
+// benchOutput is where measured sessions write: the (possibly wrapped) sink
+// plus an optional session recorder every Writer tees into. stream,
+// when set, receives a record per iteration as the run progresses.
+type benchOutput struct {
+	out      ioWriter
+	recorder *sessionRecorder
+	tail     *outputRing
+	stream   *streamEmitter
+	records  *streamEmitter
+	samples  *sampleTable
+
+	// sink is the output sink behind out, if any, for resizes.
+	sink *outputSink
+
+	metrics   *MetricsExporter
+	progress  *progressReporter
+	heartbeat *Heartbeat
+	scenario  string
+}
+
+// record hands one iteration record to --emit-stream, --emit ndjson,
+// --samples-csv, --metrics-addr, --progress and --heartbeat.
+func (o benchOutput) record(rec streamRecord) {
+	o.stream.send(rec)
+	o.records.send(rec)
+	o.samples.add(rec)
+	o.metrics.observe(o.scenario, rec)
+	o.progress.observe(rec)
+	o.heartbeat.beat(o.scenario, rec.Phase, rec.Iteration)
+}
+
+// beat tells --heartbeat about a frame of a loop that sends no records.
+func (o benchOutput) beat(phase string, iteration int) {
+	o.heartbeat.beat(o.scenario, phase, iteration)
+}
+
+// checkpoint hands a --soak checkpoint to --emit-stream, --emit ndjson and
+// --progress.
+func (o benchOutput) checkpoint(rec streamRecord) {
+	o.stream.send(rec)
+	o.records.send(rec)
+	o.progress.observe(rec)
+}
+
+func (o benchOutput) newWriter() *Writer {
+	w := newWriter(o.out)
+	w.recorder = o.recorder
+	w.tail = o.tail
+	return w
+}
+
+// outputRing keeps the last len(buf) bytes written so a failed run can show
+// what the renderer was emitting when it stopped.
+type outputRing struct {
+	mu    sync.Mutex
+	buf   []byte
+	pos   int
+	full  bool
+	total int64
+}
+
+func newOutputRing(size int) *outputRing {
+	return &outputRing{buf: make([]byte, size)}
+}
+
+func (r *outputRing) write(p []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.total += int64(len(p))
+	if len(p) >= len(r.buf) {
+		copy(r.buf, p[len(p)-len(r.buf):])
+		r.pos, r.full = 0, true
+		return
+	}
+	n := copy(r.buf[r.pos:], p)
+	if n < len(p) {
+		copy(r.buf, p[n:])
+		r.full = true
+	}
+	r.pos = (r.pos + len(p)) % len(r.buf)
+	if r.pos == 0 {
+		r.full = true
+	}
+}
+
+func (r *outputRing) bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.full {
+		return append([]byte(nil), r.buf[:r.pos]...)
+	}
+	return append(append([]byte(nil), r.buf[r.pos:]...), r.buf[:r.pos]...)
+}
+
+// OutputTail is the last output of a failed run, escaped and as hex.
+type OutputTail struct {
+	Bytes        int    `json:"bytes"`
+	TotalWritten int64  `json:"totalWritten"`
+	Escaped      string `json:"escaped"`
+	Hex          string `json:"hex"`
+}
+
+func (r *outputRing) dump() *OutputTail {
+	data := r.bytes()
+	quoted := strconv.Quote(string(data))
+	r.mu.Lock()
+	total := r.total
+	r.mu.Unlock()
+	return &OutputTail{
+		Bytes:        len(data),
+		TotalWritten: total,
+		Escaped:      quoted[1 : len(quoted)-1],
+		Hex:          hex.EncodeToString(data),
+	}
+}
+
+// RunError is returned by RunScenario for a run that started measuring: it
+// carries the injected write errors and the output tail of a run that failed
+// mid-render.
+type RunError struct {
+	err         error
+	writeErrors *WriteErrorReport
+	tail        *OutputTail
+}
+
+func (e *RunError) Error() string { return e.err.Error() }
+func (e *RunError) Unwrap() error { return e.err }
+
+func newWriter(out ioWriter) *Writer {
+	if out == nil {
+		out = discardWriter{}
+	}
+	return &Writer{out: out}
+}
+
+type discardWriter struct{}
+
+func (discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// throttledWriter emulates a slow terminal link with a token bucket refilled
+// at the configured bit rate. Writes larger than the bucket are split so a
+// single big frame drains at line rate instead of passing in one burst.
+type throttledWriter struct {
+	out         ioWriter
+	bytesPerSec float64
+	burst       float64
+
+	mu            sync.Mutex
+	tokens        float64
+	last          time.Time
+	waitNs        int64
+	delayedWrites int64
+}
+
+func newThrottledWriter(out ioWriter, bitsPerSec int64) *throttledWriter {
+	bytesPerSec := float64(bitsPerSec) / 8
+	// Allow roughly 10ms of line time to pass without waiting.
+	burst := math.Max(1, bytesPerSec/100)
+	return &throttledWriter{
+		out:         out,
+		bytesPerSec: bytesPerSec,
+		burst:       burst,
+		tokens:      burst,
+		last:        time.Now(),
+	}
+}
+
+func (w *throttledWriter) take(n int) {
+	w.mu.Lock()
+	now := time.Now()
+	w.tokens = math.Min(w.burst, w.tokens+now.Sub(w.last).Seconds()*w.bytesPerSec)
+	w.last = now
+	if w.tokens >= float64(n) {
+		w.tokens -= float64(n)
+		w.mu.Unlock()
+		return
+	}
+	deficit := float64(n) - w.tokens
+	wait := time.Duration(deficit / w.bytesPerSec * float64(time.Second))
+	w.tokens = 0
+	w.last = now.Add(wait)
+	w.waitNs += wait.Nanoseconds()
+	w.delayedWrites++
+	w.mu.Unlock()
+	time.Sleep(wait)
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for written < len(p) {
+		chunk := minInt(len(p)-written, int(w.burst))
+		w.take(chunk)
+		n, err := w.out.Write(p[written : written+chunk])
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (w *throttledWriter) result(bitsPerSec int64) *throttleResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return &throttleResult{
+		BitsPerSec:    bitsPerSec,
+		WaitMs:        nsToMs(w.waitNs),
+		DelayedWrites: w.delayedWrites,
+	}
+}
+
+// latencyWriter holds every write for one link round trip before completing
+// it, emulating a remote session where each flush waits on the far end. The
+// delay is latency plus a uniform offset in [-jitter, +jitter], floored at 0.
+type latencyWriter struct {
+	out     ioWriter
+	latency time.Duration
+	jitter  time.Duration
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	writes  int64
+	delayNs int64
+	maxNs   int64
+}
+
+func newLatencyWriter(out ioWriter, latency time.Duration, jitter time.Duration) *latencyWriter {
+	return &latencyWriter{
+		out:     out,
+		latency: latency,
+		jitter:  jitter,
+		rng:     rand.New(rand.NewPCG(0x5eed, uint64(latency))),
+	}
+}
+
+func (w *latencyWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	delay := w.latency
+	if w.jitter > 0 {
+		delay += time.Duration(w.rng.Int64N(2*int64(w.jitter)+1)) - w.jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+	w.writes++
+	w.delayNs += delay.Nanoseconds()
+	if delay.Nanoseconds() > w.maxNs {
+		w.maxNs = delay.Nanoseconds()
+	}
+	w.mu.Unlock()
+
+	n, err := w.out.Write(p)
+	time.Sleep(delay)
+	return n, err
+}
+
+func (w *latencyWriter) result() *latencyResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return &latencyResult{
+		LatencyMs:     nsToMs(w.latency.Nanoseconds()),
+		JitterMs:      nsToMs(w.jitter.Nanoseconds()),
+		DelayedWrites: w.writes,
+		TotalDelayMs:  nsToMs(w.delayNs),
+		MaxDelayMs:    nsToMs(w.maxNs),
+	}
+}
+
+// backpressureWriter puts a fixed-size buffer between the program and the
+// sink. A reader goroutine drains it, optionally stopping for stall every
+// stallEvery (a terminal under ctrl-s or a busy compositor); once the buffer
+// is full the producer blocks, and that blocked time is what gets measured.
+type backpressureWriter struct {
+	out        ioWriter
+	capacity   int
+	stall      time.Duration
+	stallEvery time.Duration
+
+	mu        sync.Mutex
+	notFull   *sync.Cond
+	notEmpty  *sync.Cond
+	queue     []byte
+	closed    bool
+	err       error
+	blockedNs int64
+	blocked   int64
+	maxQueue  int
+	stalls    int64
+	done      chan struct{}
+}
+
+func newBackpressureWriter(out ioWriter, capacity int, stall time.Duration, stallEvery time.Duration) *backpressureWriter {
+	w := &backpressureWriter{
+		out:        out,
+		capacity:   capacity,
+		stall:      stall,
+		stallEvery: stallEvery,
+		queue:      make([]byte, 0, capacity),
+		done:       make(chan struct{}),
+	}
+	w.notFull = sync.NewCond(&w.mu)
+	w.notEmpty = sync.NewCond(&w.mu)
+	go w.drain()
+	return w
+}
+
+func (w *backpressureWriter) Write(p []byte) (int, error) {
+	written := 0
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for written < len(p) {
+		if w.err != nil {
+			return written, w.err
+		}
+		if len(w.queue) == w.capacity {
+			start := time.Now()
+			for len(w.queue) == w.capacity && w.err == nil {
+				w.notFull.Wait()
+			}
+			w.blockedNs += time.Since(start).Nanoseconds()
+			w.blocked++
+			continue
+		}
+		n := minInt(w.capacity-len(w.queue), len(p)-written)
+		w.queue = append(w.queue, p[written:written+n]...)
+		written += n
+		if len(w.queue) > w.maxQueue {
+			w.maxQueue = len(w.queue)
+		}
+		w.notEmpty.Signal()
+	}
+	return written, nil
+}
+
+func (w *backpressureWriter) drain() {
+	defer close(w.done)
+	chunk := make([]byte, 0, w.capacity)
+	nextStall := time.Now().Add(w.stallEvery)
+	for {
+		w.mu.Lock()
+		for len(w.queue) == 0 && !w.closed {
+			w.notEmpty.Wait()
+		}
+		if len(w.queue) == 0 && w.closed {
+			w.mu.Unlock()
+			return
+		}
+		chunk = append(chunk[:0], w.queue...)
+		w.queue = w.queue[:0]
+		w.notFull.Broadcast()
+		w.mu.Unlock()
+
+		if w.stall > 0 && !time.Now().Before(nextStall) {
+			time.Sleep(w.stall)
+			nextStall = time.Now().Add(w.stallEvery)
+			w.mu.Lock()
+			w.stalls++
+			w.mu.Unlock()
+		}
+		if _, err := w.out.Write(chunk); err != nil {
+			w.mu.Lock()
+			w.err = err
+			w.notFull.Broadcast()
+			w.mu.Unlock()
+			return
+		}
+	}
+}
+
+// close flushes whatever is still queued to the sink and stops the reader.
+func (w *backpressureWriter) close() {
+	w.mu.Lock()
+	w.closed = true
+	w.notEmpty.Signal()
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *backpressureWriter) result() *backpressureResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return &backpressureResult{
+		CapacityBytes:      w.capacity,
+		BlockedMs:          nsToMs(w.blockedNs),
+		BlockedWrites:      w.blocked,
+		MaxQueueBytes:      w.maxQueue,
+		ReaderStalls:       w.stalls,
+		ReaderStallMs:      nsToMs(w.stall.Nanoseconds()),
+		ReaderStallEveryMs: nsToMs(w.stallEvery.Nanoseconds()),
+	}
+}
+
+// shortWriter randomly accepts only a prefix of a write and reports
+// io.ErrShortWrite, the way a loaded PTY hands back partial writes.
+type shortWriter struct {
+	out  ioWriter
+	prob float64
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	pending []byte
+	stats   shortWriteResult
+}
+
+func newShortWriter(out ioWriter, prob float64) *shortWriter {
+	return &shortWriter{
+		out:   out,
+		prob:  prob,
+		rng:   rand.New(rand.NewPCG(0x5407, 0x3717e)),
+		stats: shortWriteResult{Probability: prob},
+	}
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.stats.Writes++
+	if len(w.pending) > 0 {
+		if bytes.HasPrefix(p, w.pending) {
+			w.stats.RetriedWrites++
+		} else {
+			w.stats.LostBytes += int64(len(w.pending))
+		}
+		w.pending = nil
+	}
+	limit := len(p)
+	if len(p) > 1 && w.rng.Float64() < w.prob {
+		limit = w.rng.IntN(len(p))
+	}
+	w.mu.Unlock()
+
+	n, err := w.out.Write(p[:limit])
+	if err != nil || limit == len(p) {
+		return n, err
+	}
+
+	w.mu.Lock()
+	w.stats.ShortWrites++
+	w.stats.CutBytes += int64(len(p) - n)
+	w.pending = append([]byte(nil), p[n:]...)
+	w.mu.Unlock()
+	return n, io.ErrShortWrite
+}
+
+func (w *shortWriter) result() *shortWriteResult {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	stats := w.stats
+	// A cut still pending when the run ends was never retried.
+	stats.LostBytes += int64(len(w.pending))
+	return &stats
+}
+
+// ParseWriteErrorPoints parses a comma-separated list of kind@write points,
+// where kind is eagain or epipe.
+func ParseWriteErrorPoints(value string) ([]WriteErrorPoint, error) {
+	var points []WriteErrorPoint
+	for _, spec := range strings.Split(value, ",") {
+		kind, at, ok := strings.Cut(strings.TrimSpace(spec), "@")
+		if !ok {
+			return nil, fmt.Errorf("%q: expected kind@write", spec)
+		}
+		n, err := strconv.ParseInt(at, 10, 64)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q: write index must be a positive integer", spec)
+		}
+		point := WriteErrorPoint{kind: kind, at: n}
+		switch kind {
+		case "eagain":
+			point.err = syscall.EAGAIN
+		case "epipe":
+			point.err = syscall.EPIPE
+		default:
+			return nil, fmt.Errorf("%q: unknown error kind (expected eagain|epipe)", spec)
+		}
+		points = append(points, point)
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].at < points[j].at })
+	return points, nil
+}
+
+// writeErrorWriter fails the configured writes without forwarding them.
+type writeErrorWriter struct {
+	out    ioWriter
+	points []WriteErrorPoint
+	start  time.Time
+
+	mu       sync.Mutex
+	writes   int64
+	broken   error
+	injected []injectedWriteError
+	after    int64
+}
+
+func newWriteErrorWriter(out ioWriter, points []WriteErrorPoint) *writeErrorWriter {
+	return &writeErrorWriter{out: out, points: points, start: time.Now()}
+}
+
+func (w *writeErrorWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	w.writes++
+	if len(w.injected) > 0 {
+		w.after++
+	}
+	err := w.broken
+	for len(w.points) > 0 && w.points[0].at <= w.writes {
+		point := w.points[0]
+		w.points = w.points[1:]
+		if point.at < w.writes {
+			continue
+		}
+		err = point.err
+		if point.kind == "epipe" {
+			w.broken = point.err
+		}
+		w.injected = append(w.injected, injectedWriteError{
+			Kind:  point.kind,
+			Write: w.writes,
+			AtMs:  MsSince(w.start),
+		})
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return w.out.Write(p)
+}
+
+func (w *writeErrorWriter) report(runErr error) *WriteErrorReport {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	report := &WriteErrorReport{
+		Outcome:            "recovered",
+		Injected:           append([]injectedWriteError{}, w.injected...),
+		WritesAfterFailure: w.after,
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+		switch {
+		case errors.Is(runErr, ErrProgramExited):
+			report.Outcome = "exited"
+		case errors.Is(runErr, ErrRenderTimeout):
+			report.Outcome = "hung"
+		default:
+			report.Outcome = "failed"
+		}
+	}
+	return report
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	start := time.Now()
+	n, err := w.out.Write(p)
+	blocked := time.Since(start).Nanoseconds()
+	w.mu.Lock()
+	if n > 0 {
+		w.totalBytes += int64(n)
+		w.writeCount++
+		w.writes = append(w.writes, writeEvent{at: start, bytes: n})
+	}
+	w.blockedNs += blocked
+	if blocked > w.frameMaxNs {
+		w.frameMaxNs = blocked
+	}
+	w.mu.Unlock()
+	if w.recorder != nil && n > 0 {
+		w.recorder.write(p[:n])
+	}
+	if w.tail != nil && n > 0 {
+		w.tail.write(p[:n])
+	}
+	return n, err
+}
+
+// MarkFrame marks the point tick is sent to the program in a recorded
+// session. Sessions call it before delivering each tick.
+func (w *Writer) MarkFrame(tick int) {
+	if w.recorder != nil {
+		w.recorder.frame(tick)
+	}
+}
+
+// MarkEnd marks a program shutting down in a recorded session.
+func (w *Writer) MarkEnd() {
+	if w.recorder != nil {
+		w.recorder.end()
+	}
+}
+
+// dropWrites forgets the write events recorded so far.
+func (w *Writer) dropWrites() {
+	w.mu.Lock()
+	w.writes = w.writes[:0]
+	w.mu.Unlock()
+}
+
+// writesSince returns the write events recorded after the first mark writes.
+func (w *Writer) writesSince(mark int) []writeEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]writeEvent(nil), w.writes[mark:]...)
+}
+
+func (w *Writer) writeMark() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.writes)
+}
+
+// BeginFrame resets the per-frame blocking maximum and returns the blocked
+// total to pass to FrameBlocking once the frame has been written.
+func (w *Writer) BeginFrame() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.frameMaxNs = 0
+	return w.blockedNs
+}
+
+// FrameBlocking returns the time spent blocked in writes since BeginFrame,
+// in total and for the longest single write.
+func (w *Writer) FrameBlocking(blockedBase int64) (float64, float64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return nsToMs(w.blockedNs - blockedBase), nsToMs(w.frameMaxNs)
+}
+
+func (w *Writer) blockedMs() float64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return nsToMs(w.blockedNs)
+}
+
+func nsToMs(ns int64) float64 {
+	return float64(ns) / 1e6
+}
+
+// Snapshot returns the bytes and write calls seen so far.
+func (w *Writer) Snapshot() (int64, int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.totalBytes, w.writeCount
+}
+
+// WaitWriteAfter waits up to timeout for a write beyond baseWriteCount, for
+// renderers that flush asynchronously after the view is built, and reports
+// whether one arrived.
+func (w *Writer) WaitWriteAfter(baseWriteCount int64, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		_, writes := w.Snapshot()
+		if writes > baseWriteCount {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(200 * time.Microsecond)
+	}
+}
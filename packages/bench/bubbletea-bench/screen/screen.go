@@ -0,0 +1,307 @@
+// Package screen is a minimal diffing renderer shared by the benchmark
+// binaries that draw frames without a framework's renderer: it keeps the
+// cells on screen and writes only the ones that change.
+package screen
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rivo/uniseg"
+)
+
+// cell is one terminal column: a grapheme and the columns it covers. The
+// column after a wide grapheme holds a continuation cell of width 0.
+type cell struct {
+	text  string
+	width int
+}
+
+var blank = cell{text: " ", width: 1}
+
+// Screen is a double buffer: back holds the frame being drawn and front
+// what the terminal shows. Flush writes the cells that differ, reaching
+// each with the shortest cursor movement on offer, so its output is the
+// fewest bytes a renderer could send for the frame. Lines are compared as
+// text, like the harness's changed-cell count; styling in them is dropped.
+type Screen struct {
+	w     io.Writer
+	rows  int
+	cols  int
+	front []cell
+	back  []cell
+	out   []byte
+
+	// row and col are the cursor position, with col -1 when it is unknown:
+	// before the first move, and after a write to the last column, where
+	// terminals differ on whether the cursor has wrapped.
+	row int
+	col int
+}
+
+// New returns a rows x cols screen writing to w.
+func New(w io.Writer, rows int, cols int) *Screen {
+	s := &Screen{w: w}
+	s.Resize(rows, cols)
+	s.out = s.out[:0]
+	return s
+}
+
+// Resize starts over at a new size: the terminal is cleared, so the next
+// Flush repaints every cell that is not blank.
+func (s *Screen) Resize(rows int, cols int) {
+	s.rows, s.cols = rows, cols
+	s.front = make([]cell, rows*cols)
+	s.back = make([]cell, rows*cols)
+	for i := range s.front {
+		s.front[i] = blank
+		s.back[i] = blank
+	}
+	s.out = append(s.out, "\x1b[2J"...)
+	s.col = -1
+}
+
+// Enter and Exit switch to the alternate screen and back, hiding the
+// cursor while the scenario runs.
+func (s *Screen) Enter() {
+	s.out = append(s.out, "\x1b[?1049h\x1b[?25l\x1b[2J"...)
+	s.write()
+}
+
+func (s *Screen) Exit() {
+	s.out = append(s.out, "\x1b[?25h\x1b[?1049l"...)
+	s.write()
+}
+
+// Draw lays lines out in the back buffer, one grapheme per cell, blanking
+// the rest of the screen. Lines past the screen's height cost the top rows,
+// as in the harness's expected screen.
+func (s *Screen) Draw(lines []string) {
+	if len(lines) > s.rows {
+		lines = lines[len(lines)-s.rows:]
+	}
+	for r := 0; r < s.rows; r++ {
+		row := s.back[r*s.cols : (r+1)*s.cols]
+		c := 0
+		if r < len(lines) && isPlainASCII(lines[r]) {
+			// Every byte is a cell of its own.
+			line := lines[r]
+			for ; c < s.cols && c < len(line); c++ {
+				row[c] = cell{text: line[c : c+1], width: 1}
+			}
+		} else if r < len(lines) {
+			text := ansi.Strip(lines[r])
+			state := -1
+			for len(text) > 0 && c < s.cols {
+				var cluster string
+				var width int
+				cluster, text, width, state = uniseg.FirstGraphemeClusterInString(text, state)
+				if width == 0 {
+					continue
+				}
+				if width > 1 && c+width > s.cols {
+					break
+				}
+				row[c] = cell{text: cluster, width: width}
+				for i := 1; i < width; i++ {
+					row[c+i] = cell{}
+				}
+				c += width
+			}
+		}
+		for ; c < s.cols; c++ {
+			row[c] = blank
+		}
+	}
+}
+
+func isPlainASCII(line string) bool {
+	for i := 0; i < len(line); i++ {
+		if line[i] < ' ' || line[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// Flush writes the back buffer's changes and makes it the front.
+func (s *Screen) Flush() {
+	for r := 0; r < s.rows; r++ {
+		for c := 0; c < s.cols; c++ {
+			i := r*s.cols + c
+			next := s.back[i]
+			if next == s.front[i] {
+				continue
+			}
+			if next.width == 0 {
+				// Written with the wide grapheme before it.
+				s.front[i] = next
+				continue
+			}
+			s.moveTo(r, c)
+			s.front[i] = next
+			s.out = append(s.out, next.text...)
+			s.col = c + next.width
+			if s.col >= s.cols {
+				s.col = -1
+			}
+		}
+	}
+	s.write()
+}
+
+// Ways of moving the cursor along a row.
+const (
+	stay = iota
+	forward
+	back
+	home
+	homeForward
+	rewrite
+)
+
+// moveTo puts the cursor at r, c with the fewest bytes among an absolute
+// move, relative moves, a carriage return, and rewriting the unchanged
+// cells in between.
+func (s *Screen) moveTo(r int, c int) {
+	if s.row == r && s.col == c {
+		return
+	}
+	if s.col < 0 {
+		s.out = appendCUP(s.out, r, c)
+		s.row, s.col = r, c
+		return
+	}
+	vertical := 0
+	if r != s.row {
+		vertical = csiLen(abs(r - s.row))
+	}
+	how, horizontal := s.horizontal(r, c)
+	if vertical+horizontal >= cupLen(r, c) {
+		s.out = appendCUP(s.out, r, c)
+		s.row, s.col = r, c
+		return
+	}
+	switch {
+	case r < s.row:
+		s.out = appendCSI(s.out, s.row-r, 'A')
+	case r > s.row:
+		s.out = appendCSI(s.out, r-s.row, 'B')
+	}
+	switch how {
+	case forward:
+		s.out = appendCSI(s.out, c-s.col, 'C')
+	case back:
+		s.out = appendCSI(s.out, s.col-c, 'D')
+	case home:
+		s.out = append(s.out, '\r')
+	case homeForward:
+		s.out = append(s.out, '\r')
+		s.out = appendCSI(s.out, c, 'C')
+	case rewrite:
+		for _, gap := range s.front[r*s.cols+s.col : r*s.cols+c] {
+			s.out = append(s.out, gap.text...)
+		}
+	}
+	s.row, s.col = r, c
+}
+
+// horizontal picks the shortest way from the cursor's column to c once on
+// row r. Rewriting the cells in between is an option only on the cursor's
+// own row, where they already show, and not when a wide grapheme straddles
+// either end; Flush moves before updating the front cell at c.
+func (s *Screen) horizontal(r int, c int) (int, int) {
+	switch {
+	case c == s.col:
+		return stay, 0
+	case c == 0:
+		return home, 1
+	case c < s.col:
+		if n := 1 + csiLen(c); n < csiLen(s.col-c) {
+			return homeForward, n
+		}
+		return back, csiLen(s.col - c)
+	}
+	how, n := forward, csiLen(c-s.col)
+	if r != s.row || c-s.col >= n {
+		return how, n
+	}
+	row := s.front[r*s.cols : (r+1)*s.cols]
+	if row[s.col].width == 0 || row[c].width == 0 {
+		return how, n
+	}
+	gap := 0
+	for _, cell := range row[s.col:c] {
+		gap += len(cell.text)
+	}
+	if gap < n {
+		return rewrite, gap
+	}
+	return how, n
+}
+
+func (s *Screen) write() {
+	if len(s.out) > 0 {
+		_, _ = s.w.Write(s.out)
+		s.out = s.out[:0]
+	}
+}
+
+// appendCUP appends the absolute move to r, c, leaving out parameters
+// that are 1.
+func appendCUP(out []byte, r int, c int) []byte {
+	out = append(out, "\x1b["...)
+	if r > 0 || c > 0 {
+		out = strconv.AppendInt(out, int64(r+1), 10)
+	}
+	if c > 0 {
+		out = append(out, ';')
+		out = strconv.AppendInt(out, int64(c+1), 10)
+	}
+	return append(out, 'H')
+}
+
+func cupLen(r int, c int) int {
+	n := 3
+	if r > 0 || c > 0 {
+		n += digits(r + 1)
+	}
+	if c > 0 {
+		n += 1 + digits(c+1)
+	}
+	return n
+}
+
+// appendCSI appends a relative move of n in the direction of final,
+// leaving out an n of 1.
+func appendCSI(out []byte, n int, final byte) []byte {
+	out = append(out, "\x1b["...)
+	if n != 1 {
+		out = strconv.AppendInt(out, int64(n), 10)
+	}
+	return append(out, final)
+}
+
+func csiLen(n int) int {
+	if n == 1 {
+		return 3
+	}
+	return 3 + digits(n)
+}
+
+func digits(n int) int {
+	d := 1
+	for n >= 10 {
+		n /= 10
+		d++
+	}
+	return d
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,229 @@
+package screen
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/rivo/uniseg"
+)
+
+// term applies the sequences Screen writes to a grid of cells, to check
+// what a terminal would show for its output.
+type term struct {
+	rows, cols int
+	cells      [][]string
+	row, col   int
+}
+
+func newTerm(rows int, cols int) *term {
+	t := &term{rows: rows, cols: cols}
+	t.clear()
+	return t
+}
+
+func (t *term) clear() {
+	t.cells = make([][]string, t.rows)
+	for r := range t.cells {
+		t.cells[r] = make([]string, t.cols)
+		for c := range t.cells[r] {
+			t.cells[r][c] = " "
+		}
+	}
+}
+
+func (t *term) apply(tb testing.TB, out []byte) {
+	tb.Helper()
+	text := string(out)
+	for len(text) > 0 {
+		if rest, ok := strings.CutPrefix(text, "\x1b["); ok {
+			end := strings.IndexAny(rest, "ABCDHJhl")
+			if end < 0 {
+				tb.Fatalf("unterminated sequence %q", text)
+			}
+			t.csi(tb, rest[:end], rest[end])
+			text = rest[end+1:]
+			continue
+		}
+		if text[0] == '\r' {
+			t.col = 0
+			text = text[1:]
+			continue
+		}
+		cluster, rest, width, _ := uniseg.FirstGraphemeClusterInString(text, -1)
+		if t.col+width > t.cols {
+			tb.Fatalf("%q written past the last column at %d,%d", cluster, t.row, t.col)
+		}
+		t.cells[t.row][t.col] = cluster
+		for i := 1; i < width; i++ {
+			t.cells[t.row][t.col+i] = ""
+		}
+		t.col += width
+		text = rest
+	}
+}
+
+func (t *term) csi(tb testing.TB, params string, final byte) {
+	tb.Helper()
+	if strings.HasPrefix(params, "?") {
+		return
+	}
+	n := func(s string) int {
+		if s == "" {
+			return 1
+		}
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			tb.Fatalf("bad parameter %q", s)
+		}
+		return v
+	}
+	switch final {
+	case 'J':
+		t.clear()
+	case 'H':
+		r, c, _ := strings.Cut(params, ";")
+		t.row, t.col = n(r)-1, n(c)-1
+	case 'A':
+		t.row -= n(params)
+	case 'B':
+		t.row += n(params)
+	case 'C':
+		t.col += n(params)
+	case 'D':
+		t.col -= n(params)
+	}
+	if t.row < 0 || t.row >= t.rows || t.col < 0 || t.col > t.cols {
+		tb.Fatalf("cursor moved off the screen to %d,%d", t.row, t.col)
+	}
+}
+
+func (t *term) lines() []string {
+	out := make([]string, t.rows)
+	for r, row := range t.cells {
+		out[r] = strings.Join(row, "")
+	}
+	return out
+}
+
+func TestScreenFlush(t *testing.T) {
+	cases := []struct {
+		name   string
+		rows   int
+		cols   int
+		frames [][]string
+		want   []string
+	}{
+		{
+			name: "first frame",
+			rows: 3, cols: 6,
+			frames: [][]string{{"hello", "world"}},
+			want:   []string{"hello ", "world ", "      "},
+		},
+		{
+			name: "one cell changes",
+			rows: 3, cols: 6,
+			frames: [][]string{{"hello", "world"}, {"hello", "wOrld"}},
+			want:   []string{"hello ", "wOrld ", "      "},
+		},
+		{
+			name: "lines shrink and clear",
+			rows: 3, cols: 6,
+			frames: [][]string{{"abcdef", "abcdef", "abcdef"}, {"ab", "", "abc"}},
+			want:   []string{"ab    ", "      ", "abc   "},
+		},
+		{
+			name: "styling is dropped and long lines clipped",
+			rows: 2, cols: 4,
+			frames: [][]string{{"\x1b[1mbold\x1b[0m!", "x"}},
+			want:   []string{"bold", "x   "},
+		},
+		{
+			name: "wide graphemes",
+			rows: 2, cols: 5,
+			frames: [][]string{{"a日本", "日本語"}, {"日a本", "é日本"}},
+			want:   []string{"日a本", "e\u0301日本"},
+		},
+		{
+			name: "taller frames keep the bottom rows",
+			rows: 2, cols: 3,
+			frames: [][]string{{"one", "two", "six"}, {"one", "two", "ten"}},
+			want:   []string{"two", "ten"},
+		},
+		{
+			name: "scattered changes",
+			rows: 4, cols: 20,
+			frames: [][]string{
+				{"aaaaaaaaaaaaaaaaaaaa", "bbbbbbbbbbbbbbbbbbbb", "cccccccccccccccccccc", "dddddddddddddddddddd"},
+				{"aaaaaaaaaXaaaaaaaaaY", "Xbbbbbbbbbbbbbbbbbbb", "cXcXcXcccccccccccccX", "ddddddddddddddddddXd"},
+			},
+			want: []string{"aaaaaaaaaXaaaaaaaaaY", "Xbbbbbbbbbbbbbbbbbbb", "cXcXcXcccccccccccccX", "ddddddddddddddddddXd"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			s := New(&out, tc.rows, tc.cols)
+			vt := newTerm(tc.rows, tc.cols)
+			for _, frame := range tc.frames {
+				s.Draw(frame)
+				s.Flush()
+				vt.apply(t, out.Bytes())
+				out.Reset()
+			}
+			if got := vt.lines(); strings.Join(got, "\n") != strings.Join(tc.want, "\n") {
+				t.Errorf("screen shows %+q, want %+q", got, tc.want)
+			}
+
+			// Drawing the last frame again changes nothing.
+			s.Draw(tc.frames[len(tc.frames)-1])
+			s.Flush()
+			if out.Len() > 0 {
+				t.Errorf("an unchanged frame wrote %q", out.String())
+			}
+		})
+	}
+}
+
+func TestScreenFlushMoves(t *testing.T) {
+	type at struct{ r, c int }
+	cases := []struct {
+		name    string
+		changes []at
+		want    string
+	}{
+		{"absolute move first", []at{{0, 0}}, "\x1b[HX"},
+		{"gap rewritten", []at{{0, 0}, {0, 2}}, "\x1b[HXaX"},
+		{"gap jumped", []at{{0, 0}, {0, 8}}, "\x1b[HX\x1b[7CX"},
+		{"down a row", []at{{10, 10}, {11, 11}}, "\x1b[11;11HX\x1b[BX"},
+		{"down and home", []at{{15, 8}, {16, 0}}, "\x1b[16;9HX\x1b[B\rX"},
+		{"absolute move when shorter", []at{{0, 0}, {1, 2}}, "\x1b[HX\x1b[2;3HX"},
+		{"after the last column", []at{{3, 11}, {4, 11}}, "\x1b[4;12HX\x1b[5;12HX"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			const rows, cols = 20, 12
+			prev := make([]string, rows)
+			for r := range prev {
+				prev[r] = strings.Repeat("a", cols)
+			}
+			next := make([]string, rows)
+			copy(next, prev)
+			for _, change := range tc.changes {
+				next[change.r] = next[change.r][:change.c] + "X" + next[change.r][change.c+1:]
+			}
+
+			var out bytes.Buffer
+			s := New(&out, rows, cols)
+			s.Draw(prev)
+			s.Flush()
+			out.Reset()
+			s.Draw(next)
+			s.Flush()
+			if out.String() != tc.want {
+				t.Errorf("wrote %q, want %q", out.String(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+// Package vtverify reconstructs what a terminal shows from the bytes a
+// program writes to it, by parsing them into a VT emulator. The harness
+// verifies frames with it, and it measures what drawing a frame did: the
+// cells it changed and the cells that flickered on the way.
+package vtverify
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hinshun/vt10x"
+	"github.com/rivo/uniseg"
+)
+
+// Terminal is an emulated terminal screen. It is not safe for concurrent
+// use.
+type Terminal struct {
+	term  vt10x.Terminal
+	rows  int
+	cols  int
+	width widthFilter
+}
+
+// New returns a blank rows x cols terminal.
+func New(rows int, cols int) *Terminal {
+	return &Terminal{term: vt10x.New(vt10x.WithSize(cols, rows)), rows: rows, cols: cols}
+}
+
+// Write parses p as terminal output. It never fails.
+func (t *Terminal) Write(p []byte) (int, error) {
+	_, _ = t.term.Write(t.width.filter(p))
+	return len(p), nil
+}
+
+// widthFilter states: text, or inside an escape sequence.
+const (
+	inText = iota
+	inEscape
+	inCSI
+	inString       // OSC, DCS, SOS, PM or APC, up to BEL or ST
+	inStringEscape // an ESC inside a string, maybe the start of ST
+)
+
+// widthFilter rewrites output for vt10x, which gives every rune one cell:
+// a wide rune is followed by a space that takes the cell it covers, so the
+// cursor, wrapping and the rest of the line land where a terminal puts
+// them, and a zero-width rune such as a combining mark is dropped. Escape
+// sequences pass through untouched.
+type widthFilter struct {
+	state   int
+	pending []byte // an incomplete UTF-8 sequence
+}
+
+func (f *widthFilter) filter(p []byte) []byte {
+	out := make([]byte, 0, len(p)+len(f.pending)+8)
+	for i := 0; i < len(p); i++ {
+		b := p[i]
+		switch f.state {
+		case inEscape:
+			out = append(out, b)
+			switch {
+			case b == '[':
+				f.state = inCSI
+			case b == ']' || b == 'P' || b == 'X' || b == '^' || b == '_':
+				f.state = inString
+			case b < 0x20 || b > 0x2f:
+				// Not an intermediate byte, so the sequence ends here.
+				f.state = inText
+			}
+			continue
+		case inCSI:
+			out = append(out, b)
+			if b >= 0x40 && b <= 0x7e {
+				f.state = inText
+			}
+			continue
+		case inString:
+			out = append(out, b)
+			if b == 0x07 {
+				f.state = inText
+			} else if b == 0x1b {
+				f.state = inStringEscape
+			}
+			continue
+		case inStringEscape:
+			out = append(out, b)
+			f.state = inString
+			if b == '\\' {
+				f.state = inText
+			}
+			continue
+		}
+		if len(f.pending) > 0 && b&0xc0 != 0x80 {
+			// Not a continuation byte: pass the broken sequence on as it was.
+			out = append(out, f.pending...)
+			f.pending = f.pending[:0]
+		}
+		if b == 0x1b {
+			out = append(out, b)
+			f.state = inEscape
+			continue
+		}
+		if b < utf8.RuneSelf && len(f.pending) == 0 {
+			out = append(out, b)
+			continue
+		}
+		f.pending = append(f.pending, b)
+		if !utf8.FullRune(f.pending) {
+			continue
+		}
+		r, _ := utf8.DecodeRune(f.pending)
+		switch {
+		case r == utf8.RuneError || r < 0xa0:
+			out = append(out, f.pending...)
+		case runeWidth(r) == 0:
+		case runeWidth(r) == 2:
+			out = append(utf8.AppendRune(out, r), ' ')
+		default:
+			out = utf8.AppendRune(out, r)
+		}
+		f.pending = f.pending[:0]
+	}
+	return out
+}
+
+func runeWidth(r rune) int {
+	if r < utf8.RuneSelf {
+		return 1
+	}
+	return uniseg.StringWidth(string(r))
+}
+
+// Resize changes the screen size as a SIGWINCH would; the program is
+// expected to redraw.
+func (t *Terminal) Resize(rows int, cols int) {
+	t.term.Resize(cols, rows)
+	t.rows, t.cols = rows, cols
+}
+
+// Size returns the screen's rows and columns.
+func (t *Terminal) Size() (int, int) {
+	return t.rows, t.cols
+}
+
+// Screen returns the text on screen, one string per row exactly cols cells
+// wide, with empty cells as spaces. A wide character stands for the two
+// cells it covers. Styling is dropped.
+func (t *Terminal) Screen() []string {
+	t.term.Lock()
+	defer t.term.Unlock()
+	screen := make([]string, t.rows)
+	line := make([]rune, 0, t.cols)
+	for r := range screen {
+		line = line[:0]
+		for c := 0; c < t.cols; c++ {
+			ch := t.term.Cell(c, r).Char
+			if ch == 0 {
+				ch = ' '
+			}
+			line = append(line, ch)
+			if runeWidth(ch) == 2 {
+				// Skip the covered cell, which the filter filled with a
+				// space; at the right margin the character is cut.
+				c++
+			}
+		}
+		screen[r] = string(line)
+	}
+	return screen
+}
+
+// Mode bits of a vt10x.Glyph, which the package keeps unexported.
+const (
+	modeReverse = 1 << iota
+	modeUnderline
+	modeBold
+	_ // line-drawing charset
+	modeItalic
+	modeBlink
+)
+
+// Style is the look of a cell. FG and BG are a 256-colour palette index, a
+// 0xRRGGBB colour, or -1 for the terminal's default. The emulator stores a
+// reversed cell with its colours already swapped and a bold one in the
+// bright palette, and so does Style.
+type Style struct {
+	FG        int32
+	BG        int32
+	Bold      bool
+	Italic    bool
+	Underline bool
+	Reverse   bool
+	Blink     bool
+}
+
+// String is the style as "bold fg=9 bg=#203040", empty for the default.
+func (s Style) String() string {
+	var parts []string
+	for _, attr := range []struct {
+		on   bool
+		name string
+	}{{s.Bold, "bold"}, {s.Italic, "italic"}, {s.Underline, "underline"}, {s.Reverse, "reverse"}, {s.Blink, "blink"}} {
+		if attr.on {
+			parts = append(parts, attr.name)
+		}
+	}
+	for _, color := range []struct {
+		name  string
+		value int32
+	}{{"fg", s.FG}, {"bg", s.BG}} {
+		switch {
+		case color.value < 0:
+		case color.value < 256:
+			parts = append(parts, fmt.Sprintf("%s=%d", color.name, color.value))
+		default:
+			parts = append(parts, fmt.Sprintf("%s=#%06x", color.name, color.value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func styleOf(g vt10x.Glyph) Style {
+	// Default colours sit above the 24-bit range, swapped or not.
+	color := func(c vt10x.Color) int32 {
+		if c >= vt10x.DefaultFG {
+			return -1
+		}
+		return int32(c)
+	}
+	return Style{
+		FG:        color(g.FG),
+		BG:        color(g.BG),
+		Bold:      g.Mode&modeBold != 0,
+		Italic:    g.Mode&modeItalic != 0,
+		Underline: g.Mode&modeUnderline != 0,
+		Reverse:   g.Mode&modeReverse != 0,
+		Blink:     g.Mode&modeBlink != 0,
+	}
+}
+
+// StyleRun is a stretch of a row's cells sharing one Style other than the
+// default.
+type StyleRun struct {
+	Row   int
+	Col   int
+	Width int
+	Style Style
+}
+
+// StyleRuns returns the styled stretches of the screen, row by row.
+func (t *Terminal) StyleRuns() []StyleRun {
+	t.term.Lock()
+	defer t.term.Unlock()
+	var runs []StyleRun
+	plain := Style{FG: -1, BG: -1}
+	for r := 0; r < t.rows; r++ {
+		run := -1
+		for c := 0; c < t.cols; c++ {
+			style := styleOf(t.term.Cell(c, r))
+			switch {
+			case run >= 0 && style == runs[run].Style:
+				runs[run].Width++
+			case style == plain:
+				run = -1
+			default:
+				runs = append(runs, StyleRun{Row: r, Col: c, Width: 1, Style: style})
+				run = len(runs) - 1
+			}
+		}
+	}
+	return runs
+}
+
+// Frame is what drawing one frame did to the screen.
+type Frame struct {
+	// Before and After are the screen before the first write and after the
+	// last.
+	Before []string
+	After  []string
+
+	// ChangedCells counts cells whose text differs between Before and
+	// After.
+	ChangedCells int
+
+	// FlickerCells counts cells that, between two of the frame's writes,
+	// showed neither their Before nor their After text, as when a renderer
+	// clears a line before redrawing it. A terminal may paint between
+	// writes, so those are the states a user can see.
+	FlickerCells int
+}
+
+// DrawFrame writes a frame's output, one write at a time as the program
+// made them, and reports what it did to the screen.
+func (t *Terminal) DrawFrame(writes [][]byte) Frame {
+	frame := Frame{Before: t.Screen()}
+	var flickered map[[2]int]bool
+	var between [][]string
+	for i, p := range writes {
+		_, _ = t.Write(p)
+		if i < len(writes)-1 {
+			between = append(between, t.Screen())
+		}
+	}
+	frame.After = t.Screen()
+	frame.ChangedCells = ChangedCells(frame.Before, frame.After)
+	for _, screen := range between {
+		for r, line := range screen {
+			before, after := Cells(at(frame.Before, r)), Cells(at(frame.After, r))
+			for c, cell := range Cells(line) {
+				if cell == cellAt(before, c) || cell == cellAt(after, c) {
+					continue
+				}
+				if flickered == nil {
+					flickered = map[[2]int]bool{}
+				}
+				flickered[[2]int{r, c}] = true
+			}
+		}
+	}
+	frame.FlickerCells = len(flickered)
+	return frame
+}
+
+// Mismatch is a cell whose text is not the expected one.
+type Mismatch struct {
+	Row      int    `json:"row"`
+	Col      int    `json:"col"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// Compare checks actual, a Screen, against expected, whose rows may be
+// shorter than the screen's and are then read as padded with spaces, cell
+// by cell as Cells lays them out. It returns the number of mismatched cells
+// and the first limit of them; a wide character's covered cell reads as "".
+func Compare(expected []string, actual []string, limit int) (int, []Mismatch) {
+	count := 0
+	var mismatches []Mismatch
+	for r, line := range actual {
+		want := Cells(at(expected, r))
+		for c, got := range Cells(line) {
+			w := cellAt(want, c)
+			if w == got {
+				continue
+			}
+			count++
+			if len(mismatches) < limit {
+				mismatches = append(mismatches, Mismatch{Row: r, Col: c, Expected: w, Actual: got})
+			}
+		}
+	}
+	return count, mismatches
+}
+
+// ChangedCells counts the cells whose text differs between two screens,
+// reading missing rows and columns as spaces.
+func ChangedCells(prev []string, next []string) int {
+	changed := 0
+	for r := 0; r < max(len(prev), len(next)); r++ {
+		a, b := Cells(at(prev, r)), Cells(at(next, r))
+		for c := 0; c < max(len(a), len(b)); c++ {
+			if cellAt(a, c) != cellAt(b, c) {
+				changed++
+			}
+		}
+	}
+	return changed
+}
+
+func at(lines []string, i int) string {
+	if i < len(lines) {
+		return lines[i]
+	}
+	return ""
+}
+
+func cellAt(cells []string, i int) string {
+	if i < len(cells) {
+		return cells[i]
+	}
+	return " "
+}
+
+// Cells splits a line of plain text into the terminal cells it covers, left
+// to right: one grapheme cluster per cell, then "" for each further cell a
+// wide cluster covers. Zero-width clusters take no cell and are dropped.
+// Any ANSI must be stripped first.
+func Cells(line string) []string {
+	cells := make([]string, 0, len(line))
+	state := -1
+	for len(line) > 0 {
+		var cluster string
+		var width int
+		cluster, line, width, state = uniseg.FirstGraphemeClusterInString(line, state)
+		if width == 0 {
+			continue
+		}
+		cells = append(cells, cluster)
+		for i := 1; i < width; i++ {
+			cells = append(cells, "")
+		}
+	}
+	return cells
+}
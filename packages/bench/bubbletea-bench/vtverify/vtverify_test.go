@@ -0,0 +1,59 @@
+package vtverify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScreenWidths(t *testing.T) {
+	cases := []struct {
+		name   string
+		cols   int
+		writes []string
+		want   string
+	}{
+		{name: "ascii", cols: 6, writes: []string{"abc"}, want: "abc   "},
+		{name: "wide rune covers two cells", cols: 6, writes: []string{"字a"}, want: "字a   "},
+		{name: "cursor addressing after a wide rune", cols: 6, writes: []string{"字a\x1b[1;5Hb"}, want: "字a b "},
+		{name: "combining mark takes no cell", cols: 6, writes: []string{"e\u0301a"}, want: "ea    "},
+		{name: "rune split across writes", cols: 6, writes: []string{"\xe5\xad", "\x97a"}, want: "字a   "},
+		{name: "sequences pass through", cols: 6, writes: []string{"\x1b]0;字\x07\x1b[1m字\x1b[m!"}, want: "字!   "},
+		{name: "wide rune wraps like two cells", cols: 3, writes: []string{"a字b"}, want: "a字"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			term := New(2, tc.cols)
+			for _, w := range tc.writes {
+				_, _ = term.Write([]byte(w))
+			}
+			if got := term.Screen()[0]; got != tc.want {
+				t.Errorf("row 0 = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+		actual   string
+		want     int
+	}{
+		{name: "equal", expected: "ab", actual: "ab  ", want: 0},
+		{name: "one cell", expected: "ab", actual: "ax  ", want: 1},
+		{name: "wide rune in place", expected: "字a", actual: "字a ", want: 0},
+		{name: "wide rune missing", expected: "字a", actual: "a   ", want: 3},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, _ := Compare([]string{tc.expected}, []string{tc.actual}, 10)
+			if got != tc.want {
+				t.Errorf("Compare(%q, %q) = %d mismatches, want %d", tc.expected, tc.actual, got, tc.want)
+			}
+		})
+	}
+	if _, m := Compare([]string{"字"}, []string{"ab"}, 10); len(m) != 2 || m[1].Expected != "" || !strings.Contains(m[0].Expected, "字") {
+		t.Errorf("mismatches %+v, want the wide rune then its covered cell", m)
+	}
+}
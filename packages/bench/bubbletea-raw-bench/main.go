@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+// main runs the scenarios as Bubble Tea programs with tea.WithoutRenderer,
+// drawing each view through a diffing renderer of our own paced like Bubble
+// Tea's. The update and view phases are Bubble Tea's program machinery as
+// in bubbletea-bench; comparing the flush phases separates the cost of its
+// standard renderer from the rest of the framework.
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{
+			"github.com/charmbracelet/bubbletea",
+			"github.com/charmbracelet/lipgloss",
+		},
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, fps int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, fps, w)
+			}
+		},
+	})
+}
+
+type readyMsg struct{}
+
+type benchTickMsg struct {
+	tick   int
+	ack    chan struct{}
+	phases *harness.TickPhases
+}
+
+type benchModel struct {
+	scenario string
+	params   map[string]string
+	seed     uint64
+	cols     int
+	lines    []string
+	renderer *diffRenderer
+
+	pendingAck    chan struct{}
+	pendingPhases *harness.TickPhases
+	ready         chan struct{}
+
+	// updates and views count ticks applied and views built, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+
+	// A panic in Init, Update or View is recovered here rather than by
+	// Bubble Tea, which would print it to the terminal and return a bare
+	// ErrProgramPanic. The program then quits and the session reports it.
+	panicked error
+	quit     func()
+}
+
+func (m *benchModel) recoverPanic() {
+	if r := recover(); r != nil {
+		m.panicked = harness.NewPanicError(r)
+		go m.quit()
+	}
+}
+
+func (m *benchModel) Init() tea.Cmd {
+	defer m.recoverPanic()
+	return func() tea.Msg {
+		return readyMsg{}
+	}
+}
+
+func (m *benchModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	model = m
+	defer m.recoverPanic()
+	switch v := msg.(type) {
+	case readyMsg:
+		if m.ready != nil {
+			close(m.ready)
+			m.ready = nil
+		}
+	case tea.WindowSizeMsg:
+		if v.Width > 0 {
+			m.cols = v.Width
+		}
+	case benchTickMsg:
+		updateStart := time.Now()
+		m.lines = harness.ScenarioLines(m.scenario, m.params, m.seed, v.tick, m.cols)
+		m.updates.Add(1)
+		m.pendingAck = v.ack
+		m.pendingPhases = v.phases
+		if v.phases != nil {
+			v.phases.UpdateMs = harness.MsSince(updateStart)
+		}
+	}
+	return m, nil
+}
+
+func (m *benchModel) View() string {
+	defer m.recoverPanic()
+	viewStart := time.Now()
+	view := strings.Join(m.lines, "\n")
+	m.renderer.write(view)
+	m.views.Add(1)
+	if m.pendingPhases != nil {
+		m.pendingPhases.ViewMs = harness.MsSince(viewStart)
+		m.pendingPhases.ViewEnd = time.Now()
+		m.pendingPhases = nil
+	}
+	if m.pendingAck != nil {
+		close(m.pendingAck)
+		m.pendingAck = nil
+	}
+	return view
+}
+
+type benchSession struct {
+	program  *tea.Program
+	model    *benchModel
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+}
+
+// startBenchSession starts the scenario as a Bubble Tea program; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	fps int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	ready := make(chan struct{})
+	model := &benchModel{
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		cols:     cols,
+		lines:    []string{},
+		renderer: newDiffRenderer(writer, rows, cols, fps),
+		ready:    ready,
+	}
+
+	program := tea.NewProgram(
+		model,
+		tea.WithInput(nil),
+		tea.WithOutput(writer),
+		tea.WithoutRenderer(),
+		tea.WithoutSignalHandler(),
+	)
+
+	model.quit = program.Quit
+	session := &benchSession{program: program, model: model, writer: writer, done: make(chan struct{}), timeouts: t}
+	model.renderer.start()
+	go func() {
+		_, session.runErr = program.Run()
+		model.renderer.stop()
+		if model.panicked != nil {
+			session.runErr = model.panicked
+		}
+		close(session.done)
+	}()
+
+	select {
+	case <-ready:
+		program.Send(tea.WindowSizeMsg{Width: cols, Height: rows})
+		return session, nil
+	case <-session.done:
+		if session.runErr != nil {
+			return nil, fmt.Errorf("bubbletea: %w before initialization: %w", harness.ErrProgramExited, session.runErr)
+		}
+		return nil, fmt.Errorf("bubbletea: %w before initialization", harness.ErrProgramExited)
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for bubbletea startup after %s", t.Startup))
+	}
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	send := func() {
+		s.program.Send(benchTickMsg{tick: tick, ack: ack, phases: phases})
+	}
+	if eventLoop {
+		go send()
+	} else {
+		send()
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		if s.runErr != nil {
+			return harness.TickPhases{}, fmt.Errorf("bubbletea: %w during render tick=%d: %w", harness.ErrProgramExited, tick, s.runErr)
+		}
+		return harness.TickPhases{}, fmt.Errorf("bubbletea: %w during render tick=%d", harness.ErrProgramExited, tick)
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("bubbletea: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack; Program.Send returns once the
+// event loop has taken it.
+func (s *benchSession) SendTick(tick int) error {
+	select {
+	case <-s.done:
+		if s.runErr != nil {
+			return fmt.Errorf("bubbletea: %w during tick=%d: %w", harness.ErrProgramExited, tick, s.runErr)
+		}
+		return fmt.Errorf("bubbletea: %w during tick=%d", harness.ErrProgramExited, tick)
+	default:
+	}
+	s.writer.MarkFrame(tick)
+	s.program.Send(benchTickMsg{tick: tick})
+	return nil
+}
+
+// Resize clears the renderer's screen and delivers the WindowSizeMsg Bubble
+// Tea sends on SIGWINCH.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("bubbletea: %w before resize to %dx%d", harness.ErrProgramExited, cols, rows)
+	default:
+	}
+	s.model.renderer.resize(rows, cols)
+	s.program.Send(tea.WindowSizeMsg{Width: cols, Height: rows})
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.model.updates.Load(), s.model.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	s.program.Send(tea.Quit())
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down bubbletea after %s", s.timeouts.Shutdown))
+	}
+}
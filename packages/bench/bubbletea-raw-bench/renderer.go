@@ -0,0 +1,103 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/screen"
+)
+
+// diffRenderer stands in for Bubble Tea's standard renderer under
+// tea.WithoutRenderer. Like it, it keeps the latest view and flushes it on
+// a ticker at the frame rate, skipping views that have not changed, but it
+// writes through screen's cell diff, so the two differ only in how a frame
+// becomes output.
+type diffRenderer struct {
+	screen   *screen.Screen
+	interval time.Duration
+	done     chan struct{}
+	stopped  chan struct{}
+
+	mu   sync.Mutex
+	view string
+	// dirty is set when view has not been flushed since it was written or
+	// the screen was cleared.
+	dirty bool
+}
+
+// newDiffRenderer returns a renderer for a rows x cols screen flushing fps
+// times a second, which is clamped as Bubble Tea clamps WithFPS.
+func newDiffRenderer(w io.Writer, rows int, cols int, fps int) *diffRenderer {
+	if fps < 1 {
+		fps = 60
+	} else if fps > 120 {
+		fps = 120
+	}
+	return &diffRenderer{
+		screen:   screen.New(w, rows, cols),
+		interval: time.Second / time.Duration(fps),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+}
+
+func (r *diffRenderer) start() {
+	r.screen.Enter()
+	go r.loop()
+}
+
+func (r *diffRenderer) loop() {
+	defer close(r.stopped)
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flush()
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// write keeps view for the next flush.
+func (r *diffRenderer) write(view string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if view != r.view {
+		r.view = view
+		r.dirty = true
+	}
+}
+
+func (r *diffRenderer) flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.dirty {
+		return
+	}
+	r.screen.Draw(strings.Split(r.view, "\n"))
+	r.screen.Flush()
+	r.dirty = false
+}
+
+// resize clears the screen; the next flush repaints the view at the new
+// size.
+func (r *diffRenderer) resize(rows int, cols int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.screen.Resize(rows, cols)
+	r.dirty = true
+}
+
+// stop flushes the last view and leaves the alternate screen.
+func (r *diffRenderer) stop() {
+	close(r.done)
+	<-r.stopped
+	r.flush()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.screen.Exit()
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	zone "github.com/lrstanley/bubblezone"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+// main runs terminal-mouse-zones as a Bubble Tea program that marks every
+// cell of the grid as a bubblezone zone. Each tick hit-tests the scenario's
+// pointer moves against the zones, as a mouse-driven app would, and the view
+// marks and scans the grid again, so the update phase is the hit-test cost
+// and the view phase the cost of re-rendering through bubblezone.
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{
+			"github.com/charmbracelet/bubbletea",
+			"github.com/charmbracelet/lipgloss",
+			"github.com/lrstanley/bubblezone",
+		},
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, fps int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, fps, w)
+			}
+		},
+	})
+}
+
+type readyMsg struct{}
+
+type benchTickMsg struct {
+	tick   int
+	ack    chan struct{}
+	phases *harness.TickPhases
+}
+
+type benchModel struct {
+	scenario string
+	params   map[string]string
+	seed     uint64
+	cols     int
+	lines    []string
+
+	// zones holds where the last view put each cell; ids names them by grid
+	// row and column. width is the cells' width in columns.
+	zones *zone.Manager
+	ids   [][]string
+	width int
+	hover harness.MouseZone
+
+	pendingAck    chan struct{}
+	pendingPhases *harness.TickPhases
+	ready         chan struct{}
+
+	// updates and views count ticks applied and views built, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+
+	// A panic in Init, Update or View is recovered here rather than by
+	// Bubble Tea, which would print it to the terminal and return a bare
+	// ErrProgramPanic. The program then quits and the session reports it.
+	panicked error
+	quit     func()
+}
+
+func (m *benchModel) recoverPanic() {
+	if r := recover(); r != nil {
+		m.panicked = harness.NewPanicError(r)
+		go m.quit()
+	}
+}
+
+func (m *benchModel) Init() tea.Cmd {
+	defer m.recoverPanic()
+	return func() tea.Msg {
+		return readyMsg{}
+	}
+}
+
+func (m *benchModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	model = m
+	defer m.recoverPanic()
+	switch v := msg.(type) {
+	case readyMsg:
+		if m.ready != nil {
+			close(m.ready)
+			m.ready = nil
+		}
+	case tea.WindowSizeMsg:
+		if v.Width > 0 {
+			m.cols = v.Width
+		}
+	case tea.MouseMsg:
+		m.mouse(v)
+	case benchTickMsg:
+		updateStart := time.Now()
+		for _, move := range harness.MouseZoneMoves(m.params, harness.NewFrameRand(m.seed, v.tick)) {
+			m.mouse(tea.MouseMsg{X: move.X, Y: move.Y, Action: tea.MouseActionMotion, Button: tea.MouseButtonNone})
+		}
+		m.lines = harness.MouseZoneLines(m.params, v.tick, m.hover)
+		m.updates.Add(1)
+		m.pendingAck = v.ack
+		m.pendingPhases = v.phases
+		if v.phases != nil {
+			v.phases.UpdateMs = harness.MsSince(updateStart)
+		}
+	}
+	return m, nil
+}
+
+// mouse moves the hover to the zone under msg, found the way bubblezone apps
+// do, by asking each zone in turn whether it holds the pointer. A pointer
+// over no zone keeps the last hover, as the scenario does.
+func (m *benchModel) mouse(msg tea.MouseMsg) {
+	for r, row := range m.ids {
+		for c, id := range row {
+			if info := m.zones.Get(id); info != nil && info.InBounds(msg) {
+				m.hover = harness.MouseZone{Row: r, Col: c}
+				return
+			}
+		}
+	}
+}
+
+// mark wraps each cell of the grid rows in its zone's markers. The header
+// row and the columns right of the last cell are left unmarked.
+func (m *benchModel) mark() string {
+	var b strings.Builder
+	for i, line := range m.lines {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if i == 0 || i > len(m.ids) {
+			b.WriteString(line)
+			continue
+		}
+		end := 0
+		for c, id := range m.ids[i-1] {
+			start := c * m.width
+			end = min(start+m.width, len(line))
+			if start >= end {
+				break
+			}
+			b.WriteString(m.zones.Mark(id, line[start:end]))
+		}
+		b.WriteString(line[end:])
+	}
+	return b.String()
+}
+
+func (m *benchModel) View() string {
+	defer m.recoverPanic()
+	viewStart := time.Now()
+	// Scan strips the markers and records where each zone landed, for the
+	// next tick's hit-tests.
+	view := m.zones.Scan(m.mark())
+	m.views.Add(1)
+	if m.pendingPhases != nil {
+		m.pendingPhases.ViewMs = harness.MsSince(viewStart)
+		m.pendingPhases.ViewEnd = time.Now()
+		m.pendingPhases = nil
+	}
+	if m.pendingAck != nil {
+		close(m.pendingAck)
+		m.pendingAck = nil
+	}
+	return view
+}
+
+type benchSession struct {
+	program  *tea.Program
+	model    *benchModel
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+}
+
+// startBenchSession starts the scenario as a Bubble Tea program; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	fps int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	if scenario != "terminal-mouse-zones" {
+		return nil, fmt.Errorf("bubblezone: no zones for %q; bubblezone-bench runs terminal-mouse-zones", scenario)
+	}
+	gridRows, gridCols := harness.MouseZoneGrid(params)
+	ids := make([][]string, gridRows)
+	for r := range ids {
+		ids[r] = make([]string, gridCols)
+		for c := range ids[r] {
+			ids[r][c] = fmt.Sprintf("%d.%d", r, c)
+		}
+	}
+	// The model starts on the bare grid, so the first view lays the zones out
+	// before any tick hit-tests them.
+	ready := make(chan struct{})
+	model := &benchModel{
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		cols:     cols,
+		lines:    harness.MouseZoneLines(params, 0, harness.MouseZone{Row: -1, Col: -1}),
+		zones:    zone.New(),
+		ids:      ids,
+		width:    harness.MouseZoneWidth(params),
+		hover:    harness.MouseZone{Row: -1, Col: -1},
+		ready:    ready,
+	}
+
+	program := tea.NewProgram(
+		model,
+		tea.WithInput(nil),
+		tea.WithOutput(writer),
+		tea.WithFPS(fps),
+		tea.WithAltScreen(),
+		tea.WithMouseAllMotion(),
+		tea.WithoutSignalHandler(),
+	)
+
+	model.quit = program.Quit
+	session := &benchSession{program: program, model: model, writer: writer, done: make(chan struct{}), timeouts: t}
+	go func() {
+		_, session.runErr = program.Run()
+		if model.panicked != nil {
+			session.runErr = model.panicked
+		}
+		close(session.done)
+	}()
+
+	select {
+	case <-ready:
+		program.Send(tea.WindowSizeMsg{Width: cols, Height: rows})
+		return session, nil
+	case <-session.done:
+		if session.runErr != nil {
+			return nil, fmt.Errorf("bubblezone: %w before initialization: %w", harness.ErrProgramExited, session.runErr)
+		}
+		return nil, fmt.Errorf("bubblezone: %w before initialization", harness.ErrProgramExited)
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for bubblezone startup after %s", t.Startup))
+	}
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	send := func() {
+		s.program.Send(benchTickMsg{tick: tick, ack: ack, phases: phases})
+	}
+	if eventLoop {
+		go send()
+	} else {
+		send()
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		if s.runErr != nil {
+			return harness.TickPhases{}, fmt.Errorf("bubblezone: %w during render tick=%d: %w", harness.ErrProgramExited, tick, s.runErr)
+		}
+		return harness.TickPhases{}, fmt.Errorf("bubblezone: %w during render tick=%d", harness.ErrProgramExited, tick)
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("bubblezone: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack; Program.Send returns once the
+// event loop has taken it.
+func (s *benchSession) SendTick(tick int) error {
+	select {
+	case <-s.done:
+		if s.runErr != nil {
+			return fmt.Errorf("bubblezone: %w during tick=%d: %w", harness.ErrProgramExited, tick, s.runErr)
+		}
+		return fmt.Errorf("bubblezone: %w during tick=%d", harness.ErrProgramExited, tick)
+	default:
+	}
+	s.writer.MarkFrame(tick)
+	s.program.Send(benchTickMsg{tick: tick})
+	return nil
+}
+
+// Resize delivers the WindowSizeMsg Bubble Tea sends on SIGWINCH.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("bubblezone: %w before resize to %dx%d", harness.ErrProgramExited, cols, rows)
+	default:
+	}
+	s.program.Send(tea.WindowSizeMsg{Width: cols, Height: rows})
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.model.updates.Load(), s.model.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	s.program.Send(tea.Quit())
+	select {
+	case <-s.done:
+		s.model.zones.Close()
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down bubblezone after %s", s.timeouts.Shutdown))
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+// main runs the shared CLI with no tea.Program and no terminal: each tick
+// builds the terminal-strict-ui frame's content (the update phase) and
+// lays it out with lipgloss (the view phase), and nothing is written.
+// Comparing its view phase with bubbletea-bench's separates what Bubble
+// Tea spends on layout from what it spends on rendering and I/O. Sizes
+// come from the scenario's rows and cols params, so
+// --sweep "rows=24,40,60;cols=100,160,240" covers several in one run.
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{"github.com/charmbracelet/lipgloss"},
+		// Ticks are laid out on the caller's goroutine as they arrive, so
+		// --fps has nothing to set.
+		Start: func(cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, _ int, _ int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(scenario, params, seed, w)
+			}
+		},
+	})
+}
+
+// navigation maps the scenarios lipgloss-bench lays out to whether they
+// switch pages.
+var navigation = map[string]bool{
+	"terminal-strict-ui":            false,
+	"terminal-strict-ui-navigation": true,
+}
+
+// benchSession lays out each tick synchronously; there is no event loop to
+// hand ticks to, and no output to wait for.
+type benchSession struct {
+	writer     *harness.Writer
+	params     map[string]string
+	seed       uint64
+	navigation bool
+
+	// updates and views count ticks laid out, for --throughput.
+	updates atomic.Int64
+	views   atomic.Int64
+}
+
+func startBenchSession(scenario string, params map[string]string, seed uint64, writer *harness.Writer) (harness.Session, error) {
+	nav, ok := navigation[scenario]
+	if !ok {
+		return nil, fmt.Errorf("lipgloss: no layout for %q; lipgloss-bench lays out terminal-strict-ui and terminal-strict-ui-navigation", scenario)
+	}
+	own := maps.Clone(params)
+	if own == nil {
+		own = map[string]string{}
+	}
+	return &benchSession{writer: writer, params: own, seed: seed, navigation: nav}, nil
+}
+
+func (s *benchSession) layout(tick int) harness.TickPhases {
+	var phases harness.TickPhases
+	updateStart := time.Now()
+	frame := harness.NewStrictUIFrame(s.params, s.seed, tick, s.navigation)
+	s.updates.Add(1)
+	phases.UpdateMs = harness.MsSince(updateStart)
+
+	viewStart := time.Now()
+	frame.Lines()
+	s.views.Add(1)
+	phases.ViewMs = harness.MsSince(viewStart)
+	phases.ViewEnd = time.Now()
+	return phases
+}
+
+// RenderTick lays out tick. The frame is not written, so it has no flush
+// phase and no write blocking.
+func (s *benchSession) RenderTick(tick int, _ bool) (harness.TickPhases, error) {
+	s.writer.MarkFrame(tick)
+	return s.layout(tick), nil
+}
+
+func (s *benchSession) SendTick(tick int) error {
+	s.writer.MarkFrame(tick)
+	s.layout(tick)
+	return nil
+}
+
+// Resize lays out later ticks at the new size, which the strict-ui frame
+// takes from its rows and cols params.
+func (s *benchSession) Resize(rows int, cols int) error {
+	s.params["rows"] = strconv.Itoa(rows)
+	s.params["cols"] = strconv.Itoa(cols)
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	return nil
+}
@@ -6,9 +6,29 @@ import { BoxRenderable, TextRenderable, createCliRenderer } from "@opentui/core"
 import { createRoot, flushSync } from "@opentui/react";
 import { type ReactNode, createElement } from "react";
 import {
-  buildStrictPaneLines,
-  buildStrictSections,
-} from "../src/scenarios/terminalStrictWorkloads.ts";
+  CONTENT_UPDATE_LIST_SIZE,
+  STARTUP_TREE_SIZE,
+  entrySize,
+  layoutStressValue,
+  tableLines,
+  tableUpdateCellValue,
+  virtualListOffset,
+} from "../src/scenarios/coreWorkloads.ts";
+import { FrameRand } from "../src/scenarios/frameRand.ts";
+import { numberParam, safeMod } from "../src/scenarios/frameText.ts";
+import { scenarioLines } from "../src/scenarios/frames.ts";
+import { loadScenarioSpec } from "../src/scenarios/spec.ts";
+import { buildStrictSections } from "../src/scenarios/terminalStrictWorkloads.ts";
+import {
+  buildTerminalFpsStreamLines,
+  buildTerminalFullUiLines,
+  buildTerminalFullUiNavigationLines,
+  buildTerminalInputLatencyLines,
+  buildTerminalMemorySoakLines,
+  buildTerminalScreenTransitionLines,
+  makeLineContent,
+  makeStaticLine,
+} from "../src/scenarios/terminalWorkloads.ts";
 
 type CliArgs = Readonly<{
   scenario: string;
@@ -17,6 +37,7 @@ type CliArgs = Readonly<{
   io: "pty" | "stub";
   driver: "react" | "core";
   resultPath: string | null;
+  seed: number;
   params: Record<string, number | string>;
 }>;
 
@@ -81,6 +102,7 @@ function parseArgs(argv: readonly string[]): CliArgs {
   let io: "pty" | "stub" = "pty";
   let driver: "react" | "core" = "react";
   let resultPath: string | null = null;
+  let seed = 0;
 
   for (let i = 2; i < argv.length; i++) {
     const arg = argv[i];
@@ -107,6 +129,9 @@ function parseArgs(argv: readonly string[]): CliArgs {
       case "result-path":
         resultPath = rawVal;
         break;
+      case "seed":
+        seed = Number.parseInt(rawVal, 10) || 0;
+        break;
       default: {
         const asNum = Number(rawVal);
         params[key] = Number.isFinite(asNum) && rawVal.trim() !== "" ? asNum : rawVal;
@@ -119,7 +144,7 @@ function parseArgs(argv: readonly string[]): CliArgs {
   if (iterations <= 0) throw new Error("--iterations must be > 0");
   if (warmup < 0) throw new Error("--warmup must be >= 0");
 
-  return { scenario, warmup, iterations, io, driver, resultPath, params };
+  return { scenario, warmup, iterations, io, driver, resultPath, seed, params };
 }
 
 function takeCpu(): CpuUsage {
@@ -150,661 +175,6 @@ function tryGc(): void {
   if (typeof globalThis.gc === "function") globalThis.gc();
 }
 
-function padTo(s: string, width: number): string {
-  if (s.length >= width) return s.slice(0, width);
-  return s + " ".repeat(width - s.length);
-}
-
-function clipPad(s: string, cols: number): string {
-  if (s.length >= cols) return s.slice(0, cols);
-  return `${s}${" ".repeat(cols - s.length)}`;
-}
-
-function bar(value: number, width: number): string {
-  const filled = Math.max(0, Math.min(width, Math.round(value * width)));
-  return `${"#".repeat(filled)}${"-".repeat(width - filled)}`;
-}
-
-function safeMod(value: number, denom: number): number {
-  if (denom <= 0) return 0;
-  return value % denom;
-}
-
-function numberParam(value: number | string | undefined, fallback: number): number {
-  return Number(value ?? fallback);
-}
-
-const STARTUP_TREE_SIZE = 50;
-const CONTENT_UPDATE_LIST_SIZE = 500;
-
-function makeLineContent(row: number, tick: number, cols: number): string {
-  const v = (tick * 1103515245 + row * 12345) >>> 0;
-  return padTo(`row=${row.toString().padStart(2, "0")} tick=${tick} v=${v.toString(16)}`, cols);
-}
-
-function makeStaticLine(row: number, cols: number): string {
-  return padTo(`row=${row.toString().padStart(2, "0")} static`, cols);
-}
-
-function cellValue(row: number, col: number, tick: number, hotRow: number, hotCol: number): string {
-  if (row === hotRow && col === hotCol) return `v=${tick}`;
-  return `r${row}c${col}`;
-}
-
-function tableLines(rows: number, cols: number, tick: number): string[] {
-  const hotRow = tick % rows;
-  const hotCol = tick % cols;
-  const lines: string[] = [];
-  const header = Array.from({ length: cols }, (_, c) => `C${c}`.padEnd(10, " ")).join("");
-  lines.push(header);
-  lines.push("-".repeat(Math.min(120, header.length)));
-  for (let r = 0; r < rows; r++) {
-    let line = "";
-    for (let c = 0; c < cols; c++) {
-      line += cellValue(r, c, tick, hotRow, hotCol).padEnd(10, " ");
-    }
-    lines.push(line.slice(0, 120));
-  }
-  return lines;
-}
-
-function tableUpdateCellValue(r: number, c: number, tick: number): string {
-  const v = (tick + r * 131 + c * 17) % 10_000;
-  const wide = (tick + r + c) % 13 === 0;
-  return wide ? `val=${String(v).padStart(4, "0")} (row=${r})` : String(v);
-}
-
-function terminalScreenTransitionLines(
-  tick: number,
-  params: Readonly<Record<string, number | string>>,
-): string[] {
-  const rows = numberParam(params.rows, 40);
-  const cols = numberParam(params.cols, 120);
-  const mode = tick % 3;
-  const lines: string[] = [];
-
-  if (mode === 0) {
-    lines.push(clipPad("terminal-screen-transition [dashboard]", cols));
-    for (let i = 0; i < rows - 1; i++) {
-      const v = ((tick * 37 + i * 97) % 1000) / 1000;
-      lines.push(
-        clipPad(`svc-${String(i).padStart(2, "0")} ${bar(v, 24)} ${(v * 100).toFixed(1)}%`, cols),
-      );
-    }
-    return lines;
-  }
-
-  if (mode === 1) {
-    lines.push(clipPad("terminal-screen-transition [table]", cols));
-    lines.push(clipPad("ID        NAME                 STATE     LAT(ms)   ERR", cols));
-    for (let i = 0; i < rows - 2; i++) {
-      const id = `node-${String((tick + i) % 512).padStart(3, "0")}`;
-      const state = (tick + i) % 7 === 0 ? "degraded" : "healthy ";
-      const lat = 10 + ((tick * 13 + i * 7) % 190);
-      const err = (tick + i * 3) % 53 === 0 ? "yes" : "no ";
-      lines.push(
-        clipPad(
-          `${id}   backend-${String(i).padStart(2, "0")}        ${state}     ${String(lat).padStart(3, " ")}      ${err}`,
-          cols,
-        ),
-      );
-    }
-    return lines;
-  }
-
-  lines.push(clipPad("terminal-screen-transition [logs]", cols));
-  for (let i = 0; i < rows - 1; i++) {
-    const seq = tick * rows + i;
-    const lvl = seq % 11 === 0 ? "WARN" : seq % 23 === 0 ? "ERROR" : "INFO ";
-    lines.push(
-      clipPad(
-        `${lvl} ${new Date(1700000000000 + seq * 17).toISOString()} service=${seq % 17} msg=transition-${seq}`,
-        cols,
-      ),
-    );
-  }
-  return lines;
-}
-
-function terminalFpsStreamLines(
-  tick: number,
-  params: Readonly<Record<string, number | string>>,
-): string[] {
-  const rows = numberParam(params.rows, 40);
-  const cols = numberParam(params.cols, 120);
-  const channels = numberParam(params.channels, 12);
-  const lines: string[] = [];
-
-  lines.push(clipPad(`terminal-fps-stream tick=${tick} target=60fps channels=${channels}`, cols));
-  lines.push(clipPad("Channel  Value      Trend", cols));
-
-  const bodyRows = Math.max(1, rows - 2);
-  for (let i = 0; i < bodyRows; i++) {
-    const ch = i % channels;
-    const v = ((tick * (17 + ch) + i * 31) % 1000) / 1000;
-    const trendSeed = (tick + i * 13 + ch * 11) % 16;
-    const trend = Array.from({ length: 16 }, (_, j) =>
-      ((trendSeed + j * 3) % 16) / 15 < v ? "▮" : "▯",
-    ).join("");
-    lines.push(
-      clipPad(
-        `ch-${String(ch).padStart(2, "0")}    ${(v * 100).toFixed(2).padStart(6, " ")}%    ${trend}`,
-        cols,
-      ),
-    );
-  }
-  return lines;
-}
-
-function terminalInputLatencyLines(
-  tick: number,
-  params: Readonly<Record<string, number | string>>,
-): string[] {
-  const rows = numberParam(params.rows, 40);
-  const cols = numberParam(params.cols, 120);
-  const lines: string[] = [];
-
-  lines.push(clipPad("terminal-input-latency synthetic-key-event -> frame", cols));
-  lines.push(
-    clipPad(
-      `tick=${tick} active=${tick % 16} token=${((tick * 1103515245) >>> 0).toString(16)}`,
-      cols,
-    ),
-  );
-  for (let i = 0; i < rows - 2; i++) {
-    const active = i === tick % Math.max(1, rows - 2);
-    lines.push(
-      clipPad(
-        `${active ? ">" : " "} command-${String(i).padStart(2, "0")}  value=${(tick + i * 9) % 10000}`,
-        cols,
-      ),
-    );
-  }
-  return lines;
-}
-
-function terminalMemorySoakLines(
-  tick: number,
-  params: Readonly<Record<string, number | string>>,
-): string[] {
-  const rows = numberParam(params.rows, 40);
-  const cols = numberParam(params.cols, 120);
-  const lines: string[] = [];
-
-  lines.push(clipPad(`terminal-memory-soak tick=${tick}`, cols));
-  for (let i = 0; i < rows - 1; i++) {
-    const id = (tick * 7 + i * 19) % 100000;
-    const payload = `${String(id).padStart(5, "0")} ${"x".repeat((i % 7) + 8)} ${(tick + i) % 997}`;
-    lines.push(clipPad(payload, cols));
-  }
-  return lines;
-}
-
-function benchmarkLines(items: number, seed: number, cols: number): string[] {
-  const lines: string[] = [];
-  lines.push(clipPad(`Benchmark: ${items} items (#${seed})`, cols));
-  lines.push(clipPad(`Total: ${items}  Page 1`, cols));
-  for (let i = 0; i < items; i++) {
-    lines.push(clipPad(`${i}. Item ${i} details`, cols));
-  }
-  return lines;
-}
-
-function rerenderLines(count: number, cols: number): string[] {
-  return [
-    clipPad("Counter Benchmark", cols),
-    clipPad(`Count: ${count}  [+1]  [-1]`, cols),
-    clipPad(`Last updated: iteration ${count}`, cols),
-  ];
-}
-
-function contentUpdateLines(selected: number, cols: number): string[] {
-  const lines: string[] = [];
-  lines.push(clipPad(`Files  ${CONTENT_UPDATE_LIST_SIZE} items  Selected: ${selected}`, cols));
-  for (let i = 0; i < CONTENT_UPDATE_LIST_SIZE; i++) {
-    const marker = i === selected ? ">" : " ";
-    lines.push(
-      clipPad(
-        `${marker} ${String(i).padStart(3, " ")}. entry-${i}.log ${(i * 1024 + 512).toLocaleString()} B`,
-        cols,
-      ),
-    );
-  }
-  return lines;
-}
-
-function layoutStressLines(rows: number, cols: number, tick: number, termCols: number): string[] {
-  const lines: string[] = [];
-  lines.push(clipPad("Layout stress", termCols));
-  lines.push(clipPad(`tick=${tick}`, termCols));
-  for (let r = 0; r < rows; r++) {
-    const labels: string[] = [];
-    const values: string[] = [];
-    for (let c = 0; c < cols; c++) {
-      const v = (tick + r * 31 + c * 17) % 1000;
-      const wide = (tick + r + c) % 7 === 0;
-      const value = wide ? `value=${v} (${String(v).padStart(4, "0")})` : `v=${v}`;
-      labels.push(`C${c}`);
-      values.push(value);
-    }
-    lines.push(clipPad(labels.join(" | "), termCols));
-    lines.push(clipPad(values.join(" | "), termCols));
-  }
-  return lines;
-}
-
-function scrollStressLines(items: number, active: number, tick: number, cols: number): string[] {
-  const lines: string[] = [];
-  lines.push(clipPad("Scroll stress (non-virtualized)", cols));
-  lines.push(clipPad(`items=${items} active=${active} tick=${tick}`, cols));
-  for (let i = 0; i < items; i++) {
-    lines.push(
-      clipPad(
-        `${String(i).padStart(5, " ")} ${i === active ? "▶" : " "} Item ${i} v=${(tick + i * 17) % 1000}`,
-        cols,
-      ),
-    );
-  }
-  return lines;
-}
-
-function virtualListLines(
-  totalItems: number,
-  viewport: number,
-  tick: number,
-  cols: number,
-): string[] {
-  const lines: string[] = [];
-  const offset = safeMod(tick, totalItems - viewport);
-  const end = Math.min(totalItems, offset + viewport);
-  lines.push(clipPad("Virtual list", cols));
-  lines.push(
-    clipPad(`total=${totalItems} viewport=${viewport} offset=${offset} tick=${tick}`, cols),
-  );
-  for (let i = offset; i < end; i++) {
-    lines.push(
-      clipPad(`${String(i).padStart(6, " ")} • Item ${i} v=${(tick + i * 97) % 1000}`, cols),
-    );
-  }
-  return lines;
-}
-
-function tablesLines(rows: number, cols: number, tick: number, termCols: number): string[] {
-  const lines: string[] = [];
-  lines.push(clipPad("Table update", termCols));
-  lines.push(clipPad(`rows=${rows} cols=${cols} tick=${tick}`, termCols));
-  lines.push(
-    clipPad(["row", ...Array.from({ length: cols }, (_, c) => `Col ${c}`)].join("  "), termCols),
-  );
-  for (let r = 0; r < rows; r++) {
-    const cells: string[] = [];
-    for (let c = 0; c < cols; c++) {
-      cells.push(tableUpdateCellValue(r, c, tick));
-    }
-    lines.push(clipPad(`${String(r).padStart(4, " ")}  ${cells.join("  ")}`, termCols));
-  }
-  return lines;
-}
-
-function memoryProfileLines(tick: number, cols: number): string[] {
-  const pct = safeMod(tick, 100);
-  const filled = Math.floor(pct / 5);
-  const barText = `[${"#".repeat(filled)}${".".repeat(20 - filled)}] ${pct}%`;
-  const lines: string[] = [];
-  lines.push(clipPad(`Iteration ${tick}`, cols));
-  lines.push(clipPad(barText, cols));
-  for (let j = 0; j < 20; j++) {
-    lines.push(clipPad(`  Line ${j}: value=${tick * 20 + j}`, cols));
-  }
-  return lines;
-}
-
-function terminalRerenderLines(tick: number, cols: number): string[] {
-  return [clipPad("terminal-rerender", cols), clipPad(`tick=${tick}`, cols)];
-}
-
-function terminalVirtualListLines(
-  totalItems: number,
-  viewport: number,
-  tick: number,
-  cols: number,
-): string[] {
-  const lines: string[] = [];
-  const offset = safeMod(tick, totalItems - viewport);
-  const end = Math.min(totalItems, offset + viewport);
-  lines.push(clipPad("terminal-virtual-list", cols));
-  lines.push(
-    clipPad(`total=${totalItems} viewport=${viewport} offset=${offset} tick=${tick}`, cols),
-  );
-  for (let i = offset; i < end; i++) {
-    const active = i === offset + safeMod(tick, viewport);
-    lines.push(
-      clipPad(
-        `${String(i).padStart(6, " ")} • Item ${i} v=${(tick + i * 97) % 1000}${active ? " <" : ""}`,
-        cols,
-      ),
-    );
-  }
-  return lines;
-}
-
-function fullUiPaneWidths(cols: number): Readonly<{ left: number; center: number; right: number }> {
-  const left = Math.max(22, Math.floor(cols * 0.24));
-  const right = Math.max(24, Math.floor(cols * 0.28));
-  const center = Math.max(24, cols - left - right - 6);
-  return { left, center, right };
-}
-
-function paneLine(
-  cols: number,
-  widths: Readonly<{ left: number; center: number; right: number }>,
-  left: string,
-  center: string,
-  right: string,
-): string {
-  return clipPad(
-    `${clipPad(left, widths.left)} │ ${clipPad(center, widths.center)} │ ${clipPad(right, widths.right)}`,
-    cols,
-  );
-}
-
-function spark(seed: number, width: number): string {
-  let out = "";
-  for (let i = 0; i < width; i++) out += (seed + i * 3) % 7 > 2 ? "#" : ".";
-  return out;
-}
-
-function terminalFullUiLines(
-  tick: number,
-  params: Readonly<Record<string, number | string>>,
-): string[] {
-  const rows = Math.max(12, numberParam(params.rows, 40));
-  const cols = Math.max(80, numberParam(params.cols, 120));
-  const services = Math.max(12, numberParam(params.services, 24));
-  const widths = fullUiPaneWidths(cols);
-  const modes = ["overview", "services", "deploy", "incidents"] as const;
-  const mode = modes[tick % modes.length];
-  const navItems = [
-    "Dashboard",
-    "Services",
-    "Deployments",
-    "Incidents",
-    "Queues",
-    "Logs",
-    "Audit",
-    "Settings",
-  ] as const;
-
-  const lines: string[] = [];
-  lines.push(clipPad(`terminal-full-ui mode=${mode} tick=${tick}`, cols));
-  lines.push(
-    clipPad(
-      `cluster=prod-us-east budget=16.6ms cpu=${35 + ((tick * 7) % 40)}% mem=${42 + ((tick * 11) % 49)}% qps=${900 + ((tick * 29) % 1500)}`,
-      cols,
-    ),
-  );
-
-  const bodyRows = Math.max(1, rows - 4);
-  const activeNav = tick % navItems.length;
-  const visibleTableRows = Math.max(6, Math.min(18, bodyRows - 6));
-  const viewportOffset = tick % Math.max(1, services - visibleTableRows + 1);
-  const activeSvc = tick % services;
-
-  for (let r = 0; r < bodyRows; r++) {
-    let left = "";
-    let center = "";
-    let right = "";
-
-    if (r === 0) left = "NAV";
-    else if (r <= navItems.length) {
-      const idx = r - 1;
-      left = `${idx === activeNav ? ">" : " "} ${navItems[idx]}`;
-    } else if (r === navItems.length + 1) {
-      left = `env=${["prod", "stage", "dev"][tick % 3]} region=${["use1", "usw2", "euw1"][tick % 3]}`;
-    } else if (r === navItems.length + 2) {
-      left = `focus=svc-${String(activeSvc).padStart(3, "0")} alerts=${(tick * 3) % 19}`;
-    } else {
-      left = `saved-view-${String((tick + r) % 12).padStart(2, "0")} ${spark(tick + r, 10)}`;
-    }
-
-    if (r === 0) center = "SERVICES";
-    else if (r === 1) center = "id      state      lat   rps   err";
-    else if (r >= 2 && r < 2 + visibleTableRows) {
-      const svc = viewportOffset + (r - 2);
-      const degraded = (tick + svc * 5) % 17 === 0;
-      const lat = 12 + ((tick * 13 + svc * 7) % 180);
-      const rps = 100 + ((tick * 19 + svc * 37) % 2500);
-      const err = ((tick + svc * 11) % 70) / 10;
-      center = `${svc === activeSvc ? ">" : " "} svc-${String(svc).padStart(3, "0")} ${degraded ? "degraded" : "healthy "} ${String(lat).padStart(3, " ")}ms ${String(rps).padStart(4, " ")} ${err.toFixed(1)}%`;
-    } else if (r === 2 + visibleTableRows) {
-      const cpu = ((tick * 17) % 1000) / 1000;
-      center = `cpu ${bar(cpu, 20)} ${(cpu * 100).toFixed(1)}%  io ${(45 + ((tick * 23) % 50)).toString().padStart(2, " ")}%`;
-    } else if (r === 3 + visibleTableRows) {
-      const mem = ((tick * 31 + 211) % 1000) / 1000;
-      center = `mem ${bar(mem, 20)} ${(mem * 100).toFixed(1)}%  gc ${(tick * 97) % 999}ms`;
-    } else if (r === 4 + visibleTableRows) {
-      center = `queue depth=${(tick * 7) % 180} retries=${(tick * 11) % 37} dropped=${(tick * 13) % 9}`;
-    } else {
-      center = `timeline ${spark(tick * 3 + r, Math.max(16, widths.center - 10))}`;
-    }
-
-    if (r === 0) right = "INSPECTOR";
-    else if (r === 1)
-      right = `service=svc-${String(activeSvc).padStart(3, "0")} owner=team-${activeSvc % 7}`;
-    else if (r === 2) right = `slo p95<120ms  now=${45 + ((tick * 5 + activeSvc * 3) % 110)}ms`;
-    else if (r === 3)
-      right = `deploy=${(tick * 3 + activeSvc) % 2 === 0 ? "green" : "canary"} zone=az-${(activeSvc % 3) + 1}`;
-    else {
-      const seq = tick * bodyRows + r;
-      const lvl = seq % 19 === 0 ? "ERROR" : seq % 11 === 0 ? "WARN " : "INFO ";
-      right = `${lvl} t+${String(seq).padStart(5, "0")} op=${String((seq * 7) % 97).padStart(2, "0")} msg=event-${seq}`;
-    }
-
-    lines.push(paneLine(cols, widths, left, center, right));
-  }
-
-  lines.push(
-    clipPad(
-      `status=online conn=${1200 + ((tick * 17) % 800)} sync=${(tick * 29) % 9999} pending=${(tick * 5) % 48} diff=${(tick * 7) % 21}`,
-      cols,
-    ),
-  );
-  lines.push(
-    clipPad(
-      "hotkeys: [1]overview [2]services [3]deploy [4]incidents [/]filter [enter]open [q]quit",
-      cols,
-    ),
-  );
-
-  return lines.slice(0, rows);
-}
-
-function terminalFullUiNavigationLines(
-  tick: number,
-  params: Readonly<Record<string, number | string>>,
-): string[] {
-  const rows = Math.max(12, numberParam(params.rows, 40));
-  const cols = Math.max(80, numberParam(params.cols, 120));
-  const services = Math.max(10, numberParam(params.services, 24));
-  const dwell = Math.max(2, numberParam(params.dwell, 8));
-  const pages = ["overview", "services", "deployments", "incidents", "logs", "command"] as const;
-  const pageIndex = Math.floor(tick / dwell) % pages.length;
-  const page = pages[pageIndex];
-  const localTick = tick % dwell;
-
-  const lines: string[] = [];
-  lines.push(
-    clipPad(
-      `terminal-full-ui-navigation page=${page} tick=${tick} local=${localTick}/${dwell - 1}`,
-      cols,
-    ),
-  );
-  lines.push(
-    clipPad(
-      `tabs: ${pages.map((p, i) => `${i === pageIndex ? "[" : ""}${p}${i === pageIndex ? "]" : ""}`).join(" | ")}`,
-      cols,
-    ),
-  );
-
-  const bodyRows = Math.max(1, rows - 4);
-  for (let i = 0; i < bodyRows; i++) {
-    let line = "";
-
-    if (page === "overview") {
-      if (i === 0) line = "overview: global health + throughput + alerts";
-      else if (i <= 8) {
-        const svc = i - 1;
-        const healthy = (tick + svc * 5) % 9 !== 0;
-        const v = ((tick * 23 + svc * 41) % 1000) / 1000;
-        line = `card svc-${String(svc).padStart(2, "0")} ${healthy ? "healthy " : "degraded"} ${bar(v, 24)} ${(v * 100).toFixed(1)}%`;
-      } else if (i === 9)
-        line = `alerts open=${(tick * 3) % 11} acked=${(tick * 7) % 17} muted=${(tick * 5) % 5}`;
-      else line = `trend ${spark(tick + i * 3, Math.max(16, cols - 10))}`;
-    } else if (page === "services") {
-      if (i === 0) line = "services: inventory + selection + per-row telemetry";
-      else if (i === 1) line = "id      state      lat   rps   err";
-      else {
-        const row = i - 2;
-        const svc = (tick + row) % services;
-        const selected = row === tick % Math.max(1, bodyRows - 2);
-        const degraded = (tick + svc * 3) % 15 === 0;
-        const lat = 10 + ((tick * 13 + svc * 9) % 220);
-        const rps = 80 + ((tick * 17 + svc * 31) % 3000);
-        const err = ((tick + svc * 7) % 80) / 10;
-        line = `${selected ? ">" : " "} svc-${String(svc).padStart(3, "0")} ${degraded ? "degraded" : "healthy "} ${String(lat).padStart(3, " ")}ms ${String(rps).padStart(4, " ")} ${err.toFixed(1)}%`;
-      }
-    } else if (page === "deployments") {
-      if (i === 0) line = "deployments: staged rollout + promotion gates";
-      else {
-        const step = i % 12;
-        const pct = (tick * 7 + i * 9) % 101;
-        const gate = (tick + step) % 5 === 0 ? "blocked" : "ready  ";
-        line = `pipeline-${String(step).padStart(2, "0")} ${gate} ${bar(pct / 100, 18)} ${String(pct).padStart(3, " ")}% canary=${(tick + step) % 2 === 0 ? "on" : "off"}`;
-      }
-    } else if (page === "incidents") {
-      if (i === 0) line = "incidents: queue + assignee + response status";
-      else {
-        const incident = tick * bodyRows + i;
-        const sev = incident % 13 === 0 ? "sev1" : incident % 7 === 0 ? "sev2" : "sev3";
-        const state =
-          incident % 5 === 0 ? "mitigating" : incident % 3 === 0 ? "triaging  " : "open      ";
-        line = `${sev} inc-${String(incident % 10000).padStart(4, "0")} ${state} owner=oncall-${incident % 9} age=${(incident * 3) % 180}m`;
-      }
-    } else if (page === "logs") {
-      const seq = tick * bodyRows + i;
-      const lvl = seq % 17 === 0 ? "ERROR" : seq % 9 === 0 ? "WARN " : "INFO ";
-      line = `${lvl} trace=${String((seq * 19) % 100000).padStart(5, "0")} shard=${seq % 12} msg=stream-${seq}`;
-    } else {
-      if (i < 2) line = "command palette: type to filter actions";
-      else if (i < 10) {
-        const cmd = i - 2;
-        const selected = cmd === tick % 8;
-        line = `${selected ? ">" : " "} /command-${String(cmd).padStart(2, "0")} target=svc-${String((tick + cmd) % services).padStart(3, "0")} preview=${(tick + cmd) % 2 === 0 ? "safe" : "risky"}`;
-      } else {
-        line = `preview: ${spark(tick * 5 + i, Math.max(16, cols - 10))}`;
-      }
-    }
-
-    lines.push(clipPad(line, cols));
-  }
-
-  lines.push(
-    clipPad(
-      `route=${page} navLatency=${1 + ((tick * 7) % 9)}ms commit=${(tick * 97) % 10000} pending=${(tick * 13) % 33}`,
-      cols,
-    ),
-  );
-  lines.push(
-    clipPad(
-      "flow: [tab]next-page [shift+tab]prev-page [enter]open [esc]close [/]command [ctrl+c]quit",
-      cols,
-    ),
-  );
-  return lines.slice(0, rows);
-}
-
-function scenarioLines(
-  scenario: string,
-  params: Readonly<Record<string, number | string>>,
-  tick: number,
-  cols: number,
-): string[] {
-  switch (scenario) {
-    case "startup":
-      return benchmarkLines(STARTUP_TREE_SIZE, tick, cols);
-    case "tree-construction":
-      return benchmarkLines(numberParam(params.items, 100), tick, cols);
-    case "rerender":
-      return rerenderLines(tick, cols);
-    case "content-update":
-      return contentUpdateLines(safeMod(tick, CONTENT_UPDATE_LIST_SIZE), cols);
-    case "layout-stress":
-      return layoutStressLines(
-        numberParam(params.rows, 40),
-        numberParam(params.cols, 4),
-        tick,
-        cols,
-      );
-    case "scroll-stress": {
-      const items = numberParam(params.items, 2000);
-      return scrollStressLines(items, safeMod(tick, items), tick, cols);
-    }
-    case "virtual-list":
-      return virtualListLines(
-        numberParam(params.items, 100_000),
-        numberParam(params.viewport, 40),
-        tick,
-        cols,
-      );
-    case "tables":
-      return tablesLines(numberParam(params.rows, 100), numberParam(params.cols, 8), tick, cols);
-    case "memory-profile":
-      return memoryProfileLines(tick, cols);
-
-    case "terminal-rerender":
-      return terminalRerenderLines(tick, cols);
-    case "terminal-frame-fill": {
-      const rows = numberParam(params.rows, 40);
-      const dirtyLines = numberParam(params.dirtyLines, 1);
-      const lines: string[] = [];
-      for (let r = 0; r < rows; r++) {
-        const content = r < dirtyLines ? makeLineContent(r, tick, cols) : makeStaticLine(r, cols);
-        lines.push(content);
-      }
-      return lines;
-    }
-    case "terminal-virtual-list":
-      return terminalVirtualListLines(
-        numberParam(params.items, 100_000),
-        numberParam(params.viewport, 40),
-        tick,
-        cols,
-      );
-    case "terminal-table":
-      return tableLines(numberParam(params.rows, 40), numberParam(params.cols, 8), tick).map((ln) =>
-        clipPad(ln, cols),
-      );
-    case "terminal-screen-transition":
-      return terminalScreenTransitionLines(tick, params);
-    case "terminal-fps-stream":
-      return terminalFpsStreamLines(tick, params);
-    case "terminal-input-latency":
-      return terminalInputLatencyLines(tick, params);
-    case "terminal-memory-soak":
-      return terminalMemorySoakLines(tick, params);
-    case "terminal-full-ui":
-      return terminalFullUiLines(tick, params);
-    case "terminal-full-ui-navigation":
-      return terminalFullUiNavigationLines(tick, params);
-    case "terminal-strict-ui":
-      return [...buildStrictPaneLines(tick, params, "dashboard")];
-    case "terminal-strict-ui-navigation":
-      return [...buildStrictPaneLines(tick, params, "navigation")];
-    default:
-      throw new Error(`unsupported OpenTUI scenario "${scenario}"`);
-  }
-}
-
 function lineTree(lines: readonly string[]): ReactNode {
   return createElement(
     "box",
@@ -842,18 +212,13 @@ function benchmarkTree(items: number, seed: number): ReactNode {
 }
 
 function rerenderTree(count: number): ReactNode {
+  const [title = "", counter = "", updated = ""] = loadScenarioSpec("rerender").lines(count);
   return createElement(
     "box",
     { flexDirection: "column", paddingX: 1, gap: 1 },
-    createElement("text", null, "Counter Benchmark"),
-    createElement(
-      "box",
-      { flexDirection: "row", gap: 2 },
-      createElement("text", null, `Count: ${count}`),
-      createElement("text", null, "[+1]"),
-      createElement("text", null, "[-1]"),
-    ),
-    createElement("text", null, `Last updated: iteration ${count}`),
+    createElement("text", null, title),
+    createElement("text", null, counter),
+    createElement("text", null, updated),
   );
 }
 
@@ -868,7 +233,7 @@ function contentUpdateTree(selected: number): ReactNode {
         createElement("text", null, isSelected ? ">" : " "),
         createElement("text", null, `${String(i).padStart(3, " ")}.`),
         createElement("text", null, `entry-${i}.log`),
-        createElement("text", null, `${(i * 1024 + 512).toLocaleString()} B`),
+        createElement("text", null, entrySize(i)),
       ),
     );
   }
@@ -887,14 +252,12 @@ function contentUpdateTree(selected: number): ReactNode {
   );
 }
 
-function layoutStressTree(rows: number, cols: number, tick: number): ReactNode {
+function layoutStressTree(rows: number, cols: number, tick: number, rng: FrameRand): ReactNode {
   const grid: ReactNode[] = [];
   for (let r = 0; r < rows; r++) {
     const cells: ReactNode[] = [];
     for (let c = 0; c < cols; c++) {
-      const v = (tick + r * 31 + c * 17) % 1000;
-      const wide = (tick + r + c) % 7 === 0;
-      const value = wide ? `value=${v} (${String(v).padStart(4, "0")})` : `v=${v}`;
+      const value = layoutStressValue(r, c, tick, rng);
       cells.push(
         createElement(
           "box",
@@ -916,7 +279,12 @@ function layoutStressTree(rows: number, cols: number, tick: number): ReactNode {
   );
 }
 
-function scrollStressTree(items: number, active: number, tick: number): ReactNode {
+function scrollStressTree(
+  items: number,
+  active: number,
+  tick: number,
+  rng: FrameRand,
+): ReactNode {
   const rows: ReactNode[] = [];
   for (let i = 0; i < items; i++) {
     const isActive = i === active;
@@ -927,7 +295,7 @@ function scrollStressTree(items: number, active: number, tick: number): ReactNod
         createElement("text", null, String(i).padStart(5, " ")),
         createElement("text", null, isActive ? "▶" : " "),
         createElement("text", null, `Item ${i}`),
-        createElement("text", null, `v=${(tick + i * 17) % 1000}`),
+        createElement("text", null, `v=${rng.intn(1000)}`),
       ),
     );
   }
@@ -941,9 +309,14 @@ function scrollStressTree(items: number, active: number, tick: number): ReactNod
   );
 }
 
-function virtualListTree(totalItems: number, viewport: number, tick: number): ReactNode {
+function virtualListTree(
+  totalItems: number,
+  viewport: number,
+  tick: number,
+  rng: FrameRand,
+): ReactNode {
   const rows: ReactNode[] = [];
-  const offset = safeMod(tick, totalItems - viewport);
+  const offset = virtualListOffset(totalItems, viewport, tick);
   const start = offset;
   const end = Math.min(totalItems, offset + viewport);
 
@@ -955,7 +328,7 @@ function virtualListTree(totalItems: number, viewport: number, tick: number): Re
         createElement("text", null, String(i).padStart(6, " ")),
         createElement("text", null, "•"),
         createElement("text", null, `Item ${i}`),
-        createElement("text", null, `v=${(tick + i * 97) % 1000}`),
+        createElement("text", null, `v=${rng.intn(1000)}`),
       ),
     );
   }
@@ -973,7 +346,7 @@ function virtualListTree(totalItems: number, viewport: number, tick: number): Re
   );
 }
 
-function tablesTree(rows: number, cols: number, tick: number): ReactNode {
+function tablesTree(rows: number, cols: number, tick: number, rng: FrameRand): ReactNode {
   const headerCells: ReactNode[] = [];
   for (let c = 0; c < cols; c++) {
     headerCells.push(createElement("text", { key: `h:${c}` }, `Col ${c}`));
@@ -983,7 +356,8 @@ function tablesTree(rows: number, cols: number, tick: number): ReactNode {
   for (let r = 0; r < rows; r++) {
     const cells: ReactNode[] = [];
     for (let c = 0; c < cols; c++) {
-      cells.push(createElement("text", { key: `c:${r}:${c}` }, tableUpdateCellValue(r, c, tick)));
+      const value = tableUpdateCellValue(r, c, tick, rng);
+      cells.push(createElement("text", { key: `c:${r}:${c}` }, value));
     }
     body.push(
       createElement(
@@ -1011,30 +385,23 @@ function tablesTree(rows: number, cols: number, tick: number): ReactNode {
 }
 
 function memoryProfileTree(tick: number): ReactNode {
-  const pct = safeMod(tick, 100);
-  const filled = Math.floor(pct / 5);
-  const barText = `[${"#".repeat(filled)}${".".repeat(20 - filled)}] ${pct}%`;
-
-  const lines: ReactNode[] = [];
-  for (let j = 0; j < 20; j++) {
-    lines.push(createElement("text", { key: String(j) }, `  Line ${j}: value=${tick * 20 + j}`));
-  }
-
+  const [title = "", bar = "", ...rows] = loadScenarioSpec("memory-profile").lines(tick);
   return createElement(
     "box",
     { flexDirection: "column", paddingX: 1 },
-    createElement("text", null, `Iteration ${tick}`),
-    createElement("box", { flexDirection: "row", gap: 1 }, createElement("text", null, barText)),
-    ...lines,
+    createElement("text", null, title),
+    createElement("box", { flexDirection: "row", gap: 1 }, createElement("text", null, bar)),
+    ...rows.map((line, j) => createElement("text", { key: String(j) }, line)),
   );
 }
 
 function terminalRerenderTree(tick: number): ReactNode {
+  const [title = "", line = ""] = loadScenarioSpec("terminal-rerender").lines(tick);
   return createElement(
     "box",
     { flexDirection: "column", paddingX: 1, gap: 1 },
-    createElement("text", null, "terminal-rerender"),
-    createElement("text", null, `tick=${tick}`),
+    createElement("text", null, title),
+    createElement("text", null, line),
   );
 }
 
@@ -1043,17 +410,23 @@ function terminalFrameFillTree(
   cols: number,
   dirtyLines: number,
   tick: number,
+  rng: FrameRand,
 ): ReactNode {
   const lines: ReactNode[] = [];
   for (let r = 0; r < rows; r++) {
-    const content = r < dirtyLines ? makeLineContent(r, tick, cols) : makeStaticLine(r, cols);
+    const content = r < dirtyLines ? makeLineContent(r, tick, rng, cols) : makeStaticLine(r, cols);
     lines.push(createElement("text", { key: String(r) }, content));
   }
   return createElement("box", { flexDirection: "column", paddingX: 0 }, ...lines);
 }
 
-function terminalVirtualListTree(totalItems: number, viewport: number, tick: number): ReactNode {
-  const offset = safeMod(tick, totalItems - viewport);
+function terminalVirtualListTree(
+  totalItems: number,
+  viewport: number,
+  tick: number,
+  rng: FrameRand,
+): ReactNode {
+  const offset = virtualListOffset(totalItems, viewport, tick);
   const rows: ReactNode[] = [];
   const end = Math.min(totalItems, offset + viewport);
   for (let i = offset; i < end; i++) {
@@ -1065,7 +438,7 @@ function terminalVirtualListTree(totalItems: number, viewport: number, tick: num
         createElement("text", null, String(i).padStart(6, " ")),
         createElement("text", null, "•"),
         createElement("text", null, `Item ${i}`),
-        createElement("text", null, `v=${(tick + i * 97) % 1000}`),
+        createElement("text", null, `v=${rng.intn(1000)}`),
         createElement("text", null, active ? " <" : ""),
       ),
     );
@@ -1100,8 +473,9 @@ function terminalStrictUiTree(
   tick: number,
   params: Readonly<Record<string, number | string>>,
   variant: "dashboard" | "navigation",
+  rng: FrameRand,
 ): ReactNode {
-  const sections = buildStrictSections(tick, params, variant);
+  const sections = buildStrictSections(tick, params, variant, rng);
   const leftWidth = 24;
   const rightWidth = 32;
 
@@ -1133,6 +507,7 @@ function scenarioTree(
   scenario: string,
   params: Readonly<Record<string, number | string>>,
   tick: number,
+  rng: FrameRand,
 ): ReactNode {
   switch (scenario) {
     case "startup":
@@ -1144,19 +519,25 @@ function scenarioTree(
     case "content-update":
       return contentUpdateTree(safeMod(tick, CONTENT_UPDATE_LIST_SIZE));
     case "layout-stress":
-      return layoutStressTree(numberParam(params.rows, 40), numberParam(params.cols, 4), tick);
+      return layoutStressTree(
+        numberParam(params.rows, 40),
+        numberParam(params.cols, 4),
+        tick,
+        rng,
+      );
     case "scroll-stress": {
       const items = numberParam(params.items, 2000);
-      return scrollStressTree(items, safeMod(tick, items), tick);
+      return scrollStressTree(items, safeMod(tick, items), tick, rng);
     }
     case "virtual-list":
       return virtualListTree(
         numberParam(params.items, 100_000),
         numberParam(params.viewport, 40),
         tick,
+        rng,
       );
     case "tables":
-      return tablesTree(numberParam(params.rows, 100), numberParam(params.cols, 8), tick);
+      return tablesTree(numberParam(params.rows, 100), numberParam(params.cols, 8), tick, rng);
     case "memory-profile":
       return memoryProfileTree(tick);
 
@@ -1168,31 +549,33 @@ function scenarioTree(
         numberParam(params.cols, 120),
         numberParam(params.dirtyLines, 1),
         tick,
+        rng,
       );
     case "terminal-virtual-list":
       return terminalVirtualListTree(
         numberParam(params.items, 100_000),
         numberParam(params.viewport, 40),
         tick,
+        rng,
       );
     case "terminal-table":
       return terminalTableTree(numberParam(params.rows, 40), numberParam(params.cols, 8), tick);
     case "terminal-screen-transition":
-      return lineTree(terminalScreenTransitionLines(tick, params));
+      return lineTree(buildTerminalScreenTransitionLines(tick, params, rng));
     case "terminal-fps-stream":
-      return lineTree(terminalFpsStreamLines(tick, params));
+      return lineTree(buildTerminalFpsStreamLines(tick, params, rng));
     case "terminal-input-latency":
-      return lineTree(terminalInputLatencyLines(tick, params));
+      return lineTree(buildTerminalInputLatencyLines(tick, params, rng));
     case "terminal-memory-soak":
-      return lineTree(terminalMemorySoakLines(tick, params));
+      return lineTree(buildTerminalMemorySoakLines(tick, params, rng));
     case "terminal-full-ui":
-      return lineTree(terminalFullUiLines(tick, params));
+      return lineTree(buildTerminalFullUiLines(tick, params, rng));
     case "terminal-full-ui-navigation":
-      return lineTree(terminalFullUiNavigationLines(tick, params));
+      return lineTree(buildTerminalFullUiNavigationLines(tick, params, rng));
     case "terminal-strict-ui":
-      return terminalStrictUiTree(tick, params, "dashboard");
+      return terminalStrictUiTree(tick, params, "dashboard", rng);
     case "terminal-strict-ui-navigation":
-      return terminalStrictUiTree(tick, params, "navigation");
+      return terminalStrictUiTree(tick, params, "navigation", rng);
     default:
       throw new Error(`unsupported OpenTUI scenario "${scenario}"`);
   }
@@ -1584,12 +967,13 @@ async function renderCoreTickDirect(
   scenario: string,
   params: Readonly<Record<string, number | string>>,
   tick: number,
+  rng: FrameRand,
 ): Promise<void> {
   const strictVariant = strictScenarioVariant(scenario);
   if (strictVariant) {
-    setCoreStrictSections(scene, buildStrictSections(tick, params, strictVariant));
+    setCoreStrictSections(scene, buildStrictSections(tick, params, strictVariant, rng));
   } else {
-    setCoreLines(scene, scenarioLines(scenario, params, tick, scene.cols));
+    setCoreLines(scene, scenarioLines(scenario, params, tick, scene.cols, rng));
   }
   scene.renderer.requestRender();
   await scene.renderer.idle();
@@ -1646,7 +1030,7 @@ async function runStartupBenchReact(args: CliArgs): Promise<BenchResultData> {
     try {
       const ts = performance.now();
       flushSync(() => {
-        root.render(scenarioTree("startup", args.params, seed));
+        root.render(scenarioTree("startup", args.params, seed, new FrameRand(args.seed, seed)));
       });
       await renderer.idle();
       return { elapsedMs: performance.now() - ts, bytes: stdout.totalBytes };
@@ -1709,7 +1093,7 @@ async function runSteadyStateBenchReact(args: CliArgs): Promise<BenchResultData>
 
   const renderTickDirect = async (tick: number): Promise<void> => {
     flushSync(() => {
-      root.render(scenarioTree(args.scenario, args.params, tick));
+      root.render(scenarioTree(args.scenario, args.params, tick, new FrameRand(args.seed, tick)));
     });
     await renderer.idle();
   };
@@ -1788,7 +1172,8 @@ async function runStartupBenchCore(args: CliArgs): Promise<BenchResultData> {
 
     try {
       const ts = performance.now();
-      await renderCoreTickDirect(scene, "startup", args.params, seed);
+      const rng = new FrameRand(args.seed, seed);
+      await renderCoreTickDirect(scene, "startup", args.params, seed, rng);
       return { elapsedMs: performance.now() - ts, bytes: stdout.totalBytes };
     } finally {
       await destroyCoreScene(scene);
@@ -1849,7 +1234,8 @@ async function runSteadyStateBenchCore(args: CliArgs): Promise<BenchResultData>
   const scene = createCoreScene(renderer, cols);
 
   const renderTickDirect = async (tick: number): Promise<void> => {
-    await renderCoreTickDirect(scene, args.scenario, args.params, tick);
+    const rng = new FrameRand(args.seed, tick);
+    await renderCoreTickDirect(scene, args.scenario, args.params, tick, rng);
   };
 
   const renderTick = async (tick: number): Promise<void> => {
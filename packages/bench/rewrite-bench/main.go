@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+func main() {
+	cli.Main(cli.Framework{
+		// No framework: the output is the rewriter's own, so only the
+		// harness module is reported.
+		Modules: nil,
+		// Each tick is drawn and flushed as it arrives, with no frame rate
+		// to cap, so --fps has nothing to set.
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, w)
+			}
+		},
+	})
+}
+
+// The events of a session's loop.
+type (
+	tickEvent struct {
+		tick   int
+		ack    chan struct{}
+		phases *harness.TickPhases
+	}
+	resizeEvent struct{ rows, cols int }
+	quitEvent   struct{}
+)
+
+// benchSession rewrites each frame's changed lines on a loop of its own,
+// like the other harnesses' event loops, so the numbers differ from theirs
+// only by the rendering strategy.
+type benchSession struct {
+	rewriter *rewriter
+	writer   *harness.Writer
+	events   chan any
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	scenario string
+	params   map[string]string
+	seed     uint64
+	cols     int
+	lines    []string
+
+	// updates and views count ticks applied and frames drawn, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+}
+
+// startBenchSession starts the scenario on its own loop; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	session := &benchSession{
+		rewriter: newRewriter(writer, rows, cols),
+		writer:   writer,
+		events:   make(chan any),
+		done:     make(chan struct{}),
+		timeouts: t,
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		cols:     cols,
+		lines:    []string{},
+	}
+	ready := make(chan struct{})
+	go session.loop(ready)
+
+	select {
+	case <-ready:
+		return session, nil
+	case <-session.done:
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for rewrite startup after %s", t.Startup))
+	}
+}
+
+// loop runs every update and draw. A panic in either ends it and is
+// reported by the session instead of taking the process down.
+func (s *benchSession) loop(ready chan struct{}) {
+	defer close(s.done)
+	defer func() {
+		if r := recover(); r != nil {
+			s.runErr = harness.NewPanicError(r)
+		}
+	}()
+	s.rewriter.enter()
+	close(ready)
+	for event := range s.events {
+		switch e := event.(type) {
+		case tickEvent:
+			s.render(e)
+		case resizeEvent:
+			s.cols = e.cols
+			s.rewriter.resize(e.rows, e.cols)
+			s.rewriter.draw(s.lines)
+			s.rewriter.flush()
+		case quitEvent:
+			s.rewriter.exit()
+			return
+		}
+	}
+}
+
+func (s *benchSession) render(e tickEvent) {
+	updateStart := time.Now()
+	s.lines = harness.ScenarioLines(s.scenario, s.params, s.seed, e.tick, s.cols)
+	s.updates.Add(1)
+	if e.phases != nil {
+		e.phases.UpdateMs = harness.MsSince(updateStart)
+	}
+
+	viewStart := time.Now()
+	s.rewriter.draw(s.lines)
+	s.views.Add(1)
+	if e.phases != nil {
+		e.phases.ViewMs = harness.MsSince(viewStart)
+		e.phases.ViewEnd = time.Now()
+	}
+	if e.ack != nil {
+		close(e.ack)
+	}
+	s.rewriter.flush()
+}
+
+// send delivers event to the loop, or reports that the loop has ended.
+func (s *benchSession) send(event any) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *benchSession) exited(what string) error {
+	if s.runErr != nil {
+		return fmt.Errorf("rewrite: %w %s: %w", harness.ErrProgramExited, what, s.runErr)
+	}
+	return fmt.Errorf("rewrite: %w %s", harness.ErrProgramExited, what)
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	event := tickEvent{tick: tick, ack: ack, phases: phases}
+	if eventLoop {
+		go s.send(event)
+	} else {
+		s.send(event)
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		return harness.TickPhases{}, s.exited(fmt.Sprintf("during render tick=%d", tick))
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("rewrite: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack, returning once the loop has taken
+// it.
+func (s *benchSession) SendTick(tick int) error {
+	s.writer.MarkFrame(tick)
+	if !s.send(tickEvent{tick: tick}) {
+		return s.exited(fmt.Sprintf("during tick=%d", tick))
+	}
+	return nil
+}
+
+// Resize clears the screen and rewrites the last frame at the new size.
+func (s *benchSession) Resize(rows int, cols int) error {
+	if !s.send(resizeEvent{rows: rows, cols: cols}) {
+		return s.exited(fmt.Sprintf("before resize to %dx%d", cols, rows))
+	}
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	go s.send(quitEvent{})
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down rewrite after %s", s.timeouts.Shutdown))
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// rewriter redraws frames in place on the main screen, the way uilive and
+// most CLI progress output do: it moves the cursor up to the first line
+// that changed and rewrites each changed line whole, skipping the ones that
+// did not. There is no cell diff and no alternate screen, so its output is
+// what the simplest renderer in common use would send.
+type rewriter struct {
+	w    io.Writer
+	rows int
+	cols int
+	prev []string
+	next []string
+	out  []byte
+
+	// row is the line of the frame the cursor is on, at column 0; reached
+	// is the lowest line it has been on, below which it moves with line
+	// feeds, as it would to scroll a frame in from the bottom of a shell.
+	row     int
+	reached int
+}
+
+func newRewriter(w io.Writer, rows int, cols int) *rewriter {
+	return &rewriter{w: w, rows: rows, cols: cols}
+}
+
+// enter clears the screen so the frame starts on its top line.
+func (r *rewriter) enter() {
+	r.out = append(r.out, "\x1b[2J\x1b[H"...)
+	r.write()
+}
+
+// exit leaves the last frame on screen with the cursor below it.
+func (r *rewriter) exit() {
+	if len(r.prev) > 0 {
+		r.moveTo(len(r.prev) - 1)
+		r.out = append(r.out, "\r\n"...)
+	}
+	r.write()
+}
+
+// resize starts over at a new size: the screen is cleared, so the next
+// flush writes every line.
+func (r *rewriter) resize(rows int, cols int) {
+	r.rows, r.cols = rows, cols
+	r.prev = r.prev[:0]
+	r.row, r.reached = 0, 0
+	r.out = append(r.out, "\x1b[2J\x1b[H"...)
+}
+
+// draw fits lines to the screen for the next flush: each is cut at the
+// screen's width, which it would otherwise wrap past and throw the cursor
+// movement off, and lines past its height cost the top rows, as in the
+// harness's expected screen.
+func (r *rewriter) draw(lines []string) {
+	if len(lines) > r.rows {
+		lines = lines[len(lines)-r.rows:]
+	}
+	r.next = r.next[:0]
+	for _, line := range lines {
+		// A line of no more bytes than columns cannot be wider.
+		if len(line) > r.cols {
+			line = ansi.Truncate(line, r.cols, "")
+		}
+		r.next = append(r.next, line)
+	}
+}
+
+// flush rewrites the lines that changed since the last flush, clearing
+// what is left of a shorter line and any lines the frame no longer has.
+func (r *rewriter) flush() {
+	for i, line := range r.next {
+		if i < len(r.prev) && r.prev[i] == line {
+			continue
+		}
+		r.moveTo(i)
+		r.out = append(r.out, line...)
+		if ansi.StringWidth(line) < r.cols {
+			r.out = append(r.out, "\x1b[K"...)
+		}
+		// A full line leaves the cursor waiting to wrap; the carriage
+		// return settles it back at column 0 either way.
+		r.out = append(r.out, '\r')
+	}
+	if len(r.next) < len(r.prev) {
+		r.moveTo(len(r.next))
+		r.out = append(r.out, "\x1b[J"...)
+	}
+	r.prev = append(r.prev[:0], r.next...)
+	r.write()
+}
+
+// moveTo takes the cursor from column 0 of its line to column 0 of line.
+func (r *rewriter) moveTo(line int) {
+	switch {
+	case line < r.row:
+		r.out = appendCursor(r.out, r.row-line, 'A')
+	case line > r.row:
+		down := min(line, r.reached) - r.row
+		if down > 0 {
+			r.out = appendCursor(r.out, down, 'B')
+		}
+		for ; r.reached < line; r.reached++ {
+			r.out = append(r.out, '\n')
+		}
+	}
+	r.row = line
+}
+
+// appendCursor appends a cursor movement of n lines in direction final.
+func appendCursor(out []byte, n int, final byte) []byte {
+	out = append(out, "\x1b["...)
+	if n != 1 {
+		out = strconv.AppendInt(out, int64(n), 10)
+	}
+	return append(out, final)
+}
+
+func (r *rewriter) write() {
+	if len(r.out) > 0 {
+		_, _ = r.w.Write(r.out)
+		r.out = r.out[:0]
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+// cliImport is the package every Go harness's main hands its framework to.
+const cliImport = `"github.com/rezi-ui/bench/bubbletea-bench/cli"`
+
+// listTimeout bounds list-scenarios, which starts no program.
+const listTimeout = time.Minute
+
+// harnessBin is a harness executable and the scenarios it reports.
+type harnessBin struct {
+	name      string
+	path      string
+	scenarios map[string]bool
+}
+
+// resolveHarnesses turns specs, "NAME" or "NAME=PATH", into harnesses,
+// building the Go ones into binDir. With no specs, every Go harness under
+// benchDir is used.
+func resolveHarnesses(ctx context.Context, benchDir string, binDir string, specs []string) ([]*harnessBin, error) {
+	if len(specs) == 0 {
+		found, err := discoverHarnesses(benchDir)
+		if err != nil {
+			return nil, err
+		}
+		specs = found
+	}
+	out := make([]*harnessBin, 0, len(specs))
+	seen := map[string]bool{}
+	for _, spec := range specs {
+		name, path, prebuilt := strings.Cut(spec, "=")
+		if name == "" || seen[name] {
+			return nil, fmt.Errorf("harness %q: empty or repeated name", spec)
+		}
+		seen[name] = true
+		if !prebuilt {
+			dir := filepath.Join(benchDir, name)
+			if !isHarnessDir(dir) {
+				return nil, fmt.Errorf("harness %q: %s is not a Go harness module", name, dir)
+			}
+			var err error
+			if path, err = buildHarness(ctx, dir, filepath.Join(binDir, name)); err != nil {
+				return nil, fmt.Errorf("harness %q: %w", name, err)
+			}
+		}
+		h := &harnessBin{name: name, path: path}
+		if err := h.listScenarios(ctx); err != nil {
+			return nil, fmt.Errorf("harness %q: %w", name, err)
+		}
+		out = append(out, h)
+	}
+	return out, nil
+}
+
+// discoverHarnesses lists the directories of benchDir holding a Go harness,
+// by name.
+func discoverHarnesses(benchDir string) ([]string, error) {
+	entries, err := os.ReadDir(benchDir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && isHarnessDir(filepath.Join(benchDir, entry.Name())) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no harnesses under %s", benchDir)
+	}
+	return names, nil
+}
+
+// isHarnessDir reports whether dir is a Go module whose main package runs
+// the shared cli.
+func isHarnessDir(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+		return false
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err == nil && bytes.Contains(data, []byte("package main")) && bytes.Contains(data, []byte(cliImport)) {
+			return true
+		}
+	}
+	return false
+}
+
+// buildHarness builds the module in dir to out, with REZI_GO_BIN as the go
+// command if set.
+func buildHarness(ctx context.Context, dir string, out string) (string, error) {
+	goBin := os.Getenv("REZI_GO_BIN")
+	if goBin == "" {
+		goBin = "go"
+	}
+	out, err := filepath.Abs(out)
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.CommandContext(ctx, goBin, "build", "-o", out, ".")
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("build: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return out, nil
+}
+
+func (h *harnessBin) listScenarios(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, listTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, h.path, "list-scenarios").Output()
+	if err != nil {
+		return fmt.Errorf("list-scenarios: %w", err)
+	}
+	var doc harness.ResultFile
+	if err := json.Unmarshal(output, &doc); err != nil || !doc.OK {
+		return fmt.Errorf("list-scenarios: not a scenario listing (%s)", firstLine(output))
+	}
+	h.scenarios = make(map[string]bool, len(doc.Scenarios))
+	for _, info := range doc.Scenarios {
+		h.scenarios[info.Name] = true
+	}
+	return nil
+}
+
+// run runs one cell of the matrix and returns its result document, labelled
+// with the harness and params. A run that times out, is interrupted or
+// breaks the protocol gets a failed document in place of its own.
+func (h *harnessBin) run(ctx context.Context, r matrixRun, binDir string, timeout time.Duration, extra []string) harness.ResultFile {
+	labels := map[string]string{"harness": h.name}
+	if params := r.paramLabel(); params != "" {
+		labels["params"] = params
+	}
+	failed := func(kind string, format string, args ...any) harness.ResultFile {
+		return harness.ResultFile{OK: false, Scenario: r.scenario, Error: fmt.Sprintf(format, args...), ErrorKind: kind, Labels: labels}
+	}
+
+	result, err := os.CreateTemp(binDir, "result-*.json")
+	if err != nil {
+		return failed("error", "%v", err)
+	}
+	resultPath := result.Name()
+	_ = result.Close()
+	defer func() { _ = os.Remove(resultPath) }()
+
+	args := []string{"--scenario", r.scenario}
+	for _, name := range sortedKeys(r.params) {
+		args = append(args, "--"+name, r.params[name])
+	}
+	args = append(args, extra...)
+	for _, name := range sortedKeys(labels) {
+		args = append(args, "--label", name+"="+labels[name])
+	}
+	// Last, so no extra argument can move the result elsewhere.
+	args = append(args, "--result-path", resultPath)
+
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(runCtx, h.path, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// The harness may start programs of its own; a timeout kills them all.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error { return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL) }
+	cmd.WaitDelay = 5 * time.Second
+	runErr := cmd.Run()
+	switch {
+	case ctx.Err() != nil:
+		doc := failed("interrupted", "interrupted")
+		doc.Interrupted = true
+		return doc
+	case errors.Is(runCtx.Err(), context.DeadlineExceeded):
+		return failed("hang", "timed out after %s", timeout)
+	}
+
+	if report := harness.ValidateResults([]string{resultPath}); report.Issues > 0 {
+		issue := report.Files[0].Issues[0]
+		detail := ""
+		if runErr != nil {
+			detail = fmt.Sprintf(" (%v: %s)", runErr, firstLine(stderr.Bytes()))
+		}
+		return failed("error", "protocol: %s: %s%s", issue.Path, issue.Message, detail)
+	}
+	docs, err := harness.ReadResults(resultPath)
+	switch {
+	case err != nil:
+		return failed("error", "protocol: %v", err)
+	case len(docs) != 1:
+		return failed("error", "protocol: %d result documents, want 1", len(docs))
+	}
+	doc := docs[0]
+	switch {
+	case doc.Scenario != r.scenario:
+		return failed("error", "protocol: result is for scenario %q", doc.Scenario)
+	case doc.OK != (runErr == nil):
+		return failed("error", "protocol: ok is %t but the harness exited with %v", doc.OK, cmd.ProcessState)
+	}
+	if doc.Labels == nil {
+		doc.Labels = map[string]string{}
+	}
+	for name, value := range labels {
+		doc.Labels[name] = value
+	}
+	return doc
+}
+
+func firstLine(data []byte) string {
+	line, _, _ := bytes.Cut(bytes.TrimSpace(data), []byte("\n"))
+	return string(line)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+// fakeHarnessEnv makes the test binary act as a harness run, misbehaving as
+// the variable says.
+const fakeHarnessEnv = "RUNNER_FAKE_HARNESS"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(fakeHarnessEnv); mode != "" {
+		os.Exit(fakeHarness(mode, os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+// fakeHarness writes the result of one run to --result-path as mode says
+// and returns its exit code.
+func fakeHarness(mode string, args []string) int {
+	var scenario, resultPath string
+	labels := []string{}
+	for i := 0; i+1 < len(args); i += 2 {
+		switch args[i] {
+		case "--scenario":
+			scenario = args[i+1]
+		case "--result-path":
+			resultPath = args[i+1]
+		case "--label":
+			labels = append(labels, args[i+1])
+		}
+	}
+	doc := harness.ResultFile{SchemaVersion: harness.SchemaVersion, OK: true, Scenario: scenario, Labels: map[string]string{"args": strings.Join(labels, ";")}}
+	code := 0
+	switch mode {
+	case "failed":
+		doc.OK, doc.Error, code = false, "boom", 1
+	case "exit-mismatch":
+		code = 1
+	case "wrong-scenario":
+		doc.Scenario = "other"
+	case "hang":
+		time.Sleep(time.Minute)
+	case "no-result":
+		return 0
+	}
+	serialized, _ := json.Marshal(doc)
+	if mode == "two-documents" {
+		serialized = append(append(serialized, '\n'), serialized...)
+	}
+	if mode == "not-json" {
+		serialized = []byte("{")
+	}
+	_ = os.WriteFile(resultPath, serialized, 0o644)
+	return code
+}
+
+func TestHarnessRunProtocol(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &harnessBin{name: "fake", path: exe}
+	r := matrixRun{harness: h, scenario: "rerender", params: map[string]string{"rows": "40"}}
+
+	cases := []struct {
+		mode  string
+		ok    bool
+		kind  string
+		error string
+	}{
+		{mode: "ok", ok: true},
+		{mode: "failed", kind: "", error: "boom"},
+		{mode: "exit-mismatch", kind: "error", error: "ok is true but the harness exited"},
+		{mode: "wrong-scenario", kind: "error", error: `result is for scenario "other"`},
+		{mode: "two-documents", kind: "error", error: "2 result documents"},
+		{mode: "no-result", kind: "error", error: "protocol:"},
+		{mode: "not-json", kind: "error", error: "protocol:"},
+		{mode: "hang", kind: "hang", error: "timed out after 500ms"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.mode, func(t *testing.T) {
+			t.Setenv(fakeHarnessEnv, tc.mode)
+			doc := h.run(context.Background(), r, t.TempDir(), 500*time.Millisecond, []string{"--label", "extra=1"})
+			if doc.OK != tc.ok || doc.ErrorKind != tc.kind || !strings.Contains(doc.Error, tc.error) {
+				t.Fatalf("ok %t kind %q error %q, want %t %q %q", doc.OK, doc.ErrorKind, doc.Error, tc.ok, tc.kind, tc.error)
+			}
+			if doc.Scenario != "rerender" || doc.Labels["harness"] != "fake" || doc.Labels["params"] != "rows=40" {
+				t.Errorf("scenario %q labels %v, want rerender labelled with the harness and params", doc.Scenario, doc.Labels)
+			}
+			if tc.ok && doc.Labels["args"] != "extra=1;harness=fake;params=rows=40" {
+				t.Errorf("harness got labels %q", doc.Labels["args"])
+			}
+		})
+	}
+}
+
+func TestHarnessRunInterrupted(t *testing.T) {
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(fakeHarnessEnv, "hang")
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	h := &harnessBin{name: "fake", path: exe}
+	doc := h.run(ctx, matrixRun{harness: h, scenario: "rerender"}, t.TempDir(), time.Minute, nil)
+	if doc.OK || !doc.Interrupted || doc.ErrorKind != "interrupted" {
+		t.Errorf("ok %t interrupted %t kind %q, want an interrupted failure", doc.OK, doc.Interrupted, doc.ErrorKind)
+	}
+}
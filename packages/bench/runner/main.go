@@ -0,0 +1,183 @@
+// Command runner runs a matrix of harnesses, scenarios and param sets and
+// merges every result into one comparison document. A harness is any
+// executable speaking the bubbletea-bench CLI: list-scenarios, then
+// --scenario NAME --result-path FILE with params as --name value. Runs are
+// sequential, each in its own process group under a timeout, and a run
+// whose result breaks the protocol is recorded as failed rather than
+// merged as is.
+//
+//	runner [--matrix FILE] [--harness NAME[=PATH]]... [--scenario NAME]...
+//	       [--timeout 5m] [--bench-dir DIR] [--result-path FILE] [-- ARGS...]
+//
+// Harnesses named without a path are Go modules under --bench-dir whose
+// main runs the shared cli; they are built before the first run. Any other
+// harness, such as a Rezi engine wrapper, is given with its path. ARGS are
+// passed to every run.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+const usage = "usage: runner [--matrix FILE] [--harness NAME[=PATH]]... [--scenario NAME]... [--timeout D] [--bench-dir DIR] [--result-path FILE] [-- ARGS...]"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// options are the parsed command line.
+type options struct {
+	matrix     matrix
+	benchDir   string
+	resultPath string
+}
+
+func parseOptions(args []string) (options, error) {
+	opts := options{benchDir: "packages/bench"}
+	var matrixPath string
+	var harnesses, scenarios []string
+	var timeout string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			opts.matrix.Args = append(opts.matrix.Args, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "--") {
+			return opts, fmt.Errorf("unexpected argument %q", arg)
+		}
+		key, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, fmt.Errorf("missing value for %s", arg)
+			}
+			value = args[i+1]
+			i++
+		}
+		switch key {
+		case "matrix":
+			matrixPath = value
+		case "harness":
+			harnesses = append(harnesses, value)
+		case "scenario":
+			scenarios = append(scenarios, value)
+		case "timeout":
+			timeout = value
+		case "bench-dir":
+			opts.benchDir = value
+		case "result-path":
+			opts.resultPath = value
+		default:
+			return opts, fmt.Errorf("unknown flag %s", arg)
+		}
+	}
+
+	if matrixPath != "" {
+		m, err := loadMatrix(matrixPath)
+		if err != nil {
+			return opts, err
+		}
+		m.Args = append(m.Args, opts.matrix.Args...)
+		opts.matrix = m
+	}
+	// Flags add to the matrix file, except the timeout, which replaces it.
+	opts.matrix.Harnesses = append(opts.matrix.Harnesses, harnesses...)
+	opts.matrix.Scenarios = append(opts.matrix.Scenarios, scenarios...)
+	if timeout != "" {
+		opts.matrix.Timeout = timeout
+	}
+	return opts, opts.matrix.check()
+}
+
+func run(args []string, stdout io.Writer, stderr io.Writer) int {
+	opts, err := parseOptions(args)
+	if err != nil {
+		fmt.Fprintf(stderr, "runner: %v\n%s\n", err, usage)
+		return 2
+	}
+
+	// SIGINT and SIGTERM stop the matrix after killing the current run;
+	// what finished is still merged.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	binDir, err := os.MkdirTemp("", "bench-runner-*")
+	if err != nil {
+		fmt.Fprintf(stderr, "runner: %v\n", err)
+		return 1
+	}
+	defer func() { _ = os.RemoveAll(binDir) }()
+
+	harnesses, err := resolveHarnesses(ctx, opts.benchDir, binDir, opts.matrix.Harnesses)
+	if err != nil {
+		fmt.Fprintf(stderr, "runner: %v\n", err)
+		return 1
+	}
+
+	docs := []harness.ResultFile{}
+	runs := opts.matrix.runs(harnesses)
+	for i, r := range runs {
+		if ctx.Err() != nil {
+			break
+		}
+		if !r.harness.scenarios[r.scenario] {
+			fmt.Fprintf(stderr, "[%d/%d] %s: skipped, %s has no such scenario\n", i+1, len(runs), r, r.harness.name)
+			continue
+		}
+		start := time.Now()
+		doc := r.harness.run(ctx, r, binDir, opts.matrix.timeout(), opts.matrix.Args)
+		status := "ok"
+		if !doc.OK {
+			status = "failed: " + doc.Error
+		}
+		fmt.Fprintf(stderr, "[%d/%d] %s: %s (%s)\n", i+1, len(runs), r, status, time.Since(start).Round(time.Millisecond))
+		docs = append(docs, doc)
+	}
+
+	merged := harness.MergeResults(docs)
+	merged.SchemaVersion = harness.SchemaVersion
+	merged.Interrupted = merged.Interrupted || ctx.Err() != nil
+	if len(merged.Suite) == 0 {
+		merged.OK = false
+		merged.Error = "no runs matched the matrix"
+	}
+	serialized, _ := json.Marshal(merged)
+	if opts.resultPath != "" {
+		err = os.WriteFile(opts.resultPath, serialized, 0o644)
+	} else {
+		_, err = stdout.Write(append(serialized, '\n'))
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "runner: write results: %v\n", err)
+		return 1
+	}
+	switch {
+	case merged.Interrupted:
+		return 130
+	case !merged.OK:
+		return 1
+	}
+	return 0
+}
+
+// sortedKeys returns m's keys in order, so param flags are passed the same
+// way every run.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
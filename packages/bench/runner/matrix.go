@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultTimeout bounds a run when the matrix sets no timeout.
+const defaultTimeout = 10 * time.Minute
+
+// matrix is what to run: every scenario on every harness, once per param
+// set. A --matrix file is its JSON form:
+//
+//	{
+//	  "harnesses": ["bubbletea-bench", "tview-bench", "rezi=./rezi-harness"],
+//	  "scenarios": ["terminal-rerender", "terminal-frame-fill"],
+//	  "params": [{}, {"rows": 40, "cols": 120}],
+//	  "args": ["--iterations", "200"],
+//	  "timeout": "5m"
+//	}
+//
+// An empty harnesses list means every harness under --bench-dir. Params a
+// scenario does not take fail its run, as they would on the command line.
+type matrix struct {
+	Harnesses []string            `json:"harnesses"`
+	Scenarios []string            `json:"scenarios"`
+	Params    []map[string]string `json:"-"`
+	Args      []string            `json:"args"`
+	Timeout   string              `json:"timeout"`
+}
+
+func loadMatrix(path string) (matrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return matrix{}, err
+	}
+	var doc struct {
+		matrix
+		Params []map[string]any `json:"params"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&doc); err != nil {
+		return matrix{}, fmt.Errorf("parse --matrix %s: %w", path, err)
+	}
+	m := doc.matrix
+	for i, set := range doc.Params {
+		params := make(map[string]string, len(set))
+		for name, value := range set {
+			switch v := value.(type) {
+			case string:
+				params[name] = v
+			case json.Number:
+				params[name] = v.String()
+			case bool:
+				params[name] = fmt.Sprint(v)
+			default:
+				return matrix{}, fmt.Errorf("--matrix params[%d].%s must be a string, number or boolean", i, name)
+			}
+		}
+		m.Params = append(m.Params, params)
+	}
+	return m, nil
+}
+
+func (m matrix) check() error {
+	if len(m.Scenarios) == 0 {
+		return errors.New("no scenarios: pass --scenario or a --matrix with scenarios")
+	}
+	if m.Timeout == "" {
+		return nil
+	}
+	if d, err := time.ParseDuration(m.Timeout); err != nil || d <= 0 {
+		return fmt.Errorf("invalid timeout %q (expected a positive duration)", m.Timeout)
+	}
+	return nil
+}
+
+func (m matrix) timeout() time.Duration {
+	if m.Timeout == "" {
+		return defaultTimeout
+	}
+	d, _ := time.ParseDuration(m.Timeout)
+	return d
+}
+
+// matrixRun is one cell of the matrix.
+type matrixRun struct {
+	harness  *harnessBin
+	scenario string
+	params   map[string]string
+}
+
+// runs expands the matrix harness by harness, then scenario, then param
+// set, in the order given.
+func (m matrix) runs(harnesses []*harnessBin) []matrixRun {
+	sets := m.Params
+	if len(sets) == 0 {
+		sets = []map[string]string{{}}
+	}
+	var out []matrixRun
+	for _, h := range harnesses {
+		for _, scenario := range m.Scenarios {
+			for _, params := range sets {
+				out = append(out, matrixRun{harness: h, scenario: scenario, params: params})
+			}
+		}
+	}
+	return out
+}
+
+// paramLabel is the run's params as "cols=120,rows=40", empty without any.
+func (r matrixRun) paramLabel() string {
+	parts := make([]string, 0, len(r.params))
+	for _, name := range sortedKeys(r.params) {
+		parts = append(parts, name+"="+r.params[name])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r matrixRun) String() string {
+	s := r.harness.name + " " + r.scenario
+	if params := r.paramLabel(); params != "" {
+		s += " " + params
+	}
+	return s
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMatrix(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		want matrix
+		err  string
+	}{
+		{
+			name: "full",
+			json: `{"harnesses": ["tview-bench"], "scenarios": ["rerender"], "params": [{}, {"rows": 40, "fast": true, "mode": "a"}], "args": ["--iterations", "5"], "timeout": "1m"}`,
+			want: matrix{
+				Harnesses: []string{"tview-bench"},
+				Scenarios: []string{"rerender"},
+				Params:    []map[string]string{{}, {"rows": "40", "fast": "true", "mode": "a"}},
+				Args:      []string{"--iterations", "5"},
+				Timeout:   "1m",
+			},
+		},
+		{name: "fractional number", json: `{"scenarios": ["s"], "params": [{"ratio": 0.25}]}`, want: matrix{Scenarios: []string{"s"}, Params: []map[string]string{{"ratio": "0.25"}}}},
+		{name: "nested param", json: `{"params": [{"rows": [40]}]}`, err: "params[0].rows must be a string, number or boolean"},
+		{name: "unknown field", json: `{"scenario": ["s"]}`, err: `unknown field "scenario"`},
+		{name: "not json", json: `{`, err: "parse --matrix"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "matrix.json")
+			if err := os.WriteFile(path, []byte(tc.json), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadMatrix(path)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("matrix %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "matrix.json")
+	if err := os.WriteFile(path, []byte(`{"harnesses": ["a"], "scenarios": ["s"], "args": ["--seed", "1"], "timeout": "1m"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name    string
+		args    []string
+		want    matrix
+		timeout time.Duration
+		err     string
+	}{
+		{
+			name:    "flags",
+			args:    []string{"--harness", "a", "--harness=b=/bin/b", "--scenario", "s", "--", "--iterations", "5"},
+			want:    matrix{Harnesses: []string{"a", "b=/bin/b"}, Scenarios: []string{"s"}, Args: []string{"--iterations", "5"}},
+			timeout: defaultTimeout,
+		},
+		{
+			name:    "flags add to the matrix file",
+			args:    []string{"--matrix", path, "--scenario", "t", "--timeout", "2s", "--", "--iterations", "5"},
+			want:    matrix{Harnesses: []string{"a"}, Scenarios: []string{"s", "t"}, Args: []string{"--seed", "1", "--iterations", "5"}, Timeout: "2s"},
+			timeout: 2 * time.Second,
+		},
+		{name: "no scenario", args: []string{"--harness", "a"}, err: "no scenarios"},
+		{name: "bad timeout", args: []string{"--scenario", "s", "--timeout", "-1s"}, err: "invalid timeout"},
+		{name: "missing value", args: []string{"--scenario"}, err: "missing value"},
+		{name: "unknown flag", args: []string{"--scenarios", "s"}, err: "unknown flag"},
+		{name: "positional", args: []string{"s"}, err: "unexpected argument"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts, err := parseOptions(tc.args)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("err %v, want %q", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(opts.matrix, tc.want) || opts.matrix.timeout() != tc.timeout {
+				t.Errorf("matrix %+v timeout %s, want %+v %s", opts.matrix, opts.matrix.timeout(), tc.want, tc.timeout)
+			}
+		})
+	}
+}
+
+func TestMatrixRuns(t *testing.T) {
+	a, b := &harnessBin{name: "a"}, &harnessBin{name: "b"}
+	m := matrix{Scenarios: []string{"s", "t"}, Params: []map[string]string{{}, {"rows": "40", "cols": "120"}}}
+	var got []string
+	for _, r := range m.runs([]*harnessBin{a, b}) {
+		got = append(got, r.String())
+	}
+	want := []string{
+		"a s", "a s cols=120,rows=40", "a t", "a t cols=120,rows=40",
+		"b s", "b s cols=120,rows=40", "b t", "b t cols=120,rows=40",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runs %q, want %q", got, want)
+	}
+	if runs := (matrix{Scenarios: []string{"s"}}).runs([]*harnessBin{a}); len(runs) != 1 || runs[0].paramLabel() != "" {
+		t.Errorf("a matrix without params runs %d times", len(runs))
+	}
+}
@@ -0,0 +1,152 @@
+/**
+ * Frame export for parity checks against the Go harness.
+ *
+ * Usage:
+ *   node packages/bench/dist/export-frames.js --golden <dir> [options]
+ *
+ * Options:
+ *   --golden <dir>      Directory to write the frames and manifest.json into
+ *   --scenario <a,b>    Scenarios to export (default: every one the Go harness registers)
+ *   --ticks <n>         Export ticks 0..n-1 (default: 10)
+ *   --seed <n>          Seed of the frame content PRNG (default: 0)
+ *   --<param> <value>   A scenario param, as with the Go harness
+ *
+ * Writes the layout of the Go harness's export-frames: <dir>/<scenario>/
+ * tick-NNNNNN.txt per frame, each line ANSI-stripped and ending in a
+ * newline, and <dir>/manifest.json with every frame's SHA-256. The Go
+ * harness's parity mode checks its own generators against that manifest,
+ * and fails when it leaves out a scenario the Go registry has. Frames come
+ * from the same builders the TypeScript harnesses render, each tick drawing
+ * from FrameRand(seed, tick).
+ */
+
+import { createHash } from "node:crypto";
+import { mkdirSync, writeFileSync } from "node:fs";
+import { join } from "node:path";
+import { stripVTControlCharacters } from "node:util";
+import { FrameRand } from "./scenarios/frameRand.js";
+import { frameScenario, frameScenarios, scenarioLines } from "./scenarios/frames.js";
+
+export type ExportedFrame = Readonly<{ tick: number; path: string; sha256: string }>;
+
+export type FrameExportScenario = Readonly<{
+  scenario: string;
+  params: Readonly<Record<string, string>>;
+  rows: number;
+  cols: number;
+  frames: readonly ExportedFrame[];
+}>;
+
+/** The manifest.json of an export, shaped like the Go FrameExportReport. */
+export type FrameExportManifest = Readonly<{
+  dir: string;
+  seed: number;
+  scenarios: readonly FrameExportScenario[];
+  frames: number;
+}>;
+
+export type FrameExportOptions = Readonly<{
+  dir: string;
+  scenarios: readonly string[];
+  ticks: number;
+  seed: number;
+  params: Readonly<Record<string, string>>;
+}>;
+
+/** Writes the frames and manifest.json of opts.scenarios under opts.dir. */
+export function exportFrames(opts: FrameExportOptions): FrameExportManifest {
+  const scenarios: FrameExportScenario[] = [];
+  let total = 0;
+  for (const scenario of opts.scenarios) {
+    const { params: declared } = frameScenario(scenario, {});
+    const params = scopedParams(declared, opts.params);
+    const { rows, cols } = frameScenario(scenario, params);
+    mkdirSync(join(opts.dir, scenario), { recursive: true });
+    const frames: ExportedFrame[] = [];
+    for (let tick = 0; tick < opts.ticks; tick++) {
+      const rng = new FrameRand(opts.seed, tick);
+      const data = frameText(scenarioLines(scenario, params, tick, cols, rng));
+      const path = `${scenario}/tick-${String(tick).padStart(6, "0")}.txt`;
+      writeFileSync(join(opts.dir, path), data);
+      frames.push({ tick, path, sha256: createHash("sha256").update(data).digest("hex") });
+      total++;
+    }
+    scenarios.push({ scenario, params, rows, cols, frames });
+  }
+  const manifest: FrameExportManifest = {
+    dir: opts.dir,
+    seed: opts.seed,
+    scenarios,
+    frames: total,
+  };
+  writeFileSync(join(opts.dir, "manifest.json"), `${JSON.stringify(manifest, null, 2)}\n`);
+  return manifest;
+}
+
+/** A frame as the Go harness exports and hashes it. */
+function frameText(lines: readonly string[]): string {
+  return lines.map((line) => `${stripVTControlCharacters(line)}\n`).join("");
+}
+
+/** The declared params, as the Go harness's suiteParams keeps them. */
+function scopedParams(
+  declared: readonly string[],
+  params: Readonly<Record<string, string>>,
+): Record<string, string> {
+  const out: Record<string, string> = {};
+  for (const name of declared) {
+    const value = params[name];
+    if (value !== undefined) out[name] = value;
+  }
+  return out;
+}
+
+function parseArgs(argv: readonly string[]): FrameExportOptions {
+  let dir = "";
+  let scenarios = frameScenarios();
+  let ticks = 10;
+  let seed = 0;
+  const params: Record<string, string> = {};
+  for (let i = 2; i < argv.length; i++) {
+    const arg = argv[i] ?? "";
+    if (!arg.startsWith("--")) throw new Error(`unexpected argument "${arg}"`);
+    const value = argv[++i];
+    if (value === undefined) throw new Error(`missing value for ${arg}`);
+    switch (arg) {
+      case "--golden":
+        dir = value;
+        break;
+      case "--scenario":
+        scenarios = value.split(",").filter((name) => name !== "");
+        break;
+      case "--ticks":
+        ticks = Number(value);
+        if (!Number.isInteger(ticks) || ticks <= 0) throw new Error("--ticks must be > 0");
+        break;
+      case "--seed":
+        seed = Number(value);
+        if (!Number.isSafeInteger(seed) || seed < 0) throw new Error(`invalid --seed "${value}"`);
+        break;
+      default:
+        params[arg.slice(2)] = value;
+    }
+  }
+  if (dir === "") throw new Error("export-frames requires --golden");
+  const known = new Set(frameScenarios());
+  for (const scenario of scenarios) {
+    if (!known.has(scenario)) throw new Error(`unknown scenario "${scenario}"`);
+  }
+  return { dir, scenarios, ticks, seed, params };
+}
+
+function main(): void {
+  try {
+    const manifest = exportFrames(parseArgs(process.argv));
+    console.log(`exported ${manifest.frames} frames to ${manifest.dir}`);
+  } catch (err) {
+    console.error((err as Error).message);
+    process.exit(1);
+  }
+}
+
+main();
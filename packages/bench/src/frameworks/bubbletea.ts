@@ -18,6 +18,7 @@ type BubbleTeaResultData = Readonly<{
   heapPeakKb: number;
   bytesWritten: number;
   frames: number;
+  ptyBytesRead?: number;
 }>;
 
 type BubbleTeaResultFile =
@@ -134,13 +135,10 @@ export function checkBubbleTea(): boolean {
 
 export async function runBubbleTeaScenario(
   scenario: string,
-  config: Readonly<{ warmup: number; iterations: number }>,
+  config: Readonly<{ warmup: number; iterations: number; seed?: number }>,
   params: Record<string, number | string>,
 ): Promise<BenchMetrics> {
   const mode = getBenchIoMode() === "terminal" ? "pty" : "stub";
-  if (mode !== "pty") {
-    throw new Error("Bubble Tea benchmarks currently require PTY mode");
-  }
 
   const binary = ensureBuilt();
   const resultPath = `${os.tmpdir()}/rezi-bubbletea-${process.pid}-${Date.now()}-${Math.random().toString(16).slice(2)}.json`;
@@ -156,6 +154,8 @@ export async function runBubbleTeaScenario(
     mode,
     "--result-path",
     resultPath,
+    "--seed",
+    String(config.seed ?? 0),
   ];
   const fps = resolveBubbleTeaFps();
   if (fps !== null) {
@@ -217,7 +217,7 @@ export async function runBubbleTeaScenario(
       opsPerSec: timing.n / (d.totalWallMs / 1000),
       framesProduced: d.frames,
       bytesProduced: d.bytesWritten,
-      ptyBytesObserved: null,
+      ptyBytesObserved: d.ptyBytesRead ?? null,
     };
   } finally {
     try {
@@ -105,7 +105,7 @@ export function checkOpenTui(driver: OpenTuiDriver = "react"): boolean {
 
 export async function runOpenTuiScenario(
   scenario: string,
-  config: Readonly<{ warmup: number; iterations: number }>,
+  config: Readonly<{ warmup: number; iterations: number; seed?: number }>,
   params: Record<string, number | string>,
 ): Promise<BenchMetrics> {
   const provider = resolveOpenTuiProvider();
@@ -137,6 +137,8 @@ export async function runOpenTuiScenario(
     driver,
     "--result-path",
     resultPath,
+    "--seed",
+    String(config.seed ?? 0),
   ];
   for (const [k, v] of Object.entries(params)) {
     args.push(`--${k}`, String(v));
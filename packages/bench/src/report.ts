@@ -290,7 +290,7 @@ export function toMarkdown(run: BenchRun): string {
   const headerLines: string[] = [
     `> ${meta.timestamp} | Node ${meta.nodeVersion} | Bun ${meta.bunVersion ?? "n/a"} | rustc ${meta.rustcVersion ?? "n/a"} | cargo ${meta.cargoVersion ?? "n/a"} | ${meta.osType} ${meta.osRelease} | ${meta.platform} ${meta.arch} | ${meta.cpuModel} (${meta.cpuCores} cores) | RAM ${meta.memoryTotalMb}MB | governor=${meta.cpuGovernor ?? "n/a"} | wsl=${meta.isWsl ? "yes" : "no"}`,
     ...(meta.environmentCaveat ? [`> WARNING: ${meta.environmentCaveat}`] : []),
-    `> Invocation: suite=${invocation.suite} matchup=${invocation.matchup} scenario=${invocation.scenarioFilter ?? "all"} framework=${invocation.frameworkFilter ?? "all"} warmup=${invocation.warmupOverride ?? "default"} iterations=${invocation.iterationsOverride ?? "default"} quick=${invocation.quick ? "yes" : "no"} io=${invocation.ioMode} opentuiDriver=${invocation.opentuiDriver} replicates=${invocation.replicates} discardFirstReplicate=${invocation.discardFirstReplicate ? "yes" : "no"} shuffleFrameworkOrder=${invocation.shuffleFrameworkOrder ? "yes" : "no"} shuffleSeed=${invocation.shuffleSeed} envCheck=${invocation.envCheck} cpuAffinity=${invocation.cpuAffinity ?? "none"}`,
+    `> Invocation: suite=${invocation.suite} matchup=${invocation.matchup} scenario=${invocation.scenarioFilter ?? "all"} framework=${invocation.frameworkFilter ?? "all"} warmup=${invocation.warmupOverride ?? "default"} iterations=${invocation.iterationsOverride ?? "default"} quick=${invocation.quick ? "yes" : "no"} io=${invocation.ioMode} opentuiDriver=${invocation.opentuiDriver} replicates=${invocation.replicates} discardFirstReplicate=${invocation.discardFirstReplicate ? "yes" : "no"} shuffleFrameworkOrder=${invocation.shuffleFrameworkOrder ? "yes" : "no"} shuffleSeed=${invocation.shuffleSeed} seed=${invocation.seed} envCheck=${invocation.envCheck} cpuAffinity=${invocation.cpuAffinity ?? "none"}`,
     '> Byte columns: "Bytes(local)" = framework-local counter; "Bytes(pty)" = observed PTY bytes (cross-framework comparable in PTY mode).',
   ];
   lines.push(`${headerLines.join("\n")}\n`);
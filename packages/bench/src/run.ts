@@ -17,6 +17,7 @@
  *   --discard-first-replicate  Run first replicate as warmup and exclude from reports
  *   --shuffle-framework-order   Randomize framework execution order per replicate
  *   --shuffle-seed <seed>   Seed used for deterministic framework shuffling
+ *   --seed <n>              Seed of the frame content PRNG (default 0)
  *   --env-check <mode>      "off" | "warn" (default) | "strict"
  *   --cpu-affinity <list>   Pin child processes via taskset, e.g. "0-3"
  *   --json                  Output raw JSON instead of table
@@ -51,6 +52,7 @@ interface CliOpts {
   discardFirstReplicate: boolean;
   shuffleFrameworkOrder: boolean;
   shuffleSeed: string;
+  seed: number;
   envCheck: "off" | "warn" | "strict";
   cpuAffinity: string | null;
   json: boolean;
@@ -80,6 +82,7 @@ function parseArgs(argv: string[]): CliOpts {
     discardFirstReplicate: false,
     shuffleFrameworkOrder: false,
     shuffleSeed: "rezi-bench-seed",
+    seed: 0,
     envCheck: "warn",
     cpuAffinity: null,
     json: false,
@@ -134,6 +137,9 @@ function parseArgs(argv: string[]): CliOpts {
       case "--shuffle-seed":
         opts.shuffleSeed = argv[++i] ?? opts.shuffleSeed;
         break;
+      case "--seed":
+        opts.seed = Number.parseInt(argv[++i] ?? "", 10) || 0;
+        break;
       case "--env-check": {
         const mode = (argv[++i] ?? "warn").toLowerCase();
         opts.envCheck = mode === "off" || mode === "strict" || mode === "warn" ? mode : "warn";
@@ -459,7 +465,11 @@ async function runIsolatedPty(
           | { ok: true; result: BenchResult }
           | { ok: false; error: string };
         if ("ok" in parsed && parsed.ok) {
-          parsed.result.metrics.ptyBytesObserved = observedPtyBytes;
+          // Harnesses that allocate their own PTY (Bubble Tea) report what
+          // their terminal observed; keep that over the outer PTY count.
+          if (parsed.result.metrics.ptyBytesObserved == null) {
+            parsed.result.metrics.ptyBytesObserved = observedPtyBytes;
+          }
           // Some frameworks (e.g. OpenTUI) write directly to the fd, bypassing
           // the in-process MeasuringStdout stream, so bytesProduced stays 0.
           // Fall back to the PTY-observed byte count instead of rejecting.
@@ -596,6 +606,7 @@ async function main(): Promise<void> {
       discardFirstReplicate: opts.discardFirstReplicate,
       shuffleFrameworkOrder: opts.shuffleFrameworkOrder,
       shuffleSeed: opts.shuffleSeed,
+      seed: opts.seed,
       envCheck: opts.envCheck,
       cpuAffinity: opts.cpuAffinity,
     },
@@ -626,6 +637,7 @@ async function main(): Promise<void> {
           const config: ScenarioConfig = {
             warmup: opts.warmup ?? (opts.quick ? 10 : scenario.defaultConfig.warmup),
             iterations: opts.iterations ?? (opts.quick ? 50 : scenario.defaultConfig.iterations),
+            seed: opts.seed,
           };
 
           process.stdout.write(`  ${label} / ${fw} [rep ${replicate + 1}/${opts.replicates}] ... `);
@@ -20,6 +20,7 @@ import { runOpenTuiScenario } from "../frameworks/opentui.js";
 import { benchAsync, benchSync, tryGc } from "../measure.js";
 import { emitReziPerfSnapshot, resetReziPerfSnapshot } from "../reziProfile.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { entrySize } from "./coreWorkloads.js";
 
 const LIST_SIZE = 500;
 
@@ -38,7 +39,7 @@ function reziListTree(selected: number): VNode {
         ui.text(`entry-${i}.log`, {
           style: { bold: isSelected, inverse: isSelected },
         }),
-        ui.text(`${(i * 1024 + 512).toLocaleString()} B`, { style: { dim: true } }),
+        ui.text(entrySize(i), { style: { dim: true } }),
       ]),
     );
   }
@@ -71,7 +72,7 @@ function reactListTree(
         h(C.Text as string, { bold: isSelected }, isSelected ? ">" : " "),
         h(C.Text as string, { dimColor: !isSelected }, `${String(i).padStart(3, " ")}.`),
         h(C.Text as string, { bold: isSelected, inverse: isSelected }, `entry-${i}.log`),
-        h(C.Text as string, { dimColor: true }, `${(i * 1024 + 512).toLocaleString()} B`),
+        h(C.Text as string, { dimColor: true }, entrySize(i)),
       ),
     );
   }
@@ -106,7 +107,7 @@ function termkitListTree(
     buffer.put({ x: 0, y, attr: { bold: isSelected } }, isSelected ? ">" : " ");
     buffer.put({ x: 2, y, attr: { dim: !isSelected } }, `${String(i).padStart(3, " ")}.`);
     buffer.put({ x: 8, y, attr: { bold: isSelected, inverse: isSelected } }, `entry-${i}.log`);
-    buffer.put({ x: 25, y, attr: { dim: true } }, `${(i * 1024 + 512).toLocaleString()} B`);
+    buffer.put({ x: 25, y, attr: { dim: true } }, entrySize(i));
   }
 }
 
@@ -260,7 +261,7 @@ function buildInitialBlessedList(
     const size = blessed.text({
       top: y,
       left: 25,
-      content: `${(i * 1024 + 512).toLocaleString()} B`,
+      content: entrySize(i),
       style: { fg: "grey" },
       tags: false,
     });
@@ -0,0 +1,168 @@
+/**
+ * Content of the core suite and the terminal table.
+ *
+ * Ports of the builders in bubbletea-bench/harness/scenarios.go. The value
+ * helpers draw from the tick's FrameRand, so a Rezi tree that calls them in
+ * row order shows the numbers the Go frame shows for the same --seed.
+ */
+
+import type { FrameRand } from "./frameRand.js";
+import { clipPad, formatWithCommas, padNum, safeMod } from "./frameText.js";
+
+export const STARTUP_TREE_SIZE = 50;
+export const CONTENT_UPDATE_LIST_SIZE = 500;
+
+export function benchmarkLines(items: number, seed: number, cols: number): string[] {
+  const lines: string[] = [];
+  lines.push(clipPad(`Benchmark: ${items} items (#${seed})`, cols));
+  lines.push(clipPad(`Total: ${items}  Page 1`, cols));
+  for (let i = 0; i < items; i++) {
+    lines.push(clipPad(`${i}. Item ${i} details`, cols));
+  }
+  return lines;
+}
+
+/** The size column of a content-update entry. */
+export function entrySize(i: number): string {
+  return `${formatWithCommas(i * 1024 + 512)} B`;
+}
+
+export function contentUpdateLines(selected: number, cols: number): string[] {
+  const lines: string[] = [];
+  lines.push(clipPad(`Files  ${CONTENT_UPDATE_LIST_SIZE} items  Selected: ${selected}`, cols));
+  for (let i = 0; i < CONTENT_UPDATE_LIST_SIZE; i++) {
+    const marker = i === selected ? ">" : " ";
+    lines.push(clipPad(`${marker} ${padNum(i, 3)}. entry-${i}.log ${entrySize(i)}`, cols));
+  }
+  return lines;
+}
+
+/** The value of layout-stress cell (r, c); draw cells in row order. */
+export function layoutStressValue(r: number, c: number, tick: number, rng: FrameRand): string {
+  const v = rng.intn(1000);
+  const wide = safeMod(tick + r + c, 7) === 0;
+  return wide ? `value=${v} (${padNum(v, 4, true)})` : `v=${v}`;
+}
+
+export function layoutStressLines(
+  rows: number,
+  cols: number,
+  tick: number,
+  rng: FrameRand,
+  termCols: number,
+): string[] {
+  const lines = [clipPad("Layout stress", termCols), clipPad(`tick=${tick}`, termCols)];
+  for (let r = 0; r < rows; r++) {
+    const labels: string[] = [];
+    const values: string[] = [];
+    for (let c = 0; c < cols; c++) {
+      values.push(layoutStressValue(r, c, tick, rng));
+      labels.push(`C${c}`);
+    }
+    lines.push(clipPad(labels.join(" | "), termCols));
+    lines.push(clipPad(values.join(" | "), termCols));
+  }
+  return lines;
+}
+
+export function scrollStressLines(
+  items: number,
+  active: number,
+  tick: number,
+  rng: FrameRand,
+  cols: number,
+): string[] {
+  const lines = [
+    clipPad("Scroll stress (non-virtualized)", cols),
+    clipPad(`items=${items} active=${active} tick=${tick}`, cols),
+  ];
+  for (let i = 0; i < items; i++) {
+    const marker = i === active ? "▶" : " ";
+    lines.push(clipPad(`${padNum(i, 5)} ${marker} Item ${i} v=${rng.intn(1000)}`, cols));
+  }
+  return lines;
+}
+
+/** The first row a virtual list shows at tick. */
+export function virtualListOffset(totalItems: number, viewport: number, tick: number): number {
+  return safeMod(tick, totalItems - viewport);
+}
+
+export function virtualListLines(
+  totalItems: number,
+  viewport: number,
+  tick: number,
+  rng: FrameRand,
+  cols: number,
+): string[] {
+  const offset = virtualListOffset(totalItems, viewport, tick);
+  const end = Math.min(totalItems, offset + viewport);
+  const lines = [
+    clipPad("Virtual list", cols),
+    clipPad(`total=${totalItems} viewport=${viewport} offset=${offset} tick=${tick}`, cols),
+  ];
+  for (let i = offset; i < end; i++) {
+    lines.push(clipPad(`${padNum(i, 6)} • Item ${i} v=${rng.intn(1000)}`, cols));
+  }
+  return lines;
+}
+
+/** The value of tables cell (r, c); draw cells in row order. */
+export function tableUpdateCellValue(r: number, c: number, tick: number, rng: FrameRand): string {
+  const v = rng.intn(10_000);
+  const wide = safeMod(tick + r + c, 13) === 0;
+  return wide ? `val=${padNum(v, 4, true)} (row=${r})` : String(v);
+}
+
+export function tablesLines(
+  rows: number,
+  cols: number,
+  tick: number,
+  rng: FrameRand,
+  termCols: number,
+): string[] {
+  const lines = [
+    clipPad("Table update", termCols),
+    clipPad(`rows=${rows} cols=${cols} tick=${tick}`, termCols),
+  ];
+  const header = ["row"];
+  for (let c = 0; c < cols; c++) header.push(`Col ${c}`);
+  lines.push(clipPad(header.join("  "), termCols));
+
+  for (let r = 0; r < rows; r++) {
+    const cells: string[] = [];
+    for (let c = 0; c < cols; c++) cells.push(tableUpdateCellValue(r, c, tick, rng));
+    lines.push(clipPad(`${padNum(r, 4)}  ${cells.join("  ")}`, termCols));
+  }
+  return lines;
+}
+
+function tableCellValue(
+  row: number,
+  col: number,
+  tick: number,
+  hotRow: number,
+  hotCol: number,
+): string {
+  if (row === hotRow && col === hotCol) return `v=${tick}`;
+  return `r${row}c${col}`;
+}
+
+/** The terminal-table frame, with one hot cell that follows the tick. */
+export function tableLines(rows: number, cols: number, tick: number): string[] {
+  const hotRow = safeMod(tick, rows);
+  const hotCol = safeMod(tick, cols);
+  const lines: string[] = [];
+  let header = "";
+  for (let c = 0; c < cols; c++) header += `C${c}`.padEnd(10, " ");
+  lines.push(header);
+  lines.push("-".repeat(Math.min(120, Array.from(header).length)));
+  for (let r = 0; r < rows; r++) {
+    let line = "";
+    for (let c = 0; c < cols; c++) {
+      line += tableCellValue(r, c, tick, hotRow, hotCol).padEnd(10, " ");
+    }
+    lines.push(Array.from(line).slice(0, 120).join(""));
+  }
+  return lines;
+}
@@ -0,0 +1,47 @@
+/**
+ * Frame content PRNG.
+ *
+ * A port of the Go harness's FrameRand (bubbletea-bench/harness/registry.go):
+ * SplitMix64 seeded from the run seed and the tick alone, so a tick renders
+ * the same content however often and in whatever order it is generated, and
+ * both harnesses draw exactly the same stream for the same --seed.
+ */
+
+const BITS = 64;
+const GOLDEN = 0x9e3779b97f4a7c15n;
+
+export class FrameRand {
+  /** The run seed the PRNG was derived from. */
+  readonly seed: bigint;
+  private state: bigint;
+
+  /** The PRNG for tick of a run seeded with seed. */
+  constructor(seed: number | bigint, tick: number) {
+    this.seed = BigInt.asUintN(BITS, BigInt(seed));
+    this.state = this.seed ^ BigInt.asUintN(BITS, BigInt(tick) * GOLDEN);
+  }
+
+  /** The next value in the stream. */
+  uint64(): bigint {
+    this.state = BigInt.asUintN(BITS, this.state + GOLDEN);
+    let z = this.state;
+    z = BigInt.asUintN(BITS, (z ^ (z >> 30n)) * 0xbf58476d1ce4e5b9n);
+    z = BigInt.asUintN(BITS, (z ^ (z >> 27n)) * 0x94d049bb133111ebn);
+    return z ^ (z >> 31n);
+  }
+
+  /** The high 32 bits of the next value. */
+  uint32(): number {
+    return Number(this.uint64() >> 32n);
+  }
+
+  /**
+   * A value in [0, n), or 0 when n <= 0. It reduces by modulo, as the Go
+   * Intn does, rather than by rejection.
+   */
+  intn(n: number): number {
+    if (n <= 0) return 0;
+    return Number(this.uint64() % BigInt(n));
+  }
+}
+
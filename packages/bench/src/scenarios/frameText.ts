@@ -0,0 +1,50 @@
+/**
+ * Text helpers for frame content.
+ *
+ * Ports of the helpers in the Go harness's harness/scenarios.go, so the
+ * workload generators build their lines exactly as the Go generators do.
+ * Widths are counted in code points, as Go counts runes.
+ */
+
+export type WorkloadParam = number | string;
+
+/** Clips or pads s to cols code points. */
+export function clipPad(s: string, cols: number): string {
+  const chars = Array.from(s);
+  if (chars.length >= cols) return chars.slice(0, cols).join("");
+  return `${s}${" ".repeat(cols - chars.length)}`;
+}
+
+export function bar(value: number, width: number): string {
+  const filled = Math.max(0, Math.min(width, Math.round(value * width)));
+  return `${"#".repeat(filled)}${"-".repeat(width - filled)}`;
+}
+
+export function spark(seed: number, width: number): string {
+  let out = "";
+  for (let i = 0; i < width; i++) out += safeMod(seed + i * 3, 7) > 2 ? "#" : ".";
+  return out;
+}
+
+/** value % denom, or 0 when denom <= 0. */
+export function safeMod(value: number, denom: number): number {
+  if (denom <= 0) return 0;
+  return value % denom;
+}
+
+/** A numeric param, or fallback when it is missing or not a number. */
+export function numberParam(value: WorkloadParam | undefined, fallback: number): number {
+  if (value === undefined) return fallback;
+  const n = Math.trunc(Number(value));
+  return Number.isFinite(n) ? n : fallback;
+}
+
+/** n right-aligned in width, zero-filled when zero is set, like %0Nd. */
+export function padNum(n: number, width: number, zero = false): string {
+  return String(n).padStart(width, zero ? "0" : " ");
+}
+
+/** n with thousands separators, independent of the host locale. */
+export function formatWithCommas(n: number): string {
+  return String(n).replace(/\B(?=(\d{3})+(?!\d))/g, ",");
+}
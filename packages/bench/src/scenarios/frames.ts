@@ -0,0 +1,199 @@
+/**
+ * Logical frames of the benchmark scenarios.
+ *
+ * The TypeScript counterpart of the Go harness's ScenarioLines: the text a
+ * scenario shows at a tick, one clipped line per row, with every PRNG value
+ * drawn from FrameRand(seed, tick) in the order the Go generator draws it.
+ * Harnesses that render lines rather than trees (OpenTUI's line driver, the
+ * frame exporter) build their frames here.
+ */
+
+import {
+  CONTENT_UPDATE_LIST_SIZE,
+  STARTUP_TREE_SIZE,
+  benchmarkLines,
+  contentUpdateLines,
+  layoutStressLines,
+  scrollStressLines,
+  tableLines,
+  tablesLines,
+  virtualListLines,
+} from "./coreWorkloads.js";
+import type { FrameRand } from "./frameRand.js";
+import { clipPad, numberParam, safeMod } from "./frameText.js";
+import { listScenarioSpecs, loadScenarioSpec } from "./spec.js";
+import { buildStrictFrameLines } from "./terminalStrictWorkloads.js";
+import {
+  buildTerminalFpsStreamLines,
+  buildTerminalFrameFillLines,
+  buildTerminalFullUiLines,
+  buildTerminalFullUiNavigationLines,
+  buildTerminalInputLatencyLines,
+  buildTerminalMemorySoakLines,
+  buildTerminalMouseZonesLines,
+  buildTerminalScreenTransitionLines,
+  buildTerminalVirtualListLines,
+} from "./terminalWorkloads.js";
+
+export type FrameParams = Readonly<Record<string, number | string>>;
+
+const SPEC_SCENARIOS: ReadonlySet<string> = new Set(listScenarioSpecs());
+
+/** Default viewport, as the Go registry gives scenarios that declare none. */
+const DEFAULT_ROWS = 40;
+const DEFAULT_COLS = 120;
+
+/**
+ * What the Go registry declares for a scenario generated in code: its params
+ * and its rows, or screen for scenarios sized by their rows and cols params.
+ */
+type CodeScenario = Readonly<{
+  params: readonly string[];
+  rows?: number | ((params: FrameParams) => number);
+  screen?: boolean;
+}>;
+
+const SCREEN = ["rows", "cols"] as const;
+
+const CODE_SCENARIOS: Readonly<Record<string, CodeScenario>> = {
+  startup: { params: [], rows: Math.max(40, STARTUP_TREE_SIZE + 5) },
+  "tree-construction": {
+    params: ["items"],
+    rows: (params) => Math.max(40, numberParam(params["items"], 100) + 5),
+  },
+  "content-update": { params: [], rows: 540 },
+  "layout-stress": { params: ["rows", "cols"] },
+  "scroll-stress": { params: ["items"] },
+  "virtual-list": { params: ["items", "viewport"] },
+  tables: { params: ["rows", "cols"] },
+  "terminal-frame-fill": { params: [...SCREEN, "dirtyLines"], screen: true },
+  "terminal-virtual-list": { params: ["items", "viewport"] },
+  "terminal-table": { params: ["rows", "cols"] },
+  "terminal-screen-transition": { params: SCREEN, screen: true },
+  "terminal-fps-stream": { params: [...SCREEN, "channels"], screen: true },
+  "terminal-input-latency": { params: SCREEN, screen: true },
+  "terminal-memory-soak": { params: SCREEN, screen: true },
+  "terminal-full-ui": { params: [...SCREEN, "services"], screen: true },
+  "terminal-full-ui-navigation": { params: [...SCREEN, "services", "dwell"], screen: true },
+  "terminal-strict-ui": { params: [...SCREEN, "services", "dwell"], screen: true },
+  "terminal-strict-ui-navigation": { params: [...SCREEN, "services", "dwell"], screen: true },
+  "terminal-mouse-zones": { params: [...SCREEN, "zoneWidth", "moves"], screen: true },
+};
+
+/** A scenario's declared params and the viewport its frames fill. */
+export type FrameScenario = Readonly<{ params: readonly string[]; rows: number; cols: number }>;
+
+/** Every scenario scenarioLines renders, sorted: the shared specs and the code scenarios. */
+export function frameScenarios(): string[] {
+  return [...SPEC_SCENARIOS, ...Object.keys(CODE_SCENARIOS)].sort();
+}
+
+/**
+ * The params scenario declares and its viewport under params, as the Go
+ * registry's viewportOf computes it.
+ */
+export function frameScenario(scenario: string, params: FrameParams): FrameScenario {
+  let meta: CodeScenario & Readonly<{ cols?: number }>;
+  if (SPEC_SCENARIOS.has(scenario)) {
+    const { spec } = loadScenarioSpec(scenario);
+    meta = { ...spec, params: (spec.params ?? []).map((p) => p.name) };
+  } else {
+    const code = CODE_SCENARIOS[scenario];
+    if (!code) throw new Error(`no frame generator for scenario "${scenario}"`);
+    meta = code;
+  }
+  if (meta.screen) {
+    return {
+      params: meta.params,
+      rows: numberParam(params["rows"], DEFAULT_ROWS),
+      cols: numberParam(params["cols"], DEFAULT_COLS),
+    };
+  }
+  const rows = typeof meta.rows === "function" ? meta.rows(params) : meta.rows;
+  return { params: meta.params, rows: rows || DEFAULT_ROWS, cols: meta.cols || DEFAULT_COLS };
+}
+
+/** The lines of scenario at tick, clipped or padded to cols. */
+export function scenarioLines(
+  scenario: string,
+  params: FrameParams,
+  tick: number,
+  cols: number,
+  rng: FrameRand,
+): string[] {
+  if (SPEC_SCENARIOS.has(scenario)) return loadScenarioSpec(scenario).lines(tick, params, cols);
+
+  switch (scenario) {
+    case "startup":
+      return benchmarkLines(STARTUP_TREE_SIZE, tick, cols);
+    case "tree-construction":
+      return benchmarkLines(numberParam(params["items"], 100), tick, cols);
+    case "content-update":
+      return contentUpdateLines(safeMod(tick, CONTENT_UPDATE_LIST_SIZE), cols);
+    case "layout-stress":
+      return layoutStressLines(
+        numberParam(params["rows"], 40),
+        numberParam(params["cols"], 4),
+        tick,
+        rng,
+        cols,
+      );
+    case "scroll-stress": {
+      const items = numberParam(params["items"], 2000);
+      return scrollStressLines(items, safeMod(tick, items), tick, rng, cols);
+    }
+    case "virtual-list":
+      return virtualListLines(
+        numberParam(params["items"], 100_000),
+        numberParam(params["viewport"], 40),
+        tick,
+        rng,
+        cols,
+      );
+    case "tables":
+      return tablesLines(
+        numberParam(params["rows"], 100),
+        numberParam(params["cols"], 8),
+        tick,
+        rng,
+        cols,
+      );
+
+    case "terminal-frame-fill":
+      return [...buildTerminalFrameFillLines(tick, params, rng, cols)];
+    case "terminal-virtual-list":
+      return [
+        ...buildTerminalVirtualListLines(
+          numberParam(params["items"], 100_000),
+          numberParam(params["viewport"], 40),
+          tick,
+          rng,
+          cols,
+        ),
+      ];
+    case "terminal-table":
+      return tableLines(numberParam(params["rows"], 40), numberParam(params["cols"], 8), tick).map(
+        (ln) => clipPad(ln, cols),
+      );
+    case "terminal-screen-transition":
+      return [...buildTerminalScreenTransitionLines(tick, params, rng)];
+    case "terminal-fps-stream":
+      return [...buildTerminalFpsStreamLines(tick, params, rng)];
+    case "terminal-input-latency":
+      return [...buildTerminalInputLatencyLines(tick, params, rng)];
+    case "terminal-memory-soak":
+      return [...buildTerminalMemorySoakLines(tick, params, rng)];
+    case "terminal-full-ui":
+      return [...buildTerminalFullUiLines(tick, params, rng)];
+    case "terminal-full-ui-navigation":
+      return [...buildTerminalFullUiNavigationLines(tick, params, rng)];
+    case "terminal-strict-ui":
+      return buildStrictFrameLines(tick, params, "dashboard", rng);
+    case "terminal-strict-ui-navigation":
+      return buildStrictFrameLines(tick, params, "navigation", rng);
+    case "terminal-mouse-zones":
+      return buildTerminalMouseZonesLines(tick, params, rng);
+    default:
+      throw new Error(`no frame generator for scenario "${scenario}"`);
+  }
+}
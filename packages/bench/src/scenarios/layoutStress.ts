@@ -13,15 +13,15 @@ import { runOpenTuiScenario } from "../frameworks/opentui.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { layoutStressValue } from "./coreWorkloads.js";
+import { FrameRand } from "./frameRand.js";
 
-function reziTree(rows: number, cols: number, tick: number): VNode {
+function reziTree(rows: number, cols: number, tick: number, rng: FrameRand): VNode {
   const grid: VNode[] = [];
   for (let r = 0; r < rows; r++) {
     const cells: VNode[] = [];
     for (let c = 0; c < cols; c++) {
-      const v = (tick + r * 31 + c * 17) % 1000;
-      const wide = (tick + r + c) % 7 === 0;
-      const value = wide ? `value=${v} (${String(v).padStart(4, "0")})` : `v=${v}`;
+      const value = layoutStressValue(r, c, tick, rng);
       cells.push(
         ui.box({ flex: 1, p: 0 }, [
           ui.column({ gap: 0 }, [
@@ -47,15 +47,14 @@ function reactTree(
   rows: number,
   cols: number,
   tick: number,
+  rng: FrameRand,
 ): import("react").ReactNode {
   const h = ReactMod.createElement;
   const grid: import("react").ReactNode[] = [];
   for (let r = 0; r < rows; r++) {
     const cells: import("react").ReactNode[] = [];
     for (let c = 0; c < cols; c++) {
-      const v = (tick + r * 31 + c * 17) % 1000;
-      const wide = (tick + r + c) % 7 === 0;
-      const value = wide ? `value=${v} (${String(v).padStart(4, "0")})` : `v=${v}`;
+      const value = layoutStressValue(r, c, tick, rng);
       cells.push(
         h(
           C.Box as string,
@@ -82,7 +81,8 @@ async function runRezi(config: ScenarioConfig, rows: number, cols: number): Prom
 
   type State = { tick: number };
   const app = createApp<State>({ backend, initialState: { tick: 0 } });
-  app.view((s) => reziTree(rows, cols, s.tick));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(rows, cols, s.tick, new FrameRand(seed, s.tick)));
 
   const initial = backend.waitForFrame();
   await app.start();
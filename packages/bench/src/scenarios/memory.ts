@@ -24,19 +24,22 @@ import type {
   Scenario,
   ScenarioConfig,
 } from "../types.js";
+import { loadScenarioSpec } from "./spec.js";
+
+// The frame's text comes from the spec the Go harness renders too.
+const spec = loadScenarioSpec("memory-profile");
 
 const SAMPLE_INTERVAL = 50; // sample memory every N iterations
 
 // ── Tree builders ───────────────────────────────────────────────────
 
 function reziTree(i: number): VNode {
+  const [title = "", bar = "", ...rows] = spec.lines(i);
   return ui.column({ p: 1 }, [
-    ui.text(`Iteration ${i}`, { style: { bold: true } }),
-    ui.row({ gap: 1 }, [ui.progress((i % 100) / 100, { width: 20 }), ui.text(`${i % 100}%`)]),
+    ui.text(title, { style: { bold: true } }),
+    ui.text(bar),
     ui.divider(),
-    ...Array.from({ length: 20 }, (_, j) =>
-      ui.text(`  Line ${j}: value=${i * 20 + j}`, { style: { dim: j % 2 === 0 } }),
-    ),
+    ...rows.map((line, j) => ui.text(line, { style: { dim: j % 2 === 0 } })),
   ]);
 }
 
@@ -248,15 +251,13 @@ function termkitMemTree(
   buffer: { put: (opts: Record<string, unknown>, str: string) => void; fill: () => void },
   i: number,
 ): void {
+  const [title = "", bar = "", ...rows] = spec.lines(i);
   buffer.fill();
-  buffer.put({ x: 1, y: 0, attr: { bold: true } }, `Iteration ${i}`);
-  const pct = i % 100;
-  const filled = Math.floor(pct / 5);
-  const bar = `[${"#".repeat(filled)}${".".repeat(20 - filled)}] ${pct}%`;
+  buffer.put({ x: 1, y: 0, attr: { bold: true } }, title);
   buffer.put({ x: 1, y: 1 }, bar);
-  for (let j = 0; j < 20; j++) {
-    buffer.put({ x: 1, y: 3 + j, attr: { dim: j % 2 === 0 } }, `  Line ${j}: value=${i * 20 + j}`);
-  }
+  rows.forEach((line, j) => {
+    buffer.put({ x: 1, y: 3 + j, attr: { dim: j % 2 === 0 } }, line);
+  });
 }
 
 // ── blessed memory tree ───────────────────────────────────────────────
@@ -270,31 +271,29 @@ function blessedMemTree(
   },
   i: number,
 ): void {
+  const [title = "", bar = "", ...rows] = spec.lines(i);
   while (screen.children.length > 0) screen.remove(screen.children[0]);
   screen.append(
     blessed.text({
       top: 0,
       left: 1,
-      content: `Iteration ${i}`,
+      content: title,
       style: { bold: true },
       tags: false,
     }),
   );
-  const pct = i % 100;
-  const filled = Math.floor(pct / 5);
-  const bar = `[${"#".repeat(filled)}${".".repeat(20 - filled)}] ${pct}%`;
   screen.append(blessed.text({ top: 1, left: 1, content: bar, tags: false }));
-  for (let j = 0; j < 20; j++) {
+  rows.forEach((line, j) => {
     screen.append(
       blessed.text({
         top: 3 + j,
         left: 1,
-        content: `  Line ${j}: value=${i * 20 + j}`,
+        content: line,
         style: { fg: j % 2 === 0 ? "grey" : "white" },
         tags: false,
       }),
     );
-  }
+  });
 }
 
 async function runBlessed(config: ScenarioConfig): Promise<BenchMetrics> {
@@ -15,14 +15,19 @@ import { runOpenTuiScenario } from "../frameworks/opentui.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync, benchSync, tryGc } from "../measure.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { loadScenarioSpec } from "./spec.js";
+
+// The counter's text comes from the spec the Go harness renders too.
+const spec = loadScenarioSpec("rerender");
 
 // ── Tree builders (small counter app) ───────────────────────────────
 
 function reziCounterTree(count: number): VNode {
+  const [title = "", counter = "", updated = ""] = spec.lines(count);
   return ui.column({ p: 1, gap: 1 }, [
-    ui.text("Counter Benchmark", { style: { bold: true } }),
-    ui.row({ gap: 2 }, [ui.text(`Count: ${count}`), ui.text("[+1]"), ui.text("[-1]")]),
-    ui.text(`Last updated: iteration ${count}`, { style: { dim: true } }),
+    ui.text(title, { style: { bold: true } }),
+    ui.text(counter),
+    ui.text(updated, { style: { dim: true } }),
   ]);
 }
 
@@ -85,24 +90,23 @@ function blessedCounterTree(
   },
   count: number,
 ): void {
+  const [title = "", counter = "", updated = ""] = spec.lines(count);
   while (screen.children.length > 0) screen.remove(screen.children[0]);
   screen.append(
     blessed.text({
       top: 1,
       left: 1,
-      content: "Counter Benchmark",
+      content: title,
       style: { bold: true },
       tags: false,
     }),
   );
-  screen.append(blessed.text({ top: 3, left: 1, content: `Count: ${count}`, tags: false }));
-  screen.append(blessed.text({ top: 3, left: 12, content: "[+1]", tags: false }));
-  screen.append(blessed.text({ top: 3, left: 18, content: "[-1]", tags: false }));
+  screen.append(blessed.text({ top: 3, left: 1, content: counter, tags: false }));
   screen.append(
     blessed.text({
       top: 5,
       left: 1,
-      content: `Last updated: iteration ${count}`,
+      content: updated,
       style: { fg: "grey" },
       tags: false,
     }),
@@ -115,12 +119,11 @@ function termkitCounterTree(
   buffer: { put: (opts: Record<string, unknown>, str: string) => void; fill: () => void },
   count: number,
 ): void {
+  const [title = "", counter = "", updated = ""] = spec.lines(count);
   buffer.fill();
-  buffer.put({ x: 1, y: 1, attr: { bold: true } }, "Counter Benchmark");
-  buffer.put({ x: 1, y: 3 }, `Count: ${count}`);
-  buffer.put({ x: 12, y: 3 }, "[+1]");
-  buffer.put({ x: 18, y: 3 }, "[-1]");
-  buffer.put({ x: 1, y: 5, attr: { dim: true } }, `Last updated: iteration ${count}`);
+  buffer.put({ x: 1, y: 1, attr: { bold: true } }, title);
+  buffer.put({ x: 1, y: 3 }, counter);
+  buffer.put({ x: 1, y: 5, attr: { dim: true } }, updated);
 }
 
 async function runTermkit(config: ScenarioConfig): Promise<BenchMetrics> {
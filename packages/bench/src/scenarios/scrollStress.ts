@@ -14,8 +14,9 @@ import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import { emitReziPerfSnapshot, resetReziPerfSnapshot } from "../reziProfile.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { FrameRand } from "./frameRand.js";
 
-function reziTree(items: number, active: number, tick: number): VNode {
+function reziTree(items: number, active: number, tick: number, rng: FrameRand): VNode {
   const rows: VNode[] = [];
   for (let i = 0; i < items; i++) {
     const isActive = i === active;
@@ -24,7 +25,7 @@ function reziTree(items: number, active: number, tick: number): VNode {
         ui.text(String(i).padStart(5, " "), { style: { dim: !isActive } }),
         ui.text(isActive ? "▶" : " ", { style: { bold: isActive } }),
         ui.text(`Item ${i}`, { style: { bold: isActive } }),
-        ui.text(`v=${(tick + i * 17) % 1000}`, { style: { dim: !isActive } }),
+        ui.text(`v=${rng.intn(1000)}`, { style: { dim: !isActive } }),
       ]),
     );
   }
@@ -41,6 +42,7 @@ function reactTree(
   items: number,
   active: number,
   tick: number,
+  rng: FrameRand,
 ): import("react").ReactNode {
   const h = ReactMod.createElement;
   const rows: import("react").ReactNode[] = [];
@@ -53,7 +55,7 @@ function reactTree(
         h(C.Text as string, { dimColor: !isActive }, String(i).padStart(5, " ")),
         h(C.Text as string, { bold: isActive }, isActive ? "▶" : " "),
         h(C.Text as string, { bold: isActive }, `Item ${i}`),
-        h(C.Text as string, { dimColor: !isActive }, `v=${(tick + i * 17) % 1000}`),
+        h(C.Text as string, { dimColor: !isActive }, `v=${rng.intn(1000)}`),
       ),
     );
   }
@@ -73,7 +75,8 @@ async function runRezi(config: ScenarioConfig, items: number): Promise<BenchMetr
 
   type State = { active: number; tick: number };
   const app = createApp<State>({ backend, initialState: { active: 0, tick: 0 } });
-  app.view((s) => reziTree(items, s.active, s.tick));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(items, s.active, s.tick, new FrameRand(seed, s.tick)));
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -0,0 +1,359 @@
+/**
+ * Shared scenario specs.
+ *
+ * Scenarios whose content needs no PRNG are described once, as JSON files in
+ * bubbletea-bench/harness/specs, and rendered both here and by the Go
+ * harness, whose harness/spec.go documents the format. Both harnesses read
+ * the same files, so a frame cannot silently differ between them.
+ *
+ * Scenarios drawing on FrameRand stay in code: the spec format has no PRNG.
+ * Their Go builders are ported in coreWorkloads.ts, terminalWorkloads.ts and
+ * terminalStrictWorkloads.ts instead, and the Go harness's parity command,
+ * run on the manifest src/export-frames.ts writes, catches any drift.
+ * ratatui-bench keeps its own copy of every scenario, specs included, since
+ * it has no spec reader.
+ */
+
+import { readFileSync, readdirSync } from "node:fs";
+
+export const SPEC_VERSION = 1;
+
+const SPEC_DIR = new URL("../../bubbletea-bench/harness/specs/", import.meta.url);
+
+type SpecParam = Readonly<{ name: string; type?: string; default?: number }>;
+type SpecLine = Readonly<{ text: string; repeat?: string; dirty?: boolean }>;
+
+export type ScenarioSpec = Readonly<{
+  specVersion: number;
+  name: string;
+  description: string;
+  params?: readonly SpecParam[];
+  rows?: number;
+  cols?: number;
+  screen?: boolean;
+  eventLoop?: boolean;
+  lines: readonly SpecLine[];
+}>;
+
+export type SpecParams = Readonly<Record<string, number | string | boolean>>;
+
+export type CompiledSpec = Readonly<{
+  spec: ScenarioSpec;
+  /**
+   * The frame at tick. With cols, every line is clipped or padded to that
+   * width as in the Go harness; without, lines are left as written, for
+   * frameworks that lay text out themselves.
+   */
+  lines: (tick: number, params?: SpecParams, cols?: number) => string[];
+}>;
+
+type Value = number | string;
+type Env = Map<string, Value>;
+type Expr = Readonly<{ str: boolean; evaluate: (env: Env) => Value }>;
+
+type Part =
+  | Readonly<{ literal: string }>
+  | Readonly<{ expr: Expr; width: number; zero: boolean; left: boolean }>;
+
+type Rule = Readonly<{ parts: readonly Part[]; repeat: Expr | null }>;
+
+const cache = new Map<string, CompiledSpec>();
+
+/** The names of the shared scenario specs, sorted. */
+export function listScenarioSpecs(): string[] {
+  return readdirSync(SPEC_DIR)
+    .filter((file) => file.endsWith(".json"))
+    .map((file) => file.slice(0, -".json".length))
+    .sort();
+}
+
+/** Reads and compiles the spec of the named scenario. */
+export function loadScenarioSpec(name: string): CompiledSpec {
+  const cached = cache.get(name);
+  if (cached) return cached;
+  const spec = JSON.parse(readFileSync(new URL(`${name}.json`, SPEC_DIR), "utf-8")) as ScenarioSpec;
+  const compiled = compileSpec(spec);
+  cache.set(name, compiled);
+  return compiled;
+}
+
+function compileSpec(spec: ScenarioSpec): CompiledSpec {
+  if (spec.specVersion !== SPEC_VERSION) {
+    throw new Error(
+      `scenario spec ${spec.name}: specVersion ${spec.specVersion}, want ${SPEC_VERSION}`,
+    );
+  }
+  const names = new Set(["tick", "cols", ...(spec.params ?? []).map((p) => p.name)]);
+  const rules: Rule[] = spec.lines.map((line, n) => {
+    try {
+      if (line.repeat === undefined || line.repeat === "") {
+        return { parts: parseTemplate(line.text, names), repeat: null };
+      }
+      const repeat = parseExpr(line.repeat, names);
+      if (repeat.str) throw new Error(`"${line.repeat}" is a string`);
+      return { parts: parseTemplate(line.text, new Set([...names, "i"])), repeat };
+    } catch (err) {
+      throw new Error(`scenario spec ${spec.name}: lines[${n}]: ${(err as Error).message}`);
+    }
+  });
+
+  const lines = (tick: number, params: SpecParams = {}, cols?: number): string[] => {
+    const env: Env = new Map<string, Value>([
+      ["tick", tick],
+      ["cols", cols ?? 0],
+    ]);
+    for (const p of spec.params ?? []) {
+      env.set(p.name, paramValue(p, params[p.name]));
+    }
+    const out: string[] = [];
+    for (const rule of rules) {
+      const count = rule.repeat ? (rule.repeat.evaluate(env) as number) : 1;
+      for (let i = 0; i < count; i++) {
+        env.set("i", i);
+        let text = "";
+        for (const part of rule.parts) {
+          text += "literal" in part ? part.literal : formatValue(part, part.expr.evaluate(env));
+        }
+        out.push(cols === undefined ? text : clipPad(text, cols));
+      }
+    }
+    return out;
+  };
+
+  return { spec, lines };
+}
+
+function paramValue(p: SpecParam, raw: number | string | boolean | undefined): number {
+  if (raw === undefined) return p.default ?? 0;
+  if (p.type === "bool") return raw === true || raw === "true" || raw === "1" || raw === 1 ? 1 : 0;
+  return Math.trunc(Number(raw));
+}
+
+/** Clips or pads by code point, like the Go harness's clipPad. */
+function clipPad(s: string, cols: number): string {
+  const chars = Array.from(s);
+  if (chars.length >= cols) return chars.slice(0, cols).join("");
+  return `${s}${" ".repeat(cols - chars.length)}`;
+}
+
+function formatValue(
+  part: Readonly<{ width: number; zero: boolean; left: boolean }>,
+  value: Value,
+): string {
+  let text = String(value);
+  if (typeof value === "number" && part.zero && value < 0) {
+    return `-${text.slice(1).padStart(part.width - 1, "0")}`;
+  }
+  const pad = part.width - Array.from(text).length;
+  if (pad <= 0) return text;
+  if (part.left) return text + " ".repeat(pad);
+  if (part.zero && typeof value === "number") text = "0".repeat(pad) + text;
+  else text = " ".repeat(pad) + text;
+  return text;
+}
+
+function parseTemplate(text: string, names: ReadonlySet<string>): Part[] {
+  const parts: Part[] = [];
+  let literal = "";
+  for (let i = 0; i < text.length; i++) {
+    const c = text.charAt(i);
+    if ((c === "{" && text[i + 1] === "{") || (c === "}" && text[i + 1] === "}")) {
+      literal += c;
+      i++;
+    } else if (c === "}") {
+      throw new Error(`unmatched } at ${i}`);
+    } else if (c === "{") {
+      const end = placeholderEnd(text, i + 1);
+      if (end < 0) throw new Error(`unclosed { at ${i}`);
+      const part = parsePlaceholder(text.slice(i + 1, end), names);
+      if (literal !== "") {
+        parts.push({ literal });
+        literal = "";
+      }
+      parts.push(part);
+      i = end;
+    } else {
+      literal += c;
+    }
+  }
+  if (literal !== "") parts.push({ literal });
+  return parts;
+}
+
+function placeholderEnd(text: string, from: number): number {
+  let quoted = false;
+  for (let i = from; i < text.length; i++) {
+    if (text[i] === "'") quoted = !quoted;
+    else if (text[i] === "}" && !quoted) return i;
+  }
+  return -1;
+}
+
+function parsePlaceholder(body: string, names: ReadonlySet<string>): Part {
+  let source = body;
+  let format = "";
+  const colon = body.lastIndexOf(":");
+  if (colon >= 0 && !body.slice(colon).includes("'")) {
+    source = body.slice(0, colon);
+    format = body.slice(colon + 1);
+  }
+  let expr: Expr;
+  try {
+    expr = parseExpr(source, names);
+  } catch (err) {
+    throw new Error(`{${body}}: ${(err as Error).message}`);
+  }
+  if (format === "") return { expr, width: 0, zero: false, left: false };
+  const left = format.startsWith("-");
+  const zero = format.startsWith("0");
+  const digits = left ? format.slice(1) : format;
+  if (!/^\d+$/.test(digits)) throw new Error(`{${body}}: bad format "${format}"`);
+  return { expr, width: Number(digits), zero, left };
+}
+
+const FUNCS: Readonly<Record<string, readonly boolean[]>> = {
+  rep: [true, false],
+  min: [false, false],
+  max: [false, false],
+};
+
+function binary(op: string, x: Expr, y: Expr): Expr {
+  return {
+    str: false,
+    evaluate: (env) => {
+      const a = x.evaluate(env) as number;
+      const b = y.evaluate(env) as number;
+      switch (op) {
+        case "+":
+          return a + b;
+        case "-":
+          return a - b;
+        case "*":
+          return a * b;
+        case "/":
+          return b === 0 ? 0 : Math.trunc(a / b);
+        default:
+          return b === 0 ? 0 : a % b;
+      }
+    },
+  };
+}
+
+/** A recursive-descent parser over one expression, as in harness/spec.go. */
+function parseExpr(src: string, names: ReadonlySet<string>): Expr {
+  let pos = 0;
+
+  const peek = (): string => {
+    while (src[pos] === " ") pos++;
+    return src[pos] ?? "";
+  };
+
+  const operands = (op: string, x: Expr, y: Expr): void => {
+    if (x.str || y.str) throw new Error(`${op} on a string in "${src}"`);
+  };
+
+  const sum = (): Expr => {
+    let x = product();
+    for (let op = peek(); op === "+" || op === "-"; op = peek()) {
+      pos++;
+      const y = product();
+      operands(op, x, y);
+      x = binary(op, x, y);
+    }
+    return x;
+  };
+
+  const product = (): Expr => {
+    let x = unary();
+    for (let op = peek(); op === "*" || op === "/" || op === "%"; op = peek()) {
+      pos++;
+      const y = unary();
+      operands(op, x, y);
+      x = binary(op, x, y);
+    }
+    return x;
+  };
+
+  const unary = (): Expr => {
+    if (peek() !== "-") return primary();
+    pos++;
+    const x = unary();
+    if (x.str) throw new Error(`- on a string in "${src}"`);
+    return { str: false, evaluate: (env) => -(x.evaluate(env) as number) };
+  };
+
+  const primary = (): Expr => {
+    const c = peek();
+    if (c === "(") {
+      pos++;
+      const x = sum();
+      if (peek() !== ")") throw new Error(`missing ) in "${src}"`);
+      pos++;
+      return x;
+    }
+    if (c === "'") {
+      const end = src.indexOf("'", pos + 1);
+      if (end < 0) throw new Error(`unclosed string in "${src}"`);
+      const s = src.slice(pos + 1, end);
+      pos = end + 1;
+      return { str: true, evaluate: () => s };
+    }
+    if (/[0-9]/.test(c)) {
+      const digits = /^[0-9]+/.exec(src.slice(pos))?.[0] ?? "";
+      pos += digits.length;
+      const n = Number(digits);
+      return { str: false, evaluate: () => n };
+    }
+    if (/[A-Za-z_]/.test(c)) {
+      const name = /^[A-Za-z_0-9]+/.exec(src.slice(pos))?.[0] ?? "";
+      pos += name.length;
+      if (peek() === "(") return call(name);
+      if (!names.has(name)) throw new Error(`unknown name "${name}" in "${src}"`);
+      return { str: false, evaluate: (env) => env.get(name) ?? 0 };
+    }
+    throw new Error(`expected a value at ${pos} in "${src}"`);
+  };
+
+  const call = (name: string): Expr => {
+    const kinds = FUNCS[name];
+    if (!kinds) throw new Error(`unknown function "${name}" in "${src}"`);
+    pos++;
+    const args: Expr[] = [];
+    kinds.forEach((wantStr, i) => {
+      if (i > 0) {
+        if (peek() !== ",") throw new Error(`${name} takes ${kinds.length} arguments in "${src}"`);
+        pos++;
+      }
+      const arg = sum();
+      if (arg.str !== wantStr) {
+        throw new Error(`${name} argument ${i + 1} has the wrong type in "${src}"`);
+      }
+      args.push(arg);
+    });
+    if (peek() !== ")") throw new Error(`${name} takes ${kinds.length} arguments in "${src}"`);
+    pos++;
+    const [a, b] = args as [Expr, Expr];
+    switch (name) {
+      case "rep":
+        return {
+          str: true,
+          evaluate: (env) =>
+            (a.evaluate(env) as string).repeat(Math.max(0, b.evaluate(env) as number)),
+        };
+      case "min":
+        return {
+          str: false,
+          evaluate: (env) => Math.min(a.evaluate(env) as number, b.evaluate(env) as number),
+        };
+      default:
+        return {
+          str: false,
+          evaluate: (env) => Math.max(a.evaluate(env) as number, b.evaluate(env) as number),
+        };
+    }
+  };
+
+  const expr = sum();
+  if (peek() !== "") throw new Error(`unexpected "${src.slice(pos)}" in "${src}"`);
+  return expr;
+}
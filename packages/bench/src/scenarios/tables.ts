@@ -11,14 +11,10 @@ import { runOpenTuiScenario } from "../frameworks/opentui.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { tableUpdateCellValue } from "./coreWorkloads.js";
+import { FrameRand } from "./frameRand.js";
 
-function cellValue(r: number, c: number, tick: number): string {
-  const v = (tick + r * 131 + c * 17) % 10_000;
-  const wide = (tick + r + c) % 13 === 0;
-  return wide ? `val=${String(v).padStart(4, "0")} (row=${r})` : String(v);
-}
-
-function reziTree(rows: number, cols: number, tick: number): VNode {
+function reziTree(rows: number, cols: number, tick: number, rng: FrameRand): VNode {
   const headerCells: VNode[] = [];
   for (let c = 0; c < cols; c++) {
     headerCells.push(ui.text(`Col ${c}`, { style: { bold: true } }));
@@ -28,7 +24,9 @@ function reziTree(rows: number, cols: number, tick: number): VNode {
   for (let r = 0; r < rows; r++) {
     const cells: VNode[] = [];
     for (let c = 0; c < cols; c++) {
-      cells.push(ui.text(cellValue(r, c, tick), { style: { dim: (r + c) % 2 === 0 } }));
+      cells.push(
+        ui.text(tableUpdateCellValue(r, c, tick, rng), { style: { dim: (r + c) % 2 === 0 } }),
+      );
     }
     body.push(
       ui.row({ gap: 2 }, [ui.text(String(r).padStart(4, " "), { style: { dim: true } }), ...cells]),
@@ -50,6 +48,7 @@ function reactTree(
   rows: number,
   cols: number,
   tick: number,
+  rng: FrameRand,
 ): import("react").ReactNode {
   const h = ReactMod.createElement;
 
@@ -66,7 +65,7 @@ function reactTree(
         h(
           C.Text as string,
           { key: `c:${r}:${c}`, dimColor: (r + c) % 2 === 0 },
-          cellValue(r, c, tick),
+          tableUpdateCellValue(r, c, tick, rng),
         ),
       );
     }
@@ -101,7 +100,8 @@ async function runRezi(config: ScenarioConfig, rows: number, cols: number): Prom
 
   type State = { tick: number };
   const app = createApp<State>({ backend, initialState: { tick: 0 } });
-  app.view((s) => reziTree(rows, cols, s.tick));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(rows, cols, s.tick, new FrameRand(seed, s.tick)));
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -13,6 +13,8 @@ import { runRatatuiScenario } from "../frameworks/ratatui.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { FrameRand } from "./frameRand.js";
+import { makeLineContent, makeStaticLine } from "./terminalWorkloads.js";
 
 type BlessedElement = Readonly<{ setContent: (s: string) => void }>;
 type BlessedScreen = Readonly<{
@@ -31,25 +33,16 @@ async function loadBlessed(): Promise<BlessedModule> {
   return (mod.default ?? mod) as BlessedModule;
 }
 
-function padTo(s: string, width: number): string {
-  if (s.length >= width) return s.slice(0, width);
-  return s + " ".repeat(width - s.length);
-}
-
-function makeLineContent(row: number, tick: number, cols: number): string {
-  // Deterministic, different each tick.
-  const v = (tick * 1103515245 + row * 12345) >>> 0;
-  return padTo(`row=${row.toString().padStart(2, "0")} tick=${tick} v=${v.toString(16)}`, cols);
-}
-
-function makeStaticLine(row: number, cols: number): string {
-  return padTo(`row=${row.toString().padStart(2, "0")} static`, cols);
-}
-
-function reziTree(rows: number, cols: number, dirtyLines: number, tick: number): VNode {
+function reziTree(
+  rows: number,
+  cols: number,
+  dirtyLines: number,
+  tick: number,
+  rng: FrameRand,
+): VNode {
   const lines: VNode[] = [];
   for (let r = 0; r < rows; r++) {
-    const content = r < dirtyLines ? makeLineContent(r, tick, cols) : makeStaticLine(r, cols);
+    const content = r < dirtyLines ? makeLineContent(r, tick, rng, cols) : makeStaticLine(r, cols);
     lines.push(ui.text(content));
   }
   return ui.column({ p: 0 }, lines);
@@ -62,11 +55,12 @@ function reactTree(
   cols: number,
   dirtyLines: number,
   tick: number,
+  rng: FrameRand,
 ): import("react").ReactNode {
   const h = ReactMod.createElement;
   const lines: import("react").ReactNode[] = [];
   for (let r = 0; r < rows; r++) {
-    const content = r < dirtyLines ? makeLineContent(r, tick, cols) : makeStaticLine(r, cols);
+    const content = r < dirtyLines ? makeLineContent(r, tick, rng, cols) : makeStaticLine(r, cols);
     lines.push(h(C.Text as string, { key: String(r) }, content));
   }
   return h(C.Box as string, { flexDirection: "column" }, ...lines);
@@ -83,7 +77,8 @@ async function runRezi(
 
   type State = { tick: number };
   const app = createApp<State>({ backend, initialState: { tick: 0 } });
-  app.view((s) => reziTree(rows, cols, dirtyLines, s.tick));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(rows, cols, dirtyLines, s.tick, new FrameRand(seed, s.tick)));
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -151,11 +146,13 @@ async function runBlessed(
     screen.append(t);
   }
 
+  const seed = config.seed ?? 0;
   const renderTick = async (tick: number): Promise<void> => {
     const renderP = new Promise<void>((resolve) => screen.once("render", () => resolve()));
+    const rng = new FrameRand(seed, tick);
     for (let r = 0; r < rows; r++) {
       if (r < dirtyLines) {
-        lines[r]?.setContent(makeLineContent(r, tick, cols));
+        lines[r]?.setContent(makeLineContent(r, tick, rng, cols));
       }
     }
     screen.render();
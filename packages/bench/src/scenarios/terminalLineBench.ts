@@ -3,6 +3,7 @@ import { createBlessedOutput } from "../frameworks/blessed.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync } from "../measure.js";
 import type { BenchMetrics, ScenarioConfig } from "../types.js";
+import { FrameRand } from "./frameRand.js";
 
 type BlessedElement = Readonly<{ setContent: (s: string) => void }>;
 type BlessedScreen = Readonly<{
@@ -20,6 +21,7 @@ export type UpdateMode = "direct" | "event-loop";
 export type LineBuilder = (
   tick: number,
   params: Record<string, number | string>,
+  rng: FrameRand,
 ) => readonly string[];
 
 async function loadBlessed(): Promise<BlessedModule> {
@@ -57,7 +59,8 @@ export async function runReziLineScenario(
 
   type State = { tick: number };
   const app = createApp<State>({ backend, initialState: { tick: 0 } });
-  app.view((s) => reziTree(buildLines(s.tick, params)));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(buildLines(s.tick, params, new FrameRand(seed, s.tick))));
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -109,12 +112,10 @@ export async function runBlessedLineScenario(
     dockBorders: false,
   });
 
-  const initialLines = buildLines(0, params);
-  const maxLines = Math.max(
-    initialLines.length,
-    buildLines(1, params).length,
-    buildLines(2, params).length,
-  );
+  const seed = config.seed ?? 0;
+  const linesAt = (tick: number) => buildLines(tick, params, new FrameRand(seed, tick));
+  const initialLines = linesAt(0);
+  const maxLines = Math.max(initialLines.length, linesAt(1).length, linesAt(2).length);
 
   const lineNodes: BlessedElement[] = [];
   for (let i = 0; i < maxLines; i++) {
@@ -124,7 +125,7 @@ export async function runBlessedLineScenario(
   }
 
   const renderTick = async (tick: number): Promise<void> => {
-    const lines = buildLines(tick, params);
+    const lines = linesAt(tick);
     const doRender = () => {
       for (let i = 0; i < maxLines; i++) lineNodes[i]?.setContent(lines[i] ?? "");
       screen.render();
@@ -15,6 +15,10 @@ import { runRatatuiScenario } from "../frameworks/ratatui.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { loadScenarioSpec } from "./spec.js";
+
+// The frame's text comes from the spec the Go harness renders too.
+const spec = loadScenarioSpec("terminal-rerender");
 
 type BlessedElement = Readonly<{ setContent: (s: string) => void }>;
 type BlessedScreen = Readonly<{
@@ -39,12 +43,13 @@ async function runRezi(config: ScenarioConfig): Promise<BenchMetrics> {
 
   type State = { tick: number };
   const app = createApp<State>({ backend, initialState: { tick: 0 } });
-  app.view((s) =>
-    ui.column({ p: 1, gap: 1 }, [
-      ui.text("terminal-rerender", { style: { bold: true } }),
-      ui.text(`tick=${s.tick}`),
-    ]),
-  );
+  app.view((s) => {
+    const [title = "", line = ""] = spec.lines(s.tick);
+    return ui.column({ p: 1, gap: 1 }, [
+      ui.text(title, { style: { bold: true } }),
+      ui.text(line),
+    ]);
+  });
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -108,7 +113,7 @@ async function runBlessed(config: ScenarioConfig): Promise<BenchMetrics> {
 
   const renderTick = async (tick: number): Promise<void> => {
     const renderP = new Promise<void>((resolve) => screen.once("render", () => resolve()));
-    box.setContent(`terminal-rerender\n\ntick=${tick}\n`);
+    box.setContent(`${spec.lines(tick).join("\n\n")}\n`);
     screen.render();
     await renderP;
   };
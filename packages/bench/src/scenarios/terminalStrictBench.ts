@@ -6,6 +6,7 @@ import { createBenchBackend } from "../io.js";
 import { benchAsync } from "../measure.js";
 import { emitReziPerfSnapshot, resetReziPerfSnapshot } from "../reziProfile.js";
 import type { BenchMetrics, ScenarioConfig } from "../types.js";
+import { FrameRand } from "./frameRand.js";
 import {
   type StrictSections,
   type StrictVariant,
@@ -85,7 +86,10 @@ async function runRezi(
 
   type State = { tick: number };
   const app = createApp<State>({ backend, initialState: { tick: 0 } });
-  app.view((s) => reziTree(buildStrictSections(s.tick, params, variant)));
+  const seed = config.seed ?? 0;
+  app.view((s) =>
+    reziTree(buildStrictSections(s.tick, params, variant, new FrameRand(seed, s.tick))),
+  );
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -145,7 +149,10 @@ async function runBlessed(
 ): Promise<BenchMetrics> {
   const blessed = await loadBlessed();
   const output = createBlessedOutput();
-  const sections0 = buildStrictSections(0, params, variant);
+  const seed = config.seed ?? 0;
+  const sectionsAt = (tick: number) =>
+    buildStrictSections(tick, params, variant, new FrameRand(seed, tick));
+  const sections0 = sectionsAt(0);
 
   const screen = blessed.screen({
     smartCSR: true,
@@ -245,7 +252,7 @@ async function runBlessed(
   footerBox.append(footerHelp);
 
   const renderTick = async (tick: number): Promise<void> => {
-    const sections = buildStrictSections(tick, params, variant);
+    const sections = sectionsAt(tick);
     const renderP = new Promise<void>((resolve) => screen.once("render", () => resolve()));
 
     headerBox.setContent(sections.header);
@@ -1,4 +1,22 @@
-type WorkloadParam = number | string;
+/**
+ * Content of the terminal-strict-ui scenarios.
+ *
+ * Ports of the strict builders in bubbletea-bench/harness/scenarios.go. The
+ * sections draw from the tick's FrameRand in the Go order: the center pane,
+ * the header, the status line, the nav pane and then the right pane.
+ */
+
+import type { FrameRand } from "./frameRand.js";
+import {
+  type WorkloadParam,
+  bar,
+  clipPad,
+  numberParam,
+  padNum,
+  safeMod,
+  spark,
+} from "./frameText.js";
+
 type StrictWorkloadParams = Readonly<{
   rows?: WorkloadParam;
   cols?: WorkloadParam;
@@ -22,26 +40,6 @@ export type StrictSections = Readonly<{
   footer: string;
 }>;
 
-function clipPad(s: string, cols: number): string {
-  if (s.length >= cols) return s.slice(0, cols);
-  return `${s}${" ".repeat(cols - s.length)}`;
-}
-
-function bar(value: number, width: number): string {
-  const filled = Math.max(0, Math.min(width, Math.round(value * width)));
-  return `${"#".repeat(filled)}${"-".repeat(width - filled)}`;
-}
-
-function spark(seed: number, width: number): string {
-  let out = "";
-  for (let i = 0; i < width; i++) out += (seed + i * 3) % 7 > 2 ? "#" : ".";
-  return out;
-}
-
-function numberParam(value: WorkloadParam | undefined, fallback: number): number {
-  return Number(value ?? fallback);
-}
-
 function strictRows(params: StrictWorkloadParams): number {
   return Math.max(16, numberParam(params.rows, 40));
 }
@@ -62,57 +60,62 @@ function strictPages(): readonly string[] {
   return ["dashboard", "services", "deployments", "incidents", "logs", "commands"];
 }
 
-function navLines(page: string, tick: number): readonly string[] {
+function navLines(page: string, tick: number, rng: FrameRand): readonly string[] {
   const tabs = ["dashboard", "services", "deploy", "incidents", "logs", "settings"];
   const lines: string[] = [];
-  const active = tabs.findIndex((t) => page.startsWith(t) || page === t);
+  const active = Math.max(0, tabs.findIndex((t) => page.startsWith(t) || page === t));
   for (let i = 0; i < tabs.length; i++) {
     lines.push(`${i === active ? ">" : " "} ${tabs[i]}`);
   }
   lines.push(
-    `env=${["prod", "stage", "dev"][tick % 3]} region=${["use1", "usw2", "euw1"][tick % 3]}`,
+    `env=${["prod", "stage", "dev"][safeMod(tick, 3)]} region=${["use1", "usw2", "euw1"][safeMod(tick, 3)]}`,
   );
-  lines.push(`window=${15 + ((tick * 7) % 30)}m filter=${tick % 2 === 0 ? "on" : "off"}`);
+  lines.push(`window=${15 + rng.intn(30)}m filter=${safeMod(tick, 2) === 0 ? "on" : "off"}`);
   return lines;
 }
 
-function serviceTableLines(services: number, tick: number, rowBudget: number): readonly string[] {
+function serviceTableLines(
+  services: number,
+  tick: number,
+  rng: FrameRand,
+  rowBudget: number,
+): readonly string[] {
   const lines: string[] = [];
   lines.push("id      state      lat   rps   err");
   const viewportRows = Math.max(4, rowBudget - 4);
-  const offset = tick % Math.max(1, services - viewportRows + 1);
-  const active = tick % services;
+  const offset = safeMod(tick, Math.max(1, services - viewportRows + 1));
+  const active = safeMod(tick, services);
   for (let r = 0; r < viewportRows; r++) {
     const svc = offset + r;
-    const degraded = (tick + svc * 5) % 17 === 0;
-    const lat = 10 + ((tick * 13 + svc * 7) % 220);
-    const rps = 80 + ((tick * 19 + svc * 37) % 3000);
-    const err = ((tick + svc * 11) % 90) / 10;
+    const degraded = rng.intn(17) === 0;
+    const lat = 10 + rng.intn(220);
+    const rps = 80 + rng.intn(3000);
+    const err = rng.intn(90) / 10;
     lines.push(
-      `${svc === active ? ">" : " "} svc-${String(svc).padStart(3, "0")} ${degraded ? "degraded" : "healthy "} ${String(lat).padStart(3, " ")}ms ${String(rps).padStart(4, " ")} ${err.toFixed(1)}%`,
+      `${svc === active ? ">" : " "} svc-${padNum(svc, 3, true)} ${degraded ? "degraded" : "healthy "} ${padNum(lat, 3)}ms ${padNum(rps, 4)} ${err.toFixed(1)}%`,
     );
   }
 
-  const cpu = ((tick * 17) % 1000) / 1000;
-  const mem = ((tick * 31 + 211) % 1000) / 1000;
-  lines.push(
-    `cpu ${bar(cpu, 18)} ${(cpu * 100).toFixed(1)}% io ${String(30 + ((tick * 11) % 60)).padStart(2, " ")}%`,
-  );
-  lines.push(`mem ${bar(mem, 18)} ${(mem * 100).toFixed(1)}% gc ${(tick * 97) % 999}ms`);
-  lines.push(`queue=${(tick * 7) % 200} retry=${(tick * 11) % 40} drop=${(tick * 13) % 9}`);
+  const cpu = rng.intn(1000) / 1000;
+  const mem = rng.intn(1000) / 1000;
+  lines.push(`cpu ${bar(cpu, 18)} ${(cpu * 100).toFixed(1)}% io ${padNum(30 + rng.intn(60), 2)}%`);
+  lines.push(`mem ${bar(mem, 18)} ${(mem * 100).toFixed(1)}% gc ${rng.intn(999)}ms`);
+  const queue = rng.intn(200);
+  const retry = rng.intn(40);
+  lines.push(`queue=${queue} retry=${retry} drop=${rng.intn(9)}`);
   return lines;
 }
 
-function deploymentLines(tick: number, rowBudget: number): readonly string[] {
+function deploymentLines(tick: number, rng: FrameRand, rowBudget: number): readonly string[] {
   const lines: string[] = [];
   lines.push("pipeline rollout and gate state");
   for (let i = 1; i < rowBudget; i++) {
-    const step = i % 12;
-    const pct = (tick * 7 + i * 9) % 101;
-    const gate = (tick + step) % 5 === 0 ? "blocked" : "ready  ";
-    const canary = (tick + step) % 2 === 0 ? "on" : "off";
+    const step = safeMod(i, 12);
+    const pct = rng.intn(101);
+    const gate = safeMod(tick + step, 5) === 0 ? "blocked" : "ready  ";
+    const canary = safeMod(tick + step, 2) === 0 ? "on" : "off";
     lines.push(
-      `pipe-${String(step).padStart(2, "0")} ${gate} ${bar(pct / 100, 16)} ${String(pct).padStart(3, " ")}% canary=${canary}`,
+      `pipe-${padNum(step, 2, true)} ${gate} ${bar(pct / 100, 16)} ${padNum(pct, 3)}% canary=${canary}`,
     );
   }
   return lines;
@@ -123,10 +126,11 @@ function incidentLines(tick: number, rowBudget: number): readonly string[] {
   lines.push("incident queue and ownership");
   for (let i = 1; i < rowBudget; i++) {
     const seq = tick * rowBudget + i;
-    const sev = seq % 13 === 0 ? "sev1" : seq % 7 === 0 ? "sev2" : "sev3";
-    const state = seq % 5 === 0 ? "mitigating" : seq % 3 === 0 ? "triaging  " : "open      ";
+    const sev = safeMod(seq, 13) === 0 ? "sev1" : safeMod(seq, 7) === 0 ? "sev2" : "sev3";
+    const state =
+      safeMod(seq, 5) === 0 ? "mitigating" : safeMod(seq, 3) === 0 ? "triaging  " : "open      ";
     lines.push(
-      `${sev} inc-${String(seq % 10000).padStart(4, "0")} ${state} owner=oncall-${seq % 9} age=${(seq * 3) % 180}m`,
+      `${sev} inc-${padNum(safeMod(seq, 10000), 4, true)} ${state} owner=oncall-${safeMod(seq, 9)} age=${safeMod(seq * 3, 180)}m`,
     );
   }
   return lines;
@@ -137,9 +141,9 @@ function logLines(tick: number, rowBudget: number): readonly string[] {
   lines.push("streamed logs");
   for (let i = 1; i < rowBudget; i++) {
     const seq = tick * rowBudget + i;
-    const lvl = seq % 17 === 0 ? "ERROR" : seq % 9 === 0 ? "WARN " : "INFO ";
+    const lvl = safeMod(seq, 17) === 0 ? "ERROR" : safeMod(seq, 9) === 0 ? "WARN " : "INFO ";
     lines.push(
-      `${lvl} trace=${String((seq * 19) % 100000).padStart(5, "0")} shard=${seq % 12} msg=event-${seq}`,
+      `${lvl} trace=${padNum(safeMod(seq * 19, 100000), 5, true)} shard=${safeMod(seq, 12)} msg=event-${seq}`,
     );
   }
   return lines;
@@ -150,25 +154,32 @@ function commandLines(services: number, tick: number, rowBudget: number): readon
   lines.push("command palette actions");
   for (let i = 1; i < rowBudget; i++) {
     const cmd = i - 1;
-    const selected = cmd === tick % Math.max(1, rowBudget - 1);
-    const preview = (tick + cmd) % 2 === 0 ? "safe" : "risky";
+    const selected = cmd === safeMod(tick, Math.max(1, rowBudget - 1));
+    const preview = safeMod(tick + cmd, 2) === 0 ? "safe" : "risky";
     lines.push(
-      `${selected ? ">" : " "} /command-${String(cmd).padStart(2, "0")} target=svc-${String((tick + cmd) % services).padStart(3, "0")} preview=${preview}`,
+      `${selected ? ">" : " "} /command-${padNum(cmd, 2, true)} target=svc-${padNum(safeMod(tick + cmd, services), 3, true)} preview=${preview}`,
     );
   }
   return lines;
 }
 
-function rightPanelLines(page: string, tick: number, rowBudget: number): readonly string[] {
+function rightPanelLines(
+  page: string,
+  tick: number,
+  rng: FrameRand,
+  rowBudget: number,
+): readonly string[] {
   const lines: string[] = [];
-  lines.push(`page=${page} focus=svc-${String((tick * 3) % 24).padStart(3, "0")}`);
-  lines.push(`slo p95<120ms now=${40 + ((tick * 5) % 120)}ms`);
-  lines.push(`deploy=${tick % 2 === 0 ? "green" : "canary"} zone=az-${(tick % 3) + 1}`);
+  lines.push(`page=${page} focus=svc-${padNum(rng.intn(24), 3, true)}`);
+  lines.push(`slo p95<120ms now=${40 + rng.intn(120)}ms`);
+  lines.push(
+    `deploy=${safeMod(tick, 2) === 0 ? "green" : "canary"} zone=az-${safeMod(tick, 3) + 1}`,
+  );
   for (let i = 3; i < rowBudget; i++) {
     const seq = tick * rowBudget + i;
-    const lvl = seq % 19 === 0 ? "ERROR" : seq % 11 === 0 ? "WARN " : "INFO ";
+    const lvl = safeMod(seq, 19) === 0 ? "ERROR" : safeMod(seq, 11) === 0 ? "WARN " : "INFO ";
     lines.push(
-      `${lvl} t+${String(seq).padStart(5, "0")} op=${String((seq * 7) % 97).padStart(2, "0")} note=${spark(seq, 10)}`,
+      `${lvl} t+${padNum(seq, 5, true)} op=${padNum(safeMod(seq * 7, 97), 2, true)} note=${spark(seq, 10)}`,
     );
   }
   return lines;
@@ -180,70 +191,93 @@ function fitLines(lines: readonly string[], target: number): readonly string[] {
   return [...lines, ...Array.from({ length: target - lines.length }, () => "")];
 }
 
-function buildDashboardSections(tick: number, params: StrictWorkloadParams): StrictSections {
+function centerLines(
+  page: string,
+  services: number,
+  tick: number,
+  rng: FrameRand,
+  rowBudget: number,
+): readonly string[] {
+  switch (page) {
+    case "deployments":
+      return deploymentLines(tick, rng, rowBudget);
+    case "incidents":
+      return incidentLines(tick, rowBudget);
+    case "logs":
+      return logLines(tick, rowBudget);
+    case "commands":
+      return commandLines(services, tick, rowBudget);
+    default:
+      return serviceTableLines(services, tick, rng, rowBudget);
+  }
+}
+
+function buildDashboardSections(
+  tick: number,
+  params: StrictWorkloadParams,
+  rng: FrameRand,
+): StrictSections {
   const rows = strictRows(params);
   const cols = strictCols(params);
   const services = strictServices(params);
   const bodyRows = Math.max(4, rows - 5);
-  const leftRows = bodyRows - 1;
-  const centerRows = bodyRows - 1;
-  const rightRows = bodyRows - 1;
+  const paneRows = Math.max(1, bodyRows - 1);
   const page = "dashboard";
 
+  const center = serviceTableLines(services, tick, rng, paneRows);
+  const cpu = 35 + rng.intn(40);
+  const mem = 42 + rng.intn(49);
+  const qps = 900 + rng.intn(1500);
+  const conn = 1200 + rng.intn(800);
+  const sync = rng.intn(9999);
+  const pending = rng.intn(48);
+
   return {
     rows,
     cols,
-    header: `terminal-strict-ui page=${page} tick=${tick} cpu=${35 + ((tick * 7) % 40)}% mem=${42 + ((tick * 11) % 49)}% qps=${900 + ((tick * 29) % 1500)}`,
+    header: `terminal-strict-ui page=${page} tick=${tick} cpu=${cpu}% mem=${mem}% qps=${qps}`,
     leftTitle: "NAV",
-    leftLines: fitLines(navLines(page, tick), leftRows),
+    leftLines: fitLines(navLines(page, tick, rng), paneRows),
     centerTitle: "SERVICES",
-    centerLines: fitLines(serviceTableLines(services, tick, centerRows), centerRows),
-    rightTitle: "INSPECTOR",
-    rightLines: fitLines(rightPanelLines(page, tick, rightRows), rightRows),
-    status: `status=online conn=${1200 + ((tick * 17) % 800)} sync=${(tick * 29) % 9999} pending=${(tick * 5) % 48}`,
-    footer: "keys: [tab] move  [enter] open  [/] command  [q] quit",
+    centerLines: fitLines(center, paneRows),
+    rightTitle: "DETAILS",
+    rightLines: fitLines(rightPanelLines(page, tick, rng, paneRows), paneRows),
+    status: `status=online conn=${conn} sync=${sync} pending=${pending}`,
+    footer: "keys: [tab] move [enter] open [/] command [q] quit",
   };
 }
 
-function buildNavigationSections(tick: number, params: StrictWorkloadParams): StrictSections {
+function buildNavigationSections(
+  tick: number,
+  params: StrictWorkloadParams,
+  rng: FrameRand,
+): StrictSections {
   const rows = strictRows(params);
   const cols = strictCols(params);
   const services = strictServices(params);
   const dwell = strictDwell(params);
   const pages = strictPages();
-  const page = pages[Math.floor(tick / dwell) % pages.length] ?? "dashboard";
+  const page = pages[safeMod(Math.trunc(tick / dwell), pages.length)] ?? "dashboard";
   const bodyRows = Math.max(4, rows - 5);
-  const leftRows = bodyRows - 1;
-  const centerRows = bodyRows - 1;
-  const rightRows = bodyRows - 1;
-
-  let centerLines: readonly string[] = [];
-  if (page === "dashboard") {
-    centerLines = serviceTableLines(services, tick, centerRows);
-  } else if (page === "services") {
-    centerLines = serviceTableLines(services, tick, centerRows);
-  } else if (page === "deployments") {
-    centerLines = deploymentLines(tick, centerRows);
-  } else if (page === "incidents") {
-    centerLines = incidentLines(tick, centerRows);
-  } else if (page === "logs") {
-    centerLines = logLines(tick, centerRows);
-  } else {
-    centerLines = commandLines(services, tick, centerRows);
-  }
+  const paneRows = Math.max(1, bodyRows - 1);
+
+  const center = centerLines(page, services, tick, rng, paneRows);
+  const navLatency = 1 + rng.intn(9);
+  const commit = rng.intn(10000);
+  const pending = rng.intn(33);
 
   return {
     rows,
     cols,
-    header: `terminal-strict-ui-navigation page=${page} tick=${tick} local=${tick % dwell}/${dwell - 1}`,
+    header: `terminal-strict-ui-navigation page=${page} tick=${tick} local=${safeMod(tick, dwell)}/${dwell - 1}`,
     leftTitle: "NAVIGATION",
-    leftLines: fitLines(navLines(page, tick), leftRows),
+    leftLines: fitLines(navLines(page, tick, rng), paneRows),
     centerTitle: page.toUpperCase(),
-    centerLines: fitLines(centerLines, centerRows),
+    centerLines: fitLines(center, paneRows),
     rightTitle: "DETAILS",
-    rightLines: fitLines(rightPanelLines(page, tick, rightRows), rightRows),
-    status: `route=${page} navLatency=${1 + ((tick * 7) % 9)}ms commit=${(tick * 97) % 10000} pending=${(tick * 13) % 33}`,
-    footer: "flow: [tab] next-page  [shift+tab] prev-page  [enter] open  [esc] close",
+    rightLines: fitLines(rightPanelLines(page, tick, rng, paneRows), paneRows),
+    status: `route=${page} navLatency=${navLatency}ms commit=${commit} pending=${pending}`,
+    footer: "flow: [tab] next-page [shift+tab] prev-page [enter] open [esc] close",
   };
 }
 
@@ -251,60 +285,168 @@ export function buildStrictSections(
   tick: number,
   params: StrictWorkloadParams,
   variant: StrictVariant,
+  rng: FrameRand,
 ): StrictSections {
   return variant === "navigation"
-    ? buildNavigationSections(tick, params)
-    : buildDashboardSections(tick, params);
+    ? buildNavigationSections(tick, params, rng)
+    : buildDashboardSections(tick, params, rng);
 }
 
-function strictPaneWidths(cols: number): Readonly<{ left: number; center: number; right: number }> {
-  const left = 24;
-  const right = 32;
-  const center = Math.max(28, cols - left - right - 6);
-  return { left, center, right };
+/**
+ * cellbuf.Wrap for plain text, as lipgloss wraps a block given a Width: at
+ * spaces and after hyphens where it can, mid-word where it must.
+ */
+function wrapText(s: string, limit: number): string {
+  if (s === "" || limit < 1) return s;
+  let buf = "";
+  let word = "";
+  let space = "";
+  let curWidth = 0;
+  let wordLen = 0;
+  const addSpace = (): void => {
+    curWidth += space.length;
+    buf += space;
+    space = "";
+  };
+  const addWord = (): void => {
+    if (word === "") return;
+    addSpace();
+    curWidth += wordLen;
+    buf += word;
+    word = "";
+    wordLen = 0;
+  };
+  const flushSpace = (): void => {
+    if (wordLen === 0) {
+      if (curWidth + space.length > limit) curWidth = 0;
+      else buf += space;
+      space = "";
+    }
+  };
+
+  for (const ch of s) {
+    if (ch === "\n") {
+      flushSpace();
+      addWord();
+      buf += "\n";
+      curWidth = 0;
+      space = "";
+      continue;
+    }
+    if (ch === " " || ch === "\v" || ch === "\f" || ch === "\r") {
+      addWord();
+      space += ch;
+      continue;
+    }
+    if (ch === "-") {
+      addSpace();
+      if (curWidth + wordLen + 1 <= limit) {
+        addWord();
+        buf += ch;
+        curWidth += 1;
+        continue;
+      }
+    }
+    if (wordLen + 1 > limit) addWord();
+    word += ch;
+    wordLen += 1;
+    if (curWidth + wordLen + space.length > limit) {
+      buf += "\n";
+      curWidth = 0;
+      space = "";
+    }
+  }
+  flushSpace();
+  addWord();
+  return buf;
 }
 
-function paneLine(
-  cols: number,
-  widths: Readonly<{ left: number; center: number; right: number }>,
-  left: string,
-  center: string,
-  right: string,
-): string {
-  return clipPad(
-    `${clipPad(left, widths.left)} | ${clipPad(center, widths.center)} | ${clipPad(right, widths.right)}`,
-    cols,
-  );
+/**
+ * A lipgloss block with a NormalBorder, Width and Height: content wrapped to
+ * width, padded to at least height rows and to its widest row, then boxed.
+ */
+function borderedBlock(content: string, width: number, height: number): string[] {
+  const wrapped = wrapText(content.replaceAll("\t", "    ").replaceAll("\r\n", "\n"), width);
+  const lines = wrapped.split("\n");
+  while (lines.length < height) lines.push("");
+  const inner = Math.max(width, ...lines.map((line) => Array.from(line).length));
+  const edge = "─".repeat(inner);
+  return [
+    `┌${edge}┐`,
+    ...lines.map((line) => `│${line}${" ".repeat(inner - Array.from(line).length)}│`),
+    `└${edge}┘`,
+  ];
 }
 
-export function buildStrictPaneLines(
-  tick: number,
-  params: StrictWorkloadParams,
-  variant: StrictVariant,
-): readonly string[] {
-  const sections = buildStrictSections(tick, params, variant);
-  const rows = sections.rows;
-  const cols = sections.cols;
-  const widths = strictPaneWidths(cols);
-  const bodyRows = Math.max(1, rows - 4);
-  const lines: string[] = [];
+function strictPanelBlock(
+  title: string,
+  lines: readonly string[],
+  width: number,
+  height: number,
+): string[] {
+  const innerRows = Math.max(1, height - 2);
+  const content = fitLines([clipPad(title, Math.max(1, width - 2)), ...lines], innerRows);
+  return borderedBlock(content.join("\n"), width, height);
+}
 
-  lines.push(clipPad(sections.header, cols));
-  lines.push(paneLine(cols, widths, sections.leftTitle, sections.centerTitle, sections.rightTitle));
+/** lipgloss.JoinHorizontal(lipgloss.Top, ...): blocks side by side. */
+function joinHorizontal(blocks: readonly (readonly string[])[]): string[] {
+  const height = Math.max(...blocks.map((block) => block.length));
+  const widths = blocks.map((block) => Math.max(...block.map((line) => Array.from(line).length)));
+  const out: string[] = [];
+  for (let i = 0; i < height; i++) {
+    let line = "";
+    blocks.forEach((block, j) => {
+      const cell = block[i] ?? "";
+      line += cell + " ".repeat((widths[j] ?? 0) - Array.from(cell).length);
+    });
+    out.push(line);
+  }
+  return out;
+}
 
-  for (let i = 0; i < bodyRows; i++) {
-    lines.push(
-      paneLine(
-        cols,
-        widths,
-        sections.leftLines[i] ?? "",
-        sections.centerLines[i] ?? "",
-        sections.rightLines[i] ?? "",
-      ),
-    );
+/**
+ * The terminal-strict-ui frame of sections as the Go harness lays it out with
+ * lipgloss (strictFrameLines): bordered header, three bordered panels and a
+ * bordered footer, clipped to the screen.
+ */
+export function strictFrameLines(sections: StrictSections): string[] {
+  const headerHeight = 3;
+  const footerHeight = 4;
+  const bodyHeight = Math.max(3, sections.rows - headerHeight - footerHeight);
+  const leftWidth = 24;
+  const rightWidth = 32;
+  let centerWidth = Math.max(28, sections.cols - leftWidth - rightWidth);
+  const totalWidth = leftWidth + centerWidth + rightWidth;
+  if (totalWidth !== sections.cols) {
+    centerWidth = Math.max(12, centerWidth + sections.cols - totalWidth);
   }
+  const inner = Math.max(1, sections.cols - 2);
+
+  const raw = [
+    ...borderedBlock(clipPad(sections.header, inner), sections.cols, headerHeight),
+    ...joinHorizontal([
+      strictPanelBlock(sections.leftTitle, sections.leftLines, leftWidth, bodyHeight),
+      strictPanelBlock(sections.centerTitle, sections.centerLines, centerWidth, bodyHeight),
+      strictPanelBlock(sections.rightTitle, sections.rightLines, rightWidth, bodyHeight),
+    ]),
+    ...borderedBlock(
+      `${clipPad(sections.status, inner)}\n${clipPad(sections.footer, inner)}`,
+      sections.cols,
+      footerHeight,
+    ),
+  ];
+  const lines: string[] = [];
+  for (let i = 0; i < sections.rows; i++) lines.push(clipPad(raw[i] ?? "", sections.cols));
+  return lines;
+}
 
-  lines.push(clipPad(sections.status, cols));
-  lines.push(clipPad(sections.footer, cols));
-  return lines.slice(0, rows);
+/** The terminal-strict-ui frame at tick, as the Go generator returns it. */
+export function buildStrictFrameLines(
+  tick: number,
+  params: StrictWorkloadParams,
+  variant: StrictVariant,
+  rng: FrameRand,
+): string[] {
+  return strictFrameLines(buildStrictSections(tick, params, variant, rng));
 }
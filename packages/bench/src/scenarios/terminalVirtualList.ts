@@ -15,6 +15,8 @@ import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import { emitReziPerfSnapshot, resetReziPerfSnapshot } from "../reziProfile.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { FrameRand } from "./frameRand.js";
+import { terminalVirtualListRow } from "./terminalWorkloads.js";
 
 type BlessedElement = Readonly<{ setContent: (s: string) => void }>;
 type BlessedScreen = Readonly<{
@@ -33,7 +35,13 @@ async function loadBlessed(): Promise<BlessedModule> {
   return (mod.default ?? mod) as BlessedModule;
 }
 
-function reziTree(totalItems: number, viewport: number, offset: number, tick: number): VNode {
+function reziTree(
+  totalItems: number,
+  viewport: number,
+  offset: number,
+  tick: number,
+  rng: FrameRand,
+): VNode {
   const rows: VNode[] = [];
   const end = Math.min(totalItems, offset + viewport);
   for (let i = offset; i < end; i++) {
@@ -43,7 +51,7 @@ function reziTree(totalItems: number, viewport: number, offset: number, tick: nu
         ui.text(String(i).padStart(6, " ")),
         ui.text("•", { style: { dim: true } }),
         ui.text(`Item ${i}`),
-        ui.text(`v=${(tick + i * 97) % 1000}`, { style: { dim: true } }),
+        ui.text(`v=${rng.intn(1000)}`, { style: { dim: true } }),
         active ? ui.text(" <") : ui.text(""),
       ]),
     );
@@ -64,6 +72,7 @@ function reactTree(
   viewport: number,
   offset: number,
   tick: number,
+  rng: FrameRand,
 ): import("react").ReactNode {
   const h = ReactMod.createElement;
   const rows: import("react").ReactNode[] = [];
@@ -77,7 +86,7 @@ function reactTree(
         h(C.Text as string, null, String(i).padStart(6, " ")),
         h(C.Text as string, { dimColor: true }, "•"),
         h(C.Text as string, null, `Item ${i}`),
-        h(C.Text as string, { dimColor: true }, `v=${(tick + i * 97) % 1000}`),
+        h(C.Text as string, { dimColor: true }, `v=${rng.intn(1000)}`),
         h(C.Text as string, null, active ? " <" : ""),
       ),
     );
@@ -106,7 +115,8 @@ async function runRezi(
 
   type State = { offset: number; tick: number };
   const app = createApp<State>({ backend, initialState: { offset: 0, tick: 0 } });
-  app.view((s) => reziTree(totalItems, viewport, s.offset, s.tick));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(totalItems, viewport, s.offset, s.tick, new FrameRand(seed, s.tick)));
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -191,16 +201,16 @@ async function runBlessed(
     screen.append(t);
   }
 
+  const seed = config.seed ?? 0;
   const renderTick = async (offset: number, tick: number): Promise<void> => {
     const renderP = new Promise<void>((resolve) => screen.once("render", () => resolve()));
     header1.setContent(`total=${totalItems} viewport=${viewport} offset=${offset} tick=${tick}`);
 
+    const rng = new FrameRand(seed, tick);
     const active = tick % viewport;
     for (let r = 0; r < viewport; r++) {
       const i = offset + r;
-      lines[r]?.setContent(
-        `${String(i).padStart(6, " ")} • Item ${i} v=${(tick + i * 97) % 1000}${r === active ? " <" : ""}`,
-      );
+      lines[r]?.setContent(terminalVirtualListRow(i, rng.intn(1000), r === active));
       // Blessed style mutations are expensive; keep it to content only.
     }
 
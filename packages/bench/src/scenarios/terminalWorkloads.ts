@@ -1,26 +1,32 @@
-function clipPad(s: string, cols: number): string {
-  if (s.length >= cols) return s.slice(0, cols);
-  return `${s}${" ".repeat(cols - s.length)}`;
-}
-
-function bar(value: number, width: number): string {
-  const filled = Math.max(0, Math.min(width, Math.round(value * width)));
-  return `${"#".repeat(filled)}${"-".repeat(width - filled)}`;
-}
+/**
+ * Line content of the terminal suite's line-based scenarios.
+ *
+ * Each builder is a port of its Go counterpart in
+ * bubbletea-bench/harness/scenarios.go and draws from the tick's FrameRand in
+ * the same order, so both harnesses render the same frame for a --seed.
+ */
+
+import type { FrameRand } from "./frameRand.js";
+import {
+  type WorkloadParam,
+  bar,
+  clipPad,
+  numberParam,
+  padNum,
+  safeMod,
+  spark,
+} from "./frameText.js";
 
-type WorkloadParam = number | string;
 type TerminalWorkloadParams = Readonly<{
   rows?: WorkloadParam;
   cols?: WorkloadParam;
   channels?: WorkloadParam;
   services?: WorkloadParam;
   dwell?: WorkloadParam;
+  zoneWidth?: WorkloadParam;
+  moves?: WorkloadParam;
 }>;
 
-function numberParam(value: WorkloadParam | undefined, fallback: number): number {
-  return Number(value ?? fallback);
-}
-
 type PaneWidths = Readonly<{
   left: number;
   center: number;
@@ -47,30 +53,72 @@ function paneLine(
   );
 }
 
-function spark(seed: number, width: number): string {
-  let out = "";
-  for (let i = 0; i < width; i++) {
-    out += (seed + i * 3) % 7 > 2 ? "#" : ".";
+/** A terminal-frame-fill row that changes every tick. */
+export function makeLineContent(row: number, tick: number, rng: FrameRand, cols: number): string {
+  const v = rng.uint32();
+  return clipPad(`row=${padNum(row, 2, true)} tick=${tick} v=${v.toString(16)}`, cols);
+}
+
+/** A terminal-frame-fill row that never changes. */
+export function makeStaticLine(row: number, cols: number): string {
+  return clipPad(`row=${padNum(row, 2, true)} static`, cols);
+}
+
+export function buildTerminalFrameFillLines(
+  tick: number,
+  params: Readonly<{ rows?: WorkloadParam; dirtyLines?: WorkloadParam }>,
+  rng: FrameRand,
+  cols: number,
+): readonly string[] {
+  const rows = numberParam(params.rows, 40);
+  const dirtyLines = numberParam(params.dirtyLines, 1);
+  const lines: string[] = [];
+  for (let r = 0; r < rows; r++) {
+    lines.push(r < dirtyLines ? makeLineContent(r, tick, rng, cols) : makeStaticLine(r, cols));
   }
-  return out;
+  return lines;
+}
+
+/** One terminal-virtual-list row; value is the row's draw from the tick's rng. */
+export function terminalVirtualListRow(index: number, value: number, active: boolean): string {
+  return `${padNum(index, 6)} • Item ${index} v=${value}${active ? " <" : ""}`;
+}
+
+export function buildTerminalVirtualListLines(
+  totalItems: number,
+  viewport: number,
+  tick: number,
+  rng: FrameRand,
+  cols: number,
+): readonly string[] {
+  const offset = safeMod(tick, totalItems - viewport);
+  const end = Math.min(totalItems, offset + viewport);
+  const lines = [
+    clipPad("terminal-virtual-list", cols),
+    clipPad(`total=${totalItems} viewport=${viewport} offset=${offset} tick=${tick}`, cols),
+  ];
+  for (let i = offset; i < end; i++) {
+    const active = i === offset + safeMod(tick, viewport);
+    lines.push(clipPad(terminalVirtualListRow(i, rng.intn(1000), active), cols));
+  }
+  return lines;
 }
 
 export function buildTerminalScreenTransitionLines(
   tick: number,
   params: TerminalWorkloadParams,
+  rng: FrameRand,
 ): readonly string[] {
   const rows = numberParam(params.rows, 40);
   const cols = numberParam(params.cols, 120);
-  const mode = tick % 3;
+  const mode = safeMod(tick, 3);
   const lines: string[] = [];
 
   if (mode === 0) {
     lines.push(clipPad("terminal-screen-transition [dashboard]", cols));
     for (let i = 0; i < rows - 1; i++) {
-      const v = ((tick * 37 + i * 97) % 1000) / 1000;
-      lines.push(
-        clipPad(`svc-${String(i).padStart(2, "0")} ${bar(v, 24)} ${(v * 100).toFixed(1)}%`, cols),
-      );
+      const v = rng.intn(1000) / 1000;
+      lines.push(clipPad(`svc-${padNum(i, 2, true)} ${bar(v, 24)} ${(v * 100).toFixed(1)}%`, cols));
     }
     return lines;
   }
@@ -79,13 +127,13 @@ export function buildTerminalScreenTransitionLines(
     lines.push(clipPad("terminal-screen-transition [table]", cols));
     lines.push(clipPad("ID        NAME                 STATE     LAT(ms)   ERR", cols));
     for (let i = 0; i < rows - 2; i++) {
-      const id = `node-${String((tick + i) % 512).padStart(3, "0")}`;
-      const state = (tick + i) % 7 === 0 ? "degraded" : "healthy ";
-      const lat = 10 + ((tick * 13 + i * 7) % 190);
-      const err = (tick + i * 3) % 53 === 0 ? "yes" : "no ";
+      const id = `node-${padNum(safeMod(tick + i, 512), 3, true)}`;
+      const state = safeMod(tick + i, 7) === 0 ? "degraded" : "healthy ";
+      const lat = 10 + rng.intn(190);
+      const err = rng.intn(53) === 0 ? "yes" : "no ";
       lines.push(
         clipPad(
-          `${id}   backend-${String(i).padStart(2, "0")}        ${state}     ${String(lat).padStart(3, " ")}      ${err}`,
+          `${id.padEnd(8, " ")} service-${padNum(i, 3, true)}        ${state.padEnd(8, " ")} ${padNum(lat, 7)}   ${err}`,
           cols,
         ),
       );
@@ -95,11 +143,11 @@ export function buildTerminalScreenTransitionLines(
 
   lines.push(clipPad("terminal-screen-transition [logs]", cols));
   for (let i = 0; i < rows - 1; i++) {
-    const seq = tick * rows + i;
-    const lvl = seq % 11 === 0 ? "WARN" : seq % 23 === 0 ? "ERROR" : "INFO ";
+    const level = ["INFO", "WARN", "ERROR"][safeMod(tick + i, 3)];
+    const code = rng.intn(10_000);
     lines.push(
       clipPad(
-        `${lvl} ${new Date(1700000000000 + seq * 17).toISOString()} service=${seq % 17} msg=transition-${seq}`,
+        `${level} t=${tick} i=${i} code=${padNum(code, 4, true)} message=frame-transition`,
         cols,
       ),
     );
@@ -110,53 +158,53 @@ export function buildTerminalScreenTransitionLines(
 export function buildTerminalFpsStreamLines(
   tick: number,
   params: TerminalWorkloadParams,
+  rng: FrameRand,
 ): readonly string[] {
   const rows = numberParam(params.rows, 40);
   const cols = numberParam(params.cols, 120);
   const channels = numberParam(params.channels, 12);
-
   const lines: string[] = [];
-  lines.push(clipPad(`terminal-fps-stream tick=${tick} target=60fps channels=${channels}`, cols));
-  lines.push(clipPad("Channel  Value      Trend", cols));
 
-  const bodyRows = Math.max(1, rows - 2);
-  for (let i = 0; i < bodyRows; i++) {
-    const ch = i % channels;
-    const v = ((tick * (17 + ch) + i * 31) % 1000) / 1000;
-    const trendSeed = (tick + i * 13 + ch * 11) % 16;
-    const trend = Array.from({ length: 16 }, (_, j) =>
-      ((trendSeed + j * 3) % 16) / 15 < v ? "▮" : "▯",
-    ).join("");
+  lines.push(clipPad(`terminal-fps-stream tick=${tick} target=60fps channels=${channels}`, cols));
+  for (let i = 0; i < channels && lines.length < rows; i++) {
+    const base = 0.5 + 0.45 * Math.sin((tick + i * 7) / 8);
+    const spike = rng.intn(23) === 0 ? 0.4 : 0;
+    const value = Math.min(1, base + spike);
     lines.push(
       clipPad(
-        `ch-${String(ch).padStart(2, "0")}    ${(v * 100).toFixed(2).padStart(6, " ")}%    ${trend}`,
+        `ch-${padNum(i, 2, true)} ${bar(value, 24)} ${(value * 100).toFixed(1).padStart(5, " ")}%`,
         cols,
       ),
     );
   }
+
+  while (lines.length < rows) {
+    let digits = "";
+    for (let j = 0; j < 16; j++) {
+      digits += String(safeMod(tick * 3 + lines.length * 7 + j * 11, 10));
+    }
+    lines.push(clipPad(digits, cols));
+  }
   return lines;
 }
 
 export function buildTerminalInputLatencyLines(
   tick: number,
   params: TerminalWorkloadParams,
+  rng: FrameRand,
 ): readonly string[] {
   const rows = numberParam(params.rows, 40);
   const cols = numberParam(params.cols, 120);
   const lines: string[] = [];
 
   lines.push(clipPad("terminal-input-latency synthetic-key-event -> frame", cols));
-  lines.push(
-    clipPad(
-      `tick=${tick} active=${tick % 16} token=${((tick * 1103515245) >>> 0).toString(16)}`,
-      cols,
-    ),
-  );
-  for (let i = 0; i < rows - 2; i++) {
-    const active = i === tick % Math.max(1, rows - 2);
+  for (let i = 0; i < rows - 1; i++) {
+    const latencyMs = 1 + rng.intn(20);
+    const queueDepth = rng.intn(9);
+    const focus = safeMod(tick + i, 4) === 0 ? "focused" : "blurred";
     lines.push(
       clipPad(
-        `${active ? ">" : " "} command-${String(i).padStart(2, "0")}  value=${(tick + i * 9) % 10000}`,
+        `evt=${padNum(tick * rows + i, 4, true)} key=${focus} latency=${padNum(latencyMs, 2)}ms queue=${queueDepth}`,
         cols,
       ),
     );
@@ -167,6 +215,7 @@ export function buildTerminalInputLatencyLines(
 export function buildTerminalMemorySoakLines(
   tick: number,
   params: TerminalWorkloadParams,
+  rng: FrameRand,
 ): readonly string[] {
   const rows = numberParam(params.rows, 40);
   const cols = numberParam(params.cols, 120);
@@ -174,9 +223,15 @@ export function buildTerminalMemorySoakLines(
 
   lines.push(clipPad(`terminal-memory-soak tick=${tick}`, cols));
   for (let i = 0; i < rows - 1; i++) {
-    const id = (tick * 7 + i * 19) % 100000;
-    const payload = `${String(id).padStart(5, "0")} ${"x".repeat((i % 7) + 8)} ${(tick + i) % 997}`;
-    lines.push(clipPad(payload, cols));
+    const size = 32 + rng.intn(512);
+    const ref = rng.intn(97);
+    const checksum = (tick * rows + i).toString(16).padStart(8, "0");
+    lines.push(
+      clipPad(
+        `pool[${padNum(i, 2, true)}] size=${padNum(size, 4)}KiB refs=${padNum(ref, 2)} checksum=${checksum}`,
+        cols,
+      ),
+    );
   }
   return lines;
 }
@@ -184,13 +239,14 @@ export function buildTerminalMemorySoakLines(
 export function buildTerminalFullUiLines(
   tick: number,
   params: TerminalWorkloadParams,
+  rng: FrameRand,
 ): readonly string[] {
   const rows = Math.max(12, numberParam(params.rows, 40));
   const cols = Math.max(80, numberParam(params.cols, 120));
   const services = Math.max(12, numberParam(params.services, 24));
   const widths = fullUiPaneWidths(cols);
   const modes = ["overview", "services", "deploy", "incidents"] as const;
-  const mode = modes[tick % modes.length];
+  const mode = modes[safeMod(tick, modes.length)];
   const navItems = [
     "Dashboard",
     "Services",
@@ -204,18 +260,21 @@ export function buildTerminalFullUiLines(
 
   const lines: string[] = [];
   lines.push(clipPad(`terminal-full-ui mode=${mode} tick=${tick}`, cols));
+  const cpuPct = 35 + rng.intn(40);
+  const memPct = 42 + rng.intn(49);
+  const qps = 900 + rng.intn(1500);
   lines.push(
     clipPad(
-      `cluster=prod-us-east budget=16.6ms cpu=${35 + ((tick * 7) % 40)}% mem=${42 + ((tick * 11) % 49)}% qps=${900 + ((tick * 29) % 1500)}`,
+      `cluster=prod-us-east budget=16.6ms cpu=${cpuPct}% mem=${memPct}% qps=${qps}`,
       cols,
     ),
   );
 
   const bodyRows = Math.max(1, rows - 4);
-  const activeNav = tick % navItems.length;
+  const activeNav = safeMod(tick, navItems.length);
   const visibleTableRows = Math.max(6, Math.min(18, bodyRows - 6));
-  const viewportOffset = tick % Math.max(1, services - visibleTableRows + 1);
-  const activeSvc = tick % services;
+  const viewportOffset = safeMod(tick, Math.max(1, services - visibleTableRows + 1));
+  const activeSvc = safeMod(tick, services);
 
   for (let r = 0; r < bodyRows; r++) {
     let left = "";
@@ -227,52 +286,58 @@ export function buildTerminalFullUiLines(
       const idx = r - 1;
       left = `${idx === activeNav ? ">" : " "} ${navItems[idx]}`;
     } else if (r === navItems.length + 1) {
-      left = `env=${["prod", "stage", "dev"][tick % 3]} region=${["use1", "usw2", "euw1"][tick % 3]}`;
+      left = `env=${["prod", "stage", "dev"][safeMod(tick, 3)]} region=${["use1", "usw2", "euw1"][safeMod(tick, 3)]}`;
     } else if (r === navItems.length + 2) {
-      left = `focus=svc-${String(activeSvc).padStart(3, "0")} alerts=${(tick * 3) % 19}`;
+      left = `focus=svc-${padNum(activeSvc, 3, true)} alerts=${rng.intn(19)}`;
     } else {
-      left = `saved-view-${String((tick + r) % 12).padStart(2, "0")} ${spark(tick + r, 10)}`;
+      left = `saved-view-${padNum(safeMod(tick + r, 12), 2, true)} ${spark(tick + r, 10)}`;
     }
 
     if (r === 0) center = "SERVICES";
     else if (r === 1) center = "id      state      lat   rps   err";
     else if (r >= 2 && r < 2 + visibleTableRows) {
       const svc = viewportOffset + (r - 2);
-      const degraded = (tick + svc * 5) % 17 === 0;
-      const lat = 12 + ((tick * 13 + svc * 7) % 180);
-      const rps = 100 + ((tick * 19 + svc * 37) % 2500);
-      const err = ((tick + svc * 11) % 70) / 10;
-      center = `${svc === activeSvc ? ">" : " "} svc-${String(svc).padStart(3, "0")} ${degraded ? "degraded" : "healthy "} ${String(lat).padStart(3, " ")}ms ${String(rps).padStart(4, " ")} ${err.toFixed(1)}%`;
+      const degraded = rng.intn(17) === 0;
+      const lat = 12 + rng.intn(180);
+      const rps = 100 + rng.intn(2500);
+      const err = rng.intn(70) / 10;
+      center = `${svc === activeSvc ? ">" : " "} svc-${padNum(svc, 3, true)} ${degraded ? "degraded" : "healthy "} ${padNum(lat, 3)}ms ${padNum(rps, 4)} ${err.toFixed(1)}%`;
     } else if (r === 2 + visibleTableRows) {
-      const cpu = ((tick * 17) % 1000) / 1000;
-      center = `cpu ${bar(cpu, 20)} ${(cpu * 100).toFixed(1)}%  io ${(45 + ((tick * 23) % 50)).toString().padStart(2, " ")}%`;
+      const cpu = rng.intn(1000) / 1000;
+      center = `cpu ${bar(cpu, 20)} ${(cpu * 100).toFixed(1)}%  io ${padNum(45 + rng.intn(50), 2)}%`;
     } else if (r === 3 + visibleTableRows) {
-      const mem = ((tick * 31 + 211) % 1000) / 1000;
-      center = `mem ${bar(mem, 20)} ${(mem * 100).toFixed(1)}%  gc ${(tick * 97) % 999}ms`;
+      const mem = rng.intn(1000) / 1000;
+      center = `mem ${bar(mem, 20)} ${(mem * 100).toFixed(1)}%  gc ${rng.intn(999)}ms`;
     } else if (r === 4 + visibleTableRows) {
-      center = `queue depth=${(tick * 7) % 180} retries=${(tick * 11) % 37} dropped=${(tick * 13) % 9}`;
+      const depth = rng.intn(180);
+      const retries = rng.intn(37);
+      center = `queue depth=${depth} retries=${retries} dropped=${rng.intn(9)}`;
     } else {
-      center = `timeline ${spark(tick * 3 + r, Math.max(16, widths.center - 10))}`;
+      center = `timeline ${spark(rng.intn(7), Math.max(16, widths.center - 10))}`;
     }
 
     if (r === 0) right = "INSPECTOR";
     else if (r === 1)
-      right = `service=svc-${String(activeSvc).padStart(3, "0")} owner=team-${activeSvc % 7}`;
-    else if (r === 2) right = `slo p95<120ms  now=${45 + ((tick * 5 + activeSvc * 3) % 110)}ms`;
-    else if (r === 3)
-      right = `deploy=${(tick * 3 + activeSvc) % 2 === 0 ? "green" : "canary"} zone=az-${(activeSvc % 3) + 1}`;
-    else {
+      right = `service=svc-${padNum(activeSvc, 3, true)} owner=team-${safeMod(activeSvc, 7)}`;
+    else if (r === 2) right = `slo p95<120ms  now=${45 + rng.intn(110)}ms`;
+    else if (r === 3) {
+      const deploy = rng.intn(2) === 0 ? "green" : "canary";
+      right = `deploy=${deploy} zone=az-${safeMod(activeSvc, 3) + 1}`;
+    } else {
       const seq = tick * bodyRows + r;
-      const lvl = seq % 19 === 0 ? "ERROR" : seq % 11 === 0 ? "WARN " : "INFO ";
-      right = `${lvl} t+${String(seq).padStart(5, "0")} op=${String((seq * 7) % 97).padStart(2, "0")} msg=event-${seq}`;
+      const level = safeMod(seq, 19) === 0 ? "ERROR" : safeMod(seq, 11) === 0 ? "WARN " : "INFO ";
+      right = `${level} t+${padNum(seq, 5, true)} op=${padNum(safeMod(seq * 7, 97), 2, true)} msg=event-${seq}`;
     }
 
     lines.push(paneLine(cols, widths, left, center, right));
   }
 
+  const conn = 1200 + rng.intn(800);
+  const sync = rng.intn(9999);
+  const pending = rng.intn(48);
   lines.push(
     clipPad(
-      `status=online conn=${1200 + ((tick * 17) % 800)} sync=${(tick * 29) % 9999} pending=${(tick * 5) % 48} diff=${(tick * 7) % 21}`,
+      `status=online conn=${conn} sync=${sync} pending=${pending} diff=${rng.intn(21)}`,
       cols,
     ),
   );
@@ -282,22 +347,22 @@ export function buildTerminalFullUiLines(
       cols,
     ),
   );
-
   return lines.slice(0, rows);
 }
 
 export function buildTerminalFullUiNavigationLines(
   tick: number,
   params: TerminalWorkloadParams,
+  rng: FrameRand,
 ): readonly string[] {
   const rows = Math.max(12, numberParam(params.rows, 40));
   const cols = Math.max(80, numberParam(params.cols, 120));
   const services = Math.max(10, numberParam(params.services, 24));
   const dwell = Math.max(2, numberParam(params.dwell, 8));
   const pages = ["overview", "services", "deployments", "incidents", "logs", "command"] as const;
-  const pageIndex = Math.floor(tick / dwell) % pages.length;
+  const pageIndex = safeMod(Math.trunc(tick / dwell), pages.length);
   const page = pages[pageIndex];
-  const localTick = tick % dwell;
+  const localTick = safeMod(tick, dwell);
 
   const lines: string[] = [];
   lines.push(
@@ -307,10 +372,7 @@ export function buildTerminalFullUiNavigationLines(
     ),
   );
   lines.push(
-    clipPad(
-      `tabs: ${pages.map((p, i) => `${i === pageIndex ? "[" : ""}${p}${i === pageIndex ? "]" : ""}`).join(" | ")}`,
-      cols,
-    ),
+    clipPad(`tabs: ${pages.map((p, i) => (i === pageIndex ? `[${p}]` : p)).join(" | ")}`, cols),
   );
 
   const bodyRows = Math.max(1, rows - 4);
@@ -321,63 +383,74 @@ export function buildTerminalFullUiNavigationLines(
       if (i === 0) line = "overview: global health + throughput + alerts";
       else if (i <= 8) {
         const svc = i - 1;
-        const healthy = (tick + svc * 5) % 9 !== 0;
-        const v = ((tick * 23 + svc * 41) % 1000) / 1000;
-        line = `card svc-${String(svc).padStart(2, "0")} ${healthy ? "healthy " : "degraded"} ${bar(v, 24)} ${(v * 100).toFixed(1)}%`;
-      } else if (i === 9)
-        line = `alerts open=${(tick * 3) % 11} acked=${(tick * 7) % 17} muted=${(tick * 5) % 5}`;
-      else line = `trend ${spark(tick + i * 3, Math.max(16, cols - 10))}`;
+        const healthy = rng.intn(9) !== 0;
+        const v = rng.intn(1000) / 1000;
+        line = `card svc-${padNum(svc, 2, true)} ${healthy ? "healthy " : "degraded"} ${bar(v, 24)} ${(v * 100).toFixed(1)}%`;
+      } else if (i === 9) {
+        const open = rng.intn(11);
+        const acked = rng.intn(17);
+        line = `alerts open=${open} acked=${acked} muted=${rng.intn(5)}`;
+      } else line = `trend ${spark(tick + i * 3, Math.max(16, cols - 10))}`;
     } else if (page === "services") {
       if (i === 0) line = "services: inventory + selection + per-row telemetry";
       else if (i === 1) line = "id      state      lat   rps   err";
       else {
         const row = i - 2;
-        const svc = (tick + row) % services;
-        const selected = row === tick % Math.max(1, bodyRows - 2);
-        const degraded = (tick + svc * 3) % 15 === 0;
-        const lat = 10 + ((tick * 13 + svc * 9) % 220);
-        const rps = 80 + ((tick * 17 + svc * 31) % 3000);
-        const err = ((tick + svc * 7) % 80) / 10;
-        line = `${selected ? ">" : " "} svc-${String(svc).padStart(3, "0")} ${degraded ? "degraded" : "healthy "} ${String(lat).padStart(3, " ")}ms ${String(rps).padStart(4, " ")} ${err.toFixed(1)}%`;
+        const svc = safeMod(tick + row, services);
+        const selected = row === safeMod(tick, Math.max(1, bodyRows - 2));
+        const degraded = rng.intn(15) === 0;
+        const lat = 10 + rng.intn(220);
+        const rps = 80 + rng.intn(3000);
+        const err = rng.intn(80) / 10;
+        line = `${selected ? ">" : " "} svc-${padNum(svc, 3, true)} ${degraded ? "degraded" : "healthy "} ${padNum(lat, 3)}ms ${padNum(rps, 4)} ${err.toFixed(1)}%`;
       }
     } else if (page === "deployments") {
       if (i === 0) line = "deployments: staged rollout + promotion gates";
       else {
-        const step = i % 12;
-        const pct = (tick * 7 + i * 9) % 101;
-        const gate = (tick + step) % 5 === 0 ? "blocked" : "ready  ";
-        line = `pipeline-${String(step).padStart(2, "0")} ${gate} ${bar(pct / 100, 18)} ${String(pct).padStart(3, " ")}% canary=${(tick + step) % 2 === 0 ? "on" : "off"}`;
+        const step = safeMod(i, 12);
+        const pct = rng.intn(101);
+        const gate = safeMod(tick + step, 5) === 0 ? "blocked" : "ready  ";
+        const canary = safeMod(tick + step, 2) === 0 ? "on" : "off";
+        line = `pipeline-${padNum(step, 2, true)} ${gate} ${bar(pct / 100, 18)} ${padNum(pct, 3)}% canary=${canary}`;
       }
     } else if (page === "incidents") {
       if (i === 0) line = "incidents: queue + assignee + response status";
       else {
         const incident = tick * bodyRows + i;
-        const sev = incident % 13 === 0 ? "sev1" : incident % 7 === 0 ? "sev2" : "sev3";
+        const sev =
+          safeMod(incident, 13) === 0 ? "sev1" : safeMod(incident, 7) === 0 ? "sev2" : "sev3";
         const state =
-          incident % 5 === 0 ? "mitigating" : incident % 3 === 0 ? "triaging  " : "open      ";
-        line = `${sev} inc-${String(incident % 10000).padStart(4, "0")} ${state} owner=oncall-${incident % 9} age=${(incident * 3) % 180}m`;
+          safeMod(incident, 5) === 0
+            ? "mitigating"
+            : safeMod(incident, 3) === 0
+              ? "triaging  "
+              : "open      ";
+        line = `${sev} inc-${padNum(safeMod(incident, 10000), 4, true)} ${state} owner=oncall-${safeMod(incident, 9)} age=${safeMod(incident * 3, 180)}m`;
       }
     } else if (page === "logs") {
       const seq = tick * bodyRows + i;
-      const lvl = seq % 17 === 0 ? "ERROR" : seq % 9 === 0 ? "WARN " : "INFO ";
-      line = `${lvl} trace=${String((seq * 19) % 100000).padStart(5, "0")} shard=${seq % 12} msg=stream-${seq}`;
+      const level = safeMod(seq, 17) === 0 ? "ERROR" : safeMod(seq, 9) === 0 ? "WARN " : "INFO ";
+      line = `${level} trace=${padNum(safeMod(seq * 19, 100000), 5, true)} shard=${safeMod(seq, 12)} msg=stream-${seq}`;
     } else {
       if (i < 2) line = "command palette: type to filter actions";
       else if (i < 10) {
         const cmd = i - 2;
-        const selected = cmd === tick % 8;
-        line = `${selected ? ">" : " "} /command-${String(cmd).padStart(2, "0")} target=svc-${String((tick + cmd) % services).padStart(3, "0")} preview=${(tick + cmd) % 2 === 0 ? "safe" : "risky"}`;
+        const selected = cmd === safeMod(tick, 8);
+        const preview = safeMod(tick + cmd, 2) === 0 ? "safe" : "risky";
+        line = `${selected ? ">" : " "} /command-${padNum(cmd, 2, true)} target=svc-${padNum(safeMod(tick + cmd, services), 3, true)} preview=${preview}`;
       } else {
-        line = `preview: ${spark(tick * 5 + i, Math.max(16, cols - 10))}`;
+        line = `preview: ${spark(rng.intn(7), Math.max(16, cols - 10))}`;
       }
     }
 
     lines.push(clipPad(line, cols));
   }
 
+  const navLatency = 1 + rng.intn(9);
+  const commit = rng.intn(10000);
   lines.push(
     clipPad(
-      `route=${page} navLatency=${1 + ((tick * 7) % 9)}ms commit=${(tick * 97) % 10000} pending=${(tick * 13) % 33}`,
+      `route=${page} navLatency=${navLatency}ms commit=${commit} pending=${rng.intn(33)}`,
       cols,
     ),
   );
@@ -389,3 +462,51 @@ export function buildTerminalFullUiNavigationLines(
   );
   return lines.slice(0, rows);
 }
+
+/**
+ * The terminal-mouse-zones frame: the tick's pointer moves are drawn first,
+ * then the grid is drawn with the cell under the last move that hit one
+ * bracketed.
+ */
+export function buildTerminalMouseZonesLines(
+  tick: number,
+  params: TerminalWorkloadParams,
+  rng: FrameRand,
+): string[] {
+  const rows = numberParam(params.rows, 40);
+  const cols = numberParam(params.cols, 120);
+  const width = numberParam(params.zoneWidth, 10);
+  const moves = numberParam(params.moves, 64);
+  const gridRows = rows - 1;
+  const gridCols = Math.trunc(cols / width);
+
+  let hoverRow = -1;
+  let hoverCol = -1;
+  for (let i = 0; i < moves; i++) {
+    const x = rng.intn(cols);
+    const y = rng.intn(rows);
+    const row = y - 1;
+    const col = Math.trunc(x / width);
+    if (row >= 0 && row < gridRows && col < gridCols) {
+      hoverRow = row;
+      hoverCol = col;
+    }
+  }
+
+  const hovered = hoverRow >= 0 ? `${hoverRow}.${hoverCol}` : "-";
+  const lines = [
+    clipPad(
+      `terminal-mouse-zones tick=${tick} zones=${gridRows * gridCols} moves=${moves} hover=${hovered}`,
+      cols,
+    ),
+  ];
+  for (let r = 0; r < gridRows; r++) {
+    let line = "";
+    for (let c = 0; c < gridCols; c++) {
+      const label = clipPad(`${r}.${c}`, width - 2);
+      line += r === hoverRow && c === hoverCol ? `[${label}]` : ` ${label} `;
+    }
+    lines.push(clipPad(line, cols));
+  }
+  return lines;
+}
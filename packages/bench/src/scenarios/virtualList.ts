@@ -13,8 +13,15 @@ import { runOpenTuiScenario } from "../frameworks/opentui.js";
 import { createBenchBackend } from "../io.js";
 import { benchAsync, tryGc } from "../measure.js";
 import type { BenchMetrics, Framework, Scenario, ScenarioConfig } from "../types.js";
+import { FrameRand } from "./frameRand.js";
 
-function reziTree(totalItems: number, viewport: number, offset: number, tick: number): VNode {
+function reziTree(
+  totalItems: number,
+  viewport: number,
+  offset: number,
+  tick: number,
+  rng: FrameRand,
+): VNode {
   const rows: VNode[] = [];
   const start = offset;
   const end = Math.min(totalItems, offset + viewport);
@@ -24,7 +31,7 @@ function reziTree(totalItems: number, viewport: number, offset: number, tick: nu
         ui.text(String(i).padStart(6, " ")),
         ui.text("•", { style: { dim: true } }),
         ui.text(`Item ${i}`),
-        ui.text(`v=${(tick + i * 97) % 1000}`, { style: { dim: true } }),
+        ui.text(`v=${rng.intn(1000)}`, { style: { dim: true } }),
       ]),
     );
   }
@@ -44,6 +51,7 @@ function reactTree(
   viewport: number,
   offset: number,
   tick: number,
+  rng: FrameRand,
 ): import("react").ReactNode {
   const h = ReactMod.createElement;
   const rows: import("react").ReactNode[] = [];
@@ -57,7 +65,7 @@ function reactTree(
         h(C.Text as string, null, String(i).padStart(6, " ")),
         h(C.Text as string, { dimColor: true }, "•"),
         h(C.Text as string, null, `Item ${i}`),
-        h(C.Text as string, { dimColor: true }, `v=${(tick + i * 97) % 1000}`),
+        h(C.Text as string, { dimColor: true }, `v=${rng.intn(1000)}`),
       ),
     );
   }
@@ -84,7 +92,8 @@ async function runRezi(
 
   type State = { offset: number; tick: number };
   const app = createApp<State>({ backend, initialState: { offset: 0, tick: 0 } });
-  app.view((s) => reziTree(totalItems, viewport, s.offset, s.tick));
+  const seed = config.seed ?? 0;
+  app.view((s) => reziTree(totalItems, viewport, s.offset, s.tick, new FrameRand(seed, s.tick)));
 
   const initial = backend.waitForFrame();
   await app.start();
@@ -155,6 +155,7 @@ export interface BenchInvocation {
   discardFirstReplicate: boolean;
   shuffleFrameworkOrder: boolean;
   shuffleSeed: string;
+  seed: number;
   envCheck: "off" | "warn" | "strict";
   cpuAffinity: string | null;
 }
@@ -172,6 +173,8 @@ export interface ScenarioConfig {
   warmup: number;
   /** Measured iterations */
   iterations: number;
+  /** Seed of the frame content PRNG; 0 when unset, as in the Go harness */
+  seed?: number;
 }
 
 export interface Scenario {
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/terminfo"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{"github.com/gdamore/tcell/v2"},
+		// Each tick is drawn and shown as it arrives, with no frame rate to
+		// cap, so --fps has nothing to set.
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, w)
+			}
+		},
+	})
+}
+
+// benchTerm is the terminfo entry frames are encoded for.
+const benchTerm = "xterm-256color"
+
+// benchTty is the terminal tcell draws to: output goes to the harness
+// Writer, input never arrives and the size is the scenario viewport until
+// Resize changes it.
+type benchTty struct {
+	w *harness.Writer
+
+	mu       sync.Mutex
+	rows     int
+	cols     int
+	onResize func()
+
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+func (t *benchTty) Start() error { return nil }
+func (t *benchTty) Stop() error  { return nil }
+
+// Drain wakes tcell's input loop, blocked in Read, as the screen finishes.
+func (t *benchTty) Drain() error {
+	t.drainOnce.Do(func() { close(t.drained) })
+	return nil
+}
+
+func (t *benchTty) Close() error { return t.Drain() }
+
+func (t *benchTty) Read([]byte) (int, error) {
+	<-t.drained
+	return 0, io.EOF
+}
+
+func (t *benchTty) Write(p []byte) (int, error) { return t.w.Write(p) }
+
+func (t *benchTty) NotifyResize(cb func()) {
+	t.mu.Lock()
+	t.onResize = cb
+	t.mu.Unlock()
+}
+
+func (t *benchTty) WindowSize() (tcell.WindowSize, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return tcell.WindowSize{Width: t.cols, Height: t.rows}, nil
+}
+
+func (t *benchTty) resize(rows int, cols int) {
+	t.mu.Lock()
+	t.rows, t.cols = rows, cols
+	cb := t.onResize
+	t.mu.Unlock()
+	if cb != nil {
+		cb()
+	}
+}
+
+// tickEvent is the payload of the EventInterrupt that delivers a tick;
+// quitEvent ends the loop.
+type (
+	tickEvent struct {
+		tick   int
+		ack    chan struct{}
+		phases *harness.TickPhases
+	}
+	quitEvent struct{}
+)
+
+// benchSession is the hand-written floor: one loop over screen.PollEvent
+// that writes each frame's lines into tcell's cell buffer with SetContent
+// and lets Show send the cells that changed. Nothing sits between the
+// scenario and the buffer.
+type benchSession struct {
+	screen   tcell.Screen
+	tty      *benchTty
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	scenario string
+	params   map[string]string
+	seed     uint64
+	lines    []string
+
+	// updates and views count ticks applied and frames drawn, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+}
+
+// startBenchSession starts the scenario on a tcell screen; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	info, err := terminfo.LookupTerminfo(benchTerm)
+	if err != nil {
+		return nil, fmt.Errorf("tcell: %w", err)
+	}
+	tty := &benchTty{w: writer, rows: rows, cols: cols, drained: make(chan struct{})}
+	screen, err := tcell.NewTerminfoScreenFromTtyTerminfo(tty, info)
+	if err != nil {
+		return nil, fmt.Errorf("tcell: %w", err)
+	}
+	session := &benchSession{
+		screen:   screen,
+		tty:      tty,
+		writer:   writer,
+		done:     make(chan struct{}),
+		timeouts: t,
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		lines:    []string{},
+	}
+	ready := make(chan struct{})
+	go session.loop(ready)
+
+	select {
+	case <-ready:
+		return session, nil
+	case <-session.done:
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for tcell startup after %s", t.Startup))
+	}
+}
+
+// loop runs every update and draw. A panic in either ends it and is
+// reported by the session instead of taking the process down.
+func (s *benchSession) loop(ready chan struct{}) {
+	defer close(s.done)
+	defer func() {
+		if r := recover(); r != nil {
+			s.screen.Fini()
+			s.runErr = harness.NewPanicError(r)
+		}
+	}()
+	if err := s.screen.Init(); err != nil {
+		s.runErr = err
+		return
+	}
+	s.screen.Clear()
+	close(ready)
+	for {
+		switch ev := s.screen.PollEvent().(type) {
+		case nil:
+			return
+		case *tcell.EventResize:
+			s.draw()
+			s.screen.Sync()
+		case *tcell.EventInterrupt:
+			switch e := ev.Data().(type) {
+			case tickEvent:
+				s.render(e)
+			case quitEvent:
+				s.screen.Fini()
+				return
+			}
+		}
+	}
+}
+
+func (s *benchSession) render(e tickEvent) {
+	updateStart := time.Now()
+	cols, _ := s.screen.Size()
+	s.lines = harness.ScenarioLines(s.scenario, s.params, s.seed, e.tick, cols)
+	s.updates.Add(1)
+	if e.phases != nil {
+		e.phases.UpdateMs = harness.MsSince(updateStart)
+	}
+
+	viewStart := time.Now()
+	s.draw()
+	s.views.Add(1)
+	if e.phases != nil {
+		e.phases.ViewMs = harness.MsSince(viewStart)
+		e.phases.ViewEnd = time.Now()
+	}
+	if e.ack != nil {
+		close(e.ack)
+	}
+	s.screen.Show()
+}
+
+// draw writes the lines into the cell buffer, blanking the rest of the
+// screen. Lines past its height cost the top rows, as in the harness's
+// expected screen.
+func (s *benchSession) draw() {
+	cols, rows := s.screen.Size()
+	lines := s.lines
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	for y := 0; y < rows; y++ {
+		x := 0
+		if y < len(lines) {
+			for _, r := range lines[y] {
+				if x >= cols {
+					break
+				}
+				s.screen.SetContent(x, y, r, nil, tcell.StyleDefault)
+				x++
+			}
+		}
+		for ; x < cols; x++ {
+			s.screen.SetContent(x, y, ' ', nil, tcell.StyleDefault)
+		}
+	}
+}
+
+// post delivers data to the loop, or reports that the loop has ended.
+func (s *benchSession) post(data any) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	s.screen.PostEventWait(tcell.NewEventInterrupt(data))
+	return true
+}
+
+func (s *benchSession) exited(what string) error {
+	if s.runErr != nil {
+		return fmt.Errorf("tcell: %w %s: %w", harness.ErrProgramExited, what, s.runErr)
+	}
+	return fmt.Errorf("tcell: %w %s", harness.ErrProgramExited, what)
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	event := tickEvent{tick: tick, ack: ack, phases: phases}
+	if eventLoop {
+		go s.post(event)
+	} else {
+		s.post(event)
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		return harness.TickPhases{}, s.exited(fmt.Sprintf("during render tick=%d", tick))
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("tcell: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack, returning once it is queued.
+func (s *benchSession) SendTick(tick int) error {
+	s.writer.MarkFrame(tick)
+	if !s.post(tickEvent{tick: tick}) {
+		return s.exited(fmt.Sprintf("during tick=%d", tick))
+	}
+	return nil
+}
+
+// Resize changes the terminal size and signals it the way SIGWINCH would;
+// the loop redraws on the EventResize tcell posts.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return s.exited(fmt.Sprintf("before resize to %dx%d", cols, rows))
+	default:
+	}
+	s.tty.resize(rows, cols)
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	go s.post(quitEvent{})
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down tcell after %s", s.timeouts.Shutdown))
+	}
+}
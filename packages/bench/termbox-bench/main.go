@@ -0,0 +1,455 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/creack/pty"
+	"github.com/mattn/go-runewidth"
+	"github.com/nsf/termbox-go"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+// childEnv marks the re-executed process that runs termbox. termbox only
+// draws to /dev/tty, so it runs in a child whose controlling terminal is a
+// pty the harness owns, and everything written to that pty is copied to the
+// harness Writer.
+const childEnv = "TERMBOX_BENCH_CHILD"
+
+// benchTerm is the TERM the child runs under, which picks termbox's escape
+// sequences.
+const benchTerm = "xterm-256color"
+
+func main() {
+	if os.Getenv(childEnv) != "" {
+		os.Exit(runChild())
+	}
+	cli.Main(cli.Framework{
+		Modules: []string{"github.com/nsf/termbox-go"},
+		// Each tick is drawn and flushed as it arrives, with no frame rate
+		// to cap, so --fps has nothing to set.
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, w)
+			}
+		},
+	})
+}
+
+// The harness drives the child with NDJSON requests on fd 3 and reads one
+// report per tick from fd 4:
+//
+//	> {"op":"start","scenario":"content-update","params":{},"seed":42}
+//	< {"ready":true}
+//	> {"op":"tick","tick":7,"ack":true}
+//	< {"tick":7,"ack":true,"update_ms":0.01,"view_ms":0.04}
+//	> {"op":"quit"}
+//
+// A report is sent once the tick's cells are set and before they are
+// flushed, so the flush shows up in the harness as the wait for output.
+// A report may carry "panic" or "error" instead; the child exits after it.
+type childRequest struct {
+	Op       string            `json:"op"`
+	Scenario string            `json:"scenario,omitempty"`
+	Params   map[string]string `json:"params,omitempty"`
+	Seed     uint64            `json:"seed,omitempty"`
+	Tick     int               `json:"tick,omitempty"`
+	Ack      bool              `json:"ack,omitempty"`
+}
+
+type childReport struct {
+	Ready    bool    `json:"ready,omitempty"`
+	Tick     int     `json:"tick,omitempty"`
+	Ack      bool    `json:"ack,omitempty"`
+	UpdateMs float64 `json:"update_ms,omitempty"`
+	ViewMs   float64 `json:"view_ms,omitempty"`
+	Panic    string  `json:"panic,omitempty"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// runChild is the termbox side: it renders ticks as requested until quit or
+// EOF and returns the exit status.
+func runChild() (status int) {
+	requests := json.NewDecoder(bufio.NewReader(os.NewFile(3, "requests")))
+	reports := json.NewEncoder(os.NewFile(4, "reports"))
+
+	var start childRequest
+	if err := requests.Decode(&start); err != nil || start.Op != "start" {
+		reports.Encode(childReport{Error: fmt.Sprintf("expected start request: %v", err)})
+		return 1
+	}
+	if err := termbox.Init(); err != nil {
+		reports.Encode(childReport{Error: err.Error()})
+		return 1
+	}
+	defer termbox.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Fprintf(os.Stderr, "termbox-bench: panic: %v\n%s", r, debug.Stack())
+			reports.Encode(childReport{Panic: fmt.Sprint(r)})
+			status = 2
+		}
+	}()
+
+	ticks := make(chan childRequest)
+	go func() {
+		defer close(ticks)
+		for {
+			var req childRequest
+			if err := requests.Decode(&req); err != nil || req.Op == "quit" {
+				return
+			}
+			ticks <- req
+		}
+	}()
+	resizes := make(chan struct{}, 1)
+	go func() {
+		for {
+			switch termbox.PollEvent().Type {
+			case termbox.EventResize:
+				select {
+				case resizes <- struct{}{}:
+				default:
+				}
+			case termbox.EventInterrupt, termbox.EventError:
+				return
+			}
+		}
+	}()
+
+	if err := reports.Encode(childReport{Ready: true}); err != nil {
+		return 1
+	}
+	var lines []string
+	for {
+		select {
+		case req, ok := <-ticks:
+			if !ok {
+				return 0
+			}
+			updateStart := time.Now()
+			cols, _ := termbox.Size()
+			lines = harness.ScenarioLines(start.Scenario, start.Params, start.Seed, req.Tick, cols)
+			updateMs := harness.MsSince(updateStart)
+
+			viewStart := time.Now()
+			draw(lines)
+			report := childReport{Tick: req.Tick, Ack: req.Ack, UpdateMs: updateMs, ViewMs: harness.MsSince(viewStart)}
+			if err := reports.Encode(report); err != nil {
+				return 1
+			}
+			if err := termbox.Flush(); err != nil {
+				reports.Encode(childReport{Error: err.Error()})
+				return 1
+			}
+		case <-resizes:
+			// The back buffer takes the new size on Clear.
+			termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+			draw(lines)
+			if err := termbox.Flush(); err != nil {
+				reports.Encode(childReport{Error: err.Error()})
+				return 1
+			}
+		}
+	}
+}
+
+// draw writes the lines into termbox's back buffer, blanking the rest of
+// the screen. Lines past its height cost the top rows, as in the harness's
+// expected screen.
+func draw(lines []string) {
+	cols, rows := termbox.Size()
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	for y := 0; y < rows; y++ {
+		x := 0
+		if y < len(lines) {
+			for _, r := range lines[y] {
+				// termbox has no combining cells, so zero-width runes are
+				// dropped; a wide rune that would straddle the edge ends
+				// the line.
+				w := runewidth.RuneWidth(r)
+				if w == 0 {
+					continue
+				}
+				if x+w > cols {
+					break
+				}
+				termbox.SetCell(x, y, r, termbox.ColorDefault, termbox.ColorDefault)
+				x += w
+			}
+		}
+		for ; x < cols; x++ {
+			termbox.SetCell(x, y, ' ', termbox.ColorDefault, termbox.ColorDefault)
+		}
+	}
+}
+
+// benchSession drives the termbox child. It is a harness.ProcessSession,
+// so steady-state runs report the child's CPU and RSS rather than those of
+// this process, which only relays requests and output.
+type benchSession struct {
+	cmd      *exec.Cmd
+	ptm      *os.File
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	scenario string
+	params   map[string]string
+	seed     uint64
+
+	reqMu    sync.Mutex
+	requests *json.Encoder
+	reqPipe  io.Closer
+
+	ready chan struct{}
+	ackMu sync.Mutex
+	acks  map[int]chan childReport
+
+	// updates and views count the ticks the child has reported, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+}
+
+// startBenchSession starts the termbox child on a new pty; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("termbox: %w", err)
+	}
+	ptm, pts, err := pty.Open()
+	if err != nil {
+		return nil, fmt.Errorf("termbox: %w", err)
+	}
+	defer pts.Close()
+	if err := pty.Setsize(ptm, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+		ptm.Close()
+		return nil, fmt.Errorf("termbox: %w", err)
+	}
+	reqRead, reqWrite, err := os.Pipe()
+	if err != nil {
+		ptm.Close()
+		return nil, fmt.Errorf("termbox: %w", err)
+	}
+	repRead, repWrite, err := os.Pipe()
+	if err != nil {
+		ptm.Close()
+		reqRead.Close()
+		reqWrite.Close()
+		return nil, fmt.Errorf("termbox: %w", err)
+	}
+
+	cmd := exec.Command(self)
+	cmd.Env = append(os.Environ(), childEnv+"=1", "TERM="+benchTerm)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = pts, pts, os.Stderr
+	cmd.ExtraFiles = []*os.File{reqRead, repWrite}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	err = cmd.Start()
+	reqRead.Close()
+	repWrite.Close()
+	if err != nil {
+		ptm.Close()
+		reqWrite.Close()
+		repRead.Close()
+		return nil, fmt.Errorf("termbox: %w", err)
+	}
+
+	session := &benchSession{
+		cmd:      cmd,
+		ptm:      ptm,
+		writer:   writer,
+		done:     make(chan struct{}),
+		timeouts: t,
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		requests: json.NewEncoder(reqWrite),
+		reqPipe:  reqWrite,
+		ready:    make(chan struct{}),
+		acks:     map[int]chan childReport{},
+	}
+	copied := make(chan struct{})
+	go func() {
+		// Reads from the master fail with EIO once the child has exited.
+		io.Copy(writer, ptm)
+		close(copied)
+	}()
+	go session.readReports(repRead, copied)
+
+	session.send(childRequest{Op: "start", Scenario: scenario, Params: params, Seed: seed})
+	select {
+	case <-session.ready:
+		return session, nil
+	case <-session.done:
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		cmd.Process.Kill()
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for termbox startup after %s", t.Startup))
+	}
+}
+
+// readReports counts the child's reports and hands acked ones to the
+// waiting RenderTick; once the child exits it records why and closes done.
+func (s *benchSession) readReports(r io.ReadCloser, copied chan struct{}) {
+	defer r.Close()
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var childErr error
+	for {
+		var report childReport
+		if err := dec.Decode(&report); err != nil {
+			break
+		}
+		switch {
+		case report.Panic != "":
+			childErr = harness.NewPanicError(report.Panic)
+		case report.Error != "":
+			childErr = errors.New(report.Error)
+		case report.Ready:
+			close(s.ready)
+		default:
+			s.updates.Add(1)
+			s.views.Add(1)
+			if report.Ack {
+				s.ackMu.Lock()
+				ack := s.acks[report.Tick]
+				delete(s.acks, report.Tick)
+				s.ackMu.Unlock()
+				if ack != nil {
+					ack <- report
+				}
+			}
+		}
+	}
+	waitErr := s.cmd.Wait()
+	<-copied
+	s.ptm.Close()
+	if childErr != nil {
+		s.runErr = childErr
+	} else if waitErr != nil {
+		s.runErr = waitErr
+	}
+	close(s.done)
+}
+
+// send writes req to the child, or reports that the child has exited.
+func (s *benchSession) send(req childRequest) bool {
+	s.reqMu.Lock()
+	defer s.reqMu.Unlock()
+	return s.requests.Encode(req) == nil
+}
+
+func (s *benchSession) exited(what string) error {
+	if s.runErr != nil {
+		return fmt.Errorf("termbox: %w %s: %w", harness.ErrProgramExited, what, s.runErr)
+	}
+	return fmt.Errorf("termbox: %w %s", harness.ErrProgramExited, what)
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan childReport, 1)
+	s.ackMu.Lock()
+	s.acks[tick] = ack
+	s.ackMu.Unlock()
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	req := childRequest{Op: "tick", Tick: tick, Ack: true}
+	if eventLoop {
+		go s.send(req)
+	} else {
+		s.send(req)
+	}
+
+	select {
+	case report := <-ack:
+		viewEnd := time.Now()
+		phases := harness.TickPhases{
+			UpdateMs: report.UpdateMs,
+			ViewMs:   report.ViewMs,
+			ViewEnd:  viewEnd,
+		}
+		if s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.FlushMs = harness.MsSince(viewEnd)
+		} else {
+			phases.NoFlush = true
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return phases, nil
+	case <-s.done:
+		return harness.TickPhases{}, s.exited(fmt.Sprintf("during render tick=%d", tick))
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("termbox: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack, returning once the request is
+// written to the child's pipe.
+func (s *benchSession) SendTick(tick int) error {
+	s.writer.MarkFrame(tick)
+	if !s.send(childRequest{Op: "tick", Tick: tick}) {
+		return s.exited(fmt.Sprintf("during tick=%d", tick))
+	}
+	return nil
+}
+
+// Resize changes the pty size, which signals the child with SIGWINCH as a
+// terminal would; termbox reports it and the child redraws.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return s.exited(fmt.Sprintf("before resize to %dx%d", cols, rows))
+	default:
+	}
+	if err := pty.Setsize(s.ptm, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+		return fmt.Errorf("termbox: resize to %dx%d: %w", cols, rows, err)
+	}
+	return nil
+}
+
+func (s *benchSession) Pid() int {
+	return s.cmd.Process.Pid
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	go func() {
+		s.send(childRequest{Op: "quit"})
+		s.reqPipe.Close()
+	}()
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		s.cmd.Process.Kill()
+		return harness.NewHangError(fmt.Errorf("timeout shutting down termbox after %s", s.timeouts.Shutdown))
+	}
+}
@@ -0,0 +1,520 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{"github.com/gizak/termui/v3"},
+		// termui draws when it is told to, with no frame rate to cap, so
+		// --fps has nothing to set.
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, w)
+			}
+		},
+	})
+}
+
+// layouts are the scenarios termui-bench renders: the chart-like ones, as
+// the widgets a termui dashboard would use. Each reads its values back out
+// of the scenario's lines, so termui draws the same data as every other
+// framework, but the frames are termui's own and verify reports them as
+// mismatched.
+var layouts = map[string]func() benchLayout{
+	"terminal-fps-stream": newStreamLayout,
+	"tables":              func() benchLayout { return newTableLayout(2) },
+	"terminal-table":      func() benchLayout { return newTableLayout(2) },
+}
+
+// benchLayout holds a scenario's widgets and fills them from the lines of
+// each frame.
+type benchLayout interface {
+	update(lines []string, rows int, cols int)
+	widgets() []ui.Drawable
+}
+
+// streamLayout draws terminal-fps-stream as a header Paragraph, a Gauge per
+// channel and a SparklineGroup of the digit rows below them.
+type streamLayout struct {
+	header *widgets.Paragraph
+	gauges []*widgets.Gauge
+	sparks *widgets.SparklineGroup
+}
+
+func newStreamLayout() benchLayout {
+	header := widgets.NewParagraph()
+	header.Border = false
+	sparks := widgets.NewSparklineGroup()
+	sparks.Border = false
+	return &streamLayout{header: header, sparks: sparks}
+}
+
+func (l *streamLayout) update(lines []string, rows int, cols int) {
+	if len(lines) == 0 {
+		return
+	}
+	l.header.Text = strings.TrimRight(lines[0], " ")
+	l.header.SetRect(0, 0, cols, 1)
+	channels, sparks := 0, 0
+	for _, line := range lines[1:] {
+		if name, percent, ok := parseChannel(line); ok {
+			if channels == len(l.gauges) {
+				gauge := widgets.NewGauge()
+				gauge.Border = false
+				l.gauges = append(l.gauges, gauge)
+			}
+			gauge := l.gauges[channels]
+			gauge.Percent = int(math.Round(percent))
+			gauge.Label = fmt.Sprintf("%s %5.1f%%", name, percent)
+			gauge.SetRect(0, 1+channels, cols, 2+channels)
+			channels++
+			continue
+		}
+		if sparks == len(l.sparks.Sparklines) {
+			spark := widgets.NewSparkline()
+			spark.MaxVal = 9
+			l.sparks.Sparklines = append(l.sparks.Sparklines, spark)
+		}
+		spark := l.sparks.Sparklines[sparks]
+		spark.Data = spark.Data[:0]
+		for _, r := range strings.TrimSpace(line) {
+			if r >= '0' && r <= '9' {
+				spark.Data = append(spark.Data, float64(r-'0'))
+			}
+		}
+		sparks++
+	}
+	l.gauges = l.gauges[:channels]
+	l.sparks.Sparklines = l.sparks.Sparklines[:sparks]
+	l.sparks.SetRect(0, 1+channels, cols, rows)
+}
+
+func (l *streamLayout) widgets() []ui.Drawable {
+	out := make([]ui.Drawable, 0, len(l.gauges)+2)
+	out = append(out, l.header)
+	for _, gauge := range l.gauges {
+		out = append(out, gauge)
+	}
+	// A SparklineGroup divides its height by its sparklines.
+	if len(l.sparks.Sparklines) > 0 {
+		out = append(out, l.sparks)
+	}
+	return out
+}
+
+// parseChannel reads a "ch-03 ####---- 42.0%" telemetry line.
+func parseChannel(line string) (string, float64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || !strings.HasPrefix(fields[0], "ch-") || !strings.HasSuffix(fields[len(fields)-1], "%") {
+		return "", 0, false
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[len(fields)-1], "%"), 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return fields[0], percent, true
+}
+
+// columnGap separates the cells of a table scenario's lines, which may
+// themselves hold single spaces.
+var columnGap = regexp.MustCompile(`\s{2,}`)
+
+// tableLayout draws a table scenario as a header Paragraph over a Table of
+// the remaining lines, cut into cells at columnGap and evened out to the
+// columns of the first row, which a Table needs.
+type tableLayout struct {
+	split  int
+	header *widgets.Paragraph
+	table  *widgets.Table
+}
+
+func newTableLayout(split int) benchLayout {
+	header := widgets.NewParagraph()
+	header.Border = false
+	table := widgets.NewTable()
+	table.Border = false
+	table.RowSeparator = false
+	return &tableLayout{split: split, header: header, table: table}
+}
+
+func (l *tableLayout) update(lines []string, rows int, cols int) {
+	split := min(l.split, len(lines))
+	heading := make([]string, 0, split)
+	for _, line := range lines[:split] {
+		heading = append(heading, strings.TrimRight(line, " "))
+	}
+	l.header.Text = strings.Join(heading, "\n")
+	l.header.SetRect(0, 0, cols, split)
+
+	l.table.Rows = l.table.Rows[:0]
+	width := 0
+	for i, line := range lines[split:] {
+		cells := columnGap.Split(strings.TrimSpace(line), -1)
+		if i == 0 {
+			width = len(cells)
+		}
+		for len(cells) < width {
+			cells = append(cells, "")
+		}
+		l.table.Rows = append(l.table.Rows, cells[:width])
+	}
+	l.table.SetRect(0, split, cols, rows)
+}
+
+func (l *tableLayout) widgets() []ui.Drawable {
+	if len(l.table.Rows) == 0 {
+		return []ui.Drawable{l.header}
+	}
+	return []ui.Drawable{l.header, l.table}
+}
+
+// screenWriter stands in for termbox, which only draws to the process's
+// own terminal. It collects cells the way ui.Render does, then flushes
+// them like termbox: only cells changed since the last frame, each run
+// after a cursor move, with SGR only where the style changes.
+type screenWriter struct {
+	w     io.Writer
+	rows  int
+	cols  int
+	front [][]ui.Cell
+	back  [][]ui.Cell
+	out   bytes.Buffer
+}
+
+func newScreenWriter(w io.Writer, rows int, cols int) *screenWriter {
+	s := &screenWriter{w: w}
+	s.resize(rows, cols)
+	s.out.Reset()
+	return s
+}
+
+func newGrid(rows int, cols int, fill ui.Cell) [][]ui.Cell {
+	grid := make([][]ui.Cell, rows)
+	for r := range grid {
+		grid[r] = make([]ui.Cell, cols)
+		for c := range grid[r] {
+			grid[r][c] = fill
+		}
+	}
+	return grid
+}
+
+// resize starts over at a new size: the terminal is cleared, so the next
+// flush repaints every cell.
+func (s *screenWriter) resize(rows int, cols int) {
+	s.rows, s.cols = rows, cols
+	s.front = newGrid(rows, cols, ui.Cell{Rune: 0})
+	s.back = newGrid(rows, cols, ui.CellClear)
+	s.out.WriteString("\x1b[0m\x1b[2J")
+}
+
+// enter and exit switch to the alternate screen and back, as termbox does.
+func (s *screenWriter) enter() {
+	s.out.WriteString("\x1b[?1049h\x1b[?25l\x1b[0m\x1b[2J")
+	_, _ = s.w.Write(s.out.Bytes())
+	s.out.Reset()
+}
+
+func (s *screenWriter) exit() {
+	s.out.WriteString("\x1b[0m\x1b[?25h\x1b[?1049l")
+	_, _ = s.w.Write(s.out.Bytes())
+	s.out.Reset()
+}
+
+// draw renders items into the back grid, each through a Buffer of its own
+// rect like ui.Render.
+func (s *screenWriter) draw(items []ui.Drawable) {
+	for _, row := range s.back {
+		for c := range row {
+			row[c] = ui.CellClear
+		}
+	}
+	for _, item := range items {
+		buf := ui.NewBuffer(item.GetRect())
+		item.Lock()
+		item.Draw(buf)
+		item.Unlock()
+		for point, cell := range buf.CellMap {
+			if point.In(buf.Rectangle) && point.Y >= 0 && point.Y < s.rows && point.X >= 0 && point.X < s.cols {
+				s.back[point.Y][point.X] = cell
+			}
+		}
+	}
+}
+
+// flush writes the cells that differ from the last flush.
+func (s *screenWriter) flush() {
+	style := ui.Style{Fg: -2}
+	for r := 0; r < s.rows; r++ {
+		cursor := -1
+		for c := 0; c < s.cols; c++ {
+			cell := s.back[r][c]
+			if cell == s.front[r][c] {
+				continue
+			}
+			s.front[r][c] = cell
+			if cursor != c {
+				fmt.Fprintf(&s.out, "\x1b[%d;%dH", r+1, c+1)
+			}
+			if cell.Style != style {
+				writeStyle(&s.out, cell.Style)
+				style = cell.Style
+			}
+			s.out.WriteRune(cell.Rune)
+			cursor = c + 1
+		}
+	}
+	if s.out.Len() > 0 {
+		_, _ = s.w.Write(s.out.Bytes())
+		s.out.Reset()
+	}
+}
+
+func writeStyle(out *bytes.Buffer, style ui.Style) {
+	out.WriteString("\x1b[0")
+	if style.Modifier&ui.ModifierBold != 0 {
+		out.WriteString(";1")
+	}
+	if style.Modifier&ui.ModifierUnderline != 0 {
+		out.WriteString(";4")
+	}
+	if style.Modifier&ui.ModifierReverse != 0 {
+		out.WriteString(";7")
+	}
+	if style.Fg != ui.ColorClear {
+		fmt.Fprintf(out, ";38;5;%d", style.Fg)
+	}
+	if style.Bg != ui.ColorClear {
+		fmt.Fprintf(out, ";48;5;%d", style.Bg)
+	}
+	out.WriteByte('m')
+}
+
+// The events of a session's loop, the counterpart of the ui.PollEvents
+// loop a termui dashboard runs.
+type (
+	tickEvent struct {
+		tick   int
+		ack    chan struct{}
+		phases *harness.TickPhases
+	}
+	resizeEvent struct{ rows, cols int }
+	quitEvent   struct{}
+)
+
+type benchSession struct {
+	layout   benchLayout
+	screen   *screenWriter
+	writer   *harness.Writer
+	events   chan any
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	scenario string
+	params   map[string]string
+	seed     uint64
+	rows     int
+	cols     int
+	lines    []string
+
+	// updates and views count ticks applied and frames drawn, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+}
+
+// startBenchSession starts the scenario's termui layout on its own event
+// loop; it is the harness.StartFunc of this binary once bound to its
+// timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	newLayout, ok := layouts[scenario]
+	if !ok {
+		names := make([]string, 0, len(layouts))
+		for name := range layouts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("termui: no widget layout for %q; termui-bench renders %s", scenario, strings.Join(names, ", "))
+	}
+	session := &benchSession{
+		layout:   newLayout(),
+		screen:   newScreenWriter(writer, rows, cols),
+		writer:   writer,
+		events:   make(chan any),
+		done:     make(chan struct{}),
+		timeouts: t,
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		rows:     rows,
+		cols:     cols,
+		lines:    []string{},
+	}
+	ready := make(chan struct{})
+	go session.loop(ready)
+
+	select {
+	case <-ready:
+		return session, nil
+	case <-session.done:
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for termui startup after %s", t.Startup))
+	}
+}
+
+// loop runs every update and draw. A panic in either ends it and is
+// reported by the session instead of taking the process down.
+func (s *benchSession) loop(ready chan struct{}) {
+	defer close(s.done)
+	defer func() {
+		if r := recover(); r != nil {
+			s.runErr = harness.NewPanicError(r)
+		}
+	}()
+	s.screen.enter()
+	close(ready)
+	for event := range s.events {
+		switch e := event.(type) {
+		case tickEvent:
+			s.render(e)
+		case resizeEvent:
+			s.rows, s.cols = e.rows, e.cols
+			s.screen.resize(e.rows, e.cols)
+			s.layout.update(s.lines, s.rows, s.cols)
+			s.screen.draw(s.layout.widgets())
+			s.screen.flush()
+		case quitEvent:
+			s.screen.exit()
+			return
+		}
+	}
+}
+
+func (s *benchSession) render(e tickEvent) {
+	updateStart := time.Now()
+	s.lines = harness.ScenarioLines(s.scenario, s.params, s.seed, e.tick, s.cols)
+	s.layout.update(s.lines, s.rows, s.cols)
+	s.updates.Add(1)
+	if e.phases != nil {
+		e.phases.UpdateMs = harness.MsSince(updateStart)
+	}
+
+	viewStart := time.Now()
+	s.screen.draw(s.layout.widgets())
+	s.views.Add(1)
+	if e.phases != nil {
+		e.phases.ViewMs = harness.MsSince(viewStart)
+		e.phases.ViewEnd = time.Now()
+	}
+	if e.ack != nil {
+		close(e.ack)
+	}
+	s.screen.flush()
+}
+
+// send delivers event to the loop, or reports that the loop has ended.
+func (s *benchSession) send(event any) bool {
+	select {
+	case s.events <- event:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *benchSession) exited(what string) error {
+	if s.runErr != nil {
+		return fmt.Errorf("termui: %w %s: %w", harness.ErrProgramExited, what, s.runErr)
+	}
+	return fmt.Errorf("termui: %w %s", harness.ErrProgramExited, what)
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	event := tickEvent{tick: tick, ack: ack, phases: phases}
+	if eventLoop {
+		go s.send(event)
+	} else {
+		s.send(event)
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		return harness.TickPhases{}, s.exited(fmt.Sprintf("during render tick=%d", tick))
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("termui: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack, returning once the loop has taken
+// it.
+func (s *benchSession) SendTick(tick int) error {
+	s.writer.MarkFrame(tick)
+	if !s.send(tickEvent{tick: tick}) {
+		return s.exited(fmt.Sprintf("during tick=%d", tick))
+	}
+	return nil
+}
+
+// Resize redraws the last frame at the new size, as a termui dashboard does
+// on its <Resize> event.
+func (s *benchSession) Resize(rows int, cols int) error {
+	if !s.send(resizeEvent{rows: rows, cols: cols}) {
+		return s.exited(fmt.Sprintf("before resize to %dx%d", cols, rows))
+	}
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	go s.send(quitEvent{})
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down termui after %s", s.timeouts.Shutdown))
+	}
+}
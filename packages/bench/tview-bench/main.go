@@ -0,0 +1,372 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/gdamore/tcell/v2/terminfo"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+	"github.com/rivo/tview"
+)
+
+func main() {
+	// Frames are plain text, as in bubbletea-bench: the default theme would
+	// paint every cell's background and add colour sequences to each frame.
+	tview.Styles.PrimitiveBackgroundColor = tcell.ColorDefault
+	tview.Styles.PrimaryTextColor = tcell.ColorDefault
+
+	cli.Main(cli.Framework{
+		Modules: []string{
+			"github.com/rivo/tview",
+			"github.com/gdamore/tcell/v2",
+		},
+		// tview draws each queued update at once, with no frame rate to cap,
+		// so --fps has nothing to set.
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, w)
+			}
+		},
+	})
+}
+
+// benchTerm is the terminfo entry frames are encoded for.
+const benchTerm = "xterm-256color"
+
+// tableScenarios show the lines after their first n as the rows of a Table,
+// the way a tview app lays out a list or grid, below a TextView of the
+// first n. Other scenarios are one TextView. Either way every frame shows
+// exactly the generator's lines.
+var tableScenarios = map[string]int{
+	"startup":               2,
+	"content-update":        1,
+	"layout-stress":         2,
+	"scroll-stress":         2,
+	"virtual-list":          2,
+	"tables":                2,
+	"terminal-virtual-list": 2,
+	"terminal-table":        2,
+}
+
+// benchTty is the terminal tcell draws to: output goes to the harness
+// Writer, input never arrives and the size is the scenario viewport until
+// Resize changes it.
+type benchTty struct {
+	w *harness.Writer
+
+	mu       sync.Mutex
+	rows     int
+	cols     int
+	onResize func()
+
+	drained   chan struct{}
+	drainOnce sync.Once
+}
+
+func (t *benchTty) Start() error { return nil }
+func (t *benchTty) Stop() error  { return nil }
+
+// Drain wakes tcell's input loop, blocked in Read, as the screen finishes.
+func (t *benchTty) Drain() error {
+	t.drainOnce.Do(func() { close(t.drained) })
+	return nil
+}
+
+func (t *benchTty) Close() error { return t.Drain() }
+
+func (t *benchTty) Read([]byte) (int, error) {
+	<-t.drained
+	return 0, io.EOF
+}
+
+func (t *benchTty) Write(p []byte) (int, error) { return t.w.Write(p) }
+
+func (t *benchTty) NotifyResize(cb func()) {
+	t.mu.Lock()
+	t.onResize = cb
+	t.mu.Unlock()
+}
+
+func (t *benchTty) WindowSize() (tcell.WindowSize, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return tcell.WindowSize{Width: t.cols, Height: t.rows}, nil
+}
+
+func (t *benchTty) resize(rows int, cols int) {
+	t.mu.Lock()
+	t.rows, t.cols = rows, cols
+	cb := t.onResize
+	t.mu.Unlock()
+	if cb != nil {
+		cb()
+	}
+}
+
+// benchView holds the primitives a scenario renders into. Its Draw is the
+// view phase of a tick and acks it once the cells are laid out; the
+// application then flushes them to the screen.
+type benchView struct {
+	*tview.Flex
+	header *tview.TextView
+	table  *tview.Table
+	split  int
+
+	session *benchSession
+}
+
+func newBenchView(scenario string, session *benchSession) *benchView {
+	v := &benchView{Flex: tview.NewFlex().SetDirection(tview.FlexRow), session: session}
+	v.header = tview.NewTextView().SetWrap(false).SetDynamicColors(false).SetRegions(false)
+	split, ok := tableScenarios[scenario]
+	if !ok {
+		v.Flex.AddItem(v.header, 0, 1, false)
+		return v
+	}
+	v.split = split
+	v.table = tview.NewTable()
+	v.Flex.AddItem(v.header, split, 0, false)
+	v.Flex.AddItem(v.table, 0, 1, false)
+	return v
+}
+
+// setLines replaces the content, reusing the Table's cells where rows stay.
+func (v *benchView) setLines(lines []string) {
+	if v.table == nil {
+		v.header.SetText(strings.Join(lines, "\n")).ScrollToBeginning()
+		return
+	}
+	split := min(v.split, len(lines))
+	v.header.SetText(strings.Join(lines[:split], "\n")).ScrollToBeginning()
+	rows := lines[split:]
+	for r, line := range rows {
+		text := tview.Escape(line)
+		if r < v.table.GetRowCount() {
+			v.table.GetCell(r, 0).SetText(text)
+		} else {
+			v.table.SetCell(r, 0, tview.NewTableCell(text))
+		}
+	}
+	for v.table.GetRowCount() > len(rows) {
+		v.table.RemoveRow(v.table.GetRowCount() - 1)
+	}
+}
+
+func (v *benchView) Draw(screen tcell.Screen) {
+	s := v.session
+	defer s.recoverPanic()
+	viewStart := time.Now()
+	v.Flex.Draw(screen)
+	s.views.Add(1)
+	if s.pendingPhases != nil {
+		s.pendingPhases.ViewMs = harness.MsSince(viewStart)
+		s.pendingPhases.ViewEnd = time.Now()
+		s.pendingPhases = nil
+	}
+	if s.pendingAck != nil {
+		close(s.pendingAck)
+		s.pendingAck = nil
+	}
+}
+
+type benchSession struct {
+	app      *tview.Application
+	view     *benchView
+	tty      *benchTty
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	scenario string
+	params   map[string]string
+	seed     uint64
+	cols     int
+
+	// lines, pendingAck and pendingPhases belong to the application's
+	// event loop, which runs every update and draw.
+	lines         []string
+	pendingAck    chan struct{}
+	pendingPhases *harness.TickPhases
+
+	// updates and views count ticks applied and views drawn, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+
+	// A panic in an update or draw is recovered here rather than taking
+	// the process down; the application is stopped and the session
+	// reports it.
+	panicked error
+}
+
+func (s *benchSession) recoverPanic() {
+	if r := recover(); r != nil {
+		s.panicked = harness.NewPanicError(r)
+		go s.app.Stop()
+	}
+}
+
+// startBenchSession starts the scenario as a tview application; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	info, err := terminfo.LookupTerminfo(benchTerm)
+	if err != nil {
+		return nil, fmt.Errorf("tview: %w", err)
+	}
+	tty := &benchTty{w: writer, rows: rows, cols: cols, drained: make(chan struct{})}
+	screen, err := tcell.NewTerminfoScreenFromTtyTerminfo(tty, info)
+	if err != nil {
+		return nil, fmt.Errorf("tview: %w", err)
+	}
+
+	session := &benchSession{
+		tty:      tty,
+		writer:   writer,
+		done:     make(chan struct{}),
+		timeouts: t,
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		cols:     cols,
+		lines:    []string{},
+	}
+	session.view = newBenchView(scenario, session)
+	session.app = tview.NewApplication().SetScreen(screen).SetRoot(session.view, true)
+
+	// Run draws the screen once before its event loop, so the first draw
+	// means the application is up. QueueUpdate would block here until Run
+	// took it.
+	ready := make(chan struct{})
+	var readyOnce sync.Once
+	session.app.SetAfterDrawFunc(func(tcell.Screen) {
+		readyOnce.Do(func() { close(ready) })
+	})
+	go func() {
+		session.runErr = session.app.Run()
+		if session.panicked != nil {
+			session.runErr = session.panicked
+		}
+		close(session.done)
+	}()
+
+	select {
+	case <-ready:
+		return session, nil
+	case <-session.done:
+		if session.runErr != nil {
+			return nil, fmt.Errorf("tview: %w before initialization: %w", harness.ErrProgramExited, session.runErr)
+		}
+		return nil, fmt.Errorf("tview: %w before initialization", harness.ErrProgramExited)
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for tview startup after %s", t.Startup))
+	}
+}
+
+// update applies tick on the event loop, the tview counterpart of a Bubble
+// Tea Update.
+func (s *benchSession) update(tick int, ack chan struct{}, phases *harness.TickPhases) {
+	defer s.recoverPanic()
+	updateStart := time.Now()
+	s.lines = harness.ScenarioLines(s.scenario, s.params, s.seed, tick, s.cols)
+	s.view.setLines(s.lines)
+	s.updates.Add(1)
+	s.pendingAck = ack
+	s.pendingPhases = phases
+	if phases != nil {
+		phases.UpdateMs = harness.MsSince(updateStart)
+	}
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	send := func() {
+		s.app.QueueUpdateDraw(func() { s.update(tick, ack, phases) })
+	}
+	if eventLoop {
+		go send()
+	} else {
+		send()
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		if s.runErr != nil {
+			return harness.TickPhases{}, fmt.Errorf("tview: %w during render tick=%d: %w", harness.ErrProgramExited, tick, s.runErr)
+		}
+		return harness.TickPhases{}, fmt.Errorf("tview: %w during render tick=%d", harness.ErrProgramExited, tick)
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("tview: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack; QueueUpdateDraw returns once the
+// update is queued for the event loop.
+func (s *benchSession) SendTick(tick int) error {
+	select {
+	case <-s.done:
+		if s.runErr != nil {
+			return fmt.Errorf("tview: %w during tick=%d: %w", harness.ErrProgramExited, tick, s.runErr)
+		}
+		return fmt.Errorf("tview: %w during tick=%d", harness.ErrProgramExited, tick)
+	default:
+	}
+	s.writer.MarkFrame(tick)
+	s.app.QueueUpdateDraw(func() { s.update(tick, nil, nil) })
+	return nil
+}
+
+// Resize changes the terminal size and signals it the way SIGWINCH would;
+// tcell then posts the resize event the application redraws on.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return fmt.Errorf("tview: %w before resize to %dx%d", harness.ErrProgramExited, cols, rows)
+	default:
+	}
+	s.app.QueueUpdate(func() { s.cols = cols })
+	s.tty.resize(rows, cols)
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	s.app.Stop()
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down tview after %s", s.timeouts.Shutdown))
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"git.sr.ht/~rockorager/vaxis"
+	"github.com/creack/pty"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+)
+
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{"git.sr.ht/~rockorager/vaxis"},
+		// Each tick is drawn and rendered as it arrives, with no frame rate
+		// to cap, so --fps has nothing to set.
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, _ int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, w)
+			}
+		},
+	})
+}
+
+// Vaxis detects what the terminal supports by querying it at startup and
+// waits for the primary device attributes reply that ends the queries. The
+// pty relay answers as a terminal with synchronized output would, so every
+// frame is sent inside a 2026 update and startup does not wait on the
+// query timeout.
+var queryReplies = []struct {
+	query []byte
+	reply []byte
+}{
+	{[]byte("\x1b[?2026$p"), []byte("\x1b[?2026;2$y")},
+	{[]byte("\x1b[c"), []byte("\x1b[?62;22c")},
+}
+
+// relay copies the pty's output to the harness Writer, answering the
+// queries above as they pass. It returns once the pty is closed.
+func relay(ptm *os.File, w io.Writer) {
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := ptm.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			for _, q := range queryReplies {
+				if bytes.Contains(chunk, q.query) {
+					ptm.Write(q.reply)
+				}
+			}
+			w.Write(chunk)
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// tickEvent is posted to the vaxis event loop to deliver a tick; quitEvent
+// ends the loop.
+type (
+	tickEvent struct {
+		tick   int
+		ack    chan struct{}
+		phases *harness.TickPhases
+	}
+	quitEvent struct{}
+)
+
+// benchSession runs a vaxis event loop on a pty owned by the session; the
+// relay hands everything vaxis writes to the harness Writer.
+type benchSession struct {
+	vx       *vaxis.Vaxis
+	ptm      *os.File
+	pts      *os.File
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	scenario string
+	params   map[string]string
+	seed     uint64
+	lines    []string
+
+	// updates and views count ticks applied and frames drawn, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+}
+
+// startBenchSession starts the scenario in a vaxis event loop; it is the
+// harness.StartFunc of this binary once bound to its timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	ptm, pts, err := pty.Open()
+	if err != nil {
+		return nil, fmt.Errorf("vaxis: %w", err)
+	}
+	if err := pty.Setsize(ptm, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+		ptm.Close()
+		pts.Close()
+		return nil, fmt.Errorf("vaxis: %w", err)
+	}
+	session := &benchSession{
+		ptm:      ptm,
+		pts:      pts,
+		writer:   writer,
+		done:     make(chan struct{}),
+		timeouts: t,
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		lines:    []string{},
+	}
+	ready := make(chan struct{})
+	go session.loop(ready)
+
+	select {
+	case <-ready:
+		return session, nil
+	case <-session.done:
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for vaxis startup after %s", t.Startup))
+	}
+}
+
+// loop owns vaxis from New to Close and runs every update and draw. A panic
+// in either ends it and is reported by the session instead of taking the
+// process down.
+func (s *benchSession) loop(ready chan struct{}) {
+	relayed := make(chan struct{})
+	go func() {
+		relay(s.ptm, s.writer)
+		close(relayed)
+	}()
+	defer func() {
+		// The master reads what vaxis wrote last before it sees the closed
+		// slave.
+		s.pts.Close()
+		<-relayed
+		s.ptm.Close()
+		close(s.done)
+	}()
+	vx, err := vaxis.New(vaxis.Options{WithTTY: s.pts.Name(), DisableMouse: true})
+	if err != nil {
+		s.runErr = err
+		return
+	}
+	defer vx.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			s.runErr = harness.NewPanicError(r)
+		}
+	}()
+	s.vx = vx
+	close(ready)
+
+	for ev := range vx.Events() {
+		switch ev := ev.(type) {
+		case vaxis.Resize:
+			s.draw()
+			vx.Render()
+		case tickEvent:
+			s.render(ev)
+		case quitEvent:
+			return
+		}
+	}
+}
+
+func (s *benchSession) render(e tickEvent) {
+	updateStart := time.Now()
+	cols, _ := s.vx.Window().Size()
+	s.lines = harness.ScenarioLines(s.scenario, s.params, s.seed, e.tick, cols)
+	s.updates.Add(1)
+	if e.phases != nil {
+		e.phases.UpdateMs = harness.MsSince(updateStart)
+	}
+
+	viewStart := time.Now()
+	s.draw()
+	s.views.Add(1)
+	if e.phases != nil {
+		e.phases.ViewMs = harness.MsSince(viewStart)
+		e.phases.ViewEnd = time.Now()
+	}
+	if e.ack != nil {
+		close(e.ack)
+	}
+	s.vx.Render()
+}
+
+// draw prints each line into a one-row window; vaxis measures graphemes and
+// clips at the edge, and Render sends only the cells that changed. Lines
+// past the window's height cost the top rows, as in the harness's expected
+// screen.
+func (s *benchSession) draw() {
+	win := s.vx.Window()
+	win.Clear()
+	_, rows := win.Size()
+	lines := s.lines
+	if len(lines) > rows {
+		lines = lines[len(lines)-rows:]
+	}
+	for y, line := range lines {
+		win.New(0, y, -1, 1).Print(vaxis.Segment{Text: line})
+	}
+}
+
+// post delivers ev to the loop, or reports that the loop has ended.
+func (s *benchSession) post(ev vaxis.Event) bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	s.vx.PostEventBlocking(ev)
+	return true
+}
+
+func (s *benchSession) exited(what string) error {
+	if s.runErr != nil {
+		return fmt.Errorf("vaxis: %w %s: %w", harness.ErrProgramExited, what, s.runErr)
+	}
+	return fmt.Errorf("vaxis: %w %s", harness.ErrProgramExited, what)
+}
+
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	event := tickEvent{tick: tick, ack: ack, phases: phases}
+	if eventLoop {
+		go s.post(event)
+	} else {
+		s.post(event)
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		return harness.TickPhases{}, s.exited(fmt.Sprintf("during render tick=%d", tick))
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("vaxis: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack, returning once it is queued.
+func (s *benchSession) SendTick(tick int) error {
+	s.writer.MarkFrame(tick)
+	if !s.post(tickEvent{tick: tick}) {
+		return s.exited(fmt.Sprintf("during tick=%d", tick))
+	}
+	return nil
+}
+
+// Resize changes the pty size and raises SIGWINCH, which vaxis answers by
+// reading the new size and posting a Resize the loop redraws on. The pty is
+// not the process's controlling terminal, so the kernel does not send the
+// signal itself.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return s.exited(fmt.Sprintf("before resize to %dx%d", cols, rows))
+	default:
+	}
+	if err := pty.Setsize(s.ptm, &pty.Winsize{Rows: uint16(rows), Cols: uint16(cols)}); err != nil {
+		return fmt.Errorf("vaxis: resize to %dx%d: %w", cols, rows, err)
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGWINCH); err != nil {
+		return fmt.Errorf("vaxis: resize to %dx%d: %w", cols, rows, err)
+	}
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.updates.Load(), s.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	go s.post(quitEvent{})
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down vaxis after %s", s.timeouts.Shutdown))
+	}
+}
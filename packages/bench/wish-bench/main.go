@@ -0,0 +1,370 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/ssh"
+	"github.com/charmbracelet/wish"
+	"github.com/charmbracelet/wish/bubbletea"
+	"github.com/muesli/termenv"
+	"github.com/rezi-ui/bench/bubbletea-bench/cli"
+	"github.com/rezi-ui/bench/bubbletea-bench/harness"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// main runs the scenarios as Bubble Tea programs served over SSH by wish
+// and read back through an SSH client on the same host. Compared with
+// bubbletea-bench, the flush phase adds the SSH transport, and the written
+// bytes are those that came through the channel.
+func main() {
+	cli.Main(cli.Framework{
+		Modules: []string{
+			"github.com/charmbracelet/bubbletea",
+			"github.com/charmbracelet/lipgloss",
+			"github.com/charmbracelet/wish",
+			"golang.org/x/crypto",
+		},
+		Start: func(t cli.Timeouts) harness.StartFunc {
+			return func(scenario string, params map[string]string, seed uint64, rows int, cols int, fps int, w *harness.Writer) (harness.Session, error) {
+				return startBenchSession(t, scenario, params, seed, rows, cols, fps, w)
+			}
+		},
+	})
+}
+
+type readyMsg struct{}
+
+type benchTickMsg struct {
+	tick   int
+	ack    chan struct{}
+	phases *harness.TickPhases
+}
+
+type benchModel struct {
+	scenario string
+	params   map[string]string
+	seed     uint64
+	cols     int
+	lines    []string
+
+	pendingAck    chan struct{}
+	pendingPhases *harness.TickPhases
+	ready         chan struct{}
+
+	// updates and views count ticks applied and views built, for
+	// --throughput, which does not wait on acks.
+	updates atomic.Int64
+	views   atomic.Int64
+
+	// A panic in Init, Update or View is recovered here rather than by
+	// Bubble Tea, which would print it to the terminal and return a bare
+	// ErrProgramPanic. The program then quits and the session reports it.
+	panicked error
+	quit     func()
+}
+
+func (m *benchModel) recoverPanic() {
+	if r := recover(); r != nil {
+		m.panicked = harness.NewPanicError(r)
+		go m.quit()
+	}
+}
+
+func (m *benchModel) Init() tea.Cmd {
+	defer m.recoverPanic()
+	return func() tea.Msg {
+		return readyMsg{}
+	}
+}
+
+func (m *benchModel) Update(msg tea.Msg) (model tea.Model, cmd tea.Cmd) {
+	model = m
+	defer m.recoverPanic()
+	switch v := msg.(type) {
+	case readyMsg:
+		if m.ready != nil {
+			close(m.ready)
+			m.ready = nil
+		}
+	case tea.WindowSizeMsg:
+		if v.Width > 0 {
+			m.cols = v.Width
+		}
+	case benchTickMsg:
+		updateStart := time.Now()
+		m.lines = harness.ScenarioLines(m.scenario, m.params, m.seed, v.tick, m.cols)
+		m.updates.Add(1)
+		m.pendingAck = v.ack
+		m.pendingPhases = v.phases
+		if v.phases != nil {
+			v.phases.UpdateMs = harness.MsSince(updateStart)
+		}
+	}
+	return m, nil
+}
+
+func (m *benchModel) View() string {
+	defer m.recoverPanic()
+	viewStart := time.Now()
+	view := strings.Join(m.lines, "\n")
+	m.views.Add(1)
+	if m.pendingPhases != nil {
+		m.pendingPhases.ViewMs = harness.MsSince(viewStart)
+		m.pendingPhases.ViewEnd = time.Now()
+		m.pendingPhases = nil
+	}
+	if m.pendingAck != nil {
+		close(m.pendingAck)
+		m.pendingAck = nil
+	}
+	return view
+}
+
+// benchSession serves the program over SSH on a loopback port and watches
+// it through an SSH client, so every frame crosses the transport before it
+// reaches the writer.
+type benchSession struct {
+	program  *tea.Program
+	model    *benchModel
+	writer   *harness.Writer
+	done     chan struct{}
+	runErr   error
+	timeouts cli.Timeouts
+
+	server  *ssh.Server
+	client  *gossh.Client
+	channel *gossh.Session
+}
+
+// startBenchSession starts an SSH server whose one session runs the
+// scenario as a Bubble Tea program, and connects to it with a pty of rows x
+// cols; it is the harness.StartFunc of this binary once bound to its
+// timeouts.
+func startBenchSession(
+	t cli.Timeouts,
+	scenario string,
+	params map[string]string,
+	seed uint64,
+	rows int,
+	cols int,
+	fps int,
+	writer *harness.Writer,
+) (harness.Session, error) {
+	ready := make(chan struct{})
+	model := &benchModel{
+		scenario: scenario,
+		params:   params,
+		seed:     seed,
+		cols:     cols,
+		lines:    []string{},
+		ready:    ready,
+	}
+
+	hostKey, err := newHostKey()
+	if err != nil {
+		return nil, fmt.Errorf("wish: host key: %w", err)
+	}
+	programs := make(chan *tea.Program, 1)
+	server, err := wish.NewServer(
+		wish.WithHostKeyPEM(hostKey),
+		wish.WithMiddleware(bubbletea.MiddlewareWithProgramHandler(func(sess ssh.Session) *tea.Program {
+			opts := append(bubbletea.MakeOptions(sess),
+				tea.WithFPS(fps),
+				tea.WithAltScreen(),
+				tea.WithoutSignalHandler(),
+			)
+			program := tea.NewProgram(model, opts...)
+			model.quit = program.Quit
+			programs <- program
+			return program
+		}, termenv.ANSI256)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("wish: server: %w", err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("wish: listen: %w", err)
+	}
+	go server.Serve(listener)
+
+	session := &benchSession{model: model, writer: writer, done: make(chan struct{}), timeouts: t, server: server}
+	if err := session.connect(listener.Addr().String(), rows, cols); err != nil {
+		session.shutdown()
+		return nil, fmt.Errorf("wish: %w", err)
+	}
+	go func() {
+		// The channel closes when the program returns and the middleware
+		// ends the SSH session.
+		session.channel.Wait()
+		session.runErr = model.panicked
+		close(session.done)
+	}()
+
+	select {
+	case session.program = <-programs:
+	case <-session.done:
+		session.shutdown()
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		session.shutdown()
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for wish session after %s", t.Startup))
+	}
+	select {
+	case <-ready:
+		return session, nil
+	case <-session.done:
+		session.shutdown()
+		return nil, session.exited("before initialization")
+	case <-time.After(t.Startup):
+		session.shutdown()
+		return nil, harness.NewHangError(fmt.Errorf("timeout waiting for wish startup after %s", t.Startup))
+	}
+}
+
+// newHostKey is a fresh ed25519 host key in PEM form, so the server never
+// reads or writes a key file.
+func newHostKey() ([]byte, error) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	block, err := gossh.MarshalPrivateKey(key, "")
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(block), nil
+}
+
+// connect dials addr, asks for a rows x cols pty and starts a shell, whose
+// output is copied into the writer as it arrives. The server has no auth
+// handlers, so the client authenticates with none.
+func (s *benchSession) connect(addr string, rows int, cols int) error {
+	client, err := gossh.Dial("tcp", addr, &gossh.ClientConfig{
+		User:            "bench",
+		HostKeyCallback: gossh.InsecureIgnoreHostKey(),
+		Timeout:         s.timeouts.Startup,
+	})
+	if err != nil {
+		return fmt.Errorf("dial: %w", err)
+	}
+	s.client = client
+	channel, err := client.NewSession()
+	if err != nil {
+		return fmt.Errorf("session: %w", err)
+	}
+	s.channel = channel
+	stdout, err := channel.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("stdout: %w", err)
+	}
+	if err := channel.RequestPty("xterm-256color", rows, cols, gossh.TerminalModes{}); err != nil {
+		return fmt.Errorf("pty: %w", err)
+	}
+	go io.Copy(s.writer, stdout)
+	if err := channel.Shell(); err != nil {
+		return fmt.Errorf("shell: %w", err)
+	}
+	return nil
+}
+
+// shutdown closes the client and the server.
+func (s *benchSession) shutdown() {
+	if s.client != nil {
+		s.client.Close()
+	}
+	s.server.Close()
+}
+
+func (s *benchSession) exited(what string) error {
+	if s.runErr != nil {
+		return fmt.Errorf("wish: %w %s: %w", harness.ErrProgramExited, what, s.runErr)
+	}
+	return fmt.Errorf("wish: %w %s", harness.ErrProgramExited, what)
+}
+
+// RenderTick waits for the frame's bytes to come out of the SSH client, so
+// the flush phase spans rendering, encryption and the loopback round trip.
+func (s *benchSession) RenderTick(tick int, eventLoop bool) (harness.TickPhases, error) {
+	ack := make(chan struct{})
+	phases := &harness.TickPhases{}
+	_, writeBase := s.writer.Snapshot()
+	blockedBase := s.writer.BeginFrame()
+	s.writer.MarkFrame(tick)
+
+	send := func() {
+		s.program.Send(benchTickMsg{tick: tick, ack: ack, phases: phases})
+	}
+	if eventLoop {
+		go send()
+	} else {
+		send()
+	}
+
+	select {
+	case <-ack:
+		if !s.writer.WaitWriteAfter(writeBase, 10*time.Millisecond) {
+			phases.NoFlush = true
+		} else if !phases.ViewEnd.IsZero() {
+			phases.FlushMs = harness.MsSince(phases.ViewEnd)
+		}
+		phases.WriteBlockMs, phases.WriteBlockMaxMs = s.writer.FrameBlocking(blockedBase)
+		return *phases, nil
+	case <-s.done:
+		return harness.TickPhases{}, s.exited(fmt.Sprintf("during render tick=%d", tick))
+	case <-time.After(s.timeouts.Tick):
+		return harness.TickPhases{}, harness.NewHangError(fmt.Errorf("wish: %w tick=%d after %s", harness.ErrRenderTimeout, tick, s.timeouts.Tick))
+	}
+}
+
+// SendTick delivers tick without an ack; Program.Send returns once the
+// event loop has taken it.
+func (s *benchSession) SendTick(tick int) error {
+	select {
+	case <-s.done:
+		return s.exited(fmt.Sprintf("during tick=%d", tick))
+	default:
+	}
+	s.writer.MarkFrame(tick)
+	s.program.Send(benchTickMsg{tick: tick})
+	return nil
+}
+
+// Resize sends a window-change request over the channel, which the
+// middleware turns into a WindowSizeMsg.
+func (s *benchSession) Resize(rows int, cols int) error {
+	select {
+	case <-s.done:
+		return s.exited(fmt.Sprintf("before resize to %dx%d", cols, rows))
+	default:
+	}
+	if err := s.channel.WindowChange(rows, cols); err != nil {
+		return fmt.Errorf("wish: resize to %dx%d: %w", cols, rows, err)
+	}
+	return nil
+}
+
+func (s *benchSession) Rendered() (int64, int64) {
+	return s.model.updates.Load(), s.model.views.Load()
+}
+
+func (s *benchSession) Close() error {
+	s.writer.MarkEnd()
+	s.program.Send(tea.Quit())
+	defer s.shutdown()
+	select {
+	case <-s.done:
+		return s.runErr
+	case <-time.After(s.timeouts.Shutdown):
+		return harness.NewHangError(fmt.Errorf("timeout shutting down wish after %s", s.timeouts.Shutdown))
+	}
+}